@@ -11,7 +11,6 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/imansohibul/otp-service/config"
-	"github.com/imansohibul/otp-service/internal/handler"
 )
 
 // Author: MOCHAMAD SOHIBUL IMAN - iman@imansohibul.my.id
@@ -19,20 +18,34 @@ import (
 func main() {
 	ctx := context.Background()
 
-	restAPIServer, err := config.NewRestAPI()
+	// Migrations run before the application is initialized, as it refuses to start on an outdated schema
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(ctx, os.Args[2:])
+		return
+	}
+
+	app, err := config.NewApplication()
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to initialize REST API server")
+		log.Fatal().Err(err).Msg("failed to initialize application")
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit-log" {
+		verifyAuditLog(ctx, app)
+		return
 	}
 
+	app.Scheduler.Start()
+	app.Replicas.Start()
+
 	// Get server address from config or environment
 	address := ":8080" // You should get this from your config
 
 	// Graceful shutdown handler
 	idleConnsClosed := make(chan struct{})
-	go handleGracefulShutdown(ctx, restAPIServer, idleConnsClosed)
+	go handleGracefulShutdown(ctx, app, idleConnsClosed)
 
 	log.Info().Msgf("Starting REST API server on %s...", address)
-	if err := restAPIServer.Start(address); err != nil && err != http.ErrServerClosed {
+	if err := app.RestAPIServer.Start(address); err != nil && err != http.ErrServerClosed {
 		log.Fatal().Err(err).Msg("REST API server stopped with error")
 	}
 
@@ -40,7 +53,7 @@ func main() {
 	log.Info().Msg("Server shut down gracefully")
 }
 
-func handleGracefulShutdown(ctx context.Context, restAPIServer *handler.RestAPIServer, done chan struct{}) {
+func handleGracefulShutdown(ctx context.Context, app *config.Application, done chan struct{}) {
 	// Listen for interrupt signal (e.g., Ctrl+C, SIGTERM)
 	sigint := make(chan os.Signal, 1)
 	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
@@ -52,9 +65,35 @@ func handleGracefulShutdown(ctx context.Context, restAPIServer *handler.RestAPIS
 	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	if err := restAPIServer.Shutdown(shutdownCtx); err != nil {
+	if err := app.RestAPIServer.Shutdown(shutdownCtx); err != nil {
 		log.Fatal().Err(err).Msg("Error during server shutdown")
 	}
 
+	if err := app.Scheduler.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Error during job scheduler shutdown")
+	}
+
+	if err := app.Replicas.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Error during read replica health check shutdown")
+	}
+
 	close(done)
 }
+
+// verifyAuditLog walks the hash chain of the audit log and exits with a non-zero status if it is broken
+func verifyAuditLog(ctx context.Context, app *config.Application) {
+	result, err := app.AuditLog.VerifyChain(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to verify audit log")
+	}
+
+	if !result.Valid {
+		log.Fatal().
+			Int64("checked", result.Checked).
+			Uint64("broken_at_id", result.BrokenAtID).
+			Str("reason", result.Reason).
+			Msg("audit log hash chain is broken")
+	}
+
+	log.Info().Int64("checked", result.Checked).Msg("audit log hash chain is intact")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/imansohibul/otp-service/config"
+	"github.com/imansohibul/otp-service/db/migrate"
+)
+
+const migrateUsage = `usage: otp-service migrate <command>
+
+commands:
+  up          apply every pending migration
+  down N      roll back the last N migrations
+  down -all   roll back every migration
+  status      show the schema version of the database and the pending migrations`
+
+// runMigrate migrates the configured database with the migrations embedded in the binary
+func runMigrate(ctx context.Context, args []string) {
+	run, err := parseMigrateArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "otp-service migrate: %v\n\n%s\n", err, migrateUsage)
+		os.Exit(2)
+	}
+
+	migrator, err := config.NewMigrator()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize migrations")
+	}
+	defer migrator.DB.Close()
+
+	if err := run(ctx, migrator); err != nil {
+		log.Fatal().Err(err).Msg("failed to migrate database")
+	}
+}
+
+// parseMigrateArgs returns the migrate command selected by the arguments
+func parseMigrateArgs(args []string) (func(ctx context.Context, migrator *config.Migrator) error, error) {
+	if len(args) == 0 {
+		return nil, errors.New("missing command")
+	}
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		return migrateUp, nil
+	case command == "status" && len(args) == 1:
+		return migrateStatus, nil
+	case command == "down" && len(args) == 2:
+		steps := 0
+		if args[1] != "-all" {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+			steps = n
+		}
+		return func(ctx context.Context, migrator *config.Migrator) error {
+			return migrateDown(ctx, migrator, steps)
+		}, nil
+	case command == "up" || command == "status" || command == "down":
+		return nil, fmt.Errorf("invalid arguments for %s", command)
+	default:
+		return nil, fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func migrateUp(ctx context.Context, migrator *config.Migrator) error {
+	if err := migrate.Up(ctx, migrator.DB, migrator.Migrations); err != nil {
+		return err
+	}
+
+	return migrateStatus(ctx, migrator)
+}
+
+// migrateDown rolls back the last steps migrations, or every migration when steps is 0
+func migrateDown(ctx context.Context, migrator *config.Migrator, steps int) error {
+	if err := migrate.Down(ctx, migrator.DB, migrator.Migrations, steps); err != nil {
+		return err
+	}
+
+	return migrateStatus(ctx, migrator)
+}
+
+func migrateStatus(ctx context.Context, migrator *config.Migrator) error {
+	status, err := migrate.GetStatus(ctx, migrator.DB, migrator.Migrations)
+	if err != nil {
+		return err
+	}
+
+	version := strconv.FormatUint(status.Version, 10)
+	if status.Dirty {
+		version += " (dirty)"
+	}
+	fmt.Printf("version: %s\nlatest:  %d\n", version, status.Latest)
+	if len(status.Pending) > 0 {
+		fmt.Printf("pending:\n  %s\n", strings.Join(status.Pending, "\n  "))
+	}
+
+	return nil
+}
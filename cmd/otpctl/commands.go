@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/imansohibul/otp-service/config"
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// command is a subcommand of otpctl. Its flags are parsed before connecting to the database,
+// so that invalid flags and -h do not require a database.
+type command struct {
+	name    string
+	summary string
+	parse   func(args []string) (action, error)
+}
+
+// action performs a parsed command
+type action func(ctx context.Context, admin *config.Admin) (result, error)
+
+var commands = []command{
+	{name: "otps", summary: "List OTPs, newest first", parse: parseListOTPs},
+	{name: "revoke", summary: "Revoke an OTP, or every active OTP of a user", parse: parseRevokeOTPs},
+	{name: "lockout", summary: "Show the lockout of a user", parse: parseGetLockout},
+	{name: "clear-lockout", summary: "Clear the lockout and the failure ledger of a user", parse: parseClearLockout},
+	{name: "purge", summary: "Expire stale OTPs and purge those past the retention period, once", parse: parsePurgeOTPs},
+	{name: "stats", summary: "Count the stored OTPs of each status", parse: parseCountOTPs},
+	{name: "audit-export", summary: "Export the audit events of a user, oldest first", parse: parseExportAuditEvents},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+var (
+	// errUserRequired is returned by the commands that operate on a single user when no user is given
+	errUserRequired = errors.New("-user is required")
+	// errLimitNotPositive is returned by the listing commands when the limit is not positive
+	errLimitNotPositive = errors.New("-limit must be positive")
+)
+
+func parseListOTPs(args []string) (action, error) {
+	flags := flag.NewFlagSet("otps", flag.ExitOnError)
+	userID := flags.String("user", "", "only list OTPs of the user")
+	status := flags.String("status", "", "only list OTPs with the status: created, validated, expired or revoked")
+	purpose := flags.String("purpose", "", "only list OTPs requested for the purpose")
+	cursor := flags.String("cursor", "", "cursor of the page to list, printed after the previous page")
+	limit := flags.Int("limit", 50, "maximum number of OTPs to list")
+	flags.Parse(args)
+
+	filter := entity.OTPFilter{UserID: *userID, Purpose: *purpose}
+	if *status != "" {
+		otpStatus, ok := entity.ParseOTPStatus(*status)
+		if !ok {
+			return nil, fmt.Errorf("unknown status %q", *status)
+		}
+		filter.Status = otpStatus
+	}
+	if *limit < 1 {
+		return nil, errLimitNotPositive
+	}
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		page, err := admin.OTPs.List(ctx, filter, *cursor, *limit)
+		if err != nil {
+			return nil, err
+		}
+		return newOTPListResult(page.OTPs, page.NextCursor), nil
+	}, nil
+}
+
+func parseRevokeOTPs(args []string) (action, error) {
+	flags := flag.NewFlagSet("revoke", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user the OTPs were issued to")
+	otpID := flags.Uint64("otp", 0, "ID of the OTP to revoke, every active OTP of the user if zero")
+	reason := flags.String("reason", "", "why the OTPs are revoked, e.g. sim_swap")
+	flags.Parse(args)
+
+	if *userID == "" {
+		return nil, errUserRequired
+	}
+
+	if *otpID != 0 {
+		return func(ctx context.Context, admin *config.Admin) (result, error) {
+			otp, err := admin.OTPs.Revoke(ctx, entity.RevokeOTPParams{OTPID: *otpID, UserID: *userID, Reason: *reason})
+			if err != nil {
+				return nil, err
+			}
+			return newOTPListResult([]*entity.OTP{otp}, ""), nil
+		}, nil
+	}
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		otps, err := admin.OTPs.RevokeAllForUser(ctx, *userID, *reason)
+		if err != nil {
+			return nil, err
+		}
+		return newOTPListResult(otps, ""), nil
+	}, nil
+}
+
+func parseGetLockout(args []string) (action, error) {
+	flags := flag.NewFlagSet("lockout", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user")
+	flags.Parse(args)
+
+	if *userID == "" {
+		return nil, errUserRequired
+	}
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		lockout, err := admin.Lockouts.Get(ctx, *userID)
+		if err != nil {
+			return nil, err
+		}
+		return newLockoutResult(lockout, time.Now()), nil
+	}, nil
+}
+
+func parseClearLockout(args []string) (action, error) {
+	flags := flag.NewFlagSet("clear-lockout", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user")
+	flags.Parse(args)
+
+	if *userID == "" {
+		return nil, errUserRequired
+	}
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		if err := admin.Lockouts.Clear(ctx, *userID); err != nil {
+			return nil, err
+		}
+		return clearLockoutResult{UserID: *userID, Cleared: true}, nil
+	}, nil
+}
+
+func parsePurgeOTPs(args []string) (action, error) {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	flags.Parse(args)
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		expired, err := admin.Sweeper.ExpireStale(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		purged, err := admin.Sweeper.PurgeStale(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return purgeResult{Expired: expired, Purged: purged}, nil
+	}, nil
+}
+
+func parseCountOTPs(args []string) (action, error) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	flags.Parse(args)
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		counts, err := admin.OTPs.CountByStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newStatsResult(counts), nil
+	}, nil
+}
+
+func parseExportAuditEvents(args []string) (action, error) {
+	flags := flag.NewFlagSet("audit-export", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user")
+	from := flags.String("from", "", "only export events at or after the time, in RFC 3339 format")
+	to := flags.String("to", "", "only export events before the time, in RFC 3339 format")
+	limit := flags.Int("limit", 1000, "maximum number of events to export")
+	flags.Parse(args)
+
+	if *userID == "" {
+		return nil, errUserRequired
+	}
+	if *limit < 1 {
+		return nil, errLimitNotPositive
+	}
+
+	filter := entity.AuditEventFilter{UserID: *userID, Limit: *limit}
+	var err error
+	if filter.From, err = parseTimeFlag("from", *from); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseTimeFlag("to", *to); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, admin *config.Admin) (result, error) {
+		events, err := admin.AuditLog.ListEvents(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return newAuditEventsResult(events), nil
+	}, nil
+}
+
+// parseTimeFlag parses an RFC 3339 time flag, which is unset if empty
+func parseTimeFlag(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s must be an RFC 3339 time: %w", name, err)
+	}
+
+	return &t, nil
+}
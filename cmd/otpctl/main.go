@@ -0,0 +1,80 @@
+// Command otpctl is the command-line tool operators use to inspect and revoke OTPs, clear lockouts,
+// sweep stale OTPs and export the audit log. It connects to the database with the same
+// configuration as the server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/imansohibul/otp-service/config"
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// clientID identifies otpctl as the client of the operations recorded in the audit log
+const clientID = "otpctl"
+
+func main() {
+	flags := flag.NewFlagSet("otpctl", flag.ExitOnError)
+	output := flags.String("o", outputTable, "output format, table or json")
+	flags.Usage = func() { usage(flags) }
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := findCommand(flags.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "otpctl: unknown command %q\n\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "otpctl: unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+
+	run, err := cmd.parse(flags.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "otpctl %s: %v\n", cmd.name, err)
+		os.Exit(2)
+	}
+
+	admin, err := config.NewAdmin()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "otpctl: failed to initialize: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Operations are recorded in the audit log as performed by an admin through otpctl
+	ctx := entity.ContextWithRequestMetadata(context.Background(), entity.RequestMetadata{
+		Actor:    entity.AuditActorAdmin,
+		ClientID: clientID,
+	})
+
+	result, err := run(ctx, admin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "otpctl %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+
+	if err := printResult(os.Stdout, *output, result); err != nil {
+		fmt.Fprintf(os.Stderr, "otpctl: failed to print result: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintf(out, "Usage: otpctl [-o table|json] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nRun otpctl <command> -h for the flags of a command.\n\nGlobal flags:\n")
+	flags.PrintDefaults()
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// Output formats of otpctl
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// result is the output of a command, printed as indented JSON or as a table
+type result interface {
+	// writeTable writes a header row followed by the rows of the result, with tab separated cells
+	writeTable(w io.Writer)
+}
+
+func printResult(w io.Writer, format string, r result) error {
+	if format == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	r.writeTable(tw)
+	return tw.Flush()
+}
+
+// writeRow writes a table row, with a dash for every empty cell
+func writeRow(w io.Writer, cells ...string) {
+	for i, cell := range cells {
+		if cell == "" {
+			cells[i] = "-"
+		}
+	}
+	fmt.Fprintln(w, strings.Join(cells, "\t"))
+}
+
+// formatTime formats an optional time for a table, empty if unset
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// otpView is an OTP as printed by otpctl. The code of the OTP is never printed.
+type otpView struct {
+	ID           uint64     `json:"id"`
+	UserID       string     `json:"user_id"`
+	Status       string     `json:"status"`
+	Purpose      string     `json:"purpose,omitempty"`
+	Bound        bool       `json:"bound"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	ValidatedAt  *time.Time `json:"validated_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+	ResendCount  int        `json:"resend_count"`
+	ResendLimit  int        `json:"resend_limit"`
+}
+
+// otpListResult is a list of OTPs, with the cursor of the next page if there is one
+type otpListResult struct {
+	OTPs       []otpView `json:"otps"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+func newOTPListResult(otps []*entity.OTP, nextCursor string) otpListResult {
+	views := make([]otpView, 0, len(otps))
+	for _, otp := range otps {
+		views = append(views, otpView{
+			ID:           otp.ID,
+			UserID:       otp.UserID,
+			Status:       otp.Status.String(),
+			Purpose:      otp.Purpose,
+			Bound:        otp.IsBound(),
+			CreatedAt:    otp.CreatedAt,
+			ExpiresAt:    otp.ExpiresAt,
+			ValidatedAt:  otp.ValidatedAt,
+			RevokedAt:    otp.RevokedAt,
+			RevokeReason: otp.RevokeReason,
+			ResendCount:  otp.ResendCount,
+			ResendLimit:  otp.ResendLimit,
+		})
+	}
+
+	return otpListResult{OTPs: views, NextCursor: nextCursor}
+}
+
+func (r otpListResult) writeTable(w io.Writer) {
+	writeRow(w, "ID", "USER", "STATUS", "PURPOSE", "BOUND", "CREATED", "EXPIRES", "VALIDATED", "REVOKED", "REASON", "RESENDS")
+	for _, otp := range r.OTPs {
+		writeRow(w,
+			fmt.Sprint(otp.ID),
+			otp.UserID,
+			otp.Status,
+			otp.Purpose,
+			fmt.Sprint(otp.Bound),
+			formatTime(&otp.CreatedAt),
+			formatTime(&otp.ExpiresAt),
+			formatTime(otp.ValidatedAt),
+			formatTime(otp.RevokedAt),
+			otp.RevokeReason,
+			fmt.Sprintf("%d/%d", otp.ResendCount, otp.ResendLimit),
+		)
+	}
+	if r.NextCursor != "" {
+		fmt.Fprintf(w, "\nnext cursor: %s\n", r.NextCursor)
+	}
+}
+
+// lockoutResult is the lockout ledger of a user
+type lockoutResult struct {
+	UserID         string     `json:"user_id"`
+	Locked         bool       `json:"locked"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LastFailedAt   *time.Time `json:"last_failed_at,omitempty"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
+
+func newLockoutResult(lockout *entity.UserLockout, now time.Time) lockoutResult {
+	return lockoutResult{
+		UserID:         lockout.UserID,
+		Locked:         lockout.IsLocked(now),
+		FailedAttempts: lockout.FailedAttempts,
+		LastFailedAt:   lockout.LastFailedAt,
+		LockedUntil:    lockout.LockedUntil,
+	}
+}
+
+func (r lockoutResult) writeTable(w io.Writer) {
+	writeRow(w, "USER", "LOCKED", "FAILED ATTEMPTS", "LAST FAILED", "LOCKED UNTIL")
+	writeRow(w, r.UserID, fmt.Sprint(r.Locked), fmt.Sprint(r.FailedAttempts), formatTime(r.LastFailedAt), formatTime(r.LockedUntil))
+}
+
+// clearLockoutResult confirms that the lockout of a user was cleared
+type clearLockoutResult struct {
+	UserID  string `json:"user_id"`
+	Cleared bool   `json:"cleared"`
+}
+
+func (r clearLockoutResult) writeTable(w io.Writer) {
+	writeRow(w, "USER", "CLEARED")
+	writeRow(w, r.UserID, fmt.Sprint(r.Cleared))
+}
+
+// purgeResult is the number of OTPs a sweep expired and purged
+type purgeResult struct {
+	Expired int64 `json:"expired"`
+	Purged  int64 `json:"purged"`
+}
+
+func (r purgeResult) writeTable(w io.Writer) {
+	writeRow(w, "EXPIRED", "PURGED")
+	writeRow(w, fmt.Sprint(r.Expired), fmt.Sprint(r.Purged))
+}
+
+// statusCountView is the number of stored OTPs with a status
+type statusCountView struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// statsResult is the number of stored OTPs of each status, in lifecycle order
+type statsResult struct {
+	Statuses []statusCountView `json:"statuses"`
+	Total    int64             `json:"total"`
+}
+
+func newStatsResult(counts []entity.OTPStatusCount) statsResult {
+	stats := statsResult{Statuses: make([]statusCountView, 0, len(counts))}
+	for _, count := range counts {
+		stats.Statuses = append(stats.Statuses, statusCountView{Status: count.Status.String(), Count: count.Count})
+		stats.Total += count.Count
+	}
+	return stats
+}
+
+func (r statsResult) writeTable(w io.Writer) {
+	writeRow(w, "STATUS", "COUNT")
+	for _, count := range r.Statuses {
+		writeRow(w, count.Status, fmt.Sprint(count.Count))
+	}
+	writeRow(w, "total", fmt.Sprint(r.Total))
+}
+
+// auditEventView is an audit event as exported by otpctl, with the hashes
+// that allow the chain to be verified outside the service
+type auditEventView struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Actor     string    `json:"actor"`
+	ClientID  string    `json:"client_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserID    string    `json:"user_id"`
+	OTPID     uint64    `json:"otp_id,omitempty"`
+	Action    string    `json:"action"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason,omitempty"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// auditEventsResult is a list of audit events, oldest first
+type auditEventsResult struct {
+	Events []auditEventView `json:"events"`
+}
+
+func newAuditEventsResult(events []*entity.AuditEvent) auditEventsResult {
+	views := make([]auditEventView, 0, len(events))
+	for _, event := range events {
+		views = append(views, auditEventView{
+			ID:        event.ID,
+			CreatedAt: event.CreatedAt,
+			Actor:     event.Actor,
+			ClientID:  event.ClientID,
+			IP:        event.IP,
+			UserID:    event.UserID,
+			OTPID:     event.OTPID,
+			Action:    string(event.Action),
+			Outcome:   event.Outcome.String(),
+			Reason:    event.Reason,
+			PrevHash:  event.PrevHash,
+			Hash:      event.Hash,
+		})
+	}
+
+	return auditEventsResult{Events: views}
+}
+
+func (r auditEventsResult) writeTable(w io.Writer) {
+	writeRow(w, "ID", "TIME", "ACTOR", "CLIENT", "IP", "ACTION", "OUTCOME", "REASON", "OTP")
+	for _, event := range r.Events {
+		otpID := ""
+		if event.OTPID != 0 {
+			otpID = fmt.Sprint(event.OTPID)
+		}
+		writeRow(w,
+			fmt.Sprint(event.ID),
+			formatTime(&event.CreatedAt),
+			event.Actor,
+			event.ClientID,
+			event.IP,
+			event.Action,
+			event.Outcome,
+			event.Reason,
+			otpID,
+		)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/imansohibul/otp-service/internal/usecase"
+)
+
+// Admin holds the components operated by the otpctl command-line tool
+type Admin struct {
+	OTPs     OTPAdministrator
+	Lockouts LockoutAdministrator
+	// Sweeper expires and purges stale OTPs, like the otp-expiry-sweeper job of the server
+	Sweeper  OTPSweeper
+	AuditLog AuditExporter
+}
+
+// OTPAdministrator inspects, counts and revokes OTPs
+type OTPAdministrator interface {
+	List(ctx context.Context, filter entity.OTPFilter, cursor string, limit int) (*entity.OTPPage, error)
+	Revoke(ctx context.Context, params entity.RevokeOTPParams) (*entity.OTP, error)
+	RevokeAllForUser(ctx context.Context, userID string, reason string) ([]*entity.OTP, error)
+	CountByStatus(ctx context.Context) ([]entity.OTPStatusCount, error)
+}
+
+// LockoutAdministrator inspects and clears the lockouts of users
+type LockoutAdministrator interface {
+	Get(ctx context.Context, userID string) (*entity.UserLockout, error)
+	Clear(ctx context.Context, userID string) error
+}
+
+// OTPSweeper expires OTPs past their expiry and purges them after the retention period
+type OTPSweeper interface {
+	ExpireStale(ctx context.Context) (int64, error)
+	PurgeStale(ctx context.Context) (int64, error)
+}
+
+// AuditExporter retrieves the events of the audit log
+type AuditExporter interface {
+	ListEvents(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error)
+}
+
+// NewAdmin connects to the database with the same configuration as the server
+// and creates the components operated by the otpctl command-line tool
+func NewAdmin() (*Admin, error) {
+	// Load configuration
+	serviceConfig, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize database connection
+	db := initDatabase(serviceConfig)
+	var encryptor repository.ColumnEncryptor
+	if keyring := openKeyring(serviceConfig, db); keyring != nil {
+		encryptor = keyring
+	}
+
+	// Initialize repositories, which read from the primary so that operators see the effect of their commands right away
+	var (
+		otpRepository         = repository.NewOTPRepository(db, nil, encryptor)
+		userLockoutRepository = repository.NewUserLockoutRepository(db)
+		outboxRepository      = repository.NewOutboxRepository(db)
+		auditEventRepository  = repository.NewAuditEventRepository(db, nil)
+		idempotencyRepository = repository.NewIdempotencyRepository(db)
+		transactionManager    = repository.NewTransactionManager(db, newRetryPolicy(serviceConfig.DatabaseConfig))
+	)
+
+	auditLog := usecase.NewAuditLog(transactionManager, auditEventRepository)
+
+	return &Admin{
+		OTPs: usecase.NewOtpUsecase(
+			transactionManager,
+			otpRepository,
+			userLockoutRepository,
+			outboxRepository,
+			usecase.NewOTPGenerator(),
+			auditLog,
+			newOTPPolicy(serviceConfig),
+		),
+		Lockouts: usecase.NewUserLockoutUsecase(userLockoutRepository, auditLog),
+		Sweeper: usecase.NewOTPSweeperUsecase(
+			transactionManager,
+			otpRepository,
+			outboxRepository,
+			idempotencyRepository,
+			newSweepPolicy(serviceConfig.Sweeper),
+		),
+		AuditLog: auditLog,
+	}, nil
+}
@@ -1,10 +1,21 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/imansohibul/otp-service/db/migrate"
+	"github.com/imansohibul/otp-service/internal/crypto"
+	"github.com/imansohibul/otp-service/internal/repository"
 	"github.com/jmoiron/sqlx"
 	"github.com/kelseyhightower/envconfig"
 	_ "github.com/lib/pq"
@@ -13,6 +24,19 @@ import (
 
 type ServiceConfig struct {
 	DatabaseConfig DatabaseConfig `envconfig:"DB"`
+	LockoutConfig  LockoutConfig  `envconfig:"LOCKOUT"`
+	OpaqueErrors   OpaqueErrors   `envconfig:"OPAQUE_ERRORS"`
+	Idempotency    Idempotency    `envconfig:"IDEMPOTENCY"`
+	Validation     Validation     `envconfig:"VALIDATION"`
+	Resend         Resend         `envconfig:"RESEND"`
+	Sweeper        Sweeper        `envconfig:"SWEEPER"`
+	Partitions     Partitions     `envconfig:"PARTITIONS"`
+	Scheduler      Scheduler      `envconfig:"SCHEDULER"`
+	Outbox         Outbox         `envconfig:"OUTBOX"`
+	Webhooks       Webhooks       `envconfig:"WEBHOOKS"`
+	OTPCache       OTPCache       `envconfig:"OTP_CACHE"`
+	Encryption     Encryption     `envconfig:"ENCRYPTION"`
+	AdminAPIKey    string         `envconfig:"ADMIN_API_KEY"`
 }
 
 // LoadConfig loads the configuration from environment variables
@@ -27,21 +51,271 @@ func LoadConfig() (ServiceConfig, error) {
 	}
 
 	// parse environment variable to config struct
-	err := envconfig.Process("service", &cfg)
-	return cfg, err
+	if err := envconfig.Process("service", &cfg); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Scheduler.HolderID == "" {
+		cfg.Scheduler.HolderID = defaultHolderID()
+	}
+
+	if err := cfg.validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
 }
 
+// validate rejects the settings the service cannot run with
+func (cfg ServiceConfig) validate() error {
+	// A reservation without a lease would be taken over by the first retry, while the request is still processed
+	if cfg.Idempotency.ReservationLease <= 0 {
+		return errors.New("SERVICE_IDEMPOTENCY_RESERVATION_LEASE must be positive")
+	}
+	// A user would be locked out on the first failed validation
+	if cfg.LockoutConfig.MaxFailedAttempts <= 0 {
+		return errors.New("SERVICE_LOCKOUT_MAX_FAILED_ATTEMPTS must be positive")
+	}
+	// The sweeper and the key rotator run batches until one is not full, which a batch of nothing always is
+	if cfg.Sweeper.BatchSize <= 0 {
+		return errors.New("SERVICE_SWEEPER_BATCH_SIZE must be positive")
+	}
+	if cfg.Encryption.Enabled && cfg.Encryption.BatchSize <= 0 {
+		return errors.New("SERVICE_ENCRYPTION_BATCH_SIZE must be positive")
+	}
+	// A job with a non-positive interval would run again on every renewal of its lease
+	if cfg.Sweeper.Enabled && cfg.Sweeper.Interval <= 0 {
+		return errors.New("SERVICE_SWEEPER_INTERVAL must be positive")
+	}
+	if cfg.Partitions.Enabled && cfg.Partitions.Interval <= 0 {
+		return errors.New("SERVICE_PARTITIONS_INTERVAL must be positive")
+	}
+	if cfg.Encryption.Enabled && cfg.Encryption.RotationInterval <= 0 {
+		return errors.New("SERVICE_ENCRYPTION_ROTATION_INTERVAL must be positive")
+	}
+	if cfg.Outbox.DispatchInterval <= 0 {
+		return errors.New("SERVICE_OUTBOX_DISPATCH_INTERVAL must be positive")
+	}
+	if cfg.Webhooks.DispatchInterval <= 0 {
+		return errors.New("SERVICE_WEBHOOKS_DISPATCH_INTERVAL must be positive")
+	}
+	// The lease renewal ticker panics on a non-positive interval, and a lease that
+	// is not renewed before it lapses lets another instance run the same job
+	if cfg.Scheduler.RenewInterval <= 0 {
+		return errors.New("SERVICE_SCHEDULER_RENEW_INTERVAL must be positive")
+	}
+	if cfg.Scheduler.RenewInterval >= cfg.Scheduler.LeaseDuration {
+		return errors.New("SERVICE_SCHEDULER_RENEW_INTERVAL must be shorter than SERVICE_SCHEDULER_LEASE_DURATION")
+	}
+	// The replica health check ticker panics on a non-positive interval
+	if len(cfg.DatabaseConfig.ReplicaHosts) > 0 && cfg.DatabaseConfig.ReplicaCheckInterval <= 0 {
+		return errors.New("SERVICE_DB_REPLICA_CHECK_INTERVAL must be positive")
+	}
+	// An OTP cache holding no user would only add locking to every OTP request
+	if cfg.OTPCache.Enabled && cfg.OTPCache.Size <= 0 {
+		return errors.New("SERVICE_OTP_CACHE_SIZE must be positive when the OTP cache is enabled")
+	}
+
+	return nil
+}
+
+// defaultHolderID identifies this instance by its hostname and process ID
+func defaultHolderID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// Supported database drivers
+const (
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite"
+	// driverMemory keeps the OTPs in memory and the other data in an in-memory SQLite database,
+	// so nothing survives a restart
+	driverMemory = "memory"
+)
+
 type DatabaseConfig struct {
+	// Driver is either "mysql", "postgres", "sqlite" or "memory"
+	Driver   string `envconfig:"DRIVER" default:"mysql"`
 	Host     string `envconfig:"HOST"`
 	Port     int    `envconfig:"PORT"`
 	Username string `envconfig:"USERNAME"`
 	Password string `envconfig:"PASSWORD"`
 	Database string `envconfig:"NAME"`
+	// SSLMode is the sslmode of PostgreSQL connections
+	SSLMode string `envconfig:"SSL_MODE" default:"disable"`
+	// Path is the database file of SQLite, which is created when it does not exist
+	Path string `envconfig:"PATH" default:"otp-service.db"`
+	// SchemaWait is how long the service waits on startup for the schema of MySQL and PostgreSQL
+	// to be migrated to the version it needs. When zero, it refuses to start right away.
+	SchemaWait time.Duration `envconfig:"SCHEMA_WAIT" default:"0s"`
+	// TxMaxAttempts is how many times a transaction failing on a deadlock or a lock wait timeout is run at most,
+	// waiting a randomized exponential backoff from TxBackoffBase up to TxBackoffMax between attempts
+	TxMaxAttempts int           `envconfig:"TX_MAX_ATTEMPTS" default:"3"`
+	TxBackoffBase time.Duration `envconfig:"TX_BACKOFF_BASE" default:"20ms"`
+	TxBackoffMax  time.Duration `envconfig:"TX_BACKOFF_MAX" default:"1s"`
+	// ReplicaHosts are the hosts of the read replicas of MySQL and PostgreSQL, as host or host:port,
+	// which share the credentials and the name of the primary database
+	ReplicaHosts []string `envconfig:"REPLICA_HOSTS"`
+	// ReplicaCheckInterval is how often the read replicas are pinged, to stop sending reads to those that do not answer
+	ReplicaCheckInterval time.Duration `envconfig:"REPLICA_CHECK_INTERVAL" default:"10s"`
+
+	// multiStatements lets a MySQL query hold several statements, which only the migrations need
+	multiStatements bool
+}
+
+// LockoutConfig configures the account-level lockout after repeated OTP validation failures
+type LockoutConfig struct {
+	MaxFailedAttempts int           `envconfig:"MAX_FAILED_ATTEMPTS" default:"5"`
+	Duration          time.Duration `envconfig:"DURATION" default:"15m"`
+}
+
+// OpaqueErrors configures the anti-enumeration mode, in which every rejected
+// OTP validation is reported to the client with the same error
+type OpaqueErrors struct {
+	Enabled         bool          `envconfig:"ENABLED" default:"false"`
+	MinResponseTime time.Duration `envconfig:"MIN_RESPONSE_TIME" default:"300ms"`
+}
+
+// Idempotency configures how long responses of requests with an Idempotency-Key are replayed
+type Idempotency struct {
+	ReplayWindow time.Duration `envconfig:"REPLAY_WINDOW" default:"10m"`
+	// ReservationLease is how long a key stays reserved by a request that has not completed,
+	// after which a retry takes it over, e.g. when the instance processing the request crashed
+	ReservationLease time.Duration `envconfig:"RESERVATION_LEASE" default:"30s"`
+}
+
+// Validation configures how OTP validations are handled
+type Validation struct {
+	// GracePeriod is how long after a successful validation an identical retry from the same session succeeds again
+	GracePeriod time.Duration `envconfig:"GRACE_PERIOD" default:"30s"`
+}
+
+// Resend configures how OTPs are resent
+type Resend struct {
+	// Rotate issues a new code superseding the old one on resend, instead of delivering the same code again
+	Rotate bool `envconfig:"ROTATE" default:"false"`
+	// MaxResends is how many times an OTP can be resent
+	MaxResends int `envconfig:"MAX_RESENDS" default:"3"`
+}
+
+// Sweeper configures the background worker that expires and purges stale OTPs
+type Sweeper struct {
+	Enabled   bool          `envconfig:"ENABLED" default:"true"`
+	Interval  time.Duration `envconfig:"INTERVAL" default:"1m"`
+	BatchSize int           `envconfig:"BATCH_SIZE" default:"500"`
+	// Retention is how long after expiring an OTP is kept before it is deleted
+	Retention time.Duration `envconfig:"RETENTION" default:"168h"`
+}
+
+// Partitions configures the background worker that maintains the daily partitions of the otps table,
+// which is only partitioned on MySQL
+type Partitions struct {
+	Enabled  bool          `envconfig:"ENABLED" default:"true"`
+	Interval time.Duration `envconfig:"INTERVAL" default:"1h"`
+	// Lookahead is how far ahead of the current time partitions are created
+	Lookahead time.Duration `envconfig:"LOOKAHEAD" default:"168h"`
+	// Retention is how long after its last OTP was created a partition is dropped,
+	// it must exceed the validity of the OTPs and the retention period of the sweeper
+	Retention time.Duration `envconfig:"RETENTION" default:"720h"`
+}
+
+// Scheduler configures the leader election of background jobs across instances
+type Scheduler struct {
+	// HolderID identifies this instance as job lease holder, defaults to the hostname and process ID
+	HolderID      string        `envconfig:"HOLDER_ID"`
+	LeaseDuration time.Duration `envconfig:"LEASE_DURATION" default:"30s"`
+	RenewInterval time.Duration `envconfig:"RENEW_INTERVAL" default:"10s"`
+	// RunRetention is how long the history of a job run is kept, zero keeps it forever
+	RunRetention time.Duration `envconfig:"RUN_RETENTION" default:"720h"`
+}
+
+// Outbox configures the delivery of OTP lifecycle events to other services
+type Outbox struct {
+	// Publisher is either "webhook" or "file"; when empty, events are only delivered to webhook subscriptions
+	Publisher        string        `envconfig:"PUBLISHER"`
+	WebhookURL       string        `envconfig:"WEBHOOK_URL"`
+	WebhookTimeout   time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"5s"`
+	FilePath         string        `envconfig:"FILE_PATH" default:"outbox.jsonl"`
+	DispatchInterval time.Duration `envconfig:"DISPATCH_INTERVAL" default:"10s"`
+	BatchSize        int           `envconfig:"BATCH_SIZE" default:"100"`
+	MaxAttempts      int           `envconfig:"MAX_ATTEMPTS" default:"10"`
+	BackoffBase      time.Duration `envconfig:"BACKOFF_BASE" default:"1s"`
+	BackoffMax       time.Duration `envconfig:"BACKOFF_MAX" default:"10m"`
+}
+
+// Webhooks configures the delivery of OTP lifecycle events to the webhook subscriptions of client applications
+type Webhooks struct {
+	Timeout          time.Duration `envconfig:"TIMEOUT" default:"5s"`
+	DispatchInterval time.Duration `envconfig:"DISPATCH_INTERVAL" default:"10s"`
+	BatchSize        int           `envconfig:"BATCH_SIZE" default:"100"`
+	MaxAttempts      int           `envconfig:"MAX_ATTEMPTS" default:"10"`
+	BackoffBase      time.Duration `envconfig:"BACKOFF_BASE" default:"1s"`
+	BackoffMax       time.Duration `envconfig:"BACKOFF_MAX" default:"10m"`
+}
+
+// OTPCache configures the in-process cache of the last OTP of users, looked up on every OTP request
+type OTPCache struct {
+	Enabled bool `envconfig:"ENABLED" default:"false"`
+	// Size is how many users are cached at most, the least recently used are evicted beyond it
+	Size int `envconfig:"SIZE" default:"10000"`
+	// TTL bounds how long an instance may miss the OTPs issued or changed by the other instances
+	TTL time.Duration `envconfig:"TTL" default:"30s"`
+}
+
+// Encryption configures the envelope encryption of the user IDs and codes of the stored OTPs,
+// and the background worker that rotates the keys they are encrypted with
+type Encryption struct {
+	Enabled bool `envconfig:"ENABLED" default:"false"`
+	// Keyfile is a JSON object of the base64-encoded 32-byte master keys by ID, held by the local KMS
+	Keyfile string `envconfig:"KEYFILE"`
+	// MasterKeyID identifies the master key of the keyfile the new keys are wrapped with,
+	// the keys wrapped by the other master keys are wrapped again with it by the key rotator
+	MasterKeyID      string        `envconfig:"MASTER_KEY_ID"`
+	RotationInterval time.Duration `envconfig:"ROTATION_INTERVAL" default:"1h"`
+	// DataKeyMaxAge is how long a data key encrypts the new OTPs before it is rotated
+	DataKeyMaxAge time.Duration `envconfig:"DATA_KEY_MAX_AGE" default:"720h"`
+	BatchSize     int           `envconfig:"BATCH_SIZE" default:"500"`
 }
 
-// BuildDSN constructs the MySQL DSN in URL format
+// DatabaseDSN constructs the DSN of the configured driver
 func (db DatabaseConfig) DatabaseDSN() string {
-	return fmt.Sprintf(
+	if db.Driver == driverMemory {
+		params := url.Values{
+			"_pragma": {"foreign_keys(1)"},
+		}
+		return "file::memory:?" + params.Encode()
+	}
+
+	if db.Driver == driverSQLite {
+		// WAL lets reads run concurrently with the single writer, and transactions take the write lock
+		// when they begin, so that two transactions never both read and then wait on each other to write.
+		// _time_format is left out: the driver ignores _txlock when it is set, and writes times
+		// in the SQLite format by default.
+		params := url.Values{
+			"_pragma": {"journal_mode(WAL)", "busy_timeout(5000)", "foreign_keys(1)"},
+			"_txlock": {"immediate"},
+		}
+		return "file:" + db.Path + "?" + params.Encode()
+	}
+
+	if db.Driver == driverPostgres {
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(db.Username, db.Password),
+			Host:     net.JoinHostPort(db.Host, strconv.Itoa(db.Port)),
+			Path:     "/" + db.Database,
+			RawQuery: url.Values{"sslmode": {db.SSLMode}}.Encode(),
+		}
+		return dsn.String()
+	}
+
+	dsn := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?parseTime=true",
 		db.Username,
 		db.Password,
@@ -49,11 +323,70 @@ func (db DatabaseConfig) DatabaseDSN() string {
 		db.Port,
 		db.Database,
 	)
+	if db.multiStatements {
+		dsn += "&multiStatements=true"
+	}
+
+	return dsn
 }
 
+// schemaCheckInterval is how often the schema version is checked while waiting for it to be migrated
+const schemaCheckInterval = 5 * time.Second
+
+// migrations returns the migrations of the configured driver
+func (db DatabaseConfig) migrations() fs.FS {
+	switch db.Driver {
+	case driverPostgres:
+		return migrate.PostgreSQL()
+	case driverSQLite, driverMemory:
+		return migrate.SQLite()
+	default:
+		return migrate.MySQL()
+	}
+}
+
+// initDatabase opens the database and makes sure its schema is up to date. SQLite databases are migrated,
+// the schema of MySQL and PostgreSQL must have been migrated beforehand with the migrate command.
 func initDatabase(cfg ServiceConfig) *sqlx.DB {
-	fmt.Println("DEBUG", cfg.DatabaseConfig.DatabaseDSN())
-	db := sqlx.MustOpen("mysql", cfg.DatabaseConfig.DatabaseDSN())
+	db := openDatabase(cfg.DatabaseConfig)
+	ctx := context.Background()
+
+	// SQLite runs without a separate migration step, the service migrates its database file itself
+	if db.DriverName() == driverSQLite {
+		if err := migrate.Up(ctx, db.DB, cfg.DatabaseConfig.migrations()); err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
+		return db
+	}
+
+	if err := waitForSchema(ctx, db, cfg.DatabaseConfig); err != nil {
+		log.Fatalf("failed to check database schema: %v", err)
+	}
+
+	return db
+}
+
+// openDatabase opens the database of the configured driver
+func openDatabase(cfg DatabaseConfig) *sqlx.DB {
+	switch cfg.Driver {
+	case driverMySQL, driverPostgres, driverSQLite, driverMemory:
+	default:
+		log.Fatalf("unsupported database driver %q", cfg.Driver)
+	}
+
+	driverName := cfg.Driver
+	if driverName == driverMemory {
+		driverName = driverSQLite
+	}
+
+	fmt.Println("DEBUG", cfg.DatabaseDSN())
+	db := sqlx.MustOpen(driverName, cfg.DatabaseDSN())
+	// Every connection to :memory: opens a database of its own, so a single connection is kept open
+	if cfg.Driver == driverMemory {
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxIdleTime(0)
+		db.SetConnMaxLifetime(0)
+	}
 
 	if err := db.Ping(); err != nil {
 		log.Fatalf("failed to ping database: %v", err)
@@ -61,3 +394,73 @@ func initDatabase(cfg ServiceConfig) *sqlx.DB {
 
 	return db
 }
+
+// openReplicas opens the read replicas of the configured database, without waiting for them to answer:
+// the replicas that do not are left out of the reads until their health check succeeds
+func openReplicas(cfg DatabaseConfig) *repository.ReplicaSet {
+	if len(cfg.ReplicaHosts) == 0 {
+		return nil
+	}
+	if cfg.Driver != driverMySQL && cfg.Driver != driverPostgres {
+		log.Fatalf("read replicas are not supported by the %q database driver", cfg.Driver)
+	}
+
+	replicas := make([]*sqlx.DB, 0, len(cfg.ReplicaHosts))
+	for _, host := range cfg.ReplicaHosts {
+		replicaCfg := cfg
+		replicaCfg.Host = host
+		if replicaHost, port, err := net.SplitHostPort(host); err == nil {
+			replicaCfg.Host = replicaHost
+			if replicaCfg.Port, err = strconv.Atoi(port); err != nil {
+				log.Fatalf("invalid port of read replica %q: %v", host, err)
+			}
+		}
+
+		db, err := sqlx.Open(cfg.Driver, replicaCfg.DatabaseDSN())
+		if err != nil {
+			log.Fatalf("failed to open read replica %q: %v", host, err)
+		}
+		replicas = append(replicas, db)
+	}
+
+	return repository.NewReplicaSet(cfg.ReplicaCheckInterval, replicas...)
+}
+
+// openKeyring opens the keyring encrypting the user IDs and codes of the stored OTPs, nil when encryption is disabled.
+// The keys are loaded on startup, so that a missing or wrong master key keeps the service from starting.
+func openKeyring(cfg ServiceConfig, db *sqlx.DB) *crypto.Keyring {
+	if !cfg.Encryption.Enabled {
+		return nil
+	}
+	if cfg.DatabaseConfig.Driver == driverMemory {
+		log.Fatalf("encryption is not supported by the %q database driver", cfg.DatabaseConfig.Driver)
+	}
+
+	kms, err := crypto.LoadKeyfile(cfg.Encryption.Keyfile)
+	if err != nil {
+		log.Fatalf("failed to load encryption keyfile: %v", err)
+	}
+
+	keyring := crypto.NewKeyring(kms, cfg.Encryption.MasterKeyID, repository.NewEncryptionKeyRepository(db))
+	if err := keyring.Load(context.Background()); err != nil {
+		log.Fatalf("failed to load encryption keys: %v", err)
+	}
+
+	return keyring
+}
+
+// waitForSchema checks that the schema of the database is up to date, for up to cfg.SchemaWait
+// while it is outdated, e.g. while the migration job of a deployment is still running.
+// A dirty schema is reported right away, as it needs to be fixed manually.
+func waitForSchema(ctx context.Context, db *sqlx.DB, cfg DatabaseConfig) error {
+	deadline := time.Now().Add(cfg.SchemaWait)
+	for {
+		err := migrate.Check(ctx, db.DB, cfg.migrations())
+		if !errors.Is(err, migrate.ErrSchemaOutdated) || time.Now().After(deadline) {
+			return err
+		}
+
+		log.Printf("waiting for the database schema to be migrated: %v", err)
+		time.Sleep(schemaCheckInterval)
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+)
+
+// Migrator holds the database and the migrations of its driver, for the migrate command
+type Migrator struct {
+	DB         *sql.DB
+	Migrations fs.FS
+}
+
+// NewMigrator opens the configured database without checking its schema version, unlike the service
+func NewMigrator() (*Migrator, error) {
+	// Load configuration
+	serviceConfig, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dbConfig := serviceConfig.DatabaseConfig
+	if dbConfig.Driver == driverMemory {
+		return nil, errors.New("the in-memory database is migrated by the service on startup")
+	}
+	dbConfig.multiStatements = true
+
+	return &Migrator{
+		DB:         openDatabase(dbConfig).DB,
+		Migrations: dbConfig.migrations(),
+	}, nil
+}
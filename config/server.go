@@ -1,12 +1,37 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/imansohibul/otp-service/entity"
 	"github.com/imansohibul/otp-service/internal/handler"
 	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/imansohibul/otp-service/internal/repository/cache"
+	"github.com/imansohibul/otp-service/internal/repository/memory"
+	"github.com/imansohibul/otp-service/internal/scheduler"
 	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/worker"
 )
 
-func NewRestAPI() (*handler.RestAPIServer, error) {
+// Application holds the components started by the main entrypoint
+type Application struct {
+	RestAPIServer *handler.RestAPIServer
+	// Scheduler runs the background jobs, each on a single instance at a time
+	Scheduler *scheduler.Scheduler
+	// Replicas are the read replicas of the database, nil when there is none
+	Replicas *repository.ReplicaSet
+	// AuditLog verifies the hash chain of the audit log
+	AuditLog AuditVerifier
+}
+
+// AuditVerifier checks that the audit log has not been tampered with
+type AuditVerifier interface {
+	VerifyChain(ctx context.Context) (*entity.AuditChainVerification, error)
+}
+
+func NewApplication() (*Application, error) {
 	// Load configuration
 	serviceConfig, err := LoadConfig()
 	if err != nil {
@@ -15,21 +40,232 @@ func NewRestAPI() (*handler.RestAPIServer, error) {
 
 	// Initialize database connection
 	db := initDatabase(serviceConfig)
+	replicas := openReplicas(serviceConfig.DatabaseConfig)
+	keyring := openKeyring(serviceConfig, db)
+
+	// The user IDs and codes of the OTPs and the signing secrets of the webhooks are encrypted when a keyring is configured
+	var encryptor repository.ColumnEncryptor
+	if keyring != nil {
+		encryptor = keyring
+	}
 
 	// Initialize repositories
 	var (
-		otpRepository = repository.NewOTPRepository(db)
+		userLockoutRepository         = repository.NewUserLockoutRepository(db)
+		idempotencyRepository         = repository.NewIdempotencyRepository(db)
+		jobLeaseRepository            = repository.NewJobLeaseRepository(db)
+		jobRunRepository              = repository.NewJobRunRepository(db)
+		outboxRepository              = repository.NewOutboxRepository(db)
+		webhookSubscriptionRepository = repository.NewWebhookSubscriptionRepository(db, encryptor)
+		webhookDeliveryRepository     = repository.NewWebhookDeliveryRepository(db)
+		auditEventRepository          = repository.NewAuditEventRepository(db, replicas)
 	)
 
+	// In the memory storage mode the OTPs are kept in memory, and their transactions also span the database
+	var (
+		otpRepository      usecase.OTPRepository      = repository.NewOTPRepository(db, replicas, encryptor)
+		transactionManager usecase.TransactionManager = repository.NewTransactionManager(db, newRetryPolicy(serviceConfig.DatabaseConfig))
+	)
+	if serviceConfig.DatabaseConfig.Driver == driverMemory {
+		store := memory.NewStore()
+		otpRepository = memory.NewOTPRepository(store)
+		transactionManager = memory.NewTransactionManager(store, transactionManager)
+	}
+	// The last OTP of users is cached in process, invalidated by the writes of this instance
+	if serviceConfig.OTPCache.Enabled {
+		lru := cache.NewLRU(serviceConfig.OTPCache.Size, serviceConfig.OTPCache.TTL)
+		otpRepository = cache.NewOTPRepository(otpRepository, lru)
+		transactionManager = cache.NewTransactionManager(transactionManager, lru)
+	}
+
 	// Create usecases
 	var (
 		otpGenerator = usecase.NewOTPGenerator()
+		auditLog     = usecase.NewAuditLog(transactionManager, auditEventRepository)
 		otpUsecase   = usecase.NewOtpUsecase(
+			transactionManager,
 			otpRepository,
+			userLockoutRepository,
+			outboxRepository,
 			otpGenerator,
+			auditLog,
+			newOTPPolicy(serviceConfig),
+		)
+		userLockoutUsecase = usecase.NewUserLockoutUsecase(userLockoutRepository, auditLog)
+		idempotencyUsecase = usecase.NewIdempotencyUsecase(
+			idempotencyRepository,
+			serviceConfig.Idempotency.ReplayWindow,
+			serviceConfig.Idempotency.ReservationLease,
+		)
+		webhookUsecase = usecase.NewWebhookUsecase(
+			webhookSubscriptionRepository,
+			webhookDeliveryRepository,
+			repository.NewWebhookSender(&http.Client{Timeout: serviceConfig.Webhooks.Timeout}),
+			usecase.WebhookPolicy{
+				BatchSize:   serviceConfig.Webhooks.BatchSize,
+				MaxAttempts: serviceConfig.Webhooks.MaxAttempts,
+				BackoffBase: serviceConfig.Webhooks.BackoffBase,
+				BackoffMax:  serviceConfig.Webhooks.BackoffMax,
+			},
+		)
+		otpTimelineUsecase = usecase.NewOTPTimelineUsecase(
+			otpRepository,
+			auditEventRepository,
+			outboxRepository,
+			webhookDeliveryRepository,
+		)
+	)
+
+	app := &Application{
+		AuditLog: auditLog,
+		Replicas: replicas,
+		Scheduler: scheduler.NewScheduler(
+			scheduler.Config{
+				HolderID:      serviceConfig.Scheduler.HolderID,
+				LeaseDuration: serviceConfig.Scheduler.LeaseDuration,
+				RenewInterval: serviceConfig.Scheduler.RenewInterval,
+				RunRetention:  serviceConfig.Scheduler.RunRetention,
+			},
+			jobLeaseRepository,
+			jobRunRepository,
+		),
+	}
+
+	// Register background jobs
+	if serviceConfig.Sweeper.Enabled {
+		otpSweeperUsecase := usecase.NewOTPSweeperUsecase(
+			transactionManager,
+			otpRepository,
+			outboxRepository,
+			idempotencyRepository,
+			newSweepPolicy(serviceConfig.Sweeper),
 		)
+		app.Scheduler.Register(scheduler.Job{
+			Name:     "otp-expiry-sweeper",
+			Interval: serviceConfig.Sweeper.Interval,
+			Run:      worker.NewExpirySweeper(otpSweeperUsecase).RunOnce,
+		})
+	}
+	if serviceConfig.Partitions.Enabled && serviceConfig.DatabaseConfig.Driver == driverMySQL {
+		otpPartitionUsecase := usecase.NewOTPPartitionUsecase(
+			repository.NewOTPPartitionRepository(db),
+			usecase.PartitionPolicy{
+				Lookahead: serviceConfig.Partitions.Lookahead,
+				Retention: serviceConfig.Partitions.Retention,
+			},
+		)
+		app.Scheduler.Register(scheduler.Job{
+			Name:     "otp-partition-maintainer",
+			Interval: serviceConfig.Partitions.Interval,
+			Run:      worker.NewPartitionMaintainer(otpPartitionUsecase).RunOnce,
+		})
+	}
+	if keyring != nil {
+		encryptionUsecase := usecase.NewEncryptionUsecase(
+			keyring,
+			repository.NewOTPRepository(db, nil, keyring),
+			repository.NewWebhookSubscriptionRepository(db, keyring),
+			usecase.EncryptionPolicy{
+				DataKeyMaxAge: serviceConfig.Encryption.DataKeyMaxAge,
+				BatchSize:     serviceConfig.Encryption.BatchSize,
+			},
+		)
+		app.Scheduler.Register(scheduler.Job{
+			Name:     "otp-key-rotation",
+			Interval: serviceConfig.Encryption.RotationInterval,
+			Run:      worker.NewKeyRotator(encryptionUsecase).RunOnce,
+		})
+	}
+
+	// Outbox events are fanned out to the webhook subscriptions, and to the configured publisher if any
+	eventPublishers := []usecase.EventPublisher{webhookUsecase}
+	if serviceConfig.Outbox.Publisher != "" {
+		eventPublisher, err := newEventPublisher(serviceConfig.Outbox)
+		if err != nil {
+			return nil, err
+		}
+		eventPublishers = append(eventPublishers, eventPublisher)
+	}
+
+	outboxUsecase := usecase.NewOutboxUsecase(
+		outboxRepository,
+		usecase.NewMultiEventPublisher(eventPublishers...),
+		usecase.OutboxPolicy{
+			BatchSize:   serviceConfig.Outbox.BatchSize,
+			MaxAttempts: serviceConfig.Outbox.MaxAttempts,
+			BackoffBase: serviceConfig.Outbox.BackoffBase,
+			BackoffMax:  serviceConfig.Outbox.BackoffMax,
+		},
 	)
+	app.Scheduler.Register(scheduler.Job{
+		Name:     "outbox-dispatcher",
+		Interval: serviceConfig.Outbox.DispatchInterval,
+		Run:      worker.NewOutboxDispatcher(outboxUsecase).RunOnce,
+	})
+	app.Scheduler.Register(scheduler.Job{
+		Name:     "webhook-dispatcher",
+		Interval: serviceConfig.Webhooks.DispatchInterval,
+		Run:      worker.NewWebhookDispatcher(webhookUsecase).RunOnce,
+	})
 
 	// Initialize Rest API server
-	return handler.NewRestAPIServer(otpUsecase), nil
+	app.RestAPIServer = handler.NewRestAPIServer(
+		handler.Config{
+			AdminAPIKey:                 serviceConfig.AdminAPIKey,
+			OpaqueErrors:                serviceConfig.OpaqueErrors.Enabled,
+			OpaqueErrorsMinResponseTime: serviceConfig.OpaqueErrors.MinResponseTime,
+		},
+		otpUsecase,
+		userLockoutUsecase,
+		idempotencyUsecase,
+		webhookUsecase,
+		auditLog,
+		otpTimelineUsecase,
+	)
+
+	return app, nil
+}
+
+// newOTPPolicy creates the policy of issuing, validating and resending OTPs
+func newOTPPolicy(serviceConfig ServiceConfig) usecase.OTPPolicy {
+	return usecase.OTPPolicy{
+		Lockout: usecase.LockoutPolicy{
+			MaxFailedAttempts: serviceConfig.LockoutConfig.MaxFailedAttempts,
+			Duration:          serviceConfig.LockoutConfig.Duration,
+		},
+		Resend: usecase.ResendPolicy{
+			Rotate:     serviceConfig.Resend.Rotate,
+			MaxResends: serviceConfig.Resend.MaxResends,
+		},
+		ValidationGracePeriod: serviceConfig.Validation.GracePeriod,
+	}
+}
+
+// newRetryPolicy creates the policy of running again the transactions failing on a transient error
+func newRetryPolicy(cfg DatabaseConfig) repository.RetryPolicy {
+	return repository.RetryPolicy{
+		MaxAttempts: cfg.TxMaxAttempts,
+		BackoffBase: cfg.TxBackoffBase,
+		BackoffMax:  cfg.TxBackoffMax,
+	}
+}
+
+// newSweepPolicy creates the policy of expiring and purging stale OTPs
+func newSweepPolicy(cfg Sweeper) usecase.SweepPolicy {
+	return usecase.SweepPolicy{
+		BatchSize: cfg.BatchSize,
+		Retention: cfg.Retention,
+	}
+}
+
+// newEventPublisher creates the publisher that delivers outbox events to other services
+func newEventPublisher(cfg Outbox) (usecase.EventPublisher, error) {
+	switch cfg.Publisher {
+	case "webhook":
+		return repository.NewWebhookEventPublisher(cfg.WebhookURL, &http.Client{Timeout: cfg.WebhookTimeout}), nil
+	case "file":
+		return repository.NewFileEventPublisher(cfg.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
 }
@@ -0,0 +1,10 @@
+-- This SQL script creates a table named 'user_lockouts' in the database.
+-- The table is a per-user ledger of failed OTP validations across all OTPs,
+-- used to lock a user out after too many failures.
+CREATE TABLE IF NOT EXISTS user_lockouts (
+    user_id VARCHAR(50) PRIMARY KEY,                -- Reference to the user (short identifier)
+    failed_attempts INT NOT NULL DEFAULT 0,         -- Failed validations since the last reset or lock
+    last_failed_at TIMESTAMP NULL,                  -- When the most recent failed validation happened
+    locked_until TIMESTAMP NULL,                    -- The user is locked out until this timestamp
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
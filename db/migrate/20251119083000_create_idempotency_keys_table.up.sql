@@ -0,0 +1,13 @@
+-- This SQL script creates a table named 'idempotency_keys' in the database.
+-- The table stores the response of requests made with an Idempotency-Key header,
+-- so that retries within the replay window get the original response.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,       -- Client generated key from the Idempotency-Key header
+    request_hash CHAR(64) NOT NULL,                 -- SHA-256 hash of the request body, hex encoded
+    response_status SMALLINT NOT NULL DEFAULT 0,    -- HTTP status of the stored response, 0 while in progress
+    response_body BLOB NULL,                        -- Raw body of the stored response
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Automatically set creation timestamp
+    expires_at TIMESTAMP NOT NULL,                  -- The stored response is replayed until this timestamp
+
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
@@ -0,0 +1,3 @@
+-- Drop column validated_session_hash (rollback migration)
+ALTER TABLE otps
+    DROP COLUMN validated_session_hash;
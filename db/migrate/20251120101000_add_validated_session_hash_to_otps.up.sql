@@ -0,0 +1,4 @@
+-- Store which client session validated an OTP, so that an identical retry
+-- from the same session within the grace period gets the original success.
+ALTER TABLE otps
+    ADD COLUMN validated_session_hash CHAR(64) NULL AFTER validated_at; -- SHA-256 hash of the session identifier, hex encoded
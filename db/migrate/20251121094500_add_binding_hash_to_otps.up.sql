@@ -0,0 +1,4 @@
+-- Bind an OTP to the session or device that requested it, so that a phished
+-- code cannot be validated from another device.
+ALTER TABLE otps
+    ADD COLUMN binding_hash CHAR(64) NULL AFTER validated_session_hash; -- SHA-256 hash of the binding nonce or device ID, hex encoded
@@ -0,0 +1,4 @@
+-- Drop the expiry sweeper indexes (rollback migration)
+ALTER TABLE otps
+    DROP INDEX idx_otps_status_expires_at,
+    DROP INDEX idx_otps_expires_at;
@@ -0,0 +1,6 @@
+-- This SQL script adds the indexes used by the background expiry sweeper.
+-- idx_otps_status_expires_at finds created OTPs that are past their expiry,
+-- idx_otps_expires_at finds OTPs that are past the retention period.
+ALTER TABLE otps
+    ADD INDEX idx_otps_status_expires_at (status, expires_at),
+    ADD INDEX idx_otps_expires_at (expires_at);
@@ -0,0 +1,22 @@
+-- This SQL script creates the tables used by the background job scheduler.
+-- 'job_leases' elects a single instance to run each job: the instance holding
+-- an unexpired lease is the leader and keeps renewing it while it is alive.
+CREATE TABLE IF NOT EXISTS job_leases (
+    job_name VARCHAR(100) PRIMARY KEY,              -- Name of the scheduled job
+    holder_id VARCHAR(255) NOT NULL,                -- Identifier of the instance holding the lease
+    expires_at TIMESTAMP(3) NOT NULL,               -- When the lease lapses unless it is renewed
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- 'job_runs' keeps the history of job runs for debugging.
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,           -- Auto-incrementing ID
+    job_name VARCHAR(100) NOT NULL,                 -- Name of the scheduled job
+    holder_id VARCHAR(255) NOT NULL,                -- Instance that ran the job
+    status TINYINT NOT NULL,                        -- Run status (1 = running, 2 = succeeded, 3 = failed), see the application code.
+    error TEXT NULL,                                -- Error message of a failed run
+    started_at TIMESTAMP(3) NOT NULL,               -- When the run started
+    finished_at TIMESTAMP(3) NULL,                  -- When the run finished
+
+    INDEX idx_job_runs_job_name_started_at (job_name, started_at)
+);
@@ -0,0 +1,3 @@
+-- Drop tables webhook_deliveries and webhook_subscriptions (rollback migration)
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,32 @@
+-- This SQL script creates the tables of the webhooks to client applications.
+-- 'webhook_subscriptions' holds the endpoints subscribed to OTP lifecycle events.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,           -- Auto-incrementing ID
+    client_id VARCHAR(100) NOT NULL,                -- Client application owning the subscription
+    url VARCHAR(2048) NOT NULL,                     -- Endpoint the events are posted to
+    event_types JSON NOT NULL,                      -- Subscribed event types (e.g. ["otp.validated"])
+    secret VARCHAR(255) NOT NULL,                   -- Key of the HMAC-SHA256 signature of the deliveries
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Automatically set creation timestamp
+
+    INDEX idx_webhook_subscriptions_client_id (client_id)
+);
+
+-- 'webhook_deliveries' records every delivery of an event to a subscription.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,           -- Auto-incrementing ID
+    subscription_id BIGINT NOT NULL,                -- Reference to the webhook subscription
+    event_id BIGINT NOT NULL,                       -- Reference to the outbox event
+    event_type VARCHAR(50) NOT NULL,                -- Event type, see the application code.
+    payload JSON NOT NULL,                          -- Event payload
+    status TINYINT NOT NULL DEFAULT 1,              -- Delivery status (1 = pending, 2 = delivered, 3 = failed), see the application code.
+    attempts INT NOT NULL DEFAULT 0,                -- Number of failed delivery attempts
+    next_attempt_at TIMESTAMP(3) NOT NULL,          -- The delivery is not attempted before this timestamp
+    response_code INT NULL,                         -- HTTP status code of the last attempt
+    last_error TEXT NULL,                           -- Error of the last failed attempt
+    created_at TIMESTAMP(3) NOT NULL,               -- When the delivery was created
+    delivered_at TIMESTAMP(3) NULL,                 -- When the event was delivered
+
+    CONSTRAINT uq_webhook_delivery_subscription_event UNIQUE(subscription_id, event_id), -- Deliver each event once per subscription
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    INDEX idx_webhook_deliveries_status_next_attempt_at (status, next_attempt_at)
+);
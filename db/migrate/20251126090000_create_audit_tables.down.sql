@@ -0,0 +1,3 @@
+-- Drop tables audit_chain_head and audit_events (rollback migration)
+DROP TABLE IF EXISTS audit_chain_head;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,30 @@
+-- This SQL script creates the tables of the tamper-evident audit log.
+-- 'audit_events' is append-only: every row holds the hash of the previous row,
+-- so that a modified or deleted row breaks the chain.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,           -- Auto-incrementing ID, in chain order
+    actor VARCHAR(50) NOT NULL,                     -- Who performed the operation (client, admin or system)
+    client_id VARCHAR(100) NULL,                    -- Client application that sent the request
+    ip VARCHAR(45) NULL,                            -- Source IP of the request (IPv4 or IPv6)
+    user_id VARCHAR(255) NOT NULL,                  -- User the operation applied to
+    otp_id BIGINT NULL,                             -- OTP the operation applied to
+    action VARCHAR(50) NOT NULL,                    -- Audited operation, see the application code.
+    outcome TINYINT NOT NULL,                       -- Outcome (1 = success, 2 = failure), see the application code.
+    reason VARCHAR(100) NULL,                       -- Error code of a failed operation
+    created_at TIMESTAMP(6) NOT NULL,               -- When the operation was performed, covered by the hash
+    prev_hash CHAR(64) NOT NULL,                    -- Hash of the previous row, empty for the first row
+    hash CHAR(64) NOT NULL,                         -- SHA-256 of prev_hash and the other columns
+
+    INDEX idx_audit_events_user_id_created_at (user_id, created_at),
+    INDEX idx_audit_events_otp_id (otp_id)
+);
+
+-- 'audit_chain_head' holds the single row pointing at the last audit event. Appending an event
+-- locks it, which serializes the appends, and it detects rows deleted from the end of the chain.
+CREATE TABLE IF NOT EXISTS audit_chain_head (
+    id TINYINT PRIMARY KEY,                         -- Always 1
+    last_id BIGINT NOT NULL,                        -- ID of the last audit event, 0 while the log is empty
+    last_hash CHAR(64) NOT NULL                     -- Hash of the last audit event
+);
+
+INSERT INTO audit_chain_head (id, last_id, last_hash) VALUES (1, 0, '');
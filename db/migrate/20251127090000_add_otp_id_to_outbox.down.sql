@@ -0,0 +1,7 @@
+-- Drop column otp_id and the event index of the webhook deliveries (rollback migration)
+ALTER TABLE webhook_deliveries
+    DROP INDEX idx_webhook_deliveries_event_id;
+
+ALTER TABLE outbox
+    DROP INDEX idx_outbox_otp_id,
+    DROP COLUMN otp_id;
@@ -0,0 +1,13 @@
+-- This SQL script links outbox events to the OTP they describe, so that the
+-- timeline of an OTP can include the delivery of its events, and indexes the
+-- webhook deliveries by event.
+ALTER TABLE outbox
+    ADD COLUMN otp_id BIGINT NULL AFTER event_type, -- OTP the event describes, NULL for user events
+    ADD INDEX idx_outbox_otp_id (otp_id);
+
+UPDATE outbox
+SET otp_id = JSON_EXTRACT(payload, '$.otp_id')
+WHERE JSON_EXTRACT(payload, '$.otp_id') IS NOT NULL;
+
+ALTER TABLE webhook_deliveries
+    ADD INDEX idx_webhook_deliveries_event_id (event_id);
@@ -0,0 +1,6 @@
+-- Drop column purpose and the admin listing indexes (rollback migration)
+ALTER TABLE otps
+    DROP INDEX idx_otps_user_id_id,
+    DROP INDEX idx_otps_purpose_id,
+    DROP INDEX idx_otps_created_at,
+    DROP COLUMN purpose;
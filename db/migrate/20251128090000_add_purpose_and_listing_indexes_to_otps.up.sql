@@ -0,0 +1,8 @@
+-- This SQL script adds the purpose of an OTP and the indexes used by the admin listing,
+-- which pages through OTPs newest first. idx_otps_user_id_id and idx_otps_purpose_id
+-- serve the user and purpose filters in ID order, idx_otps_created_at the created-at range.
+ALTER TABLE otps
+    ADD COLUMN purpose VARCHAR(50) NULL AFTER binding_hash, -- What the OTP was requested for (e.g. login), if given
+    ADD INDEX idx_otps_user_id_id (user_id, id),
+    ADD INDEX idx_otps_purpose_id (purpose, id),
+    ADD INDEX idx_otps_created_at (created_at);
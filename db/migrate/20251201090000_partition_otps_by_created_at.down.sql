@@ -0,0 +1,39 @@
+-- Copy the OTPs back into a table that is not partitioned, with the unique key of the codes
+-- of a user restored, and drop otp_codes (rollback migration)
+RENAME TABLE otps TO otps_partitioned;
+
+CREATE TABLE otps (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    otp_code CHAR(6) NOT NULL,
+    status TINYINT DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    validated_at TIMESTAMP NULL,
+    validated_session_hash CHAR(64) NULL,
+    binding_hash CHAR(64) NULL,
+    purpose VARCHAR(50) NULL,
+    revoked_at TIMESTAMP NULL,
+    revoke_reason VARCHAR(100) NULL,
+    resend_count INT NOT NULL DEFAULT 0,
+    resend_limit INT NOT NULL DEFAULT 0,
+
+    CONSTRAINT uq_otp_user_code UNIQUE(user_id, otp_code),
+    INDEX idx_otps_status_expires_at (status, expires_at),
+    INDEX idx_otps_expires_at (expires_at),
+    INDEX idx_otps_user_id_id (user_id, id),
+    INDEX idx_otps_purpose_id (purpose, id),
+    INDEX idx_otps_created_at (created_at)
+);
+
+INSERT INTO otps (
+    id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash,
+    binding_hash, purpose, revoked_at, revoke_reason, resend_count, resend_limit
+)
+SELECT
+    id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash,
+    binding_hash, purpose, revoked_at, revoke_reason, resend_count, resend_limit
+FROM otps_partitioned;
+
+DROP TABLE otps_partitioned;
+DROP TABLE IF EXISTS otp_codes;
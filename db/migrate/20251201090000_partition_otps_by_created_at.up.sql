@@ -0,0 +1,58 @@
+-- This SQL script partitions the otps table by day of creation, so that old OTPs are dropped a partition
+-- at a time instead of row by row. Every unique key of a partitioned table must hold the partitioning column,
+-- so the primary key becomes (id, created_at) and the uniqueness of the codes of a user moves to otp_codes,
+-- which is not partitioned. The OTPs are copied into a new partitioned otps table, which starts with p_future
+-- holding every OTP; the partition maintenance job splits it into daily partitions ahead of time.
+-- The OTPs cannot be written while they are copied: on a large table, run it in a maintenance window.
+CREATE TABLE IF NOT EXISTS otp_codes (
+    user_id VARCHAR(50) NOT NULL,   -- Reference to the user (short identifier)
+    otp_code CHAR(6) NOT NULL,      -- OTP code (6 digits)
+    created_at TIMESTAMP NOT NULL,  -- Creation timestamp of the OTP holding the code, to look it up in its partition
+
+    PRIMARY KEY (user_id, otp_code),             -- Prevent duplicate OTPs for the same user
+    INDEX idx_otp_codes_created_at (created_at)  -- Releases the codes of the dropped partitions
+);
+
+RENAME TABLE otps TO otps_unpartitioned;
+
+CREATE TABLE otps (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    user_id VARCHAR(50) NOT NULL,
+    otp_code CHAR(6) NOT NULL,
+    status TINYINT DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    validated_at TIMESTAMP NULL,
+    validated_session_hash CHAR(64) NULL,
+    binding_hash CHAR(64) NULL,
+    purpose VARCHAR(50) NULL,
+    revoked_at TIMESTAMP NULL,
+    revoke_reason VARCHAR(100) NULL,
+    resend_count INT NOT NULL DEFAULT 0,
+    resend_limit INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (id, created_at),
+    INDEX idx_otps_user_code (user_id, otp_code),
+    INDEX idx_otps_status_expires_at (status, expires_at),
+    INDEX idx_otps_expires_at (expires_at),
+    INDEX idx_otps_user_id_id (user_id, id),
+    INDEX idx_otps_purpose_id (purpose, id),
+    INDEX idx_otps_created_at (created_at)
+)
+PARTITION BY RANGE (UNIX_TIMESTAMP(created_at)) (
+    PARTITION p_future VALUES LESS THAN (MAXVALUE)
+);
+
+INSERT INTO otps (
+    id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash,
+    binding_hash, purpose, revoked_at, revoke_reason, resend_count, resend_limit
+)
+SELECT
+    id, user_id, otp_code, status, COALESCE(created_at, CURRENT_TIMESTAMP), expires_at, validated_at, validated_session_hash,
+    binding_hash, purpose, revoked_at, revoke_reason, resend_count, resend_limit
+FROM otps_unpartitioned;
+
+INSERT INTO otp_codes (user_id, otp_code, created_at)
+SELECT user_id, otp_code, created_at FROM otps;
+
+DROP TABLE otps_unpartitioned;
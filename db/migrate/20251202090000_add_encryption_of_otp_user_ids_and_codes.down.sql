@@ -0,0 +1,16 @@
+-- Drop the encryption of the user IDs and codes of OTPs (rollback migration).
+-- The encrypted OTPs cannot be read without their keys, so they are deleted along with the codes they reserved.
+DELETE FROM otp_codes WHERE CHAR_LENGTH(otp_code) > 6;
+DELETE FROM otps WHERE key_id IS NOT NULL;
+
+ALTER TABLE otp_codes
+    MODIFY COLUMN otp_code CHAR(6) NOT NULL;
+
+ALTER TABLE otps
+    DROP INDEX idx_otps_key_id,
+    DROP COLUMN key_id,
+    DROP COLUMN otp_code_ciphertext,
+    DROP COLUMN user_id_ciphertext,
+    MODIFY COLUMN otp_code CHAR(6) NOT NULL;
+
+DROP TABLE IF EXISTS encryption_keys;
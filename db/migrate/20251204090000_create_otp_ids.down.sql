@@ -0,0 +1,2 @@
+-- Drop table otp_ids (rollback migration)
+DROP TABLE IF EXISTS otp_ids;
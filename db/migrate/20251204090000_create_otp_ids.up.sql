@@ -0,0 +1,12 @@
+-- This SQL script maps the ID of every OTP to its creation time in otp_ids, which is not partitioned,
+-- so that an OTP looked up by its ID is only searched in its partition of the otps table.
+CREATE TABLE IF NOT EXISTS otp_ids (
+    id BIGINT NOT NULL,             -- ID of the OTP
+    created_at TIMESTAMP NOT NULL,  -- Creation timestamp of the OTP, to look it up in its partition
+
+    PRIMARY KEY (id),
+    INDEX idx_otp_ids_created_at (created_at)  -- Releases the IDs of the dropped partitions
+);
+
+INSERT INTO otp_ids (id, created_at)
+SELECT id, created_at FROM otps;
@@ -0,0 +1,314 @@
+// Package migrate embeds the database migrations and applies them. The migrations of MySQL are in this
+// directory, those of PostgreSQL and SQLite in the postgres and sqlite directories, each named
+// <version>_<name>.up.sql and <version>_<name>.down.sql like golang-migrate expects.
+// The schema version is recorded in the schema_migrations table the same way golang-migrate records it,
+// so a database migrated by either tool can be migrated further by the other.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql postgres/*.sql sqlite/*.sql
+var migrationFiles embed.FS
+
+// nilVersion is the version golang-migrate records while the first migration is rolled back
+const nilVersion = -1
+
+var (
+	// ErrSchemaOutdated is returned when the schema of a database is behind the migrations
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+	// ErrSchemaDirty is returned when a migration failed partway, leaving the schema to be fixed manually
+	ErrSchemaDirty = errors.New("database schema is dirty")
+)
+
+// MySQL returns the migrations of MySQL
+func MySQL() fs.FS {
+	return migrationFiles
+}
+
+// PostgreSQL returns the migrations of PostgreSQL
+func PostgreSQL() fs.FS {
+	return sub("postgres")
+}
+
+// SQLite returns the migrations of SQLite
+func SQLite() fs.FS {
+	return sub("sqlite")
+}
+
+// sub returns the embedded migrations of a directory
+func sub(dir string) fs.FS {
+	migrations, err := fs.Sub(migrationFiles, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return migrations
+}
+
+// Status is the schema version of a database compared with the migrations
+type Status struct {
+	Version uint64   // Version of the last applied migration, 0 when none was applied
+	Dirty   bool     // Whether the last migration failed partway
+	Latest  uint64   // Version of the last migration
+	Pending []string // Up migrations newer than the schema version, in version order
+}
+
+// UpToDate reports whether every migration has been applied
+func (s Status) UpToDate() bool {
+	return !s.Dirty && s.Version >= s.Latest
+}
+
+// migration is the up and down files of a single version
+type migration struct {
+	version uint64
+	up      string
+	down    string
+}
+
+// Up applies the up migrations in migrations that are newer than the schema version of the database,
+// each in its own transaction.
+func Up(ctx context.Context, db *sql.DB, migrations fs.FS) error {
+	all, err := readMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	current, err := cleanSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		if m.version <= current {
+			continue
+		}
+		if err := run(ctx, db, migrations, m.up, int64(m.version)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.up, err)
+		}
+	}
+
+	return nil
+}
+
+// Down rolls back the last steps applied migrations, or every applied migration when steps is not positive,
+// each in its own transaction.
+func Down(ctx context.Context, db *sql.DB, migrations fs.FS, steps int) error {
+	all, err := readMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	current, err := cleanSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if steps <= 0 {
+		steps = len(all)
+	}
+
+	for i := len(all) - 1; i >= 0 && current > 0 && steps > 0; i-- {
+		m := all[i]
+		if m.version > current {
+			continue
+		}
+		if m.version < current {
+			return fmt.Errorf("no migration found for version %d", current)
+		}
+		if m.down == "" {
+			return fmt.Errorf("no down migration found for version %d", m.version)
+		}
+
+		target := int64(nilVersion)
+		if i > 0 {
+			target = int64(all[i-1].version)
+		}
+		if err := run(ctx, db, migrations, m.down, target); err != nil {
+			return fmt.Errorf("failed to roll back migration %s: %w", m.down, err)
+		}
+
+		current = 0
+		if target != nilVersion {
+			current = uint64(target)
+		}
+		steps--
+	}
+
+	return nil
+}
+
+// GetStatus compares the schema version of the database with the migrations
+func GetStatus(ctx context.Context, db *sql.DB, migrations fs.FS) (*Status, error) {
+	all, err := readMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	version, dirty, err := schemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Version: version, Dirty: dirty}
+	for _, m := range all {
+		status.Latest = m.version
+		if m.version > version {
+			status.Pending = append(status.Pending, m.up)
+		}
+	}
+
+	return status, nil
+}
+
+// Check returns ErrSchemaOutdated if some migrations have not been applied to the database,
+// and ErrSchemaDirty if the last one failed partway. A schema newer than the migrations passes,
+// so that a previous release keeps running while the next one migrates the database.
+func Check(ctx context.Context, db *sql.DB, migrations fs.FS) error {
+	status, err := GetStatus(ctx, db, migrations)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w at version %d, fix it manually", ErrSchemaDirty, status.Version)
+	}
+	if !status.UpToDate() {
+		return fmt.Errorf("%w: the database is at version %d, the service needs version %d", ErrSchemaOutdated, status.Version, status.Latest)
+	}
+
+	return nil
+}
+
+// readMigrations returns the migrations in version order
+func readMigrations(migrations fs.FS) ([]migration, error) {
+	files, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*migration)
+	for _, file := range files {
+		prefix, _, found := strings.Cut(path.Base(file), "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %s", file)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", file, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version}
+			byVersion[version] = m
+		}
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			m.up = file
+		case strings.HasSuffix(file, ".down.sql"):
+			m.down = file
+		default:
+			return nil, fmt.Errorf("invalid migration file name %s", file)
+		}
+	}
+
+	all := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("no up migration found for version %d", m.version)
+		}
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].version < all[j].version
+	})
+
+	return all, nil
+}
+
+// schemaVersion returns the version of the last applied migration, 0 when none was applied,
+// and whether it failed partway. The schema_migrations table is created if it does not exist.
+func schemaVersion(ctx context.Context, db *sql.DB) (uint64, bool, error) {
+	const createTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+	if _, err := db.ExecContext(ctx, createTableQuery); err != nil {
+		return 0, false, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version == nilVersion {
+		version = 0
+	}
+
+	return uint64(version), dirty, nil
+}
+
+// cleanSchemaVersion returns the version of the last applied migration,
+// or ErrSchemaDirty if it failed partway
+func cleanSchemaVersion(ctx context.Context, db *sql.DB) (uint64, error) {
+	version, dirty, err := schemaVersion(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d, fix it manually", ErrSchemaDirty, version)
+	}
+
+	return version, nil
+}
+
+// run executes a migration file and records the schema version it leads to, nilVersion when none is left.
+// The version is first recorded as dirty within the same transaction, so that it stays dirty if the migration
+// fails partway on a database that commits schema changes right away, like MySQL does.
+func run(ctx context.Context, db *sql.DB, migrations fs.FS, file string, version int64) error {
+	statements, err := fs.ReadFile(migrations, file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The version is written in the queries, placeholders differ between the databases
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO schema_migrations (version, dirty) VALUES (%d, true)`, version)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, string(statements)); err != nil {
+		return err
+	}
+
+	// Like golang-migrate, no row is left once every migration has been rolled back
+	finishQuery := `UPDATE schema_migrations SET dirty = false`
+	if version == nilVersion {
+		finishQuery = `DELETE FROM schema_migrations`
+	}
+	if _, err := tx.ExecContext(ctx, finishQuery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
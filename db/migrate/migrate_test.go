@@ -0,0 +1,175 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/imansohibul/otp-service/db/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDB opens an empty SQLite database in a temporary file
+func newDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// testMigrations are three migrations creating a table each
+var testMigrations = fstest.MapFS{
+	"1_create_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INTEGER PRIMARY KEY)`)},
+	"1_create_a.down.sql": {Data: []byte(`DROP TABLE a`)},
+	"2_create_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY)`)},
+	"2_create_b.down.sql": {Data: []byte(`DROP TABLE b`)},
+	"3_create_c.up.sql":   {Data: []byte(`CREATE TABLE c (id INTEGER PRIMARY KEY)`)},
+	"3_create_c.down.sql": {Data: []byte(`DROP TABLE c`)},
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := newDB(t)
+	ctx := context.TODO()
+
+	// The embedded migrations can all be rolled back and applied again
+	require.NoError(t, migrate.Up(ctx, db, migrate.SQLite()))
+	require.NoError(t, migrate.Down(ctx, db, migrate.SQLite(), 0))
+
+	status, err := migrate.GetStatus(ctx, db, migrate.SQLite())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), status.Version)
+	assert.Equal(t, uint64(20251205090000), status.Latest)
+	assert.Len(t, status.Pending, 19)
+
+	var rows int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&rows))
+	assert.Zero(t, rows)
+
+	require.NoError(t, migrate.Up(ctx, db, migrate.SQLite()))
+	assert.NoError(t, migrate.Check(ctx, db, migrate.SQLite()))
+}
+
+func TestDown(t *testing.T) {
+	tests := []struct {
+		name            string
+		steps           int
+		expectedVersion uint64
+		expectedPending []string
+	}{
+		{
+			name:            "Should roll back the given number of migrations",
+			steps:           2,
+			expectedVersion: 1,
+			expectedPending: []string{"2_create_b.up.sql", "3_create_c.up.sql"},
+		},
+		{
+			name:            "Should stop once every migration is rolled back",
+			steps:           5,
+			expectedPending: []string{"1_create_a.up.sql", "2_create_b.up.sql", "3_create_c.up.sql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDB(t)
+			ctx := context.TODO()
+			require.NoError(t, migrate.Up(ctx, db, testMigrations))
+
+			require.NoError(t, migrate.Down(ctx, db, testMigrations, tt.steps))
+
+			status, err := migrate.GetStatus(ctx, db, testMigrations)
+			require.NoError(t, err)
+			assert.Equal(t, &migrate.Status{Version: tt.expectedVersion, Latest: 3, Pending: tt.expectedPending}, status)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		prepare   func(t *testing.T, db *sql.DB)
+		expectErr error
+	}{
+		{
+			name: "Should pass when every migration is applied",
+			prepare: func(t *testing.T, db *sql.DB) {
+				require.NoError(t, migrate.Up(context.TODO(), db, testMigrations))
+			},
+		},
+		{
+			name: "Should pass when the schema is newer than the migrations",
+			prepare: func(t *testing.T, db *sql.DB) {
+				require.NoError(t, migrate.Up(context.TODO(), db, testMigrations))
+				_, err := db.Exec(`UPDATE schema_migrations SET version = 4`)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "Should fail when no migration is applied",
+			expectErr: migrate.ErrSchemaOutdated,
+		},
+		{
+			name: "Should fail when some migrations are pending",
+			prepare: func(t *testing.T, db *sql.DB) {
+				require.NoError(t, migrate.Up(context.TODO(), db, testMigrations))
+				require.NoError(t, migrate.Down(context.TODO(), db, testMigrations, 1))
+			},
+			expectErr: migrate.ErrSchemaOutdated,
+		},
+		{
+			name: "Should fail when the last migration failed partway",
+			prepare: func(t *testing.T, db *sql.DB) {
+				require.NoError(t, migrate.Up(context.TODO(), db, testMigrations))
+				_, err := db.Exec(`UPDATE schema_migrations SET dirty = true`)
+				require.NoError(t, err)
+			},
+			expectErr: migrate.ErrSchemaDirty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDB(t)
+			if tt.prepare != nil {
+				tt.prepare(t, db)
+			}
+
+			assert.ErrorIs(t, migrate.Check(context.TODO(), db, testMigrations), tt.expectErr)
+		})
+	}
+}
+
+func TestUp_Failure(t *testing.T) {
+	db := newDB(t)
+	ctx := context.TODO()
+
+	migrations := fstest.MapFS{
+		"1_create_a.up.sql": testMigrations["1_create_a.up.sql"],
+		"2_broken.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY); CREATE TABLE`)},
+	}
+	assert.Error(t, migrate.Up(ctx, db, migrations))
+
+	// SQLite rolls back the schema changes of the failed migration, so the schema stays clean at the previous version
+	status, err := migrate.GetStatus(ctx, db, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, &migrate.Status{Version: 1, Latest: 2, Pending: []string{"2_broken.up.sql"}}, status)
+
+	var tables int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'b'`).Scan(&tables))
+	assert.Zero(t, tables)
+
+	t.Run("Should refuse to migrate a dirty schema", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE schema_migrations SET dirty = true`)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, migrate.Up(ctx, db, migrations), migrate.ErrSchemaDirty)
+		assert.ErrorIs(t, migrate.Down(ctx, db, migrations, 1), migrate.ErrSchemaDirty)
+	})
+}
@@ -0,0 +1,3 @@
+-- Drop table user_lockouts and the updated_at trigger function if exists (rollback migration)
+DROP TABLE IF EXISTS user_lockouts;
+DROP FUNCTION IF EXISTS set_updated_at();
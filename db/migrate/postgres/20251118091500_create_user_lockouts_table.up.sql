@@ -0,0 +1,22 @@
+-- This SQL script creates a table named 'user_lockouts' in the database.
+-- The table is a per-user ledger of failed OTP validations across all OTPs,
+-- used to lock a user out after too many failures.
+CREATE TABLE IF NOT EXISTS user_lockouts (
+    user_id VARCHAR(50) PRIMARY KEY,                -- Reference to the user (short identifier)
+    failed_attempts INT NOT NULL DEFAULT 0,         -- Failed validations since the last reset or lock
+    last_failed_at TIMESTAMPTZ NULL,                -- When the most recent failed validation happened
+    locked_until TIMESTAMPTZ NULL,                  -- The user is locked out until this timestamp
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- PostgreSQL has no ON UPDATE CURRENT_TIMESTAMP, updated_at is maintained by a trigger instead.
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_lockouts_updated_at
+    BEFORE UPDATE ON user_lockouts
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,5 @@
+-- This SQL script adds the indexes used by the background expiry sweeper.
+-- idx_otps_status_expires_at finds created OTPs that are past their expiry,
+-- idx_otps_expires_at finds OTPs that are past the retention period.
+CREATE INDEX idx_otps_status_expires_at ON otps (status, expires_at);
+CREATE INDEX idx_otps_expires_at ON otps (expires_at);
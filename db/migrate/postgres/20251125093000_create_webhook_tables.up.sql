@@ -0,0 +1,33 @@
+-- This SQL script creates the tables of the webhooks to client applications.
+-- 'webhook_subscriptions' holds the endpoints subscribed to OTP lifecycle events.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, -- Auto-incrementing ID
+    client_id VARCHAR(100) NOT NULL,                -- Client application owning the subscription
+    url VARCHAR(2048) NOT NULL,                     -- Endpoint the events are posted to
+    event_types JSONB NOT NULL,                     -- Subscribed event types (e.g. ["otp.validated"]), JSONB for the containment lookup
+    secret VARCHAR(255) NOT NULL,                   -- Key of the HMAC-SHA256 signature of the deliveries
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP -- Automatically set creation timestamp
+);
+
+CREATE INDEX idx_webhook_subscriptions_client_id ON webhook_subscriptions (client_id);
+
+-- 'webhook_deliveries' records every delivery of an event to a subscription.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, -- Auto-incrementing ID
+    subscription_id BIGINT NOT NULL,                -- Reference to the webhook subscription
+    event_id BIGINT NOT NULL,                       -- Reference to the outbox event
+    event_type VARCHAR(50) NOT NULL,                -- Event type, see the application code.
+    payload JSON NOT NULL,                          -- Event payload
+    status SMALLINT NOT NULL DEFAULT 1,             -- Delivery status (1 = pending, 2 = delivered, 3 = failed), see the application code.
+    attempts INT NOT NULL DEFAULT 0,                -- Number of failed delivery attempts
+    next_attempt_at TIMESTAMPTZ(3) NOT NULL,        -- The delivery is not attempted before this timestamp
+    response_code INT NULL,                         -- HTTP status code of the last attempt
+    last_error TEXT NULL,                           -- Error of the last failed attempt
+    created_at TIMESTAMPTZ(3) NOT NULL,             -- When the delivery was created
+    delivered_at TIMESTAMPTZ(3) NULL,               -- When the event was delivered
+
+    CONSTRAINT uq_webhook_delivery_subscription_event UNIQUE(subscription_id, event_id), -- Deliver each event once per subscription
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
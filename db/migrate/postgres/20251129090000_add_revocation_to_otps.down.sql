@@ -0,0 +1,4 @@
+-- Drop columns revoked_at and revoke_reason (rollback migration)
+ALTER TABLE otps
+    DROP COLUMN revoked_at,
+    DROP COLUMN revoke_reason;
@@ -0,0 +1,5 @@
+-- This SQL script records the revocation of OTPs (status 4 = revoked), which cancels
+-- an OTP before it is used, e.g. when a user reports a SIM swap.
+ALTER TABLE otps
+    ADD COLUMN revoked_at TIMESTAMPTZ NULL,         -- When the OTP was revoked
+    ADD COLUMN revoke_reason VARCHAR(100) NULL;     -- Why the OTP was revoked, if given
@@ -0,0 +1,5 @@
+-- This SQL script adds the resend counter of OTPs and the number of resends allowed,
+-- which is fixed when the OTP is issued. OTPs issued before cannot be resent.
+ALTER TABLE otps
+    ADD COLUMN resend_count INT NOT NULL DEFAULT 0, -- Times the OTP, or the OTPs it superseded, were resent
+    ADD COLUMN resend_limit INT NOT NULL DEFAULT 0; -- Times the OTP can be resent
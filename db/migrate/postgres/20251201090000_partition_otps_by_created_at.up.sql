@@ -0,0 +1,3 @@
+-- The otps table is only partitioned on MySQL, see the MySQL migration.
+-- This migration keeps the versions of the databases aligned.
+SELECT 1;
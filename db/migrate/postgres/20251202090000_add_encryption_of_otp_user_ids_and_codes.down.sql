@@ -0,0 +1,13 @@
+-- Drop the encryption of the user IDs and codes of OTPs (rollback migration).
+-- The encrypted OTPs cannot be read without their keys, so they are deleted.
+DELETE FROM otps WHERE key_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_otps_key_id;
+
+ALTER TABLE otps
+    DROP COLUMN key_id,
+    DROP COLUMN otp_code_ciphertext,
+    DROP COLUMN user_id_ciphertext,
+    ALTER COLUMN otp_code TYPE CHAR(6);
+
+DROP TABLE IF EXISTS encryption_keys;
@@ -0,0 +1,21 @@
+-- This SQL script prepares the otps table for the envelope encryption of the user IDs and codes of OTPs.
+-- With encryption enabled, user_id and otp_code hold the keyed blind indexes the OTPs are looked up by,
+-- and the values themselves are encrypted in the ciphertext columns with the data key identified by key_id.
+-- The keys are stored in encryption_keys, wrapped by a master key that never leaves the KMS.
+CREATE TABLE IF NOT EXISTS encryption_keys (
+    id VARCHAR(64) PRIMARY KEY,                     -- Identifier of the key, stored along with the values it encrypts
+    kind VARCHAR(16) NOT NULL,                      -- data to encrypt values, index to derive blind indexes
+    master_key_id VARCHAR(255) NOT NULL,            -- Identifier of the master key the key is wrapped by
+    wrapped_key BYTEA NOT NULL,                     -- Key encrypted by the master key
+    created_at TIMESTAMPTZ NOT NULL                 -- The newest data key encrypts the new values
+);
+
+CREATE INDEX idx_encryption_keys_kind_created_at ON encryption_keys (kind, created_at);
+
+ALTER TABLE otps
+    ALTER COLUMN otp_code TYPE VARCHAR(64),         -- OTP code, or its blind index
+    ADD COLUMN user_id_ciphertext BYTEA NULL,       -- Encrypted user ID, NULL when not encrypted
+    ADD COLUMN otp_code_ciphertext BYTEA NULL,      -- Encrypted OTP code, NULL when not encrypted
+    ADD COLUMN key_id VARCHAR(64) NULL;             -- Data key of the ciphertexts, NULL when not encrypted
+
+CREATE INDEX idx_otps_key_id ON otps (key_id);
@@ -0,0 +1,2 @@
+-- Nothing to roll back, otp_ids only exists on MySQL (rollback migration)
+SELECT 1;
@@ -0,0 +1,2 @@
+-- Drop table otps if exists (rollback migration)
+DROP TABLE IF EXISTS otps;
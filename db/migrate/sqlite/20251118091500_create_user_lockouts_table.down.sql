@@ -0,0 +1,2 @@
+-- Drop table user_lockouts if exists (rollback migration)
+DROP TABLE IF EXISTS user_lockouts;
@@ -0,0 +1,2 @@
+-- Drop table idempotency_keys if exists (rollback migration)
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,3 @@
+-- Drop column binding_hash (rollback migration)
+ALTER TABLE otps
+    DROP COLUMN binding_hash;
@@ -0,0 +1,3 @@
+-- Drop the expiry sweeper indexes (rollback migration)
+DROP INDEX IF EXISTS idx_otps_status_expires_at;
+DROP INDEX IF EXISTS idx_otps_expires_at;
@@ -0,0 +1,3 @@
+-- Drop tables job_runs and job_leases (rollback migration)
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS job_leases;
@@ -0,0 +1,29 @@
+-- This SQL script creates the tables used by the background job scheduler.
+-- 'job_leases' elects a single instance to run each job: the instance holding
+-- an unexpired lease is the leader and keeps renewing it while it is alive.
+CREATE TABLE IF NOT EXISTS job_leases (
+    job_name VARCHAR(100) PRIMARY KEY,              -- Name of the scheduled job
+    holder_id VARCHAR(255) NOT NULL,                -- Identifier of the instance holding the lease
+    expires_at TIMESTAMP NOT NULL,                  -- When the lease lapses unless it is renewed
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_job_leases_updated_at
+    AFTER UPDATE ON job_leases
+    FOR EACH ROW
+BEGIN
+    UPDATE job_leases SET updated_at = CURRENT_TIMESTAMP WHERE job_name = NEW.job_name;
+END;
+
+-- 'job_runs' keeps the history of job runs for debugging.
+CREATE TABLE IF NOT EXISTS job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,           -- Auto-incrementing ID
+    job_name VARCHAR(100) NOT NULL,                 -- Name of the scheduled job
+    holder_id VARCHAR(255) NOT NULL,                -- Instance that ran the job
+    status SMALLINT NOT NULL,                       -- Run status (1 = running, 2 = succeeded, 3 = failed), see the application code.
+    error TEXT NULL,                                -- Error message of a failed run
+    started_at TIMESTAMP NOT NULL,                  -- When the run started
+    finished_at TIMESTAMP NULL                      -- When the run finished
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_name_started_at ON job_runs (job_name, started_at);
@@ -0,0 +1,2 @@
+-- Drop table outbox if exists (rollback migration)
+DROP TABLE IF EXISTS outbox;
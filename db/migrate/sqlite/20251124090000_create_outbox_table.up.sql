@@ -0,0 +1,16 @@
+-- This SQL script creates a table named 'outbox' in the database.
+-- OTP lifecycle events are written to the outbox in the same transaction as
+-- the change they describe, and delivered to other services by a dispatcher.
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,           -- Auto-incrementing ID, also the event ID seen by consumers
+    event_type VARCHAR(50) NOT NULL,                -- Event type (e.g. otp.created), see the application code.
+    payload TEXT NOT NULL,                          -- Event payload (JSON)
+    status SMALLINT NOT NULL DEFAULT 1,             -- Delivery status (1 = pending, 2 = delivered, 3 = failed), see the application code.
+    attempts INT NOT NULL DEFAULT 0,                -- Number of failed delivery attempts
+    next_attempt_at TIMESTAMP NOT NULL,             -- The event is not delivered before this timestamp
+    last_error TEXT NULL,                           -- Error of the last failed delivery attempt
+    created_at TIMESTAMP NOT NULL,                  -- When the event occurred
+    delivered_at TIMESTAMP NULL                     -- When the event was delivered
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_status_next_attempt_at ON outbox (status, next_attempt_at);
@@ -0,0 +1,6 @@
+-- Drop column otp_id and the event index of the webhook deliveries (rollback migration)
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_id;
+DROP INDEX IF EXISTS idx_outbox_otp_id;
+
+ALTER TABLE outbox
+    DROP COLUMN otp_id;
@@ -0,0 +1,13 @@
+-- This SQL script links outbox events to the OTP they describe, so that the
+-- timeline of an OTP can include the delivery of its events, and indexes the
+-- webhook deliveries by event.
+ALTER TABLE outbox
+    ADD COLUMN otp_id BIGINT NULL; -- OTP the event describes, NULL for user events
+
+CREATE INDEX IF NOT EXISTS idx_outbox_otp_id ON outbox (otp_id);
+
+UPDATE outbox
+SET otp_id = json_extract(payload, '$.otp_id')
+WHERE json_extract(payload, '$.otp_id') IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
@@ -0,0 +1,7 @@
+-- Drop column purpose and the admin listing indexes (rollback migration)
+DROP INDEX IF EXISTS idx_otps_user_id_id;
+DROP INDEX IF EXISTS idx_otps_purpose_id;
+DROP INDEX IF EXISTS idx_otps_created_at;
+
+ALTER TABLE otps
+    DROP COLUMN purpose;
@@ -0,0 +1,9 @@
+-- This SQL script adds the purpose of an OTP and the indexes used by the admin listing,
+-- which pages through OTPs newest first. idx_otps_user_id_id and idx_otps_purpose_id
+-- serve the user and purpose filters in ID order, idx_otps_created_at the created-at range.
+ALTER TABLE otps
+    ADD COLUMN purpose VARCHAR(50) NULL; -- What the OTP was requested for (e.g. login), if given
+
+CREATE INDEX IF NOT EXISTS idx_otps_user_id_id ON otps (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_otps_purpose_id ON otps (purpose, id);
+CREATE INDEX IF NOT EXISTS idx_otps_created_at ON otps (created_at);
@@ -0,0 +1,6 @@
+-- Drop columns revoked_at and revoke_reason (rollback migration)
+ALTER TABLE otps
+    DROP COLUMN revoked_at;
+
+ALTER TABLE otps
+    DROP COLUMN revoke_reason;
@@ -0,0 +1,7 @@
+-- This SQL script records the revocation of OTPs (status 4 = revoked), which cancels
+-- an OTP before it is used, e.g. when a user reports a SIM swap.
+ALTER TABLE otps
+    ADD COLUMN revoked_at TIMESTAMP NULL;           -- When the OTP was revoked
+
+ALTER TABLE otps
+    ADD COLUMN revoke_reason VARCHAR(100) NULL;     -- Why the OTP was revoked, if given
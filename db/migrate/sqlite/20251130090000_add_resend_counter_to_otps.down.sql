@@ -0,0 +1,6 @@
+-- Drop columns resend_count and resend_limit (rollback migration)
+ALTER TABLE otps
+    DROP COLUMN resend_count;
+
+ALTER TABLE otps
+    DROP COLUMN resend_limit;
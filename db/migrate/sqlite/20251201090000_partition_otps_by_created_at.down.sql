@@ -0,0 +1,2 @@
+-- Nothing to roll back, the otps table is only partitioned on MySQL (rollback migration)
+SELECT 1;
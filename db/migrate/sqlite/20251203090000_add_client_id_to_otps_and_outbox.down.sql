@@ -0,0 +1,6 @@
+-- Drop column client_id from otps and outbox (rollback migration)
+ALTER TABLE outbox
+    DROP COLUMN client_id;
+
+ALTER TABLE otps
+    DROP COLUMN client_id;
@@ -0,0 +1,8 @@
+-- This SQL script records the client application that requested an OTP, and the client
+-- of every outbox event, so that events are only published to the webhooks of that client.
+-- The OTPs and events from before have no client, and are published to no webhook.
+ALTER TABLE otps
+    ADD COLUMN client_id VARCHAR(100) NULL;         -- Client application that requested the OTP, if known
+
+ALTER TABLE outbox
+    ADD COLUMN client_id VARCHAR(100) NULL;         -- Client application the event is published to, if known
@@ -0,0 +1,8 @@
+-- Drop the encryption of the signing secrets of the webhooks (rollback migration).
+-- The encrypted secrets cannot be read without their keys, so their subscriptions are deleted.
+DELETE FROM webhook_subscriptions WHERE key_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_webhook_subscriptions_key_id;
+
+ALTER TABLE webhook_subscriptions DROP COLUMN key_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN secret_ciphertext;
@@ -0,0 +1,10 @@
+-- This SQL script prepares the webhook_subscriptions table for the envelope encryption of the signing secrets.
+-- With encryption enabled, secret is left empty and the secret is encrypted in secret_ciphertext
+-- with the data key identified by key_id, like the user IDs and codes of the OTPs.
+ALTER TABLE webhook_subscriptions
+    ADD COLUMN secret_ciphertext BLOB NULL;         -- Encrypted signing secret, NULL when not encrypted
+
+ALTER TABLE webhook_subscriptions
+    ADD COLUMN key_id VARCHAR(64) NULL;             -- Data key of the ciphertext, NULL when not encrypted
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_key_id ON webhook_subscriptions (key_id);
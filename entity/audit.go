@@ -0,0 +1,159 @@
+package entity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditAction identifies an audited operation.
+type AuditAction string
+
+const (
+	// AuditActionOTPRequest is recorded for every OTP request.
+	AuditActionOTPRequest AuditAction = "otp.request"
+	// AuditActionOTPValidate is recorded for every OTP validation attempt.
+	AuditActionOTPValidate AuditAction = "otp.validate"
+	// AuditActionOTPRevoke is recorded for every revocation of an OTP.
+	AuditActionOTPRevoke AuditAction = "otp.revoke"
+	// AuditActionOTPResend is recorded for every resend of an OTP.
+	AuditActionOTPResend AuditAction = "otp.resend"
+	// AuditActionLockoutClear is recorded when an admin clears the lockout of a user.
+	AuditActionLockoutClear AuditAction = "lockout.clear"
+)
+
+// AuditOutcome represents whether an audited operation succeeded.
+type AuditOutcome int8
+
+const (
+	// AuditOutcomeSuccess means the operation succeeded.
+	AuditOutcomeSuccess AuditOutcome = iota + 1
+	// AuditOutcomeFailure means the operation was rejected or failed.
+	AuditOutcomeFailure
+)
+
+// String returns the string representation of AuditOutcome.
+func (o AuditOutcome) String() string {
+	outcomeToStringMap := map[AuditOutcome]string{
+		AuditOutcomeSuccess: "success",
+		AuditOutcomeFailure: "failure",
+	}
+
+	str, _ := outcomeToStringMap[o]
+	return str
+}
+
+const (
+	// AuditActorClient is the actor of operations requested through the public OTP endpoints.
+	AuditActorClient = "client"
+	// AuditActorAdmin is the actor of operations requested through the admin endpoints.
+	AuditActorAdmin = "admin"
+	// AuditActorSystem is the actor of operations performed by the service itself.
+	AuditActorSystem = "system"
+)
+
+// AuditEvent is an entry of the append-only audit log. Every event is chained to the
+// previous one by hash, so that a modified or deleted event breaks the chain.
+type AuditEvent struct {
+	ID        uint64
+	Actor     string // Who performed the operation, see the AuditActor constants
+	ClientID  string // Client application that sent the request, if known
+	IP        string // Source IP of the request, if any
+	UserID    string
+	OTPID     uint64 // OTP the operation applied to, zero if none was found
+	Action    AuditAction
+	Outcome   AuditOutcome
+	Reason    string // Error code of a failed operation
+	CreatedAt time.Time
+	PrevHash  string // Hash of the previous event, empty for the first event
+	Hash      string
+}
+
+// auditEventDigest is the canonical encoding of the fields covered by the hash of an audit event
+type auditEventDigest struct {
+	PrevHash  string      `json:"prev_hash"`
+	Actor     string      `json:"actor"`
+	ClientID  string      `json:"client_id"`
+	IP        string      `json:"ip"`
+	UserID    string      `json:"user_id"`
+	OTPID     uint64      `json:"otp_id"`
+	Action    AuditAction `json:"action"`
+	Outcome   int8        `json:"outcome"`
+	Reason    string      `json:"reason"`
+	CreatedAt string      `json:"created_at"`
+}
+
+// ComputeHash returns the hex encoded SHA-256 of the previous hash and the fields of the event.
+// The ID is not covered, as it is only assigned once the event is stored.
+func (e *AuditEvent) ComputeHash() string {
+	digest, _ := json.Marshal(auditEventDigest{
+		PrevHash:  e.PrevHash,
+		Actor:     e.Actor,
+		ClientID:  e.ClientID,
+		IP:        e.IP,
+		UserID:    e.UserID,
+		OTPID:     e.OTPID,
+		Action:    e.Action,
+		Outcome:   int8(e.Outcome),
+		Reason:    e.Reason,
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(digest)
+	return hex.EncodeToString(sum[:])
+}
+
+// Chain links the event to the event before it and seals it with its hash.
+func (e *AuditEvent) Chain(prevHash string) {
+	e.PrevHash = prevHash
+	e.Hash = e.ComputeHash()
+}
+
+// AuditChainHead is the last event of the audit log, which detects events deleted from its end.
+type AuditChainHead struct {
+	LastID   uint64 // Zero while the audit log is empty
+	LastHash string
+}
+
+// AuditEventFilter selects the audit events of a user within a time range.
+type AuditEventFilter struct {
+	UserID string
+	From   *time.Time // Inclusive, unbounded if nil
+	To     *time.Time // Exclusive, unbounded if nil
+	Limit  int
+}
+
+// AuditChainVerification is the result of walking the audit log hash chain.
+type AuditChainVerification struct {
+	Checked    int64  // Number of events verified
+	Valid      bool   // Whether the chain is intact
+	BrokenAtID uint64 // First event that does not match the chain, zero if the end of the chain was removed
+	Reason     string // Why the chain is broken
+}
+
+// RequestMetadata describes who sent the request an operation is performed for.
+type RequestMetadata struct {
+	Actor    string
+	ClientID string
+	IP       string
+}
+
+// requestMetadataKey is the key of the request metadata stored in a context
+type requestMetadataKey struct{}
+
+// ContextWithRequestMetadata returns a copy of ctx carrying the metadata of the request.
+func ContextWithRequestMetadata(ctx context.Context, metadata RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, metadata)
+}
+
+// RequestMetadataFromContext returns the request metadata stored in ctx.
+// Operations that are not performed for a request are attributed to the system.
+func RequestMetadataFromContext(ctx context.Context) RequestMetadata {
+	metadata, ok := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	if !ok {
+		return RequestMetadata{Actor: AuditActorSystem}
+	}
+	return metadata
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+)
+
+// EncryptionKeyKind is the use of an encryption key.
+type EncryptionKeyKind string
+
+const (
+	// EncryptionKeyKindData is a data key, encrypting the sensitive columns of the rows.
+	EncryptionKeyKindData EncryptionKeyKind = "data"
+	// EncryptionKeyKindIndex is the blind index key, deriving the keyed indexes the encrypted columns are looked up by.
+	EncryptionKeyKindIndex EncryptionKeyKind = "index"
+)
+
+// EncryptionKey is a key of the envelope encryption of the sensitive columns, stored wrapped by a master key.
+type EncryptionKey struct {
+	ID          string
+	Kind        EncryptionKeyKind
+	MasterKeyID string // Identifier of the master key the key is wrapped by
+	WrappedKey  []byte
+	CreatedAt   time.Time
+}
+
+// KeyRotation is the outcome of a rotation of the encryption keys.
+type KeyRotation struct {
+	DataKeyRotated     bool  // Whether a new data key encrypts the new values
+	Rewrapped          int   // Number of keys wrapped again by the current master key
+	Reencrypted        int64 // Number of OTPs encrypted again with the current data key
+	ReencryptedSecrets int64 // Number of webhook signing secrets encrypted again with the current data key
+}
@@ -19,7 +19,8 @@ func NewDomainError(code, message string) *DomainError {
 
 var (
 	// GENERAL errors
-	ErrInvalidRequest = NewDomainError("invalid_request", "Invalid request: Please check the request body and try again")
+	ErrInvalidRequest  = NewDomainError("invalid_request", "Invalid request: Please check the request body and try again")
+	ErrInvalidClientID = NewDomainError("invalid_client_id", "X-Client-Id must be at most 100 letters, digits, dots, underscores, colons or dashes")
 
 	// OTP specific errors
 	ErrOTPExpired           = NewDomainError("otp_expired", "OTP has expired")
@@ -27,4 +28,26 @@ var (
 	ErrOTPNotFound          = NewDomainError("otp_not_found", "OTP Not Found")
 	ErrOTPDuplicate         = NewDomainError("duplicate_otp_code", "OTP Code Already Exists")
 	ErrOTPRateLimitExceeded = NewDomainError("otp_rete_limit_exceeded", "OTP requested too frequently, please wait before requesting again")
+	ErrOTPInvalid           = NewDomainError("invalid_otp", "OTP is invalid, expired or has already been used")
+	ErrOTPBindingMismatch   = NewDomainError("otp_binding_mismatch", "OTP was requested from a different session or device")
+	ErrOTPRevoked           = NewDomainError("otp_revoked", "OTP has been revoked")
+	ErrOTPResendLimit       = NewDomainError("otp_resend_limit_exceeded", "OTP has been resent too many times, please request a new one")
+
+	// Idempotency errors
+	ErrIdempotencyKeyReused         = NewDomainError("idempotency_key_reused", "Idempotency-Key has already been used with a different request")
+	ErrIdempotencyRequestInProgress = NewDomainError("idempotency_request_in_progress", "A request with the same Idempotency-Key is still in progress")
+	ErrIdempotencyKeyDuplicate      = NewDomainError("duplicate_idempotency_key", "Idempotency-Key Already Exists")
+	ErrIdempotencyRecordNotFound    = NewDomainError("idempotency_record_not_found", "Idempotency Record Not Found")
+
+	// User lockout errors
+	ErrUserLocked          = NewDomainError("user_locked", "Too many failed OTP validations, please try again later")
+	ErrUserLockoutNotFound = NewDomainError("user_lockout_not_found", "User Lockout Not Found")
+
+	// Webhook errors
+	ErrWebhookSubscriptionNotFound = NewDomainError("webhook_subscription_not_found", "Webhook Subscription Not Found")
+	ErrWebhookDeliveryNotFound     = NewDomainError("webhook_delivery_not_found", "Webhook Delivery Not Found")
+
+	// Encryption errors
+	ErrEncryptionKeyNotFound  = NewDomainError("encryption_key_not_found", "Encryption Key Not Found")
+	ErrEncryptionKeyDuplicate = NewDomainError("duplicate_encryption_key", "Encryption Key Already Exists")
 )
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+)
+
+// IdempotencyRecord stores the outcome of a request made with an Idempotency-Key header,
+// so that a retry of the same request can be answered with the original response.
+type IdempotencyRecord struct {
+	Key            string
+	RequestHash    string
+	ResponseStatus int    // Zero while the original request is still in progress
+	ResponseBody   []byte // Raw response body of the original request
+	CreatedAt      time.Time
+	ExpiresAt      time.Time // End of the lease of the original request while in progress, then of the replay window
+}
+
+// IsCompleted reports whether the response of the original request has been stored.
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.ResponseStatus != 0
+}
+
+// IsExpired reports whether the record can no longer be replayed at the given time.
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
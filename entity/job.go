@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+)
+
+// JobRunStatus represents the outcome of a run of a scheduled background job.
+type JobRunStatus int8
+
+const (
+	// JobRunStatusRunning means the run has started and not finished yet.
+	JobRunStatusRunning JobRunStatus = iota + 1
+	// JobRunStatusSucceeded means the run finished without error.
+	JobRunStatusSucceeded
+	// JobRunStatusFailed means the run finished with an error.
+	JobRunStatusFailed
+)
+
+// String returns the string representation of JobRunStatus.
+func (s JobRunStatus) String() string {
+	statusToStringMap := map[JobRunStatus]string{
+		JobRunStatusRunning:   "running",
+		JobRunStatusSucceeded: "succeeded",
+		JobRunStatusFailed:    "failed",
+	}
+
+	str, _ := statusToStringMap[s]
+	return str
+}
+
+// JobRun records a single run of a scheduled background job, kept for debugging.
+type JobRun struct {
+	ID         uint64
+	JobName    string
+	HolderID   string // Identifier of the instance that held the job lease during the run
+	Status     JobRunStatus
+	Error      string // Error message of a failed run
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
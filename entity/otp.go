@@ -14,6 +14,8 @@ const (
 	OTPStatusValidated
 	// OTPStatusExpired means the OTP has expired and can no longer be used.
 	OTPStatusExpired
+	// OTPStatusRevoked means the OTP was cancelled before it was used, e.g. after a SIM swap.
+	OTPStatusRevoked
 )
 
 // String returns the string representation of OTPStatus.
@@ -22,19 +24,124 @@ func (o OTPStatus) String() string {
 		OTPStatusCreated:   "created",
 		OTPStatusValidated: "validated",
 		OTPStatusExpired:   "expired",
+		OTPStatusRevoked:   "revoked",
 	}
 
 	str, _ := statusToStringMap[o]
 	return str
 }
 
+// OTPStatuses returns every OTPStatus, in lifecycle order.
+func OTPStatuses() []OTPStatus {
+	return []OTPStatus{OTPStatusCreated, OTPStatusValidated, OTPStatusExpired, OTPStatusRevoked}
+}
+
+// ParseOTPStatus returns the OTPStatus with the given string representation.
+func ParseOTPStatus(s string) (OTPStatus, bool) {
+	for _, status := range OTPStatuses() {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
 // OTP represents a one-time password (OTP)
 type OTP struct {
-	ID          uint64
+	ID                   uint64
+	UserID               string
+	OTPCode              string
+	Status               OTPStatus
+	CreatedAt            time.Time
+	ExpiresAt            time.Time
+	ValidatedAt          *time.Time
+	ValidatedSessionHash string // Hash of the session identifier that validated the OTP, if any
+	BindingHash          string // Hash of the binding nonce or device ID the OTP was requested with, if any
+	Purpose              string // What the OTP was requested for (e.g. login), if given
+	ClientID             string // Client application that requested the OTP, if known; its events are only published to its webhooks
+	RevokedAt            *time.Time
+	RevokeReason         string // Why the OTP was revoked, if given
+	ResendCount          int    // Number of times the OTP, or the OTPs it superseded, were resent
+	ResendLimit          int    // Number of times the OTP can be resent
+}
+
+// CanResend reports whether the OTP has resends left.
+func (o *OTP) CanResend() bool {
+	return o.ResendCount < o.ResendLimit
+}
+
+// IsBound reports whether the OTP can only be validated with the binding it was requested with.
+func (o *OTP) IsBound() bool {
+	return o.BindingHash != ""
+}
+
+// CreateOTPParams holds the input of an OTP request.
+type CreateOTPParams struct {
+	UserID string
+	// BindingID is an optional client generated nonce or device ID. When set,
+	// the OTP can only be validated by a request carrying the same value.
+	BindingID string
+	// Purpose optionally labels what the OTP is requested for, e.g. login or transaction.
+	Purpose string
+}
+
+// ValidateOTPParams holds the input of an OTP validation attempt.
+type ValidateOTPParams struct {
+	UserID  string
+	OTPCode string
+	// SessionID identifies the client session making the attempt. It is optional; when set,
+	// an identical retry from the same session shortly after a successful validation
+	// gets the original success instead of entity.ErrOTPUsed.
+	SessionID string
+	// BindingID must match the binding the OTP was requested with, if any.
+	BindingID string
+}
+
+// RevokeOTPParams holds the input of the revocation of a single OTP.
+type RevokeOTPParams struct {
+	OTPID uint64
+	// UserID must be the user the OTP was issued to.
+	UserID string
+	// Reason optionally describes why the OTP is revoked, e.g. sim_swap.
+	Reason string
+}
+
+// RevokeReasonSuperseded is the revocation reason of an OTP replaced by a new code when it was resent.
+const RevokeReasonSuperseded = "superseded"
+
+// ResendOTPParams holds the input of a resend of an OTP.
+type ResendOTPParams struct {
+	OTPID uint64
+	// UserID must be the user the OTP was issued to.
+	UserID string
+	// Channel optionally asks for the OTP to be delivered through another channel, e.g. voice.
+	Channel string
+}
+
+// OTPFilter selects OTPs for the admin listing. Zero fields match every OTP.
+type OTPFilter struct {
 	UserID      string
-	OTPCode     string
 	Status      OTPStatus
-	CreatedAt   time.Time
-	ExpiresAt   time.Time
-	ValidatedAt *time.Time
+	Purpose     string
+	CreatedFrom *time.Time // Inclusive, unbounded if nil
+	CreatedTo   *time.Time // Exclusive, unbounded if nil
+}
+
+// OTPPage is a page of the admin listing of OTPs, newest first.
+type OTPPage struct {
+	OTPs       []*OTP
+	NextCursor string // Cursor of the next page, empty on the last page
+}
+
+// OTPStatusCount is the number of stored OTPs with a status.
+type OTPStatusCount struct {
+	Status OTPStatus
+	Count  int64
+}
+
+// OTPPartition is a partition of the stored OTPs, holding the OTPs created
+// before its bound and not before the bound of the previous partition.
+type OTPPartition struct {
+	Name   string
+	Before time.Time // Exclusive upper bound of the creation time of its OTPs
 }
@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// OTPTimelineEventType identifies a kind of entry of the timeline of an OTP.
+type OTPTimelineEventType string
+
+const (
+	// OTPTimelineIssued is the issuance of the OTP.
+	OTPTimelineIssued OTPTimelineEventType = "issued"
+	// OTPTimelineDelivery is a delivery of the otp.created event, to the event sink or to a webhook subscription.
+	OTPTimelineDelivery OTPTimelineEventType = "delivery"
+	// OTPTimelineValidation is a validation attempt that matched the OTP.
+	OTPTimelineValidation OTPTimelineEventType = "validation"
+	// OTPTimelineResend is a resend request that matched the OTP.
+	OTPTimelineResend OTPTimelineEventType = "resend"
+	// OTPTimelineExpired is the expiry of the OTP before it was validated.
+	OTPTimelineExpired OTPTimelineEventType = "expired"
+	// OTPTimelineRevoked is the revocation of the OTP.
+	OTPTimelineRevoked OTPTimelineEventType = "revoked"
+	// OTPTimelineSuperseded is the issuance of a newer OTP to the user while the OTP was still outstanding.
+	OTPTimelineSuperseded OTPTimelineEventType = "superseded"
+)
+
+// OTPTimelineEvent is an entry of the timeline of an OTP, built from the persisted OTP,
+// its audit events, its outbox events and their webhook deliveries.
+type OTPTimelineEvent struct {
+	Type       OTPTimelineEventType
+	OccurredAt time.Time
+	// Result is the outcome of the entry: success or failure for an issuance, validation or resend,
+	// pending, delivered or failed for a delivery.
+	Result   string
+	Reason   string // Error code of a failed validation or resend, error of the last failed delivery attempt, or reason of the revocation
+	Actor    string // Who requested the issuance, validation, resend or revocation, see the AuditActor constants
+	ClientID string // Client application that requested the issuance, validation, resend or revocation, if known
+	IP       string // Source IP of the issuance, validation, resend or revocation request, if any
+
+	Attempts              int    // Number of failed attempts of a delivery
+	ResponseCode          int    // HTTP status code of the last attempt of a webhook delivery
+	WebhookSubscriptionID uint64 // Subscription of a webhook delivery, zero for a delivery to the event sink
+	SupersededByOTPID     uint64 // OTP that superseded the OTP
+}
+
+// OTPTimeline is the ordered history of an OTP.
+type OTPTimeline struct {
+	OTP    *OTP
+	Events []OTPTimelineEvent // Ordered by time of occurrence
+}
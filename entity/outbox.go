@@ -0,0 +1,154 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType identifies a kind of OTP lifecycle event published to other services.
+type EventType string
+
+const (
+	// EventTypeOTPCreated is published when an OTP is issued.
+	EventTypeOTPCreated EventType = "otp.created"
+	// EventTypeOTPValidated is published when an OTP is successfully validated.
+	EventTypeOTPValidated EventType = "otp.validated"
+	// EventTypeOTPExpired is published when an OTP is marked as expired.
+	EventTypeOTPExpired EventType = "otp.expired"
+	// EventTypeOTPRevoked is published when an OTP is revoked.
+	EventTypeOTPRevoked EventType = "otp.revoked"
+	// EventTypeOTPResent is published when an OTP is resent, with the new code if it was rotated.
+	EventTypeOTPResent EventType = "otp.resent"
+	// EventTypeUserLocked is published when a user is locked out after repeated validation failures.
+	EventTypeUserLocked EventType = "user.locked"
+)
+
+// IsValid reports whether the event type is one of the published OTP lifecycle events.
+func (t EventType) IsValid() bool {
+	switch t {
+	case EventTypeOTPCreated, EventTypeOTPValidated, EventTypeOTPExpired, EventTypeOTPRevoked, EventTypeOTPResent, EventTypeUserLocked:
+		return true
+	}
+	return false
+}
+
+// OTPEvent is the payload of an OTP lifecycle event.
+type OTPEvent struct {
+	Type          EventType  `json:"type"`
+	UserID        string     `json:"user_id"`
+	OTPID         uint64     `json:"otp_id,omitempty"`
+	ClientID      string     `json:"client_id,omitempty"` // Client application that requested the OTP, or whose validations locked the user
+	OccurredAt    time.Time  `json:"occurred_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`      // Set on otp.created and otp.resent
+	Reason        string     `json:"reason,omitempty"`          // Set on otp.revoked, if given
+	ResendCount   int        `json:"resend_count,omitempty"`    // Set on otp.resent
+	Channel       string     `json:"channel,omitempty"`         // Set on otp.resent, if another channel was asked for
+	PreviousOTPID uint64     `json:"previous_otp_id,omitempty"` // Set on otp.resent, if the code was rotated
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`    // Set on user.locked
+}
+
+// NewOTPEvent creates an event of the given type about an OTP.
+func NewOTPEvent(eventType EventType, otp *OTP, occurredAt time.Time) OTPEvent {
+	event := OTPEvent{
+		Type:       eventType,
+		UserID:     otp.UserID,
+		OTPID:      otp.ID,
+		ClientID:   otp.ClientID,
+		OccurredAt: occurredAt,
+	}
+	switch eventType {
+	case EventTypeOTPCreated:
+		expiresAt := otp.ExpiresAt
+		event.ExpiresAt = &expiresAt
+	case EventTypeOTPResent:
+		expiresAt := otp.ExpiresAt
+		event.ExpiresAt = &expiresAt
+		event.ResendCount = otp.ResendCount
+	case EventTypeOTPRevoked:
+		event.Reason = otp.RevokeReason
+	}
+
+	return event
+}
+
+// NewOTPResentEvent creates the event published when an OTP is resent through the given channel, if any.
+// The previous OTP is the one the resend was requested for when it was rotated to a new code, or nil.
+func NewOTPResentEvent(otp *OTP, previous *OTP, channel string, occurredAt time.Time) OTPEvent {
+	event := NewOTPEvent(EventTypeOTPResent, otp, occurredAt)
+	event.Channel = channel
+	if previous != nil {
+		event.PreviousOTPID = previous.ID
+	}
+
+	return event
+}
+
+// NewUserLockedEvent creates the event published when a user is locked out until the given time,
+// after failed validations sent by the given client, if known.
+func NewUserLockedEvent(userID string, clientID string, lockedUntil time.Time, occurredAt time.Time) OTPEvent {
+	return OTPEvent{
+		Type:        EventTypeUserLocked,
+		UserID:      userID,
+		ClientID:    clientID,
+		OccurredAt:  occurredAt,
+		LockedUntil: &lockedUntil,
+	}
+}
+
+// OutboxStatus represents the delivery status of an outbox event.
+type OutboxStatus int8
+
+const (
+	// OutboxStatusPending means the event has not been delivered yet and will be (re)tried.
+	OutboxStatusPending OutboxStatus = iota + 1
+	// OutboxStatusDelivered means the event has been delivered.
+	OutboxStatusDelivered
+	// OutboxStatusFailed means the event could not be delivered within the maximum number of attempts.
+	OutboxStatusFailed
+)
+
+// String returns the string representation of OutboxStatus.
+func (s OutboxStatus) String() string {
+	statusToStringMap := map[OutboxStatus]string{
+		OutboxStatusPending:   "pending",
+		OutboxStatusDelivered: "delivered",
+		OutboxStatusFailed:    "failed",
+	}
+
+	str, _ := statusToStringMap[s]
+	return str
+}
+
+// OutboxEvent is an event stored in the outbox in the same transaction as the change it describes,
+// and delivered to other services afterwards.
+type OutboxEvent struct {
+	ID            uint64
+	EventType     EventType
+	OTPID         uint64 // OTP the event describes, zero for user events
+	ClientID      string // Client application the event is published to, if known
+	Payload       []byte // JSON encoded OTPEvent
+	Status        OutboxStatus
+	Attempts      int       // Number of failed delivery attempts
+	NextAttemptAt time.Time // The event is not delivered before this time
+	LastError     string    // Error of the last failed delivery attempt
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// NewOutboxEvent creates a pending outbox event carrying the given event.
+func NewOutboxEvent(event OTPEvent) (*OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		EventType:     event.Type,
+		OTPID:         event.OTPID,
+		ClientID:      event.ClientID,
+		Payload:       payload,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: event.OccurredAt,
+		CreatedAt:     event.OccurredAt,
+	}, nil
+}
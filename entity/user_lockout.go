@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+)
+
+// UserLockout tracks failed OTP validations for a user across all of their OTPs.
+// Once the number of failures reaches the configured threshold, the user is locked
+// out of both requesting and validating OTPs until LockedUntil has passed.
+type UserLockout struct {
+	UserID         string
+	FailedAttempts int
+	LastFailedAt   *time.Time
+	LockedUntil    *time.Time
+	UpdatedAt      time.Time
+}
+
+// IsLocked reports whether the user is locked out at the given time.
+func (l *UserLockout) IsLocked(now time.Time) bool {
+	return l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
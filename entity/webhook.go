@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+)
+
+// WebhookSubscription subscribes an endpoint of a client application to OTP lifecycle events.
+type WebhookSubscription struct {
+	ID         uint64
+	ClientID   string
+	URL        string
+	EventTypes []EventType
+	Secret     string // Key of the HMAC-SHA256 signature of every delivery
+	CreatedAt  time.Time
+}
+
+// Subscribes reports whether the subscription wants events of the given type.
+func (s *WebhookSubscription) Subscribes(eventType EventType) bool {
+	for _, subscribed := range s.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookSubscriptionParams holds the parameters of a new webhook subscription.
+type CreateWebhookSubscriptionParams struct {
+	ClientID   string
+	URL        string
+	EventTypes []EventType
+}
+
+// WebhookDelivery is the delivery of an event to a webhook subscription.
+type WebhookDelivery struct {
+	ID             uint64
+	SubscriptionID uint64
+	EventID        uint64 // ID of the outbox event being delivered
+	EventType      EventType
+	Payload        []byte // JSON encoded OTPEvent
+	Status         OutboxStatus
+	Attempts       int       // Number of failed delivery attempts
+	NextAttemptAt  time.Time // The delivery is not attempted before this time
+	ResponseCode   int       // HTTP status code of the last attempt, zero if no response was received
+	LastError      string    // Error of the last failed attempt
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// Replay resets the delivery so that it is attempted again as soon as possible.
+func (d *WebhookDelivery) Replay(now time.Time) {
+	d.Status = OutboxStatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.DeliveredAt = nil
+}
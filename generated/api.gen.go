@@ -8,14 +8,122 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo/v4"
+	"github.com/oapi-codegen/runtime"
 )
 
+const (
+	AdminApiKeyScopes = "AdminApiKey.Scopes"
+)
+
+// Defines values for AuditEventOutcome.
+const (
+	Failure AuditEventOutcome = "failure"
+	Success AuditEventOutcome = "success"
+)
+
+// Defines values for OtpTimelineEventType.
+const (
+	OtpTimelineEventTypeDelivery   OtpTimelineEventType = "delivery"
+	OtpTimelineEventTypeExpired    OtpTimelineEventType = "expired"
+	OtpTimelineEventTypeIssued     OtpTimelineEventType = "issued"
+	OtpTimelineEventTypeResend     OtpTimelineEventType = "resend"
+	OtpTimelineEventTypeRevoked    OtpTimelineEventType = "revoked"
+	OtpTimelineEventTypeSuperseded OtpTimelineEventType = "superseded"
+	OtpTimelineEventTypeValidation OtpTimelineEventType = "validation"
+)
+
+// Defines values for WebhookDeliveryStatus.
+const (
+	Delivered WebhookDeliveryStatus = "delivered"
+	Failed    WebhookDeliveryStatus = "failed"
+	Pending   WebhookDeliveryStatus = "pending"
+)
+
+// Defines values for WebhookEventType.
+const (
+	OtpCreated   WebhookEventType = "otp.created"
+	OtpExpired   WebhookEventType = "otp.expired"
+	OtpResent    WebhookEventType = "otp.resent"
+	OtpRevoked   WebhookEventType = "otp.revoked"
+	OtpValidated WebhookEventType = "otp.validated"
+	UserLocked   WebhookEventType = "user.locked"
+)
+
+// Defines values for GetAdminOtpsParamsStatus.
+const (
+	GetAdminOtpsParamsStatusCreated   GetAdminOtpsParamsStatus = "created"
+	GetAdminOtpsParamsStatusExpired   GetAdminOtpsParamsStatus = "expired"
+	GetAdminOtpsParamsStatusRevoked   GetAdminOtpsParamsStatus = "revoked"
+	GetAdminOtpsParamsStatusValidated GetAdminOtpsParamsStatus = "validated"
+)
+
+// AuditEvent defines model for AuditEvent.
+type AuditEvent struct {
+	// Action The audited operation, one of otp.request, otp.validate, otp.resend, otp.revoke or lockout.clear.
+	Action string `json:"action"`
+
+	// Actor Who performed the operation, one of client, admin or system.
+	Actor string `json:"actor"`
+
+	// ClientId The client application that sent the request, from the X-Client-Id header.
+	ClientId *string `json:"client_id,omitempty"`
+
+	// CreatedAt When the operation was performed.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Hash The SHA-256 of the previous hash and the fields of the event.
+	Hash string `json:"hash"`
+
+	// Id The position of the event in the audit log.
+	Id int64 `json:"id"`
+
+	// Ip The source IP of the request.
+	Ip *string `json:"ip,omitempty"`
+
+	// OtpId The OTP the operation applied to, absent if none was found.
+	OtpId *int64 `json:"otp_id,omitempty"`
+
+	// Outcome Whether the operation succeeded.
+	Outcome AuditEventOutcome `json:"outcome"`
+
+	// PrevHash The hash of the previous event of the audit log.
+	PrevHash string `json:"prev_hash"`
+
+	// Reason The error code of a failed operation.
+	Reason *string `json:"reason,omitempty"`
+
+	// UserId The user the operation applied to.
+	UserId string `json:"user_id"`
+}
+
+// AuditEventOutcome Whether the operation succeeded.
+type AuditEventOutcome string
+
+// AuditEventListResponse defines model for AuditEventListResponse.
+type AuditEventListResponse struct {
+	Events []AuditEvent `json:"events"`
+}
+
+// CreateWebhookSubscriptionBody defines model for CreateWebhookSubscriptionBody.
+type CreateWebhookSubscriptionBody struct {
+	// ClientId The client application that owns the subscription.
+	ClientId string `json:"client_id"`
+
+	// EventTypes The event types delivered to the endpoint.
+	EventTypes []WebhookEventType `json:"event_types"`
+
+	// Url The http(s) endpoint the events are posted to.
+	Url string `json:"url"`
+}
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	// Error The error code.
@@ -25,26 +133,238 @@ type ErrorResponse struct {
 	ErrorDescription string `json:"error_description"`
 }
 
+// OtpListResponse defines model for OtpListResponse.
+type OtpListResponse struct {
+	// NextCursor The cursor of the next page, absent on the last page.
+	NextCursor *string      `json:"next_cursor,omitempty"`
+	Otps       []OtpSummary `json:"otps"`
+}
+
+// OtpSummary defines model for OtpSummary.
+type OtpSummary struct {
+	// Bound Whether the OTP can only be validated with the binding_id it was requested with.
+	Bound bool `json:"bound"`
+
+	// CreatedAt When the OTP was issued.
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt When the OTP expires.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Id The unique identifier of the OTP.
+	Id int64 `json:"id"`
+
+	// Purpose What the OTP was requested for.
+	Purpose *string `json:"purpose,omitempty"`
+
+	// RevokeReason Why the OTP was revoked.
+	RevokeReason *string `json:"revoke_reason,omitempty"`
+
+	// RevokedAt When the OTP was revoked.
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	// Status The current status of the OTP, one of created, validated, expired or revoked.
+	Status string `json:"status"`
+
+	// UserId The user the OTP was issued to.
+	UserId string `json:"user_id"`
+
+	// ValidatedAt When the OTP was validated.
+	ValidatedAt *time.Time `json:"validated_at,omitempty"`
+}
+
+// OtpTimelineEvent defines model for OtpTimelineEvent.
+type OtpTimelineEvent struct {
+	// Actor Who requested the issuance, validation, resend or revocation, one of client, admin or system.
+	Actor *string `json:"actor,omitempty"`
+
+	// Attempts The number of failed attempts of a delivery.
+	Attempts *int `json:"attempts,omitempty"`
+
+	// ClientId The client application that requested the issuance, validation, resend or revocation.
+	ClientId *string `json:"client_id,omitempty"`
+
+	// Ip The source IP of the issuance, validation, resend or revocation request.
+	Ip *string `json:"ip,omitempty"`
+
+	// OccurredAt When the event occurred. A delivery occurred when it was delivered, or when it was queued if it has not been delivered yet.
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// Reason The error code of a failed validation or resend, the error of the last failed delivery attempt, or the reason of a revocation.
+	Reason *string `json:"reason,omitempty"`
+
+	// ResponseCode The HTTP status code of the last attempt of a webhook delivery.
+	ResponseCode *int `json:"response_code,omitempty"`
+
+	// Result The outcome, success or failure for an issuance, validation or resend, pending, delivered or failed for a delivery.
+	Result *string `json:"result,omitempty"`
+
+	// SupersededByOtpId The newer OTP issued to the user while this one was outstanding.
+	SupersededByOtpId *int64 `json:"superseded_by_otp_id,omitempty"`
+
+	// Type The kind of event.
+	Type OtpTimelineEventType `json:"type"`
+
+	// WebhookSubscriptionId The webhook subscription of a delivery, absent for the delivery to the event sink.
+	WebhookSubscriptionId *int64 `json:"webhook_subscription_id,omitempty"`
+}
+
+// OtpTimelineEventType The kind of event.
+type OtpTimelineEventType string
+
+// OtpTimelineResponse defines model for OtpTimelineResponse.
+type OtpTimelineResponse struct {
+	// CreatedAt When the OTP was issued.
+	CreatedAt time.Time          `json:"created_at"`
+	Events    []OtpTimelineEvent `json:"events"`
+
+	// ExpiresAt When the OTP expires.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// OtpId The unique identifier of the OTP.
+	OtpId int64 `json:"otp_id"`
+
+	// Status The current status of the OTP, one of created, validated, expired or revoked.
+	Status string `json:"status"`
+
+	// UserId The user the OTP was issued to.
+	UserId string `json:"user_id"`
+
+	// ValidatedAt When the OTP was validated.
+	ValidatedAt *time.Time `json:"validated_at,omitempty"`
+}
+
 // RequestOtpBody defines model for RequestOtpBody.
 type RequestOtpBody struct {
+	// BindingId Optional client generated nonce or device ID. When set, the OTP can only be
+	// validated by a request carrying the same binding_id.
+	BindingId *string `json:"binding_id,omitempty"`
+
+	// Purpose Optional label of what the OTP is requested for, which admins can filter OTPs by.
+	Purpose *string `json:"purpose,omitempty"`
+
 	// UserId The unique identifier of the user requesting the OTP.
 	UserId string `json:"user_id"`
 }
 
 // RequestOtpResponseSuccess defines model for RequestOtpResponseSuccess.
 type RequestOtpResponseSuccess struct {
-	// Otp The one-time password (OTP) generated for the user."
-	Otp string `json:"otp"`
+	// ExpiresAt The OTP can no longer be validated after this time.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Otp The one-time password (OTP) generated for the user. It is left out of a response replayed
+	// to a retry with the same Idempotency-Key, as the code is not stored; resend the OTP to get it again.
+	Otp *string `json:"otp,omitempty"`
+
+	// OtpId The unique identifier of the issued OTP.
+	OtpId int64 `json:"otp_id"`
 
 	// UserId The unique identifier of the user who requested the OTP.
 	UserId string `json:"user_id"`
 }
 
+// ResendOtpBody defines model for ResendOtpBody.
+type ResendOtpBody struct {
+	// Channel Optional channel to deliver the OTP through instead of the usual one.
+	Channel *string `json:"channel,omitempty"`
+
+	// UserId The unique identifier of the user the OTP was issued to.
+	UserId string `json:"user_id"`
+}
+
+// ResendOtpResponseSuccess defines model for ResendOtpResponseSuccess.
+type ResendOtpResponseSuccess struct {
+	// ExpiresAt The OTP can no longer be validated after this time.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Otp The one-time password (OTP) that was resent.
+	Otp string `json:"otp"`
+
+	// OtpId The unique identifier of the resent OTP, which differs from the requested one if it was rotated.
+	OtpId int64 `json:"otp_id"`
+
+	// ResendCount The number of times the OTP has been resent.
+	ResendCount int `json:"resend_count"`
+
+	// ResendsRemaining The number of times the OTP can still be resent.
+	ResendsRemaining int `json:"resends_remaining"`
+
+	// Rotated Whether the requested OTP was superseded by a new OTP with a new code.
+	Rotated bool `json:"rotated"`
+
+	// UserId The user the OTP was issued to.
+	UserId string `json:"user_id"`
+}
+
+// RevokeOtpBody defines model for RevokeOtpBody.
+type RevokeOtpBody struct {
+	// Reason Optional reason of the revocation.
+	Reason *string `json:"reason,omitempty"`
+
+	// UserId The unique identifier of the user the OTP was issued to.
+	UserId string `json:"user_id"`
+}
+
+// RevokeOtpResponseSuccess defines model for RevokeOtpResponseSuccess.
+type RevokeOtpResponseSuccess struct {
+	// OtpId The unique identifier of the revoked OTP.
+	OtpId int64 `json:"otp_id"`
+
+	// RevokedAt When the OTP was revoked.
+	RevokedAt time.Time `json:"revoked_at"`
+
+	// Status The status of the OTP, which is revoked.
+	Status string `json:"status"`
+
+	// UserId The user the OTP was issued to.
+	UserId string `json:"user_id"`
+}
+
+// RevokeUserOtpsBody defines model for RevokeUserOtpsBody.
+type RevokeUserOtpsBody struct {
+	// Reason Optional reason of the revocation.
+	Reason *string `json:"reason,omitempty"`
+}
+
+// RevokeUserOtpsResponse defines model for RevokeUserOtpsResponse.
+type RevokeUserOtpsResponse struct {
+	// RevokedOtpIds The OTPs that have been revoked, empty if the user had no active OTP.
+	RevokedOtpIds []int64 `json:"revoked_otp_ids"`
+
+	// UserId The unique identifier of the user.
+	UserId string `json:"user_id"`
+}
+
+// UserLockoutResponse defines model for UserLockoutResponse.
+type UserLockoutResponse struct {
+	// FailedAttempts Failed validations since the last reset or lock.
+	FailedAttempts int `json:"failed_attempts"`
+
+	// LastFailedAt When the most recent failed validation happened.
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"`
+
+	// Locked Whether the user is currently locked out.
+	Locked bool `json:"locked"`
+
+	// LockedUntil The user is locked out until this time.
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+
+	// UserId The unique identifier of the user.
+	UserId string `json:"user_id"`
+}
+
 // ValidateOtpBody defines model for ValidateOtpBody.
 type ValidateOtpBody struct {
+	// BindingId The binding_id the OTP was requested with, required if one was supplied.
+	BindingId *string `json:"binding_id,omitempty"`
+
 	// Otp The one-time password (OTP) generated for the user.
 	Otp string `json:"otp"`
 
+	// SessionId Optional identifier of the client session. If the response to a successful validation
+	// is lost, an identical retry from the same session within the grace period succeeds again.
+	SessionId *string `json:"session_id,omitempty"`
+
 	// UserId The unique identifier of the user who requested the OTP.
 	UserId string `json:"user_id"`
 }
@@ -57,20 +377,212 @@ type ValidateOtpResponseSuccess struct {
 	UserId string `json:"user_id"`
 }
 
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	// Attempts The number of failed attempts.
+	Attempts int `json:"attempts"`
+
+	// CreatedAt When the event occurred.
+	CreatedAt time.Time `json:"created_at"`
+
+	// DeliveredAt When the endpoint acknowledged the delivery.
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	// EventId The unique identifier of the delivered event, sent as the id of the envelope.
+	EventId int64 `json:"event_id"`
+
+	// EventType A kind of OTP lifecycle event.
+	EventType WebhookEventType `json:"event_type"`
+
+	// Id The unique identifier of the delivery.
+	Id int64 `json:"id"`
+
+	// LastError The error of the last failed attempt.
+	LastError *string `json:"last_error,omitempty"`
+
+	// NextAttemptAt The delivery is not attempted before this time.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+
+	// ResponseCode HTTP status code of the last attempt, absent if no response was received.
+	ResponseCode *int `json:"response_code,omitempty"`
+
+	// Status pending deliveries are still being attempted, failed deliveries were given up
+	// after the maximum number of attempts.
+	Status WebhookDeliveryStatus `json:"status"`
+
+	// SubscriptionId The subscription the event is delivered to.
+	SubscriptionId int64 `json:"subscription_id"`
+}
+
+// WebhookDeliveryStatus pending deliveries are still being attempted, failed deliveries were given up
+// after the maximum number of attempts.
+type WebhookDeliveryStatus string
+
+// WebhookDeliveryListResponse defines model for WebhookDeliveryListResponse.
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookEventType A kind of OTP lifecycle event.
+type WebhookEventType string
+
+// WebhookSubscription defines model for WebhookSubscription.
+type WebhookSubscription struct {
+	// ClientId The client application that owns the subscription.
+	ClientId string `json:"client_id"`
+
+	// CreatedAt When the subscription was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// EventTypes The event types delivered to the endpoint.
+	EventTypes []WebhookEventType `json:"event_types"`
+
+	// Id The unique identifier of the subscription.
+	Id int64 `json:"id"`
+
+	// Secret Key of the HMAC-SHA256 signature in the X-Webhook-Signature header of every delivery.
+	// Only returned when the subscription is created.
+	Secret *string `json:"secret,omitempty"`
+
+	// Url The http(s) endpoint the events are posted to.
+	Url string `json:"url"`
+}
+
+// WebhookSubscriptionListResponse defines model for WebhookSubscriptionListResponse.
+type WebhookSubscriptionListResponse struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+}
+
+// GetAdminAuditEventsParams defines parameters for GetAdminAuditEvents.
+type GetAdminAuditEventsParams struct {
+	// UserId The user whose audit events are listed.
+	UserId string `form:"user_id" json:"user_id"`
+
+	// From Only list events recorded at or after this time.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Only list events recorded before this time.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit The maximum number of events to list.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetAdminOtpsParams defines parameters for GetAdminOtps.
+type GetAdminOtpsParams struct {
+	// UserId Only list the OTPs of this user.
+	UserId *string `form:"user_id,omitempty" json:"user_id,omitempty"`
+
+	// Status Only list the OTPs with this status.
+	Status *GetAdminOtpsParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+
+	// Purpose Only list the OTPs requested for this purpose.
+	Purpose *string `form:"purpose,omitempty" json:"purpose,omitempty"`
+
+	// CreatedFrom Only list the OTPs issued at or after this time.
+	CreatedFrom *time.Time `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Only list the OTPs issued before this time.
+	CreatedTo *time.Time `form:"created_to,omitempty" json:"created_to,omitempty"`
+
+	// Cursor The next_cursor of the previous page.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit The maximum number of OTPs to list.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetAdminOtpsParamsStatus defines parameters for GetAdminOtps.
+type GetAdminOtpsParamsStatus string
+
+// GetAdminWebhooksParams defines parameters for GetAdminWebhooks.
+type GetAdminWebhooksParams struct {
+	// ClientId Only list the subscriptions of this client application.
+	ClientId *string `form:"client_id,omitempty" json:"client_id,omitempty"`
+}
+
+// GetAdminWebhooksSubscriptionIdDeliveriesParams defines parameters for GetAdminWebhooksSubscriptionIdDeliveries.
+type GetAdminWebhooksSubscriptionIdDeliveriesParams struct {
+	// Limit The maximum number of deliveries to list.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PostOtpRequestParams defines parameters for PostOtpRequest.
+type PostOtpRequestParams struct {
+	// IdempotencyKey Client generated key identifying the request. A retry with the same key and body
+	// within the replay window gets the original response, without the OTP code, instead of
+	// issuing another OTP. Keys are deleted by the expiry sweeper once the replay window is over.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// PostAdminUsersUserIdRevokeOtpsJSONRequestBody defines body for PostAdminUsersUserIdRevokeOtps for application/json ContentType.
+type PostAdminUsersUserIdRevokeOtpsJSONRequestBody = RevokeUserOtpsBody
+
+// PostAdminWebhooksJSONRequestBody defines body for PostAdminWebhooks for application/json ContentType.
+type PostAdminWebhooksJSONRequestBody = CreateWebhookSubscriptionBody
+
 // PostOtpRequestJSONRequestBody defines body for PostOtpRequest for application/json ContentType.
 type PostOtpRequestJSONRequestBody = RequestOtpBody
 
 // PostOtpValidateJSONRequestBody defines body for PostOtpValidate for application/json ContentType.
 type PostOtpValidateJSONRequestBody = ValidateOtpBody
 
+// PostOtpIdResendJSONRequestBody defines body for PostOtpIdResend for application/json ContentType.
+type PostOtpIdResendJSONRequestBody = ResendOtpBody
+
+// PostOtpIdRevokeJSONRequestBody defines body for PostOtpIdRevoke for application/json ContentType.
+type PostOtpIdRevokeJSONRequestBody = RevokeOtpBody
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Query the audit log of a user
+	// (GET /admin/audit-events)
+	GetAdminAuditEvents(ctx echo.Context, params GetAdminAuditEventsParams) error
+	// Search and list OTPs
+	// (GET /admin/otps)
+	GetAdminOtps(ctx echo.Context, params GetAdminOtpsParams) error
+	// Get the lifecycle timeline of an OTP
+	// (GET /admin/otps/{id}/timeline)
+	GetAdminOtpsIdTimeline(ctx echo.Context, id int64) error
+	// Clear the validation lockout of a user
+	// (DELETE /admin/users/{user_id}/lockout)
+	DeleteAdminUsersUserIdLockout(ctx echo.Context, userId string) error
+	// Inspect the validation lockout of a user
+	// (GET /admin/users/{user_id}/lockout)
+	GetAdminUsersUserIdLockout(ctx echo.Context, userId string) error
+	// Revoke all active OTPs of a user
+	// (POST /admin/users/{user_id}/revoke-otps)
+	PostAdminUsersUserIdRevokeOtps(ctx echo.Context, userId string) error
+	// List webhook subscriptions
+	// (GET /admin/webhooks)
+	GetAdminWebhooks(ctx echo.Context, params GetAdminWebhooksParams) error
+	// Subscribe an endpoint of a client application to OTP lifecycle events
+	// (POST /admin/webhooks)
+	PostAdminWebhooks(ctx echo.Context) error
+	// Replay a webhook delivery
+	// (POST /admin/webhooks/deliveries/{delivery_id}/replay)
+	PostAdminWebhooksDeliveriesDeliveryIdReplay(ctx echo.Context, deliveryId int64) error
+	// Delete a webhook subscription and its deliveries
+	// (DELETE /admin/webhooks/{subscription_id})
+	DeleteAdminWebhooksSubscriptionId(ctx echo.Context, subscriptionId int64) error
+	// Get a webhook subscription
+	// (GET /admin/webhooks/{subscription_id})
+	GetAdminWebhooksSubscriptionId(ctx echo.Context, subscriptionId int64) error
+	// List the most recent deliveries of a webhook subscription
+	// (GET /admin/webhooks/{subscription_id}/deliveries)
+	GetAdminWebhooksSubscriptionIdDeliveries(ctx echo.Context, subscriptionId int64, params GetAdminWebhooksSubscriptionIdDeliveriesParams) error
 	// Request a new OTP
 	// (POST /otp/request)
-	PostOtpRequest(ctx echo.Context) error
+	PostOtpRequest(ctx echo.Context, params PostOtpRequestParams) error
 	// Validate an OTP
 	// (POST /otp/validate)
 	PostOtpValidate(ctx echo.Context) error
+	// Resend an OTP
+	// (POST /otp/{id}/resend)
+	PostOtpIdResend(ctx echo.Context, id int64) error
+	// Revoke an OTP
+	// (POST /otp/{id}/revoke)
+	PostOtpIdRevoke(ctx echo.Context, id int64) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -78,12 +590,319 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// GetAdminAuditEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminAuditEvents(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAdminAuditEventsParams
+	// ------------- Required query parameter "user_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "user_id", ctx.QueryParams(), &params.UserId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", ctx.QueryParams(), &params.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter to: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminAuditEvents(ctx, params)
+	return err
+}
+
+// GetAdminOtps converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminOtps(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAdminOtpsParams
+	// ------------- Optional query parameter "user_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "user_id", ctx.QueryParams(), &params.UserId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// ------------- Optional query parameter "purpose" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "purpose", ctx.QueryParams(), &params.Purpose)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter purpose: %s", err))
+	}
+
+	// ------------- Optional query parameter "created_from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_from", ctx.QueryParams(), &params.CreatedFrom)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter created_from: %s", err))
+	}
+
+	// ------------- Optional query parameter "created_to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_to", ctx.QueryParams(), &params.CreatedTo)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter created_to: %s", err))
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminOtps(ctx, params)
+	return err
+}
+
+// GetAdminOtpsIdTimeline converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminOtpsIdTimeline(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminOtpsIdTimeline(ctx, id)
+	return err
+}
+
+// DeleteAdminUsersUserIdLockout converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteAdminUsersUserIdLockout(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "user_id", runtime.ParamLocationPath, ctx.Param("user_id"), &userId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteAdminUsersUserIdLockout(ctx, userId)
+	return err
+}
+
+// GetAdminUsersUserIdLockout converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminUsersUserIdLockout(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "user_id", runtime.ParamLocationPath, ctx.Param("user_id"), &userId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminUsersUserIdLockout(ctx, userId)
+	return err
+}
+
+// PostAdminUsersUserIdRevokeOtps converts echo context to params.
+func (w *ServerInterfaceWrapper) PostAdminUsersUserIdRevokeOtps(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "user_id", runtime.ParamLocationPath, ctx.Param("user_id"), &userId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAdminUsersUserIdRevokeOtps(ctx, userId)
+	return err
+}
+
+// GetAdminWebhooks converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminWebhooks(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAdminWebhooksParams
+	// ------------- Optional query parameter "client_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "client_id", ctx.QueryParams(), &params.ClientId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter client_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminWebhooks(ctx, params)
+	return err
+}
+
+// PostAdminWebhooks converts echo context to params.
+func (w *ServerInterfaceWrapper) PostAdminWebhooks(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAdminWebhooks(ctx)
+	return err
+}
+
+// PostAdminWebhooksDeliveriesDeliveryIdReplay converts echo context to params.
+func (w *ServerInterfaceWrapper) PostAdminWebhooksDeliveriesDeliveryIdReplay(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "delivery_id" -------------
+	var deliveryId int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "delivery_id", runtime.ParamLocationPath, ctx.Param("delivery_id"), &deliveryId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter delivery_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAdminWebhooksDeliveriesDeliveryIdReplay(ctx, deliveryId)
+	return err
+}
+
+// DeleteAdminWebhooksSubscriptionId converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteAdminWebhooksSubscriptionId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "subscription_id" -------------
+	var subscriptionId int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "subscription_id", runtime.ParamLocationPath, ctx.Param("subscription_id"), &subscriptionId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter subscription_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteAdminWebhooksSubscriptionId(ctx, subscriptionId)
+	return err
+}
+
+// GetAdminWebhooksSubscriptionId converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminWebhooksSubscriptionId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "subscription_id" -------------
+	var subscriptionId int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "subscription_id", runtime.ParamLocationPath, ctx.Param("subscription_id"), &subscriptionId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter subscription_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminWebhooksSubscriptionId(ctx, subscriptionId)
+	return err
+}
+
+// GetAdminWebhooksSubscriptionIdDeliveries converts echo context to params.
+func (w *ServerInterfaceWrapper) GetAdminWebhooksSubscriptionIdDeliveries(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "subscription_id" -------------
+	var subscriptionId int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "subscription_id", runtime.ParamLocationPath, ctx.Param("subscription_id"), &subscriptionId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter subscription_id: %s", err))
+	}
+
+	ctx.Set(AdminApiKeyScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAdminWebhooksSubscriptionIdDeliveriesParams
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAdminWebhooksSubscriptionIdDeliveries(ctx, subscriptionId, params)
+	return err
+}
+
 // PostOtpRequest converts echo context to params.
 func (w *ServerInterfaceWrapper) PostOtpRequest(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostOtpRequestParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PostOtpRequest(ctx)
+	err = w.Handler.PostOtpRequest(ctx, params)
 	return err
 }
 
@@ -96,6 +915,38 @@ func (w *ServerInterfaceWrapper) PostOtpValidate(ctx echo.Context) error {
 	return err
 }
 
+// PostOtpIdResend converts echo context to params.
+func (w *ServerInterfaceWrapper) PostOtpIdResend(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostOtpIdResend(ctx, id)
+	return err
+}
+
+// PostOtpIdRevoke converts echo context to params.
+func (w *ServerInterfaceWrapper) PostOtpIdRevoke(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostOtpIdRevoke(ctx, id)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -124,26 +975,115 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/admin/audit-events", wrapper.GetAdminAuditEvents)
+	router.GET(baseURL+"/admin/otps", wrapper.GetAdminOtps)
+	router.GET(baseURL+"/admin/otps/:id/timeline", wrapper.GetAdminOtpsIdTimeline)
+	router.DELETE(baseURL+"/admin/users/:user_id/lockout", wrapper.DeleteAdminUsersUserIdLockout)
+	router.GET(baseURL+"/admin/users/:user_id/lockout", wrapper.GetAdminUsersUserIdLockout)
+	router.POST(baseURL+"/admin/users/:user_id/revoke-otps", wrapper.PostAdminUsersUserIdRevokeOtps)
+	router.GET(baseURL+"/admin/webhooks", wrapper.GetAdminWebhooks)
+	router.POST(baseURL+"/admin/webhooks", wrapper.PostAdminWebhooks)
+	router.POST(baseURL+"/admin/webhooks/deliveries/:delivery_id/replay", wrapper.PostAdminWebhooksDeliveriesDeliveryIdReplay)
+	router.DELETE(baseURL+"/admin/webhooks/:subscription_id", wrapper.DeleteAdminWebhooksSubscriptionId)
+	router.GET(baseURL+"/admin/webhooks/:subscription_id", wrapper.GetAdminWebhooksSubscriptionId)
+	router.GET(baseURL+"/admin/webhooks/:subscription_id/deliveries", wrapper.GetAdminWebhooksSubscriptionIdDeliveries)
 	router.POST(baseURL+"/otp/request", wrapper.PostOtpRequest)
 	router.POST(baseURL+"/otp/validate", wrapper.PostOtpValidate)
+	router.POST(baseURL+"/otp/:id/resend", wrapper.PostOtpIdResend)
+	router.POST(baseURL+"/otp/:id/revoke", wrapper.PostOtpIdRevoke)
 
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/9xW30/bMBD+V6zbHkDKmha2B/KItElIY62g28uGKhNfW6PEZ+xLWYX6v092EqaUQCcN",
-	"2I+nurbPd9/X77vrLeRUWjJo2EN2Cz5fYinj8r1z5M7QWzIew4Z1ZNGxxniM4TgsFPrcacuaDGQwXaKI",
-	"RyInhQNIAL/L0hYIGRDbmSGezakyChLgtQ3bnp02C9gk9ZuzzoMPv1+i93KxlWI8nYhPxOJDf4pNAg6v",
-	"K+1QQfa1wdCX9+IulC6vMOdQ3RleV+h5zPaY1Po+I5VHN9Oqv+bK6OsKhVZoWM81OkFzweHAoxOuflqb",
-	"RdwbTyddWI4u0TEkUGrzEc2Cl5CNdqFr63kcS/sLn1d5jt7fh0Vs+yGRwTesSxRWen9DTom98XSyLxZo",
-	"0ElGJebk7jAOvkEH0ujg8Gh41KeC3+DxZkktl6h2UPlr5CURfx+FX2ShlWR8UA9PRdx/S9tO6TUWj+2m",
-	"Y/H2ESV8HTuvimL9D7DSIrrPTIjRZk6h0kLn2DRdI8tw6/RkGtCw5pj3c6jvHN1K5wgJrND5GtVoMBwM",
-	"w02yaKTVkMFh3ErASl5GVlNimzbAIudUfwbmZWDnREEGE2raQ32vBoSeW63nZBhNDJTWFjqPoemVr7t2",
-	"PUjC6rXDOWTwKv05adJmzKRbLXXTJY5dhXGjlkks/mA4fIbs20qMhXT1EmS3lF5cIhrh0XBXepsE3j5h",
-	"ad3h21POsVStOGPug6OXyz0lEqfSrEVDoBd7oW2JQpc6zLD9UNG7l2TjxDA6Iwvh0a3Q1f8Qog19VZbS",
-	"rSFrR56QwuBNsHKwrVz4YNDw7SJcj95YNd1lpznaNvRM7tieMC9sj0c69QP+WPW35T/sjb9OiS2xQpp+",
-	"HYbbMTxs3kLlCsgglVanqxFsLjY/AgAA//9046SDtgsAAA==",
+	"H4sIAAAAAAAC/+xd65PbNpL/V1C8+5BUcTQPj5PY+2nW3t1MJVn74rlNqjIuLUS2JKwpgAbAGatc879f",
+	"NR4kSIISJc8rvvlij8QHGo3Grx/obn1OMrEqBQeuVfLyc6KyJayo+fOsypn+2xVwjZ9KKUqQmoG5RjPN",
+	"BMe/clCZZKX9mFwsgVB8DnKC91P8PiWCAxFzInQ5kfCxAqVT8+GKFiynGlJ3SQHP/d9X4gMQIUkhsg+i",
+	"0pOsAConSZrAJ7oqC0heJuErkjTR6xK/VVoyvkhuUqRSyD6Rvy0FKUHOhVxBTvQSIqRmBQOuU0LzFeNI",
+	"hlorDav2+Pam2Mj2ypTlcRbZy4SWZcEyMzDRS6qJwm+RoJpLcylW5pvfD16Zhw7Oc7IEmkOHF9kSDJ8O",
+	"aFlGKZJANeRTqmMMAd7mA7mmquERjoR/4bMJMvtAs1WU40uqlvEpv/vx7ODk+XfIWxyplHDFRKUIPkEo",
+	"t+swZ1Dkyt8DKHuT2DBDfC2FYob88A2E2ckZuSSFWLQY930wNcb1d6fNeIxrWIA0A5bxAZWoZAbk/K0f",
+	"0S1ce21Ojp5NjibHx88m38dmI3Q5KClvLt52VsYIDcqtSAmdGYlhc8JRbnHR5qLieWv405NRUxSVzsQK",
+	"otKhlyA7ZKgqywByKxzAq1Xy8o/EfKkUigtlRSUheR+ZL679dFhS8EpPTOxKinl/JXuvl0DVEDaBlEKS",
+	"TORmk1OCZIZQ1cOXKXwqmYQ8NlClQA4uHF4cXLn2MFLMQEZgxMzlY2WGf/lHwvLEQ1ozduqhuFnA1l4P",
+	"ue22Z7MkYvYfyDROpcH6n5nSv4IqBVfQx32zDOYvpmFl/vhvCfPkZfJfh40iOXRa5LB5bXJTj0qlpOve",
+	"7NybY8S9MtP5DWZLIT68q2Y1q/8q8nWfxj2xV1xzZRZMBSNsxNgV/fQz8IVeJi9Pnj9PkxXj/vNxRFzM",
+	"DKf4tRoQTiPj5gaSQ8GuQBppMVQBz0vBLB6O4r3jl+H+BdJyYyg8t88ed5cjTSpZDGxIrctv1Lc1CQ20",
+	"KkKlQV0dEWt8TL08PPRcm7hLk0ysDpE0dSh0l49Hpz9sYWRHbprVtjNo8zkmTn9DDNgg4nh5G3r0cYIL",
+	"PTXYG0MK8+C09cLh969AKbroDIF64J9Ck7/Hh+huJjOH2LgxhrzR5eZdz+GTnmaVVEOMsdc8POPtpKQL",
+	"qBWUsBq4oMpemAyowfHI8kaX76rVisr1VmQx7x2Yt39Hb8ozw+eNuhCXJKOcCF6syQyIt0Rzcs300twy",
+	"YzxnfDFlOWHaqGdnH7ibAj7MhCiA8tGmGo6OL2RKVbtYaFafqe0vdzeOf/OgJuTsYwWE5cA1mzOo5eTN",
+	"xdt9DJWykqVQUUOF6hZvGmbPRcdcLsSC8bj1gI7HdMiI+G257gyBt7cNrkSx1VRd03L4/SMXN3j7uDVQ",
+	"mupKDW5SiZvR3hMsQuP0WMFLG1FOnRjk6AJF5+qe2d8+agvyaOMoTWoix/Gyvn0sN2PmV2N2OU6nDik6",
+	"RlewywaQ54KtoGAchh3sIde1kWqcHrKN8gzqRTNerHWl/aJlt+rbUq1hVeoBKePVama3uLOt/e3W4HZ2",
+	"zbo12Elsm+9pxu3Lnd286dH+4HgC9nMdM7Ort2wB5zy5eyfkrF6G+ktyjTc7JVUbnymSGF75WAFuUjbH",
+	"L5ZUES40mQHw5hmyBj0esPZw1hpGWgbaoJGuHxDzxthwj9TTdcJo5mXddRzevn5IFtC684p8xdSK6mwZ",
+	"n4q1n6ZIcXxGP15cvPX46+dV0+pos8RcW+s9vl+eHz2L7RgJqip0fGTnHKbEuejIAOejo3IklEdFNeRw",
+	"CYYHabDU7iVWwQ7t7joWENNXVQlSQQ75dLaebgqEcLgGacC8VhSGdUaPXC9ZAUQvmSI+DiIqrTQ1FLeN",
+	"jGejjAz7TYyQDwx37ryJTvngh6UrSRPPhaTWUdZBt4ystUOe1NZAEnIiGjNx8jANndNBXnnhCW9uw29t",
+	"mc/dRvAXPFstZCjGP7S4N4Z5Hb1pLreRaotKHHZE7tYy3i220dPhN32X+u6M7U1b5bYN7vs3Kutbv36z",
+	"0i1k1LQcMinTTdGyX60Z8UaX8fBY45P2Z/fG/EELb10tgIM0Li0XPDPHMTlcMbRwXk+I4YUCncbc4Uve",
+	"+MOztVGvhi6SUSnXjC/MQ4quQi95cslbq2QHO/h+/oy+yHaPuA16ivU8CzqDAqX0OvQdWcd1TFHBZEtr",
+	"MCszyzkrtNVHiszWcd8yoPb50VZiN4v10JY28u6I9UztbvNG2HcJrHl6NsuYx+p3LvLfE7dNEHgRiA0X",
+	"pBB8AbIdSqFzbTY0UwS3004AGR9QcPsQKalS10Lm5Js3F2+/DWTd60TkwISca5SHAuYabQpvKtpZEwll",
+	"QdeQX3ItzPdarpvwj5Hu8xxWpdDAs/XBT4Ca14aZjf3HrAWttJCQ/8X7BV4OtSAL0Ghq0wVlvLs7jk+e",
+	"vTh6cWvawSHlnkriC8T3uufUDkvwzmC6xRP/1XB8ECyzJeUcik1Iae/AtXI2VLN8SymqxZIwrjTQvJlz",
+	"RQsUw47OEyyD+0SNXbTk7QGHY/fXgxsm6mCDdcp7BHe1Re0Q1ryyKiln8zlI1eQJNBtJcHCOuqFOaG+l",
+	"7OoLWVSaZqLielvQBzmkatHCCIGJDkR4czw8lJpKWFHGkVU7jYcCoTQrChSGyJjRQJPjzOZgf8NWv2Ua",
+	"h83aNxyu7TVEf/vRnxT1Q/x3asNuB0V77tayKD0XOssdW5L4xkYzfhBHh8I8NYw2gRjL7XgoRkvKlT3w",
+	"nmaUZ1AUkLcx8/joKwRNx9utoLknqODr91b8D3egEfE5LSiygXOZJtpyv07lDi5fwM1hUfhfBfKNLtWd",
+	"7rXgEGun7XWzlezh+I6fveWQGtT8ymrdJb0Cr1/MgymBVanXhNmpmXVbUvReCaLGVd+y/OP0OD09eR/k",
+	"VIwODgaZE3vjyV7y04hNl18xkUGu/2xzKIcZb6O40+Gjnb93Y++KKMYzaMLXEhRon7C5XeviM9N62A3Q",
+	"sRLm5ZmJVnapIEtalsB3QRQkb5u2N4LDlI9uFWtin0IPsDW1OS0UxBS8vX9acc2KDaCCnmX9ZmLu3sdo",
+	"fTAB7MpNzd6YJP7LLhvsFZq6aKdTxI/50fZKiScVYcAfB6jKJt9NbjO+dFsxhpEugwKlhsL+Nbj3l9nF",
+	"8tzTE3Je+xI2hmEiF+5YaF4Vwe665EY+FZ4Vc/fmjBYuzFG7HCbM4V5vlsAl3C4kzYCUIJnIfb6oiocy",
+	"ns1Pshf0Ozh4nh/PDk6zH+DgBf1+fnAy+y4/hR+yY/riaPf1eRQxibbVvWVfbLXwXHKY9ZDD5LB/1a5v",
+	"s5TFOnn8XPEzinHG5TC+9gdqPXbsmYuw3REdddLUOVcfDdf1AeqW1/t8S5p94OK6gHzhuB6es+5wuLX7",
+	"qteU2rmmtkLBRTBZHdICfgWFKGGPzPomW3OfhNZ9J9SO2B8fjyLVmCxb00Mj6QdO7Fpj+hNdh8O5Txt0",
+	"Ts3zo2exZTSZmO51g6ExP0cfXXb3Y6AC5kLCPhbGluSGMYkN7XKFRv9YFZ4Bu4J8TJrDkEvo8hP89BnY",
+	"7GQfD8JLNSfSTmYI3nwNEsiCXQEnVXnJfQQRyIp+YqtqFcBJjSOXPEgAcAQkwQavTaToof6ow/zwprCo",
+	"pZ0j/qWn9NYL7dATwEZrowb+amD5dWWzBaIj0H1zDnKzUKNP6Dvv35ooHAyxgdwGf3rLdVYnh6BGLtgc",
+	"snVWQD9VBAvXmsTJsIzNf25SROrKOF1/8FEMYzz2DO5GviIlEw9YLrFfSVpL/BEq3FO7qr4HrbvoBgx2",
+	"VluDTB4XwVeQSYhw+SdY+xF+/OXs1cG7H8+wRk+xBae6kuBL534/cLM6eFdfskWILhNKrhvNesnfYAqA",
+	"BF1J7jMLe0vJmpW8jCZiP1g5yog04M1FJ2OBL9yXm8Ev5NzO+BcOsxUD2yP1ibfCVEmm1+9wGEvfWb5i",
+	"/KxkP4Gx0RkulZWPJE04XeEbfj8wdx2clQzPwRtGU/vcDb6b8bnANxQsA8cJ9/gv5xeGeKbNamJUi7wD",
+	"eWUPTa9AKishx5OjyRHeKUrgtGToXJqv0qSkemnIPTSJHIemjPCgSfta2C1SF+yd58nL5B+g7ezqUjZl",
+	"XiXpCjRIlbz8YzC6c70UylcrBgJaMOUAzDDqY2VzBd1Ew+ieXxctK0hdbbbxAjcHYHvBAdyPOKqnQkIm",
+	"ZG4sU4zYRQ5LY5Shw5+EZIxLdxpPTcw8jRGixS2QcRG17BxJWhgKhwgo2IrpFg05zKlJvrUBcvteNGGP",
+	"TJTCfop4mjfvG9vaCOHJ0RH+lwmuXWFAoHoP/+Mi+83A48ovW/BidtpA3b5nQOD1p0QUOaA3w6Qy+HV6",
+	"izS2q/EipP2V5j7mYMc+vr+xf2FKod8gJGE2n80VTZy9PScfwOjy5/fJjHOuQWKYT4HEVBPrjYaobPCo",
+	"hcd/vEchU77SLfmfyuT5hnXUNqsJFztJE00XiGr2Jcl7fLkDTF+ht4jZEihi1iJcgaY51TQ4mVPEpM37",
+	"DDWbPadSk9XtJWtCLlxalIVJjnZFbUZMLvlbqlRdYDhtag6DfCyqfC2iy50yo4miENc4tqk9NOZGHOTx",
+	"dGobujfwVc/NzJOpOpi7GdZvAcbrkV2qGVPO+R4avfbXmsG9L9L4IaEP0k9Rf78fha1ESkuqS8scotVd",
+	"bnNqh6SoUWS5E9yd9J+36m5dD3apGqsHPUG3pg87G6vVgcHX7UYJMY98iWzHtbFhyv66+Hmoik8eVBV3",
+	"a6wj2H5mWOyn3cbGJ637Z9e674DKzHa6MXse13irsj38zPKbQ+3KTLb6KKi+znNflTLKTdlSKmI2HPpN",
+	"zX7b4ppsDzze8TbrVRENmLsYosPVYNp1l1ExQ/eRif3p0en9EeS5lAuwBwnwiSn9p9x9/3CmYBOT9ZvK",
+	"GJAc57lxN6L5pg4/Oyvu5tB1JXORadDQ35WvzffmVRitUPjPee5ScZLeJjiNR7vcQE0qremDBvkTKt+G",
+	"XLxCZhrJCHKKPMs3OUXpZiQes+C3x6pYitfAfvY1v+YwWVp1JLIPxn2A0Ot/kq/bkK9zrkrI9H4Str/2",
+	"bjmibfU9JrzYtdbfD+Og9Q4PfGTg8ZCcJqVQkTCFTUVV7tAC1Vs7vZvqdhVBWLU6WUxIcyxsHpBQCmkK",
+	"Tsi7818IJszGAgxvheoBQ53WrdzMQGmfF3crEh5JFr65ubm5QyAayPMdti2GEnmf0Oc20McuB6FFEaQ/",
+	"q5HBPpefsv1k5Dd/406Bs9ZBUx1B6582D4YcgiO4sVGHu/Q8tp3pDeyBWKsGlZq4HioIvQQmiT2+VU+b",
+	"4jY2Ba5KnO1xJSxURPZrPA+E/y4gfHPry5ubm65O7KP78V2K+JBYt476G9fFHfibQL8nkyxFkbtUEiPn",
+	"ViUotuDqkrdzC/5CmPZZbXV+QZ1Q/BQj+7PHyKzUzAA98jq9w6irWBqSiKVZqVFK7bDJ9Tr87KXLGbRY",
+	"0f9ltmyseVPErg3G/fKQWtzaxRyNvCpciWzY5GcGLpcXdw9WsNadO3OmSjysw2o6WxJjS4gvedAXzOdJ",
+	"GgFUrSRLCVoyn9azBTZf18vg/lqjXWxWoAdkJ7cNZE1iYBzEanbVAKYcO/On4OBFRM6/hkihlb5IB7Zx",
+	"uPK5k0N7MzJC6DdEqFzP89FRwmjHr1ps7fhPQjvMqq9AcK0wERqfoD/paPTe7kHNsTJ69BAWZ2zWjR+F",
+	"U3dJsE+b4CveBHjOE98BtxxhjQ0xYOb1qyq+yNQbo3QO29USe+zq1yFObGVTP3OjIeC28jeOHzR/Y1Ot",
+	"ymbzEVkQyeKP5Xc8odLXiUo/+6Bn2MugLR7/D0BL6PLQh0tefq4d1r6HaOqg7X1bZvuq26byA6z9vOse",
+	"k25MbHsd68+Hj6B1NBP5+pIH5es2EkCuGc/FNVmAy24Vki2YbaNipaYVrbVtqEQOadD2DevoVYX0UC6M",
+	"R43pNeQnWNtMV2efey/c5F6uiboGKHHxfJuNNkFMEXEF0rrZ0YqLTufBoYTK7ZX0Flnv4oCq1ap0VDjz",
+	"6A5G71bdR2Cg1c3MBE5aZfYPHnp8cX9jn/mRNze6bMJCjJNSioUEpUz3CaZdbjeOjpKv0kvum2cyhTcj",
+	"Z00LuwyM7S4B4dcdngNV5lfCSEFLZQNNyIOTk/vVVt35IkW0kEDztRWTSvmibur69KHceObNjMgj3fe4",
+	"dhdCkF8oXxMn/Ip8g8BJjBHG+OLbx6Rfg5iMZVndZC/Qi/jp/Y3XL/WvQW5TML5dxh2d23Rb3dwztG3o",
+	"KDKAbVfx9iEPiGuuv7QoKRozRiwUWbmmucDprDA5GSbuKAGrE9udoZi65E12hiL/dnYuNsv6d69wAtV1",
+	"VlSmgUDFsd0G96UoLkUKcnNmruqTpns0jv9pjzpMDQ007YMn5ifB6oMwFuPWxGLMs/vFxkhzK5c4IwRZ",
+	"IQD1Wnmp0bN5dPjkt1s/kbSNTp/tAZMC+9NijzU7O36U5Fxf5SbpE3eCn4BpMGQN7mApJcI1pirWdT9k",
+	"bwS71smTS/4afPMOwZtGtzkpRcGyukRd2XLftDE5fA/tpmrfj1r3azQ9rEb2am31j3RlZ+SM937pzbXh",
+	"pZfc6E3Iuw1p6xcZTHE71s5JtarimA5oGzoxe6NLPBVzPx5yN4Z42AX73u3wgZ7QG1LSO42F711PhXS0",
+	"bL4gXRCvBT90EZAcpLg9eDq/+YWnsKVpvT3rLOBHozwklCaBJFTzLqFZ3b8pHRfFRsMZJHik9rRB13Ha",
+	"CoX1z6etXpnu0GOVlcvtnUFGK2WDLUGr6Tr/oV3LirumbkJto0t1I+UNrem3oLxh913mAz8Yyg80sR63",
+	"tQLIfIL5O4H5R4hSNnt5AKXwXvOwxSPTLig5pCU7vDpObt7f/N8AHxg/INqCAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -0,0 +1,260 @@
+// Package crypto encrypts the sensitive columns of the stored rows with envelope encryption: the values are
+// encrypted with data keys, which are stored wrapped by a master key held by a KMS and only unwrapped in memory.
+package crypto
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+const (
+	// indexKeyID identifies the single blind index key, so that instances creating it concurrently conflict
+	indexKeyID = "blind-index"
+	// refreshInterval is how long the current data key is used before it is read again,
+	// so that the data key rotated by another instance is picked up
+	refreshInterval = time.Minute
+)
+
+// unwrappedKey is a key unwrapped by the KMS
+type unwrappedKey struct {
+	kind entity.EncryptionKeyKind
+	key  []byte
+}
+
+// Keyring encrypts and decrypts values with data keys wrapped by a master key, and derives the blind indexes
+// the encrypted values are looked up by. The new values are encrypted with the data key created last.
+// It is safe for concurrent use.
+type Keyring struct {
+	kms         KMS
+	masterKeyID string
+	keyRepo     EncryptionKeyRepository
+
+	mu           sync.Mutex
+	keys         map[string]unwrappedKey // Unwrapped keys by ID
+	currentKeyID string
+	refreshedAt  time.Time
+}
+
+// NewKeyring creates a keyring wrapping its new keys with the master key of the given ID.
+func NewKeyring(kms KMS, masterKeyID string, keyRepo EncryptionKeyRepository) *Keyring {
+	return &Keyring{
+		kms:         kms,
+		masterKeyID: masterKeyID,
+		keyRepo:     keyRepo,
+		keys:        make(map[string]unwrappedKey),
+	}
+}
+
+// Load unwraps the blind index key and the current data key, creating them if they do not exist yet.
+// It is called on startup, so that the keys are not created within the transaction of a request.
+func (k *Keyring) Load(ctx context.Context) error {
+	if _, err := k.indexKey(ctx); err != nil {
+		return err
+	}
+
+	_, err := k.CurrentKeyID(ctx)
+	return err
+}
+
+// CurrentKeyID returns the ID of the data key the new values are encrypted with.
+func (k *Keyring) CurrentKeyID(ctx context.Context) (string, error) {
+	k.mu.Lock()
+	currentKeyID, refreshedAt := k.currentKeyID, k.refreshedAt
+	k.mu.Unlock()
+	if currentKeyID != "" && time.Since(refreshedAt) < refreshInterval {
+		return currentKeyID, nil
+	}
+
+	current, err := k.keyRepo.GetLatest(ctx, entity.EncryptionKeyKindData)
+	if errors.Is(err, entity.ErrEncryptionKeyNotFound) {
+		current, err = k.createKey(ctx, newDataKeyID(), entity.EncryptionKeyKindData)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	k.mu.Lock()
+	k.currentKeyID, k.refreshedAt = current.ID, time.Now()
+	k.mu.Unlock()
+
+	return current.ID, nil
+}
+
+// Encrypt encrypts a value with the data key of the given ID, authenticating the associated data along with it.
+func (k *Keyring) Encrypt(ctx context.Context, keyID string, plaintext []byte, associatedData []byte) ([]byte, error) {
+	key, err := k.key(ctx, keyID, entity.EncryptionKeyKindData)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(key, plaintext, associatedData)
+}
+
+// Decrypt decrypts a value encrypted with the data key of the given ID and the same associated data.
+func (k *Keyring) Decrypt(ctx context.Context, keyID string, ciphertext []byte, associatedData []byte) ([]byte, error) {
+	key, err := k.key(ctx, keyID, entity.EncryptionKeyKindData)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(key, ciphertext, associatedData)
+}
+
+// BlindIndex derives the keyed index of a value with HMAC-SHA256, which is the same for equal values
+// and reveals nothing else about them to whoever does not hold the blind index key.
+func (k *Keyring) BlindIndex(ctx context.Context, value []byte) (string, error) {
+	key, err := k.indexKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(value)
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// RotateDataKey creates a new data key to encrypt the new values with if the current one was created
+// longer ago than maxAge, and reports whether it did. The values encrypted with the previous data keys
+// stay readable until they are encrypted again.
+func (k *Keyring) RotateDataKey(ctx context.Context, maxAge time.Duration) (bool, error) {
+	current, err := k.keyRepo.GetLatest(ctx, entity.EncryptionKeyKindData)
+	if err != nil && !errors.Is(err, entity.ErrEncryptionKeyNotFound) {
+		return false, err
+	}
+	if err == nil && time.Since(current.CreatedAt) < maxAge {
+		return false, nil
+	}
+
+	rotated, err := k.createKey(ctx, newDataKeyID(), entity.EncryptionKeyKindData)
+	if err != nil {
+		return false, err
+	}
+
+	k.mu.Lock()
+	k.currentKeyID, k.refreshedAt = rotated.ID, time.Now()
+	k.mu.Unlock()
+
+	return true, nil
+}
+
+// RewrapKeys wraps the keys wrapped by another master key with the current one, so that the previous
+// master keys can be retired, and returns the number of keys it wrapped again. The keys themselves
+// do not change, so the values they encrypt do not need to be encrypted again.
+func (k *Keyring) RewrapKeys(ctx context.Context) (int, error) {
+	keys, err := k.keyRepo.ListWrappedByOther(ctx, k.masterKeyID)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, key := range keys {
+		plaintext, err := k.kms.Decrypt(ctx, key.MasterKeyID, key.WrappedKey)
+		if err != nil {
+			return i, fmt.Errorf("failed to unwrap key %s: %w", key.ID, err)
+		}
+
+		wrapped, err := k.kms.Encrypt(ctx, k.masterKeyID, plaintext)
+		if err != nil {
+			return i, err
+		}
+
+		key.MasterKeyID, key.WrappedKey = k.masterKeyID, wrapped
+		if err := k.keyRepo.UpdateWrapping(ctx, key); err != nil {
+			return i, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+// indexKey returns the blind index key, creating it if it does not exist yet.
+// It is created once and never rotated, as the blind indexes derived from it are what the values are looked up by.
+func (k *Keyring) indexKey(ctx context.Context) ([]byte, error) {
+	key, err := k.key(ctx, indexKeyID, entity.EncryptionKeyKindIndex)
+	if !errors.Is(err, entity.ErrEncryptionKeyNotFound) {
+		return key, err
+	}
+
+	// Another instance may have created it in the meantime
+	if _, err := k.createKey(ctx, indexKeyID, entity.EncryptionKeyKindIndex); err != nil && !errors.Is(err, entity.ErrEncryptionKeyDuplicate) {
+		return nil, err
+	}
+
+	return k.key(ctx, indexKeyID, entity.EncryptionKeyKindIndex)
+}
+
+// key returns the unwrapped key of the given ID, which must be of the given kind
+func (k *Keyring) key(ctx context.Context, id string, kind entity.EncryptionKeyKind) ([]byte, error) {
+	k.mu.Lock()
+	cached, ok := k.keys[id]
+	k.mu.Unlock()
+	if !ok {
+		wrapped, err := k.keyRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := k.kms.Decrypt(ctx, wrapped.MasterKeyID, wrapped.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap key %s: %w", id, err)
+		}
+
+		cached = unwrappedKey{kind: wrapped.Kind, key: key}
+		k.mu.Lock()
+		k.keys[id] = cached
+		k.mu.Unlock()
+	}
+
+	if cached.kind != kind {
+		return nil, fmt.Errorf("key %s is not a %s key", id, kind)
+	}
+
+	return cached.key, nil
+}
+
+// createKey generates a key of the given kind, and stores it wrapped by the master key
+func (k *Keyring) createKey(ctx context.Context, id string, kind entity.EncryptionKeyKind) (*entity.EncryptionKey, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.kms.Encrypt(ctx, k.masterKeyID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	created := &entity.EncryptionKey{
+		ID:          id,
+		Kind:        kind,
+		MasterKeyID: k.masterKeyID,
+		WrappedKey:  wrapped,
+		CreatedAt:   time.Now(),
+	}
+	if err := k.keyRepo.Create(ctx, created); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.keys[id] = unwrappedKey{kind: kind, key: key}
+	k.mu.Unlock()
+
+	return created, nil
+}
+
+// newDataKeyID generates a random data key ID
+func newDataKeyID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return "dk-" + hex.EncodeToString(id)
+}
@@ -0,0 +1,235 @@
+package crypto_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/crypto"
+	"github.com/imansohibul/otp-service/internal/crypto/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKMS creates a KMS holding the master-1 and master-2 master keys
+func newKMS(t *testing.T) *crypto.LocalKMS {
+	kms, err := crypto.NewLocalKMS(map[string][]byte{"master-1": masterKey(1), "master-2": masterKey(2)})
+	require.NoError(t, err)
+	return kms
+}
+
+// wrappedKey returns a stored key filled with the given byte, wrapped by the given master key
+func wrappedKey(t *testing.T, kms crypto.KMS, id string, kind entity.EncryptionKeyKind, masterKeyID string, b byte) *entity.EncryptionKey {
+	wrapped, err := kms.Encrypt(context.TODO(), masterKeyID, bytes.Repeat([]byte{b}, 32))
+	require.NoError(t, err)
+	return &entity.EncryptionKey{
+		ID:          id,
+		Kind:        kind,
+		MasterKeyID: masterKeyID,
+		WrappedKey:  wrapped,
+		CreatedAt:   time.Now().Add(-time.Hour),
+	}
+}
+
+func TestKeyring_Load(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should create the blind index key and the data key when they do not exist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		keyRepo := mock.NewMockEncryptionKeyRepository(ctrl)
+		gomock.InOrder(
+			keyRepo.EXPECT().FindByID(gomock.Any(), "blind-index").Return(nil, entity.ErrEncryptionKeyNotFound),
+			keyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key *entity.EncryptionKey) error {
+				assert.Equal(t, "blind-index", key.ID)
+				assert.Equal(t, entity.EncryptionKeyKindIndex, key.Kind)
+				assert.Equal(t, "master-1", key.MasterKeyID)
+				return nil
+			}),
+			keyRepo.EXPECT().GetLatest(gomock.Any(), entity.EncryptionKeyKindData).Return(nil, entity.ErrEncryptionKeyNotFound),
+			keyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key *entity.EncryptionKey) error {
+				assert.Regexp(t, "^dk-[0-9a-f]{32}$", key.ID)
+				assert.Equal(t, entity.EncryptionKeyKindData, key.Kind)
+				assert.Equal(t, "master-1", key.MasterKeyID)
+				return nil
+			}),
+		)
+
+		keyring := crypto.NewKeyring(newKMS(t), "master-1", keyRepo)
+		require.NoError(t, keyring.Load(ctx))
+
+		// The created keys are kept unwrapped, and the current data key is not read again until it is refreshed
+		keyID, err := keyring.CurrentKeyID(ctx)
+		require.NoError(t, err)
+		ciphertext, err := keyring.Encrypt(ctx, keyID, []byte("user123"), []byte("otps.user_id"))
+		require.NoError(t, err)
+		plaintext, err := keyring.Decrypt(ctx, keyID, ciphertext, []byte("otps.user_id"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("user123"), plaintext)
+	})
+
+	t.Run("Should use the blind index key created concurrently by another instance", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		kms := newKMS(t)
+		indexKey := wrappedKey(t, kms, "blind-index", entity.EncryptionKeyKindIndex, "master-1", 7)
+		keyRepo := mock.NewMockEncryptionKeyRepository(ctrl)
+		gomock.InOrder(
+			keyRepo.EXPECT().FindByID(gomock.Any(), "blind-index").Return(nil, entity.ErrEncryptionKeyNotFound),
+			keyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.ErrEncryptionKeyDuplicate),
+			keyRepo.EXPECT().FindByID(gomock.Any(), "blind-index").Return(indexKey, nil),
+			keyRepo.EXPECT().GetLatest(gomock.Any(), entity.EncryptionKeyKindData).
+				Return(wrappedKey(t, kms, "dk-1", entity.EncryptionKeyKindData, "master-1", 8), nil),
+		)
+
+		keyring := crypto.NewKeyring(kms, "master-1", keyRepo)
+		require.NoError(t, keyring.Load(ctx))
+
+		// The blind indexes are those of the stored key, whichever instance derives them
+		expected := crypto.NewKeyring(kms, "master-1", keyRepo)
+		keyRepo.EXPECT().FindByID(gomock.Any(), "blind-index").Return(indexKey, nil)
+		index, err := keyring.BlindIndex(ctx, []byte("user123"))
+		require.NoError(t, err)
+		expectedIndex, err := expected.BlindIndex(ctx, []byte("user123"))
+		require.NoError(t, err)
+		assert.Equal(t, expectedIndex, index)
+	})
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	ctx := context.TODO()
+	ctrl := gomock.NewController(t)
+	kms := newKMS(t)
+	keyRepo := mock.NewMockEncryptionKeyRepository(ctrl)
+	keyRepo.EXPECT().FindByID(gomock.Any(), "dk-1").
+		Return(wrappedKey(t, kms, "dk-1", entity.EncryptionKeyKindData, "master-1", 8), nil)
+	keyRepo.EXPECT().FindByID(gomock.Any(), "blind-index").
+		Return(wrappedKey(t, kms, "blind-index", entity.EncryptionKeyKindIndex, "master-1", 7), nil)
+	keyring := crypto.NewKeyring(kms, "master-1", keyRepo)
+
+	ciphertext, err := keyring.Encrypt(ctx, "dk-1", []byte("123456"), []byte("otps.otp_code"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "123456")
+
+	t.Run("Should decrypt a value with the data key and the associated data it was encrypted with", func(t *testing.T) {
+		plaintext, err := keyring.Decrypt(ctx, "dk-1", ciphertext, []byte("otps.otp_code"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("123456"), plaintext)
+	})
+
+	t.Run("Should not decrypt a value with other associated data", func(t *testing.T) {
+		_, err := keyring.Decrypt(ctx, "dk-1", ciphertext, []byte("otps.user_id"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Should encrypt equal values differently", func(t *testing.T) {
+		other, err := keyring.Encrypt(ctx, "dk-1", []byte("123456"), []byte("otps.otp_code"))
+		require.NoError(t, err)
+		assert.NotEqual(t, ciphertext, other)
+	})
+
+	t.Run("Should derive the same blind index for equal values only", func(t *testing.T) {
+		index, err := keyring.BlindIndex(ctx, []byte("user123"))
+		require.NoError(t, err)
+		again, err := keyring.BlindIndex(ctx, []byte("user123"))
+		require.NoError(t, err)
+		other, err := keyring.BlindIndex(ctx, []byte("user124"))
+		require.NoError(t, err)
+
+		assert.Equal(t, index, again)
+		assert.NotEqual(t, index, other)
+		assert.Len(t, index, 43)
+	})
+
+	t.Run("Should not encrypt with the blind index key", func(t *testing.T) {
+		_, err := keyring.Encrypt(ctx, "blind-index", []byte("123456"), nil)
+		assert.EqualError(t, err, "key blind-index is not a data key")
+	})
+
+	t.Run("Should return error for an unknown data key", func(t *testing.T) {
+		keyRepo.EXPECT().FindByID(gomock.Any(), "dk-2").Return(nil, entity.ErrEncryptionKeyNotFound)
+		_, err := keyring.Decrypt(ctx, "dk-2", ciphertext, []byte("otps.otp_code"))
+		assert.ErrorIs(t, err, entity.ErrEncryptionKeyNotFound)
+	})
+}
+
+func TestKeyring_RotateDataKey(t *testing.T) {
+	ctx := context.TODO()
+	kms := newKMS(t)
+
+	tests := []struct {
+		name           string
+		current        *entity.EncryptionKey
+		expectRotation bool
+	}{
+		{
+			name:           "Should not rotate a data key younger than the maximum age",
+			current:        wrappedKey(t, kms, "dk-1", entity.EncryptionKeyKindData, "master-1", 8),
+			expectRotation: false,
+		},
+		{
+			name: "Should rotate a data key older than the maximum age",
+			current: func() *entity.EncryptionKey {
+				key := wrappedKey(t, kms, "dk-1", entity.EncryptionKeyKindData, "master-1", 8)
+				key.CreatedAt = time.Now().Add(-25 * time.Hour)
+				return key
+			}(),
+			expectRotation: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			keyRepo := mock.NewMockEncryptionKeyRepository(ctrl)
+			keyRepo.EXPECT().GetLatest(gomock.Any(), entity.EncryptionKeyKindData).Return(tt.current, nil)
+
+			var created string
+			if tt.expectRotation {
+				keyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key *entity.EncryptionKey) error {
+					assert.Equal(t, entity.EncryptionKeyKindData, key.Kind)
+					created = key.ID
+					return nil
+				})
+			}
+
+			keyring := crypto.NewKeyring(kms, "master-1", keyRepo)
+			rotated, err := keyring.RotateDataKey(ctx, 24*time.Hour)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectRotation, rotated)
+
+			// The new values are encrypted with the rotated data key right away
+			if tt.expectRotation {
+				keyID, err := keyring.CurrentKeyID(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, created, keyID)
+			}
+		})
+	}
+}
+
+func TestKeyring_RewrapKeys(t *testing.T) {
+	ctx := context.TODO()
+	ctrl := gomock.NewController(t)
+	kms := newKMS(t)
+	keyRepo := mock.NewMockEncryptionKeyRepository(ctrl)
+
+	stale := wrappedKey(t, kms, "dk-1", entity.EncryptionKeyKindData, "master-1", 8)
+	keyRepo.EXPECT().ListWrappedByOther(gomock.Any(), "master-2").Return([]*entity.EncryptionKey{stale}, nil)
+	keyRepo.EXPECT().UpdateWrapping(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key *entity.EncryptionKey) error {
+		assert.Equal(t, "dk-1", key.ID)
+		assert.Equal(t, "master-2", key.MasterKeyID)
+
+		// The key itself is unchanged
+		unwrapped, err := kms.Decrypt(ctx, "master-2", key.WrappedKey)
+		assert.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{8}, 32), unwrapped)
+		return nil
+	})
+
+	keyring := crypto.NewKeyring(kms, "master-2", keyRepo)
+	rewrapped, err := keyring.RewrapKeys(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rewrapped)
+}
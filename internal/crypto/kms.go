@@ -0,0 +1,92 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KMS wraps and unwraps keys with the master keys it holds, like the Encrypt and Decrypt operations
+// of a cloud key management service, so that the master keys never leave it.
+type KMS interface {
+	// Encrypt encrypts a key with the master key of the given ID.
+	Encrypt(ctx context.Context, masterKeyID string, plaintext []byte) ([]byte, error)
+
+	// Decrypt decrypts a key encrypted with the master key of the given ID.
+	Decrypt(ctx context.Context, masterKeyID string, ciphertext []byte) ([]byte, error)
+}
+
+// LocalKMS is a KMS holding its master keys in process, for the deployments without a key management service.
+// The master key ID is authenticated along with every key it wraps.
+type LocalKMS struct {
+	masterKeys map[string][]byte
+}
+
+// NewLocalKMS creates a KMS holding the given 256-bit master keys, by ID.
+func NewLocalKMS(masterKeys map[string][]byte) (*LocalKMS, error) {
+	for id, key := range masterKeys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("master key %q is %d bytes long instead of %d", id, len(key), keySize)
+		}
+	}
+
+	return &LocalKMS{
+		masterKeys: masterKeys,
+	}, nil
+}
+
+// LoadKeyfile creates a KMS holding the master keys of a keyfile,
+// a JSON object mapping the master key IDs to base64 encoded 256-bit keys.
+func LoadKeyfile(path string) (*LocalKMS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var encodedKeys map[string]string
+	if err := json.Unmarshal(data, &encodedKeys); err != nil {
+		return nil, fmt.Errorf("invalid keyfile %s: %w", path, err)
+	}
+
+	masterKeys := make(map[string][]byte, len(encodedKeys))
+	for id, encodedKey := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key %q in keyfile %s: %w", id, path, err)
+		}
+		masterKeys[id] = key
+	}
+
+	return NewLocalKMS(masterKeys)
+}
+
+// Encrypt encrypts a key with the master key of the given ID.
+func (k *LocalKMS) Encrypt(_ context.Context, masterKeyID string, plaintext []byte) ([]byte, error) {
+	masterKey, err := k.masterKey(masterKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(masterKey, plaintext, []byte(masterKeyID))
+}
+
+// Decrypt decrypts a key encrypted with the master key of the given ID.
+func (k *LocalKMS) Decrypt(_ context.Context, masterKeyID string, ciphertext []byte) ([]byte, error) {
+	masterKey, err := k.masterKey(masterKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(masterKey, ciphertext, []byte(masterKeyID))
+}
+
+// masterKey returns the master key of the given ID
+func (k *LocalKMS) masterKey(id string) ([]byte, error) {
+	masterKey, ok := k.masterKeys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", id)
+	}
+	return masterKey, nil
+}
@@ -0,0 +1,88 @@
+package crypto_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imansohibul/otp-service/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// masterKey returns a 256-bit master key filled with the given byte
+func masterKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestLocalKMS(t *testing.T) {
+	ctx := context.TODO()
+	kms, err := crypto.NewLocalKMS(map[string][]byte{"master-1": masterKey(1), "master-2": masterKey(2)})
+	require.NoError(t, err)
+
+	wrapped, err := kms.Encrypt(ctx, "master-1", []byte("data key"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(wrapped), "data key")
+
+	t.Run("Should unwrap a key with the master key that wrapped it", func(t *testing.T) {
+		key, err := kms.Decrypt(ctx, "master-1", wrapped)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("data key"), key)
+	})
+
+	t.Run("Should not unwrap a key with another master key", func(t *testing.T) {
+		_, err := kms.Decrypt(ctx, "master-2", wrapped)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error for an unknown master key", func(t *testing.T) {
+		_, err := kms.Encrypt(ctx, "master-3", []byte("data key"))
+		assert.EqualError(t, err, `unknown master key "master-3"`)
+	})
+
+	t.Run("Should reject a master key that is not 256 bits long", func(t *testing.T) {
+		_, err := crypto.NewLocalKMS(map[string][]byte{"short": []byte("too short")})
+		assert.EqualError(t, err, `master key "short" is 9 bytes long instead of 32`)
+	})
+}
+
+func TestLoadKeyfile(t *testing.T) {
+	writeKeyfile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("Should load the base64 encoded master keys by ID", func(t *testing.T) {
+		path := writeKeyfile(t, `{"master-1": "`+base64.StdEncoding.EncodeToString(masterKey(1))+`"}`)
+
+		kms, err := crypto.LoadKeyfile(path)
+		require.NoError(t, err)
+
+		wrapped, err := kms.Encrypt(context.TODO(), "master-1", []byte("data key"))
+		require.NoError(t, err)
+		expected, err := crypto.NewLocalKMS(map[string][]byte{"master-1": masterKey(1)})
+		require.NoError(t, err)
+		key, err := expected.Decrypt(context.TODO(), "master-1", wrapped)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("data key"), key)
+	})
+
+	t.Run("Should return error for a key that is not base64 encoded", func(t *testing.T) {
+		_, err := crypto.LoadKeyfile(writeKeyfile(t, `{"master-1": "not base64!"}`))
+		assert.ErrorContains(t, err, `invalid master key "master-1"`)
+	})
+
+	t.Run("Should return error for a keyfile that is not a JSON object", func(t *testing.T) {
+		_, err := crypto.LoadKeyfile(writeKeyfile(t, `master-1`))
+		assert.ErrorContains(t, err, "invalid keyfile")
+	})
+
+	t.Run("Should return error for a missing keyfile", func(t *testing.T) {
+		_, err := crypto.LoadKeyfile(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	entity "github.com/imansohibul/otp-service/entity"
+)
+
+// MockEncryptionKeyRepository is a mock of EncryptionKeyRepository interface.
+type MockEncryptionKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEncryptionKeyRepositoryMockRecorder
+}
+
+// MockEncryptionKeyRepositoryMockRecorder is the mock recorder for MockEncryptionKeyRepository.
+type MockEncryptionKeyRepositoryMockRecorder struct {
+	mock *MockEncryptionKeyRepository
+}
+
+// NewMockEncryptionKeyRepository creates a new mock instance.
+func NewMockEncryptionKeyRepository(ctrl *gomock.Controller) *MockEncryptionKeyRepository {
+	mock := &MockEncryptionKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockEncryptionKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEncryptionKeyRepository) EXPECT() *MockEncryptionKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockEncryptionKeyRepository) Create(ctx context.Context, key *entity.EncryptionKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockEncryptionKeyRepositoryMockRecorder) Create(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockEncryptionKeyRepository)(nil).Create), ctx, key)
+}
+
+// FindByID mocks base method.
+func (m *MockEncryptionKeyRepository) FindByID(ctx context.Context, id string) (*entity.EncryptionKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*entity.EncryptionKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockEncryptionKeyRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockEncryptionKeyRepository)(nil).FindByID), ctx, id)
+}
+
+// GetLatest mocks base method.
+func (m *MockEncryptionKeyRepository) GetLatest(ctx context.Context, kind entity.EncryptionKeyKind) (*entity.EncryptionKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatest", ctx, kind)
+	ret0, _ := ret[0].(*entity.EncryptionKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatest indicates an expected call of GetLatest.
+func (mr *MockEncryptionKeyRepositoryMockRecorder) GetLatest(ctx, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatest", reflect.TypeOf((*MockEncryptionKeyRepository)(nil).GetLatest), ctx, kind)
+}
+
+// ListWrappedByOther mocks base method.
+func (m *MockEncryptionKeyRepository) ListWrappedByOther(ctx context.Context, masterKeyID string) ([]*entity.EncryptionKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWrappedByOther", ctx, masterKeyID)
+	ret0, _ := ret[0].([]*entity.EncryptionKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWrappedByOther indicates an expected call of ListWrappedByOther.
+func (mr *MockEncryptionKeyRepositoryMockRecorder) ListWrappedByOther(ctx, masterKeyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWrappedByOther", reflect.TypeOf((*MockEncryptionKeyRepository)(nil).ListWrappedByOther), ctx, masterKeyID)
+}
+
+// UpdateWrapping mocks base method.
+func (m *MockEncryptionKeyRepository) UpdateWrapping(ctx context.Context, key *entity.EncryptionKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWrapping", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWrapping indicates an expected call of UpdateWrapping.
+func (mr *MockEncryptionKeyRepositoryMockRecorder) UpdateWrapping(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWrapping", reflect.TypeOf((*MockEncryptionKeyRepository)(nil).UpdateWrapping), ctx, key)
+}
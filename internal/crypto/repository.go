@@ -0,0 +1,34 @@
+package crypto
+
+// This file contains the interfaces for the repository layer used by the keyring.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+
+import (
+	"context"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+//go:generate mockgen -destination=mock/repository.go -package=mock -source=repository.go
+
+// EncryptionKeyRepository defines the interface for the storage of the wrapped encryption keys.
+// Its writes are never part of the transaction of the context, so that a key outlives the rows it encrypted
+// within a transaction that is rolled back.
+type EncryptionKeyRepository interface {
+	// Create inserts a new key. Returns entity.ErrEncryptionKeyDuplicate if a key with the same ID exists.
+	Create(ctx context.Context, key *entity.EncryptionKey) error
+
+	// FindByID retrieves a key by its ID. Returns entity.ErrEncryptionKeyNotFound if no key exists with the ID.
+	FindByID(ctx context.Context, id string) (*entity.EncryptionKey, error)
+
+	// GetLatest retrieves the key of the given kind created last.
+	// Returns entity.ErrEncryptionKeyNotFound if no key of the kind exists.
+	GetLatest(ctx context.Context, kind entity.EncryptionKeyKind) (*entity.EncryptionKey, error)
+
+	// ListWrappedByOther retrieves the keys wrapped by another master key than the given one.
+	ListWrappedByOther(ctx context.Context, masterKeyID string) ([]*entity.EncryptionKey, error)
+
+	// UpdateWrapping stores the master key ID and the wrapped key of a key.
+	UpdateWrapping(ctx context.Context, key *entity.EncryptionKey) error
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultAuditEventsLimit is the number of audit events listed when no limit is requested, see api.yml
+const defaultAuditEventsLimit = 100
+
+// Query the audit log of a user
+// (GET /admin/audit-events)
+func (r *RestAPIServer) GetAdminAuditEvents(eCtx echo.Context, params generated.GetAdminAuditEventsParams) error {
+	filter := entity.AuditEventFilter{
+		UserID: params.UserId,
+		From:   params.From,
+		To:     params.To,
+		Limit:  defaultAuditEventsLimit,
+	}
+	if params.Limit != nil {
+		filter.Limit = *params.Limit
+	}
+
+	events, err := r.AuditUsecase.ListEvents(eCtx.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	response := generated.AuditEventListResponse{
+		Events: make([]generated.AuditEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, toAuditEventResponse(event))
+	}
+
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// toAuditEventResponse maps an audit event to its API representation
+func toAuditEventResponse(event *entity.AuditEvent) generated.AuditEvent {
+	response := generated.AuditEvent{
+		Id:        int64(event.ID),
+		Actor:     event.Actor,
+		UserId:    event.UserID,
+		Action:    string(event.Action),
+		Outcome:   generated.AuditEventOutcome(event.Outcome.String()),
+		CreatedAt: event.CreatedAt,
+		PrevHash:  event.PrevHash,
+		Hash:      event.Hash,
+	}
+	if event.ClientID != "" {
+		response.ClientId = &event.ClientID
+	}
+	if event.IP != "" {
+		response.Ip = &event.IP
+	}
+	if event.OTPID != 0 {
+		otpID := int64(event.OTPID)
+		response.OtpId = &otpID
+	}
+	if event.Reason != "" {
+		response.Reason = &event.Reason
+	}
+
+	return response
+}
@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/imansohibul/otp-service/internal/handler"
+	usecasemock "github.com/imansohibul/otp-service/internal/handler/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAdminAuditEvents(t *testing.T) {
+	from := time.Date(2025, 11, 26, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		params             generated.GetAdminAuditEventsParams
+		mockSetup          func(*testing.T, *usecasemock.MockAuditUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:   "List Audit Events - Success",
+			params: generated.GetAdminAuditEventsParams{UserId: "user123", From: &from},
+			mockSetup: func(t *testing.T, auditUsecase *usecasemock.MockAuditUsecase) {
+				auditUsecase.EXPECT().
+					ListEvents(gomock.Any(), entity.AuditEventFilter{UserID: "user123", From: &from, Limit: 100}).
+					Return([]*entity.AuditEvent{{
+						ID:       7,
+						Actor:    entity.AuditActorClient,
+						IP:       "203.0.113.7",
+						UserID:   "user123",
+						OTPID:    42,
+						Action:   entity.AuditActionOTPValidate,
+						Outcome:  entity.AuditOutcomeFailure,
+						Reason:   "otp_expired",
+						PrevHash: "previous-hash",
+						Hash:     "hash",
+					}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"otp_id":42,"outcome":"failure"`,
+		},
+		{
+			name:   "List Audit Events - Usecase Error",
+			params: generated.GetAdminAuditEventsParams{UserId: "user123"},
+			mockSetup: func(t *testing.T, auditUsecase *usecasemock.MockAuditUsecase) {
+				auditUsecase.EXPECT().
+					ListEvents(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("db error"))
+			},
+			expectedError:      errors.New("db error"),
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit-events", nil)
+			rec := httptest.NewRecorder()
+
+			mockAuditUsecase := usecasemock.NewMockAuditUsecase(ctrl)
+			tt.mockSetup(t, mockAuditUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:         e,
+				AuditUsecase: mockAuditUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.GetAdminAuditEvents(c, tt.params)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered on the default registry, which is served by the /metrics route.
+var (
+	otpValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Name:      "otp_validation_failures_total",
+		Help:      "Number of rejected OTP validations, partitioned by the detailed rejection reason.",
+	}, []string{"reason"})
+)
@@ -10,29 +10,41 @@ import (
 )
 
 func ErrorHandler(err error, ctx echo.Context) {
-	var domainErr *entity.DomainError
-
 	if ctx.Response().Committed {
 		return
 	}
 
+	_ = ctx.JSON(ErrorResponse(err))
+}
+
+// ErrorResponse returns the HTTP status and the body of the response to an error,
+// for the handlers that store the response besides writing it
+func ErrorResponse(err error) (int, generated.ErrorResponse) {
+	var domainErr *entity.DomainError
+
 	// Check if the error is a DomainError
 	if errors.As(err, &domainErr) {
 		httpStatus := http.StatusBadRequest
-		if domainErr.Code == entity.ErrOTPNotFound.Code  {
+		switch domainErr.Code {
+		case entity.ErrOTPNotFound.Code,
+			entity.ErrWebhookSubscriptionNotFound.Code,
+			entity.ErrWebhookDeliveryNotFound.Code:
 			httpStatus = http.StatusNotFound
+		case entity.ErrUserLocked.Code:
+			httpStatus = http.StatusLocked
+		case entity.ErrOTPResendLimit.Code:
+			httpStatus = http.StatusTooManyRequests
 		}
 
-		_ = ctx.JSON(httpStatus, generated.ErrorResponse{
+		return httpStatus, generated.ErrorResponse{
 			Error:            domainErr.Code,
 			ErrorDescription: domainErr.Message,
-		})
-		return
+		}
 	}
 
 	// For other errors, return a 500 Internal Server Error
-	_ = ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+	return http.StatusInternalServerError, generated.ErrorResponse{
 		Error:            "INTERNAL_SERVER_ERROR",
-		ErrorDescription: "Something went wrong! Please try again later"},
-	)
+		ErrorDescription: "Something went wrong! Please try again later",
+	}
 }
@@ -43,6 +43,20 @@ func TestErrorHandler(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 			wantBody:   generated.ErrorResponse{Error: entity.ErrInvalidRequest.Code, ErrorDescription: "Invalid request"},
 		},
+		{
+			name:       "DomainError - Locked",
+			err:        entity.ErrUserLocked,
+			committed:  false,
+			wantStatus: http.StatusLocked,
+			wantBody:   generated.ErrorResponse{Error: entity.ErrUserLocked.Code, ErrorDescription: entity.ErrUserLocked.Message},
+		},
+		{
+			name:       "DomainError - TooManyRequests",
+			err:        entity.ErrOTPResendLimit,
+			committed:  false,
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   generated.ErrorResponse{Error: entity.ErrOTPResendLimit.Code, ErrorDescription: entity.ErrOTPResendLimit.Message},
+		},
 		{
 			name:       "Other error - InternalServerError",
 			err:        errors.New("some internal error"),
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderClientID identifies the client application sending a request
+const HeaderClientID = "X-Client-Id"
+
+// clientIDPattern is the format of client IDs. The header is not authenticated, so its value is checked
+// before it is stored with the audited operations and used to route the events of a client to its webhooks.
+var clientIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,99}$`)
+
+// RequestMetadata stores who sent the request in its context, so that the usecases can attribute
+// the operations they audit: the admin for admin endpoints, the client application otherwise,
+// along with the client ID and source IP of the request.
+// Requests with a malformed client ID are rejected with entity.ErrInvalidClientID.
+func RequestMetadata() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			actor := entity.AuditActorClient
+			if strings.HasPrefix(c.Path(), "/api/v1/admin/") {
+				actor = entity.AuditActorAdmin
+			}
+
+			req := c.Request()
+			clientID := req.Header.Get(HeaderClientID)
+			if clientID != "" && !clientIDPattern.MatchString(clientID) {
+				return entity.ErrInvalidClientID
+			}
+
+			ctx := entity.ContextWithRequestMetadata(req.Context(), entity.RequestMetadata{
+				Actor:    actor,
+				ClientID: clientID,
+				IP:       c.RealIP(),
+			})
+			c.SetRequest(req.WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
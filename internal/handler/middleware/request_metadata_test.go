@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/handler/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected entity.RequestMetadata
+	}{
+		{
+			name:     "public endpoint",
+			path:     "/api/v1/otp/validate",
+			expected: entity.RequestMetadata{Actor: entity.AuditActorClient, ClientID: "checkout-app", IP: "203.0.113.7"},
+		},
+		{
+			name:     "admin endpoint",
+			path:     "/api/v1/admin/users/:user_id/lockout",
+			expected: entity.RequestMetadata{Actor: entity.AuditActorAdmin, ClientID: "checkout-app", IP: "203.0.113.7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(middleware.HeaderClientID, "checkout-app")
+			req.Header.Set(echo.HeaderXRealIP, "203.0.113.7")
+			c := e.NewContext(req, httptest.NewRecorder())
+			c.SetPath(tt.path)
+
+			var metadata entity.RequestMetadata
+			handler := middleware.RequestMetadata()(func(c echo.Context) error {
+				metadata = entity.RequestMetadataFromContext(c.Request().Context())
+				return nil
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.expected, metadata)
+		})
+	}
+}
+
+func TestRequestMetadata_InvalidClientID(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientID      string
+		expectedError error
+	}{
+		{
+			name:          "no client ID",
+			clientID:      "",
+			expectedError: nil,
+		},
+		{
+			name:          "longest client ID",
+			clientID:      strings.Repeat("a", 100),
+			expectedError: nil,
+		},
+		{
+			name:          "too long client ID",
+			clientID:      strings.Repeat("a", 101),
+			expectedError: entity.ErrInvalidClientID,
+		},
+		{
+			name:          "client ID with unexpected characters",
+			clientID:      "checkout app<script>",
+			expectedError: entity.ErrInvalidClientID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(middleware.HeaderClientID, tt.clientID)
+			c := e.NewContext(req, httptest.NewRecorder())
+			c.SetPath("/api/v1/otp/request")
+
+			called := false
+			handler := middleware.RequestMetadata()(func(c echo.Context) error {
+				called = true
+				return nil
+			})
+
+			assert.Equal(t, tt.expectedError, handler(c))
+			assert.Equal(t, tt.expectedError == nil, called)
+		})
+	}
+}
@@ -36,31 +36,397 @@ func (m *MockOTPUsecase) EXPECT() *MockOTPUsecaseMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockOTPUsecase) Create(ctx context.Context, userID string) (*entity.OTP, error) {
+func (m *MockOTPUsecase) Create(ctx context.Context, params entity.CreateOTPParams) (*entity.OTP, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, userID)
+	ret := m.ctrl.Call(m, "Create", ctx, params)
 	ret0, _ := ret[0].(*entity.OTP)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockOTPUsecaseMockRecorder) Create(ctx, userID interface{}) *gomock.Call {
+func (mr *MockOTPUsecaseMockRecorder) Create(ctx, params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOTPUsecase)(nil).Create), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOTPUsecase)(nil).Create), ctx, params)
+}
+
+// List mocks base method.
+func (m *MockOTPUsecase) List(ctx context.Context, filter entity.OTPFilter, cursor string, limit int) (*entity.OTPPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, cursor, limit)
+	ret0, _ := ret[0].(*entity.OTPPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockOTPUsecaseMockRecorder) List(ctx, filter, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOTPUsecase)(nil).List), ctx, filter, cursor, limit)
+}
+
+// Resend mocks base method.
+func (m *MockOTPUsecase) Resend(ctx context.Context, params entity.ResendOTPParams) (*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resend", ctx, params)
+	ret0, _ := ret[0].(*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resend indicates an expected call of Resend.
+func (mr *MockOTPUsecaseMockRecorder) Resend(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resend", reflect.TypeOf((*MockOTPUsecase)(nil).Resend), ctx, params)
+}
+
+// Revoke mocks base method.
+func (m *MockOTPUsecase) Revoke(ctx context.Context, params entity.RevokeOTPParams) (*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, params)
+	ret0, _ := ret[0].(*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOTPUsecaseMockRecorder) Revoke(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOTPUsecase)(nil).Revoke), ctx, params)
+}
+
+// RevokeAllForUser mocks base method.
+func (m *MockOTPUsecase) RevokeAllForUser(ctx context.Context, userID, reason string) ([]*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllForUser", ctx, userID, reason)
+	ret0, _ := ret[0].([]*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAllForUser indicates an expected call of RevokeAllForUser.
+func (mr *MockOTPUsecaseMockRecorder) RevokeAllForUser(ctx, userID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllForUser", reflect.TypeOf((*MockOTPUsecase)(nil).RevokeAllForUser), ctx, userID, reason)
 }
 
 // Validate mocks base method.
-func (m *MockOTPUsecase) Validate(ctx context.Context, userID, otpCode string) (*entity.OTP, error) {
+func (m *MockOTPUsecase) Validate(ctx context.Context, params entity.ValidateOTPParams) (*entity.OTP, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Validate", ctx, userID, otpCode)
+	ret := m.ctrl.Call(m, "Validate", ctx, params)
 	ret0, _ := ret[0].(*entity.OTP)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Validate indicates an expected call of Validate.
-func (mr *MockOTPUsecaseMockRecorder) Validate(ctx, userID, otpCode interface{}) *gomock.Call {
+func (mr *MockOTPUsecaseMockRecorder) Validate(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockOTPUsecase)(nil).Validate), ctx, params)
+}
+
+// MockUserLockoutUsecase is a mock of UserLockoutUsecase interface.
+type MockUserLockoutUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserLockoutUsecaseMockRecorder
+}
+
+// MockUserLockoutUsecaseMockRecorder is the mock recorder for MockUserLockoutUsecase.
+type MockUserLockoutUsecaseMockRecorder struct {
+	mock *MockUserLockoutUsecase
+}
+
+// NewMockUserLockoutUsecase creates a new mock instance.
+func NewMockUserLockoutUsecase(ctrl *gomock.Controller) *MockUserLockoutUsecase {
+	mock := &MockUserLockoutUsecase{ctrl: ctrl}
+	mock.recorder = &MockUserLockoutUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserLockoutUsecase) EXPECT() *MockUserLockoutUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockUserLockoutUsecase) Clear(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockUserLockoutUsecaseMockRecorder) Clear(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockUserLockoutUsecase)(nil).Clear), ctx, userID)
+}
+
+// Get mocks base method.
+func (m *MockUserLockoutUsecase) Get(ctx context.Context, userID string) (*entity.UserLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID)
+	ret0, _ := ret[0].(*entity.UserLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockUserLockoutUsecaseMockRecorder) Get(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserLockoutUsecase)(nil).Get), ctx, userID)
+}
+
+// MockIdempotencyUsecase is a mock of IdempotencyUsecase interface.
+type MockIdempotencyUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyUsecaseMockRecorder
+}
+
+// MockIdempotencyUsecaseMockRecorder is the mock recorder for MockIdempotencyUsecase.
+type MockIdempotencyUsecaseMockRecorder struct {
+	mock *MockIdempotencyUsecase
+}
+
+// NewMockIdempotencyUsecase creates a new mock instance.
+func NewMockIdempotencyUsecase(ctrl *gomock.Controller) *MockIdempotencyUsecase {
+	mock := &MockIdempotencyUsecase{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyUsecase) EXPECT() *MockIdempotencyUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIdempotencyUsecase) Complete(ctx context.Context, key string, responseStatus int, responseBody []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, key, responseStatus, responseBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyUsecaseMockRecorder) Complete(ctx, key, responseStatus, responseBody interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyUsecase)(nil).Complete), ctx, key, responseStatus, responseBody)
+}
+
+// Release mocks base method.
+func (m *MockIdempotencyUsecase) Release(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIdempotencyUsecaseMockRecorder) Release(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotencyUsecase)(nil).Release), ctx, key)
+}
+
+// Reserve mocks base method.
+func (m *MockIdempotencyUsecase) Reserve(ctx context.Context, key, requestHash string) (*entity.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, key, requestHash)
+	ret0, _ := ret[0].(*entity.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIdempotencyUsecaseMockRecorder) Reserve(ctx, key, requestHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIdempotencyUsecase)(nil).Reserve), ctx, key, requestHash)
+}
+
+// MockWebhookUsecase is a mock of WebhookUsecase interface.
+type MockWebhookUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookUsecaseMockRecorder
+}
+
+// MockWebhookUsecaseMockRecorder is the mock recorder for MockWebhookUsecase.
+type MockWebhookUsecaseMockRecorder struct {
+	mock *MockWebhookUsecase
+}
+
+// NewMockWebhookUsecase creates a new mock instance.
+func NewMockWebhookUsecase(ctrl *gomock.Controller) *MockWebhookUsecase {
+	mock := &MockWebhookUsecase{ctrl: ctrl}
+	mock.recorder = &MockWebhookUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookUsecase) EXPECT() *MockWebhookUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateSubscription mocks base method.
+func (m *MockWebhookUsecase) CreateSubscription(ctx context.Context, params entity.CreateWebhookSubscriptionParams) (*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, params)
+	ret0, _ := ret[0].(*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockWebhookUsecaseMockRecorder) CreateSubscription(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockWebhookUsecase)(nil).CreateSubscription), ctx, params)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockWebhookUsecase) DeleteSubscription(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockWebhookUsecaseMockRecorder) DeleteSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockWebhookUsecase)(nil).DeleteSubscription), ctx, id)
+}
+
+// GetSubscription mocks base method.
+func (m *MockWebhookUsecase) GetSubscription(ctx context.Context, id uint64) (*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscription", ctx, id)
+	ret0, _ := ret[0].(*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscription indicates an expected call of GetSubscription.
+func (mr *MockWebhookUsecaseMockRecorder) GetSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscription", reflect.TypeOf((*MockWebhookUsecase)(nil).GetSubscription), ctx, id)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockWebhookUsecase) ListDeliveries(ctx context.Context, subscriptionID uint64, limit int) ([]*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, subscriptionID, limit)
+	ret0, _ := ret[0].([]*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockWebhookUsecaseMockRecorder) ListDeliveries(ctx, subscriptionID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockWebhookUsecase)(nil).ListDeliveries), ctx, subscriptionID, limit)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockWebhookUsecase) ListSubscriptions(ctx context.Context, clientID string) ([]*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx, clientID)
+	ret0, _ := ret[0].([]*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockWebhookUsecaseMockRecorder) ListSubscriptions(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockWebhookUsecase)(nil).ListSubscriptions), ctx, clientID)
+}
+
+// ReplayDelivery mocks base method.
+func (m *MockWebhookUsecase) ReplayDelivery(ctx context.Context, id uint64) (*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayDelivery", ctx, id)
+	ret0, _ := ret[0].(*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayDelivery indicates an expected call of ReplayDelivery.
+func (mr *MockWebhookUsecaseMockRecorder) ReplayDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayDelivery", reflect.TypeOf((*MockWebhookUsecase)(nil).ReplayDelivery), ctx, id)
+}
+
+// MockAuditUsecase is a mock of AuditUsecase interface.
+type MockAuditUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditUsecaseMockRecorder
+}
+
+// MockAuditUsecaseMockRecorder is the mock recorder for MockAuditUsecase.
+type MockAuditUsecaseMockRecorder struct {
+	mock *MockAuditUsecase
+}
+
+// NewMockAuditUsecase creates a new mock instance.
+func NewMockAuditUsecase(ctrl *gomock.Controller) *MockAuditUsecase {
+	mock := &MockAuditUsecase{ctrl: ctrl}
+	mock.recorder = &MockAuditUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditUsecase) EXPECT() *MockAuditUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ListEvents mocks base method.
+func (m *MockAuditUsecase) ListEvents(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", ctx, filter)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockAuditUsecaseMockRecorder) ListEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockAuditUsecase)(nil).ListEvents), ctx, filter)
+}
+
+// MockOTPTimelineUsecase is a mock of OTPTimelineUsecase interface.
+type MockOTPTimelineUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockOTPTimelineUsecaseMockRecorder
+}
+
+// MockOTPTimelineUsecaseMockRecorder is the mock recorder for MockOTPTimelineUsecase.
+type MockOTPTimelineUsecaseMockRecorder struct {
+	mock *MockOTPTimelineUsecase
+}
+
+// NewMockOTPTimelineUsecase creates a new mock instance.
+func NewMockOTPTimelineUsecase(ctrl *gomock.Controller) *MockOTPTimelineUsecase {
+	mock := &MockOTPTimelineUsecase{ctrl: ctrl}
+	mock.recorder = &MockOTPTimelineUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOTPTimelineUsecase) EXPECT() *MockOTPTimelineUsecaseMockRecorder {
+	return m.recorder
+}
+
+// GetTimeline mocks base method.
+func (m *MockOTPTimelineUsecase) GetTimeline(ctx context.Context, otpID uint64) (*entity.OTPTimeline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimeline", ctx, otpID)
+	ret0, _ := ret[0].(*entity.OTPTimeline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimeline indicates an expected call of GetTimeline.
+func (mr *MockOTPTimelineUsecaseMockRecorder) GetTimeline(ctx, otpID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockOTPUsecase)(nil).Validate), ctx, userID, otpCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimeline", reflect.TypeOf((*MockOTPTimelineUsecase)(nil).GetTimeline), ctx, otpID)
 }
@@ -1,51 +1,202 @@
 package handler
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/imansohibul/otp-service/entity"
 	"github.com/imansohibul/otp-service/generated"
+	intmiddleware "github.com/imansohibul/otp-service/internal/handler/middleware"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 )
 
+// headerIdempotentReplayed marks a response replayed from a previous request with the same Idempotency-Key
+const headerIdempotentReplayed = "Idempotent-Replayed"
+
 // Request a new OTP
 // (POST /otp/request)
-func (r *RestAPIServer) PostOtpRequest(eCtx echo.Context) error {
+func (r *RestAPIServer) PostOtpRequest(eCtx echo.Context, params generated.PostOtpRequestParams) error {
 	var (
 		ctx = eCtx.Request().Context()
 		req = new(generated.PostOtpRequestJSONRequestBody)
 	)
 
 	if err := eCtx.Bind(req); err != nil {
-		return eCtx.JSON(http.StatusBadRequest, entity.ErrInvalidRequest)
+		return entity.ErrInvalidRequest
+	}
+
+	if params.IdempotencyKey != nil {
+		return r.requestOTPIdempotently(eCtx, *params.IdempotencyKey, req)
 	}
 
-	otp, err := r.OtpUsecase.Create(ctx, req.UserId)
+	response, err := r.requestOTP(ctx, req)
 	if err != nil {
-		return eCtx.JSON(http.StatusBadRequest, err)
+		return err
 	}
 
-	return eCtx.JSON(http.StatusOK, generated.RequestOtpResponseSuccess{
-		UserId: otp.UserID,
-		Otp:    otp.OTPCode,
-	})
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// requestOTP issues an OTP and returns the payload of the response
+func (r *RestAPIServer) requestOTP(ctx context.Context, req *generated.RequestOtpBody) (*generated.RequestOtpResponseSuccess, error) {
+	params := entity.CreateOTPParams{
+		UserID: req.UserId,
+	}
+	if req.BindingId != nil {
+		params.BindingID = *req.BindingId
+	}
+	if req.Purpose != nil {
+		params.Purpose = *req.Purpose
+	}
+
+	otp, err := r.OtpUsecase.Create(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RequestOtpResponseSuccess{
+		OtpId:     int64(otp.ID),
+		UserId:    otp.UserID,
+		Otp:       &otp.OTPCode,
+		ExpiresAt: otp.ExpiresAt,
+	}, nil
+}
+
+// replayedOTPResponse is what is stored of a successful OTP request to replay it. The code is left out,
+// so that it is only stored with the OTP, and the user ID is that of the retry, which has the same body.
+type replayedOTPResponse struct {
+	OtpId     int64     `json:"otp_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// requestOTPIdempotently issues an OTP at most once per idempotency key. A retry with the same
+// key and body replays the stored response, and a reused key with a different body is rejected.
+func (r *RestAPIServer) requestOTPIdempotently(eCtx echo.Context, key string, req *generated.RequestOtpBody) error {
+	var (
+		ctx = eCtx.Request().Context()
+		// The outcome is stored even if the client goes away, since that is exactly when it retries
+		storeCtx = context.WithoutCancel(ctx)
+	)
+
+	requestHash, err := hashRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	record, err := r.IdempotencyUsecase.Reserve(ctx, key, requestHash)
+	switch {
+	case errors.Is(err, entity.ErrIdempotencyKeyReused):
+		return eCtx.JSON(http.StatusUnprocessableEntity, generated.ErrorResponse{
+			Error:            entity.ErrIdempotencyKeyReused.Code,
+			ErrorDescription: entity.ErrIdempotencyKeyReused.Message,
+		})
+	case errors.Is(err, entity.ErrIdempotencyRequestInProgress):
+		return eCtx.JSON(http.StatusConflict, generated.ErrorResponse{
+			Error:            entity.ErrIdempotencyRequestInProgress.Code,
+			ErrorDescription: entity.ErrIdempotencyRequestInProgress.Message,
+		})
+	case err != nil:
+		return err
+	}
+
+	if record != nil {
+		eCtx.Response().Header().Set(headerIdempotentReplayed, "true")
+		if record.ResponseStatus != http.StatusOK {
+			return eCtx.JSONBlob(record.ResponseStatus, record.ResponseBody)
+		}
+
+		var replayed replayedOTPResponse
+		if err := json.Unmarshal(record.ResponseBody, &replayed); err != nil {
+			return err
+		}
+		return eCtx.JSON(http.StatusOK, generated.RequestOtpResponseSuccess{
+			OtpId:     replayed.OtpId,
+			UserId:    req.UserId,
+			ExpiresAt: replayed.ExpiresAt,
+		})
+	}
+
+	var (
+		status           = http.StatusOK
+		body, stored     []byte
+		marshalErr       error
+		response, reqErr = r.requestOTP(ctx, req)
+		domainErr        *entity.DomainError
+	)
+	if reqErr == nil {
+		body, marshalErr = json.Marshal(response)
+		if marshalErr == nil {
+			stored, marshalErr = json.Marshal(replayedOTPResponse{OtpId: response.OtpId, ExpiresAt: response.ExpiresAt})
+		}
+	} else if errors.As(reqErr, &domainErr) {
+		var errResponse generated.ErrorResponse
+		status, errResponse = intmiddleware.ErrorResponse(reqErr)
+		body, marshalErr = json.Marshal(errResponse)
+		stored = body
+	}
+
+	// Unexpected errors are likely transient, so the key is released for the retry instead of storing them
+	if marshalErr != nil || (reqErr != nil && domainErr == nil) {
+		if err := r.IdempotencyUsecase.Release(storeCtx, key); err != nil {
+			log.Error().Err(err).Str("idempotency_key", key).Msg("failed to release idempotency key")
+		}
+		if marshalErr != nil {
+			return marshalErr
+		}
+		return reqErr
+	}
+
+	if err := r.IdempotencyUsecase.Complete(storeCtx, key, status, stored); err != nil {
+		log.Error().Err(err).Str("idempotency_key", key).Msg("failed to store idempotent response")
+	}
+
+	return eCtx.JSONBlob(status, body)
+}
+
+// hashRequestBody returns the hex encoded SHA-256 hash of the JSON encoded request body
+func hashRequestBody(req any) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:]), nil
 }
 
 // Validate an OTP
 // (POST /otp/validate)
 func (r *RestAPIServer) PostOtpValidate(eCtx echo.Context) error {
 	var (
-		ctx = eCtx.Request().Context()
-		req = new(generated.PostOtpValidateJSONRequestBody)
+		ctx       = eCtx.Request().Context()
+		req       = new(generated.PostOtpValidateJSONRequestBody)
+		startedAt = time.Now()
 	)
 
 	if err := eCtx.Bind(req); err != nil {
-		return eCtx.JSON(http.StatusBadRequest, entity.ErrInvalidRequest)
+		return entity.ErrInvalidRequest
 	}
 
-	otp, err := r.OtpUsecase.Validate(ctx, req.UserId, req.Otp)
+	params := entity.ValidateOTPParams{
+		UserID:  req.UserId,
+		OTPCode: req.Otp,
+	}
+	if req.SessionId != nil {
+		params.SessionID = *req.SessionId
+	}
+	if req.BindingId != nil {
+		params.BindingID = *req.BindingId
+	}
+
+	otp, err := r.OtpUsecase.Validate(ctx, params)
 	if err != nil {
-		return eCtx.JSON(http.StatusBadRequest, err)
+		return r.rejectOTPValidation(eCtx, req.UserId, startedAt, err)
 	}
 
 	return eCtx.JSON(http.StatusOK, generated.ValidateOtpResponseSuccess{
@@ -53,3 +204,115 @@ func (r *RestAPIServer) PostOtpValidate(eCtx echo.Context) error {
 		Message: "OTP Validated successfully",
 	})
 }
+
+// Resend an OTP
+// (POST /otp/{id}/resend)
+func (r *RestAPIServer) PostOtpIdResend(eCtx echo.Context, id int64) error {
+	req := new(generated.PostOtpIdResendJSONRequestBody)
+	if err := eCtx.Bind(req); err != nil {
+		return entity.ErrInvalidRequest
+	}
+
+	params := entity.ResendOTPParams{
+		OTPID:  uint64(id),
+		UserID: req.UserId,
+	}
+	if req.Channel != nil {
+		params.Channel = *req.Channel
+	}
+
+	otp, err := r.OtpUsecase.Resend(eCtx.Request().Context(), params)
+	if err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusOK, generated.ResendOtpResponseSuccess{
+		OtpId:            int64(otp.ID),
+		UserId:           otp.UserID,
+		Otp:              otp.OTPCode,
+		ExpiresAt:        otp.ExpiresAt,
+		Rotated:          otp.ID != params.OTPID,
+		ResendCount:      otp.ResendCount,
+		ResendsRemaining: otp.ResendLimit - otp.ResendCount,
+	})
+}
+
+// Revoke an OTP
+// (POST /otp/{id}/revoke)
+func (r *RestAPIServer) PostOtpIdRevoke(eCtx echo.Context, id int64) error {
+	req := new(generated.PostOtpIdRevokeJSONRequestBody)
+	if err := eCtx.Bind(req); err != nil {
+		return entity.ErrInvalidRequest
+	}
+
+	params := entity.RevokeOTPParams{
+		OTPID:  uint64(id),
+		UserID: req.UserId,
+	}
+	if req.Reason != nil {
+		params.Reason = *req.Reason
+	}
+
+	otp, err := r.OtpUsecase.Revoke(eCtx.Request().Context(), params)
+	if err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusOK, generated.RevokeOtpResponseSuccess{
+		OtpId:     int64(otp.ID),
+		UserId:    otp.UserID,
+		Status:    otp.Status.String(),
+		RevokedAt: *otp.RevokedAt,
+	})
+}
+
+// rejectOTPValidation writes the response of a failed OTP validation.
+// The detailed reason is always logged and counted. In opaque errors mode the client
+// receives entity.ErrOTPInvalid with the same status for every rejection, and the response is delayed
+// until the configured minimum response time so that found and not-found lookups take about as long.
+// Otherwise the error is left to the error handler, like on the other endpoints.
+func (r *RestAPIServer) rejectOTPValidation(eCtx echo.Context, userID string, startedAt time.Time, err error) error {
+	var (
+		ctx       = eCtx.Request().Context()
+		domainErr *entity.DomainError
+		reason    = "internal_error"
+	)
+	if errors.As(err, &domainErr) {
+		reason = domainErr.Code
+	}
+
+	log.Info().
+		Err(err).
+		Str("user_id", userID).
+		Str("reason", reason).
+		Str("request_id", eCtx.Response().Header().Get(echo.HeaderXRequestID)).
+		Msg("OTP validation rejected")
+	otpValidationFailures.WithLabelValues(reason).Inc()
+
+	// Unexpected errors are no rejections, and reveal nothing about the codes of the user
+	if !r.Config.OpaqueErrors || domainErr == nil {
+		return err
+	}
+
+	waitUntil(ctx, startedAt.Add(r.Config.OpaqueErrorsMinResponseTime))
+	return eCtx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+		Error:            entity.ErrOTPInvalid.Code,
+		ErrorDescription: entity.ErrOTPInvalid.Message,
+	})
+}
+
+// waitUntil blocks until the given time or until the context is done, whichever comes first
+func waitUntil(ctx context.Context, deadline time.Time) {
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultOTPsLimit is the number of OTPs listed when no limit is requested, see api.yml
+const defaultOTPsLimit = 50
+
+// Search and list OTPs
+// (GET /admin/otps)
+func (r *RestAPIServer) GetAdminOtps(eCtx echo.Context, params generated.GetAdminOtpsParams) error {
+	filter := entity.OTPFilter{
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+	}
+	if params.UserId != nil {
+		filter.UserID = *params.UserId
+	}
+	if params.Status != nil {
+		status, ok := entity.ParseOTPStatus(string(*params.Status))
+		if !ok {
+			return eCtx.JSON(http.StatusBadRequest, entity.ErrInvalidRequest)
+		}
+		filter.Status = status
+	}
+	if params.Purpose != nil {
+		filter.Purpose = *params.Purpose
+	}
+
+	var cursor string
+	if params.Cursor != nil {
+		cursor = *params.Cursor
+	}
+
+	limit := defaultOTPsLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	page, err := r.OtpUsecase.List(eCtx.Request().Context(), filter, cursor, limit)
+	if err != nil {
+		return err
+	}
+
+	// Only the metadata of the OTPs is listed, never their codes
+	response := generated.OtpListResponse{
+		Otps: make([]generated.OtpSummary, 0, len(page.OTPs)),
+	}
+	for _, otp := range page.OTPs {
+		response.Otps = append(response.Otps, toOtpSummaryResponse(otp))
+	}
+	if page.NextCursor != "" {
+		response.NextCursor = &page.NextCursor
+	}
+
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// Revoke all active OTPs of a user
+// (POST /admin/users/{user_id}/revoke-otps)
+func (r *RestAPIServer) PostAdminUsersUserIdRevokeOtps(eCtx echo.Context, userID string) error {
+	req := new(generated.PostAdminUsersUserIdRevokeOtpsJSONRequestBody)
+	if err := eCtx.Bind(req); err != nil {
+		return eCtx.JSON(http.StatusBadRequest, entity.ErrInvalidRequest)
+	}
+
+	var reason string
+	if req.Reason != nil {
+		reason = *req.Reason
+	}
+
+	otps, err := r.OtpUsecase.RevokeAllForUser(eCtx.Request().Context(), userID, reason)
+	if err != nil {
+		return err
+	}
+
+	response := generated.RevokeUserOtpsResponse{
+		UserId:        userID,
+		RevokedOtpIds: make([]int64, 0, len(otps)),
+	}
+	for _, otp := range otps {
+		response.RevokedOtpIds = append(response.RevokedOtpIds, int64(otp.ID))
+	}
+
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// toOtpSummaryResponse maps an OTP to its API representation, without its code
+func toOtpSummaryResponse(otp *entity.OTP) generated.OtpSummary {
+	response := generated.OtpSummary{
+		Id:          int64(otp.ID),
+		UserId:      otp.UserID,
+		Status:      otp.Status.String(),
+		Bound:       otp.IsBound(),
+		CreatedAt:   otp.CreatedAt,
+		ExpiresAt:   otp.ExpiresAt,
+		ValidatedAt: otp.ValidatedAt,
+		RevokedAt:   otp.RevokedAt,
+	}
+	if otp.Purpose != "" {
+		response.Purpose = &otp.Purpose
+	}
+	if otp.RevokeReason != "" {
+		response.RevokeReason = &otp.RevokeReason
+	}
+
+	return response
+}
@@ -0,0 +1,171 @@
+package handler_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/imansohibul/otp-service/internal/handler"
+	usecasemock "github.com/imansohibul/otp-service/internal/handler/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAdminOtps(t *testing.T) {
+	createdAt := time.Date(2025, 11, 28, 9, 0, 0, 0, time.UTC)
+	userID := "user123"
+	status := generated.GetAdminOtpsParamsStatusCreated
+	cursor := "NDQ"
+
+	tests := []struct {
+		name               string
+		params             generated.GetAdminOtpsParams
+		mockSetup          func(*testing.T, *usecasemock.MockOTPUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:   "List OTPs - Success",
+			params: generated.GetAdminOtpsParams{UserId: &userID, Status: &status, Cursor: &cursor},
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					List(gomock.Any(), entity.OTPFilter{UserID: "user123", Status: entity.OTPStatusCreated}, "NDQ", 50).
+					Return(&entity.OTPPage{
+						OTPs: []*entity.OTP{{
+							ID:          43,
+							UserID:      "user123",
+							OTPCode:     "123456",
+							Status:      entity.OTPStatusCreated,
+							CreatedAt:   createdAt,
+							ExpiresAt:   createdAt.Add(2 * time.Minute),
+							BindingHash: "binding-hash",
+							Purpose:     "login",
+						}},
+						NextCursor: "NDM",
+					}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"next_cursor":"NDM","otps":[{"bound":true,"created_at":"2025-11-28T09:00:00Z",` +
+				`"expires_at":"2025-11-28T09:02:00Z","id":43,"purpose":"login","status":"created","user_id":"user123"}]}`,
+		},
+		{
+			name:   "List OTPs - Invalid Cursor",
+			params: generated.GetAdminOtpsParams{Cursor: &cursor},
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					List(gomock.Any(), entity.OTPFilter{}, "NDQ", 50).
+					Return(nil, entity.ErrInvalidRequest)
+			},
+			expectedError:      entity.ErrInvalidRequest,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/otps", nil)
+			rec := httptest.NewRecorder()
+
+			mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+			tt.mockSetup(t, mockOTPUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:       e,
+				OtpUsecase: mockOTPUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.GetAdminOtps(c, tt.params)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPostAdminUsersUserIdRevokeOtps(t *testing.T) {
+	tests := []struct {
+		name               string
+		requestBody        string
+		mockSetup          func(*usecasemock.MockOTPUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:        "Revoke OTPs of User - Success",
+			requestBody: `{"reason":"sim_swap"}`,
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					RevokeAllForUser(gomock.Any(), "user123", "sim_swap").
+					Return([]*entity.OTP{{ID: 41}, {ID: 42}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"user_id":"user123","revoked_otp_ids":[41,42]}`,
+		},
+		{
+			name: "Revoke OTPs of User - No Body and No Active OTP",
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					RevokeAllForUser(gomock.Any(), "user123", "").
+					Return(nil, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"user_id":"user123","revoked_otp_ids":[]}`,
+		},
+		{
+			name:        "Revoke OTPs of User - Usecase Error",
+			requestBody: `{}`,
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					RevokeAllForUser(gomock.Any(), "user123", "").
+					Return(nil, errors.New("db error"))
+			},
+			expectedError:      errors.New("db error"),
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/user123/revoke-otps", bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+			tt.mockSetup(mockOTPUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:       e,
+				OtpUsecase: mockOTPUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.PostAdminUsersUserIdRevokeOtps(c, "user123")
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -2,15 +2,19 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/imansohibul/otp-service/entity"
 	"github.com/imansohibul/otp-service/generated"
 	"github.com/imansohibul/otp-service/internal/handler"
+	intmiddleware "github.com/imansohibul/otp-service/internal/handler/middleware"
 	usecasemock "github.com/imansohibul/otp-service/internal/handler/mock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -29,7 +33,7 @@ func TestPostOtpRequest(t *testing.T) {
 			requestBody: &generated.PostOtpRequestJSONRequestBody{UserId: "user123"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Create(gomock.Any(), "user123").
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user123"}).
 					Return(&entity.OTP{UserID: "user123", OTPCode: "123456"}, nil)
 			},
 			expectedStatusCode: http.StatusOK,
@@ -40,12 +44,34 @@ func TestPostOtpRequest(t *testing.T) {
 			requestBody: &generated.PostOtpRequestJSONRequestBody{UserId: "user456"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Create(gomock.Any(), "user456").
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user456"}).
 					Return(&entity.OTP{UserID: "user456", OTPCode: "654321"}, nil)
 			},
 			expectedStatusCode: http.StatusOK,
 			expectedBody:       `"otp":"654321"`,
 		},
+		{
+			name:        "Request OTP - Success with Binding",
+			requestBody: map[string]string{"user_id": "user123", "binding_id": "device-1"},
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user123", BindingID: "device-1"}).
+					Return(&entity.OTP{UserID: "user123", OTPCode: "123456"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"user_id":"user123"`,
+		},
+		{
+			name:        "Request OTP - Success with Purpose",
+			requestBody: map[string]string{"user_id": "user123", "purpose": "login"},
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user123", Purpose: "login"}).
+					Return(&entity.OTP{UserID: "user123", OTPCode: "123456", Purpose: "login"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"user_id":"user123"`,
+		},
 		{
 			name:        "Request OTP - Invalid Request Body",
 			requestBody: "invalid json",
@@ -53,7 +79,7 @@ func TestPostOtpRequest(t *testing.T) {
 				// No mock needed for invalid request
 			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       "", // Will contain entity.ErrInvalidRequest
+			expectedBody:       `"error":"invalid_request"`,
 		},
 		{
 			name:        "Request OTP - Nil Request Body",
@@ -69,11 +95,11 @@ func TestPostOtpRequest(t *testing.T) {
 			requestBody: &generated.PostOtpRequestJSONRequestBody{UserId: "user789"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Create(gomock.Any(), "user789").
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user789"}).
 					Return(nil, entity.ErrOTPDuplicate)
 			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       "",
+			expectedBody:       `"error":"duplicate_otp_code"`,
 		},
 	}
 
@@ -100,9 +126,8 @@ func TestPostOtpRequest(t *testing.T) {
 			}
 
 			c := e.NewContext(req, rec)
-			err := server.PostOtpRequest(c)
-			if err != nil {
-				t.Errorf("Error: %v", err)
+			if err := server.PostOtpRequest(c, generated.PostOtpRequestParams{}); err != nil {
+				intmiddleware.ErrorHandler(err, c)
 			}
 
 			assert.Equal(t, tt.expectedStatusCode, rec.Code)
@@ -126,7 +151,7 @@ func TestPostOtpValidate(t *testing.T) {
 			requestBody: &generated.PostOtpValidateJSONRequestBody{UserId: "user123", Otp: "123456"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Validate(gomock.Any(), "user123", "123456").
+					Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user123", OTPCode: "123456"}).
 					Return(&entity.OTP{UserID: "user123", OTPCode: "123456"}, nil)
 			},
 			expectedStatusCode: http.StatusOK,
@@ -137,12 +162,23 @@ func TestPostOtpValidate(t *testing.T) {
 			requestBody: &generated.PostOtpValidateJSONRequestBody{UserId: "user456", Otp: "654321"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Validate(gomock.Any(), "user456", "654321").
+					Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user456", OTPCode: "654321"}).
 					Return(&entity.OTP{UserID: "user456", OTPCode: "654321"}, nil)
 			},
 			expectedStatusCode: http.StatusOK,
 			expectedBody:       `"message":"OTP Validated successfully"`,
 		},
+		{
+			name:        "Validate OTP - Success with Session ID",
+			requestBody: map[string]string{"user_id": "user123", "otp": "123456", "session_id": "session-1"},
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user123", OTPCode: "123456", SessionID: "session-1"}).
+					Return(&entity.OTP{UserID: "user123", OTPCode: "123456"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"user_id":"user123"`,
+		},
 		{
 			name:        "Validate OTP - Invalid Request Body",
 			requestBody: "invalid json",
@@ -150,7 +186,7 @@ func TestPostOtpValidate(t *testing.T) {
 				// No mock needed for invalid request
 			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       "", // Will contain entity.ErrInvalidRequest
+			expectedBody:       `"error":"invalid_request"`,
 		},
 		{
 			name:        "Validate OTP - Nil Request Body",
@@ -166,33 +202,33 @@ func TestPostOtpValidate(t *testing.T) {
 			requestBody: &generated.PostOtpValidateJSONRequestBody{UserId: "user789", Otp: "789012"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Validate(gomock.Any(), "user789", "789012").
+					Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user789", OTPCode: "789012"}).
 					Return(nil, entity.ErrOTPExpired)
 			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       "",
+			expectedBody:       `"error":"otp_expired"`,
 		},
 		{
 			name:        "Validate OTP - OTP Already Used",
 			requestBody: &generated.PostOtpValidateJSONRequestBody{UserId: "user101", Otp: "101010"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Validate(gomock.Any(), "user101", "101010").
+					Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user101", OTPCode: "101010"}).
 					Return(nil, entity.ErrOTPUsed)
 			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       "",
+			expectedBody:       `"error":"otp_used"`,
 		},
 		{
 			name:        "Validate OTP - OTP Not Found",
 			requestBody: &generated.PostOtpValidateJSONRequestBody{UserId: "user202", Otp: "999999"},
 			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase) {
 				otpUsecase.EXPECT().
-					Validate(gomock.Any(), "user202", "999999").
+					Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user202", OTPCode: "999999"}).
 					Return(nil, entity.ErrOTPNotFound)
 			},
-			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       "",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       `"error":"otp_not_found"`,
 		},
 	}
 
@@ -218,16 +254,434 @@ func TestPostOtpValidate(t *testing.T) {
 				OtpUsecase: mockOTPUsecase,
 			}
 
+			c := e.NewContext(req, rec)
+			if err := server.PostOtpValidate(c); err != nil {
+				intmiddleware.ErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestPostOtpValidate_OpaqueErrors(t *testing.T) {
+	const (
+		minResponseTime = 50 * time.Millisecond
+		opaqueBody      = `{"error":"invalid_otp","error_description":"OTP is invalid, expired or has already been used"}`
+	)
+
+	tests := []struct {
+		name               string
+		usecaseErr         error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Validate OTP - OTP Not Found is hidden",
+			usecaseErr:         entity.ErrOTPNotFound,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+		{
+			name:               "Validate OTP - OTP Expired is hidden",
+			usecaseErr:         entity.ErrOTPExpired,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+		{
+			name:               "Validate OTP - OTP Already Used is hidden",
+			usecaseErr:         entity.ErrOTPUsed,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+		{
+			name:               "Validate OTP - OTP Revoked is hidden",
+			usecaseErr:         entity.ErrOTPRevoked,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+		{
+			name:               "Validate OTP - Binding Mismatch is hidden",
+			usecaseErr:         entity.ErrOTPBindingMismatch,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+		{
+			name:               "Validate OTP - User Locked is hidden",
+			usecaseErr:         entity.ErrUserLocked,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+		{
+			name:               "Validate OTP - Rate Limit is hidden",
+			usecaseErr:         entity.ErrOTPRateLimitExceeded,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       opaqueBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+
+			bodyBytes, _ := json.Marshal(&generated.PostOtpValidateJSONRequestBody{UserId: "user123", Otp: "123456"})
+			req := httptest.NewRequest(http.MethodPost, "/otp/validate", bytes.NewReader(bodyBytes))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+			mockOTPUsecase.EXPECT().
+				Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user123", OTPCode: "123456"}).
+				Return(nil, tt.usecaseErr)
+
+			server := handler.RestAPIServer{
+				Config: handler.Config{
+					OpaqueErrors:                true,
+					OpaqueErrorsMinResponseTime: minResponseTime,
+				},
+				Echo:       e,
+				OtpUsecase: mockOTPUsecase,
+			}
+
+			startedAt := time.Now()
 			c := e.NewContext(req, rec)
 			err := server.PostOtpValidate(c)
-			if err != nil {
-				t.Errorf("Error: %v", err)
+
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, time.Since(startedAt), minResponseTime)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+
+	t.Run("Validate OTP - Unexpected Error is left to the error handler", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+
+		bodyBytes, _ := json.Marshal(&generated.PostOtpValidateJSONRequestBody{UserId: "user123", Otp: "123456"})
+		req := httptest.NewRequest(http.MethodPost, "/otp/validate", bytes.NewReader(bodyBytes))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		usecaseErr := errors.New("db error")
+		mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+		mockOTPUsecase.EXPECT().
+			Validate(gomock.Any(), entity.ValidateOTPParams{UserID: "user123", OTPCode: "123456"}).
+			Return(nil, usecaseErr)
+
+		server := handler.RestAPIServer{
+			Config:     handler.Config{OpaqueErrors: true, OpaqueErrorsMinResponseTime: minResponseTime},
+			Echo:       e,
+			OtpUsecase: mockOTPUsecase,
+		}
+
+		err := server.PostOtpValidate(e.NewContext(req, rec))
+
+		assert.Equal(t, usecaseErr, err)
+	})
+}
+
+func TestPostOtpRequest_IdempotencyKey(t *testing.T) {
+	const idempotencyKey = "key-1"
+
+	expiresAt := time.Date(2025, 11, 19, 8, 32, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		mockSetup          func(*testing.T, *usecasemock.MockOTPUsecase, *usecasemock.MockIdempotencyUsecase)
+		expectedStatusCode int
+		expectedBody       string
+		expectedReplayed   bool
+	}{
+		{
+			name: "Request OTP - First Request Stores Response",
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase, idempotencyUsecase *usecasemock.MockIdempotencyUsecase) {
+				idempotencyUsecase.EXPECT().
+					Reserve(gomock.Any(), idempotencyKey, gomock.Any()).
+					Return(nil, nil)
+				otpUsecase.EXPECT().
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user123"}).
+					Return(&entity.OTP{ID: 42, UserID: "user123", OTPCode: "123456", ExpiresAt: expiresAt}, nil)
+				idempotencyUsecase.EXPECT().
+					Complete(gomock.Any(), idempotencyKey, http.StatusOK, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, key string, status int, body []byte) error {
+						assert.JSONEq(t, `{"otp_id":42,"expires_at":"2025-11-19T08:32:00Z"}`, string(body))
+						return nil
+					})
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"otp":"123456"`,
+		},
+		{
+			name: "Request OTP - Retry Replays Stored Response",
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase, idempotencyUsecase *usecasemock.MockIdempotencyUsecase) {
+				idempotencyUsecase.EXPECT().
+					Reserve(gomock.Any(), idempotencyKey, gomock.Any()).
+					Return(&entity.IdempotencyRecord{
+						Key:            idempotencyKey,
+						ResponseStatus: http.StatusOK,
+						ResponseBody:   []byte(`{"otp_id":42,"expires_at":"2025-11-19T08:32:00Z"}`),
+					}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"expires_at":"2025-11-19T08:32:00Z","otp_id":42,"user_id":"user123"}`,
+			expectedReplayed:   true,
+		},
+		{
+			name: "Request OTP - Domain Error Is Stored",
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase, idempotencyUsecase *usecasemock.MockIdempotencyUsecase) {
+				idempotencyUsecase.EXPECT().
+					Reserve(gomock.Any(), idempotencyKey, gomock.Any()).
+					Return(nil, nil)
+				otpUsecase.EXPECT().
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user123"}).
+					Return(nil, entity.ErrOTPRateLimitExceeded)
+				idempotencyUsecase.EXPECT().
+					Complete(gomock.Any(), idempotencyKey, http.StatusBadRequest, gomock.Any()).
+					Return(nil)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       `"error":"otp_rete_limit_exceeded"`,
+		},
+		{
+			name: "Request OTP - Unexpected Error Releases Key",
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase, idempotencyUsecase *usecasemock.MockIdempotencyUsecase) {
+				idempotencyUsecase.EXPECT().
+					Reserve(gomock.Any(), idempotencyKey, gomock.Any()).
+					Return(nil, nil)
+				otpUsecase.EXPECT().
+					Create(gomock.Any(), entity.CreateOTPParams{UserID: "user123"}).
+					Return(nil, errors.New("db error"))
+				idempotencyUsecase.EXPECT().
+					Release(gomock.Any(), idempotencyKey).
+					Return(nil)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       `"error":"INTERNAL_SERVER_ERROR"`,
+		},
+		{
+			name: "Request OTP - Key Reused With Different Body",
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase, idempotencyUsecase *usecasemock.MockIdempotencyUsecase) {
+				idempotencyUsecase.EXPECT().
+					Reserve(gomock.Any(), idempotencyKey, gomock.Any()).
+					Return(nil, entity.ErrIdempotencyKeyReused)
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedBody:       `"error":"idempotency_key_reused"`,
+		},
+		{
+			name: "Request OTP - Original Request In Progress",
+			mockSetup: func(t *testing.T, otpUsecase *usecasemock.MockOTPUsecase, idempotencyUsecase *usecasemock.MockIdempotencyUsecase) {
+				idempotencyUsecase.EXPECT().
+					Reserve(gomock.Any(), idempotencyKey, gomock.Any()).
+					Return(nil, entity.ErrIdempotencyRequestInProgress)
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedBody:       `"error":"idempotency_request_in_progress"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+
+			bodyBytes, _ := json.Marshal(&generated.PostOtpRequestJSONRequestBody{UserId: "user123"})
+			req := httptest.NewRequest(http.MethodPost, "/otp/request", bytes.NewReader(bodyBytes))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+			mockIdempotencyUsecase := usecasemock.NewMockIdempotencyUsecase(ctrl)
+			tt.mockSetup(t, mockOTPUsecase, mockIdempotencyUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:               e,
+				OtpUsecase:         mockOTPUsecase,
+				IdempotencyUsecase: mockIdempotencyUsecase,
+			}
+
+			key := idempotencyKey
+			c := e.NewContext(req, rec)
+			if err := server.PostOtpRequest(c, generated.PostOtpRequestParams{IdempotencyKey: &key}); err != nil {
+				intmiddleware.ErrorHandler(err, c)
 			}
 
 			assert.Equal(t, tt.expectedStatusCode, rec.Code)
 			if tt.expectedBody != "" {
 				assert.Contains(t, rec.Body.String(), tt.expectedBody)
 			}
+			if tt.expectedReplayed {
+				assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func TestPostOtpIdRevoke(t *testing.T) {
+	revokedAt := time.Date(2025, 11, 29, 9, 0, 0, 0, time.UTC)
+	reason := "transaction_cancelled"
+
+	tests := []struct {
+		name               string
+		requestBody        interface{}
+		mockSetup          func(*usecasemock.MockOTPUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:        "Revoke OTP - Success",
+			requestBody: &generated.PostOtpIdRevokeJSONRequestBody{UserId: "user123", Reason: &reason},
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Revoke(gomock.Any(), entity.RevokeOTPParams{OTPID: 42, UserID: "user123", Reason: reason}).
+					Return(&entity.OTP{ID: 42, UserID: "user123", Status: entity.OTPStatusRevoked, RevokedAt: &revokedAt}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"otp_id":42,"user_id":"user123","status":"revoked","revoked_at":"2025-11-29T09:00:00Z"}`,
+		},
+		{
+			name:               "Revoke OTP - Invalid Request Body",
+			requestBody:        "invalid json",
+			mockSetup:          func(otpUsecase *usecasemock.MockOTPUsecase) {},
+			expectedError:      entity.ErrInvalidRequest,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+		{
+			name:        "Revoke OTP - Already Used",
+			requestBody: &generated.PostOtpIdRevokeJSONRequestBody{UserId: "user123"},
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Revoke(gomock.Any(), entity.RevokeOTPParams{OTPID: 42, UserID: "user123"}).
+					Return(nil, entity.ErrOTPUsed)
+			},
+			expectedError:      entity.ErrOTPUsed,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+
+			bodyBytes, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/otp/42/revoke", bytes.NewReader(bodyBytes))
+			if tt.requestBody != "invalid json" {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			}
+			rec := httptest.NewRecorder()
+
+			mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+			tt.mockSetup(mockOTPUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:       e,
+				OtpUsecase: mockOTPUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.PostOtpIdRevoke(c, 42)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPostOtpIdResend(t *testing.T) {
+	expiresAt := time.Date(2025, 11, 30, 9, 2, 0, 0, time.UTC)
+	channel := "voice"
+
+	tests := []struct {
+		name               string
+		requestBody        interface{}
+		mockSetup          func(*usecasemock.MockOTPUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:        "Resend OTP - Same Code",
+			requestBody: &generated.PostOtpIdResendJSONRequestBody{UserId: "user123", Channel: &channel},
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Resend(gomock.Any(), entity.ResendOTPParams{OTPID: 42, UserID: "user123", Channel: "voice"}).
+					Return(&entity.OTP{ID: 42, UserID: "user123", OTPCode: "123456", ExpiresAt: expiresAt, ResendCount: 1, ResendLimit: 3}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"otp_id":42,"user_id":"user123","otp":"123456","expires_at":"2025-11-30T09:02:00Z",` +
+				`"rotated":false,"resend_count":1,"resends_remaining":2}`,
+		},
+		{
+			name:        "Resend OTP - Rotated Code",
+			requestBody: &generated.PostOtpIdResendJSONRequestBody{UserId: "user123"},
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Resend(gomock.Any(), entity.ResendOTPParams{OTPID: 42, UserID: "user123"}).
+					Return(&entity.OTP{ID: 43, UserID: "user123", OTPCode: "654321", ExpiresAt: expiresAt, ResendCount: 3, ResendLimit: 3}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"otp_id":43,"user_id":"user123","otp":"654321","expires_at":"2025-11-30T09:02:00Z",` +
+				`"rotated":true,"resend_count":3,"resends_remaining":0}`,
+		},
+		{
+			name:        "Resend OTP - Limit Exceeded",
+			requestBody: &generated.PostOtpIdResendJSONRequestBody{UserId: "user123"},
+			mockSetup: func(otpUsecase *usecasemock.MockOTPUsecase) {
+				otpUsecase.EXPECT().
+					Resend(gomock.Any(), entity.ResendOTPParams{OTPID: 42, UserID: "user123"}).
+					Return(nil, entity.ErrOTPResendLimit)
+			},
+			expectedError:      entity.ErrOTPResendLimit,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+
+			bodyBytes, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/otp/42/resend", bytes.NewReader(bodyBytes))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			mockOTPUsecase := usecasemock.NewMockOTPUsecase(ctrl)
+			tt.mockSetup(mockOTPUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:       e,
+				OtpUsecase: mockOTPUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.PostOtpIdResend(c, 42)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
 		})
 	}
 }
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/labstack/echo/v4"
+)
+
+// Get the lifecycle timeline of an OTP
+// (GET /admin/otps/{id}/timeline)
+func (r *RestAPIServer) GetAdminOtpsIdTimeline(eCtx echo.Context, id int64) error {
+	timeline, err := r.OTPTimelineUsecase.GetTimeline(eCtx.Request().Context(), uint64(id))
+	if err != nil {
+		return err
+	}
+
+	response := generated.OtpTimelineResponse{
+		OtpId:       int64(timeline.OTP.ID),
+		UserId:      timeline.OTP.UserID,
+		Status:      timeline.OTP.Status.String(),
+		CreatedAt:   timeline.OTP.CreatedAt,
+		ExpiresAt:   timeline.OTP.ExpiresAt,
+		ValidatedAt: timeline.OTP.ValidatedAt,
+		Events:      make([]generated.OtpTimelineEvent, 0, len(timeline.Events)),
+	}
+	for _, event := range timeline.Events {
+		response.Events = append(response.Events, toOtpTimelineEventResponse(event))
+	}
+
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// toOtpTimelineEventResponse maps an entry of the timeline of an OTP to its API representation,
+// leaving out the fields that do not apply to its type
+func toOtpTimelineEventResponse(event entity.OTPTimelineEvent) generated.OtpTimelineEvent {
+	response := generated.OtpTimelineEvent{
+		Type:       generated.OtpTimelineEventType(event.Type),
+		OccurredAt: event.OccurredAt,
+	}
+	if event.Result != "" {
+		response.Result = &event.Result
+	}
+	if event.Reason != "" {
+		response.Reason = &event.Reason
+	}
+	if event.Actor != "" {
+		response.Actor = &event.Actor
+	}
+	if event.ClientID != "" {
+		response.ClientId = &event.ClientID
+	}
+	if event.IP != "" {
+		response.Ip = &event.IP
+	}
+	if event.Type == entity.OTPTimelineDelivery {
+		response.Attempts = &event.Attempts
+	}
+	if event.ResponseCode != 0 {
+		response.ResponseCode = &event.ResponseCode
+	}
+	if event.WebhookSubscriptionID != 0 {
+		subscriptionID := int64(event.WebhookSubscriptionID)
+		response.WebhookSubscriptionId = &subscriptionID
+	}
+	if event.SupersededByOTPID != 0 {
+		otpID := int64(event.SupersededByOTPID)
+		response.SupersededByOtpId = &otpID
+	}
+
+	return response
+}
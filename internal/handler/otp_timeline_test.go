@@ -0,0 +1,96 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/handler"
+	usecasemock "github.com/imansohibul/otp-service/internal/handler/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAdminOtpsIdTimeline(t *testing.T) {
+	issuedAt := time.Date(2025, 11, 26, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		mockSetup          func(*testing.T, *usecasemock.MockOTPTimelineUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Get OTP Timeline - Success",
+			mockSetup: func(t *testing.T, otpTimelineUsecase *usecasemock.MockOTPTimelineUsecase) {
+				otpTimelineUsecase.EXPECT().
+					GetTimeline(gomock.Any(), uint64(42)).
+					Return(&entity.OTPTimeline{
+						OTP: &entity.OTP{
+							ID:        42,
+							UserID:    "user123",
+							OTPCode:   "123456",
+							Status:    entity.OTPStatusExpired,
+							CreatedAt: issuedAt,
+							ExpiresAt: issuedAt.Add(2 * time.Minute),
+						},
+						Events: []entity.OTPTimelineEvent{
+							{Type: entity.OTPTimelineIssued, OccurredAt: issuedAt, Result: "success", IP: "203.0.113.7"},
+							{Type: entity.OTPTimelineDelivery, OccurredAt: issuedAt, Result: "delivered"},
+							{Type: entity.OTPTimelineValidation, OccurredAt: issuedAt.Add(time.Minute), Result: "failure", Reason: "otp_binding_mismatch", IP: "198.51.100.2"},
+							{Type: entity.OTPTimelineExpired, OccurredAt: issuedAt.Add(2 * time.Minute)},
+						},
+					}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"created_at":"2025-11-26T09:00:00Z","events":[` +
+				`{"ip":"203.0.113.7","occurred_at":"2025-11-26T09:00:00Z","result":"success","type":"issued"},` +
+				`{"attempts":0,"occurred_at":"2025-11-26T09:00:00Z","result":"delivered","type":"delivery"},` +
+				`{"ip":"198.51.100.2","occurred_at":"2025-11-26T09:01:00Z","reason":"otp_binding_mismatch","result":"failure","type":"validation"},` +
+				`{"occurred_at":"2025-11-26T09:02:00Z","type":"expired"}],` +
+				`"expires_at":"2025-11-26T09:02:00Z","otp_id":42,"status":"expired","user_id":"user123"}`,
+		},
+		{
+			name: "Get OTP Timeline - Not Found",
+			mockSetup: func(t *testing.T, otpTimelineUsecase *usecasemock.MockOTPTimelineUsecase) {
+				otpTimelineUsecase.EXPECT().
+					GetTimeline(gomock.Any(), uint64(42)).
+					Return(nil, entity.ErrOTPNotFound)
+			},
+			expectedError:      entity.ErrOTPNotFound,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/otps/42/timeline", nil)
+			rec := httptest.NewRecorder()
+
+			mockOTPTimelineUsecase := usecasemock.NewMockOTPTimelineUsecase(ctrl)
+			tt.mockSetup(t, mockOTPTimelineUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:               e,
+				OTPTimelineUsecase: mockOTPTimelineUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.GetAdminOtpsIdTimeline(c, 42)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
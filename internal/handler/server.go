@@ -2,8 +2,11 @@ package handler
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/imansohibul/otp-service/generated"
 	intmiddleware "github.com/imansohibul/otp-service/internal/handler/middleware"
 	"github.com/labstack/echo-contrib/echoprometheus"
@@ -13,19 +16,55 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// adminAPIKeySecurityScheme is the name of the security scheme protecting admin endpoints, see api.yml
+const adminAPIKeySecurityScheme = "AdminApiKey"
+
+// Config holds the settings of the REST API server
+type Config struct {
+	// AdminAPIKey is the key admin endpoints expect in the X-Admin-Api-Key header.
+	// Admin endpoints reject every request when it is empty.
+	AdminAPIKey string
+
+	// OpaqueErrors hides why an OTP validation was rejected from the client,
+	// so that it cannot learn which codes once existed for a user.
+	OpaqueErrors bool
+	// OpaqueErrorsMinResponseTime is the minimum duration of a rejected OTP validation in opaque errors mode.
+	OpaqueErrorsMinResponseTime time.Duration
+}
+
 // RestServer encapsulates the Echo instance and usecases
 type RestAPIServer struct {
-	Echo       *echo.Echo
-	OtpUsecase OTPUsecase
+	Config             Config
+	Echo               *echo.Echo
+	OtpUsecase         OTPUsecase
+	UserLockoutUsecase UserLockoutUsecase
+	IdempotencyUsecase IdempotencyUsecase
+	WebhookUsecase     WebhookUsecase
+	AuditUsecase       AuditUsecase
+	OTPTimelineUsecase OTPTimelineUsecase
 }
 
 // NewRestAPIServer constructs the server with injected usecases
-func NewRestAPIServer(otpUsecase OTPUsecase) *RestAPIServer {
+func NewRestAPIServer(
+	cfg Config,
+	otpUsecase OTPUsecase,
+	userLockoutUsecase UserLockoutUsecase,
+	idempotencyUsecase IdempotencyUsecase,
+	webhookUsecase WebhookUsecase,
+	auditUsecase AuditUsecase,
+	otpTimelineUsecase OTPTimelineUsecase,
+) *RestAPIServer {
 	var (
 		e      = echo.New()
 		server = &RestAPIServer{
-			Echo:       e,
-			OtpUsecase: otpUsecase,
+			Config:             cfg,
+			Echo:               e,
+			OtpUsecase:         otpUsecase,
+			UserLockoutUsecase: userLockoutUsecase,
+			IdempotencyUsecase: idempotencyUsecase,
+			WebhookUsecase:     webhookUsecase,
+			AuditUsecase:       auditUsecase,
+			OTPTimelineUsecase: otpTimelineUsecase,
 		}
 	)
 
@@ -34,6 +73,7 @@ func NewRestAPIServer(otpUsecase OTPUsecase) *RestAPIServer {
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(middleware.RequestID())
+	e.Use(intmiddleware.RequestMetadata())             // attributes audited operations to the request
 	e.Use(echoprometheus.NewMiddleware("otp-service")) // adds middleware to gather metrics
 
 	spec, err := generated.GetSwagger()
@@ -41,7 +81,12 @@ func NewRestAPIServer(otpUsecase OTPUsecase) *RestAPIServer {
 		log.Fatal().Err(err).Msg("REST API server stopped with error")
 	}
 
-	e.Use(oapimiddleware.OapiRequestValidatorWithOptions(spec, &oapimiddleware.Options{ErrorHandler: validationErrorHandler}))
+	e.Use(oapimiddleware.OapiRequestValidatorWithOptions(spec, &oapimiddleware.Options{
+		ErrorHandler: validationErrorHandler,
+		Options: openapi3filter.Options{
+			AuthenticationFunc: adminAPIKeyAuthenticator(cfg.AdminAPIKey),
+		},
+	}))
 
 	e.GET("/metrics", echoprometheus.NewHandler()) // adds route to serve gathered metrics
 	e.HTTPErrorHandler = intmiddleware.ErrorHandler
@@ -63,7 +108,25 @@ func (s *RestAPIServer) Shutdown(ctx context.Context) error {
 	return s.Echo.Shutdown(ctx)
 }
 
-// validationErrorHandler handles OpenAPI validation errors and returns 400 Bad Request
+// adminAPIKeyAuthenticator verifies the admin API key of requests to endpoints
+// protected by the AdminApiKey security scheme
+func adminAPIKeyAuthenticator(adminAPIKey string) openapi3filter.AuthenticationFunc {
+	return func(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
+		if input.SecuritySchemeName != adminAPIKeySecurityScheme {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Unsupported security scheme")
+		}
+
+		apiKey := input.RequestValidationInput.Request.Header.Get(input.SecurityScheme.Name)
+		if adminAPIKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(adminAPIKey)) != 1 {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Missing or invalid admin API key")
+		}
+
+		return nil
+	}
+}
+
+// validationErrorHandler handles OpenAPI validation errors and returns 400 Bad Request,
+// or 401 Unauthorized when the request failed authentication
 func validationErrorHandler(c echo.Context, err *echo.HTTPError) error {
 	// Log the validation error
 	log.Warn().
@@ -72,6 +135,13 @@ func validationErrorHandler(c echo.Context, err *echo.HTTPError) error {
 		Str("method", c.Request().Method).
 		Msg("Request validation failed")
 
+	if err.Code == http.StatusUnauthorized {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"error":             "unauthorized",
+			"error_description": err.Message,
+		})
+	}
+
 	// Return 400 Bad Request with error details
 	return c.JSON(http.StatusBadRequest, map[string]interface{}{
 		"error":             "bad_request",
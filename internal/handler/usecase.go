@@ -9,14 +9,107 @@ import (
 //go:generate mockgen -destination=mock/usecase.go -package=mock -source=usecase.go
 
 // OTPUsecase defines the business logic interface for OTP (One-Time Password) operations.
-// It handles the creation and validation of OTPs.
+// It handles the creation, validation, resending and revocation of OTPs, and their listing for admins.
 type OTPUsecase interface {
 	// Create generates a new OTP for the specified user and stores it in the system.
 	// The OTP will have an expiration time and can only be used once.
-	Create(ctx context.Context, userID string) (*entity.OTP, error)
+	// When a binding is supplied, only validations carrying the same binding are accepted.
+	Create(ctx context.Context, params entity.CreateOTPParams) (*entity.OTP, error)
 
 	// Validate verifies that the provided OTP code is valid for the specified user.
 	// This checks if the code matches, hasn't expired, and hasn't been used before.
 	// Upon successful validation, the OTP should be marked as validated.
-	Validate(ctx context.Context, userID string, otpCode string) (*entity.OTP, error)
+	// An identical retry from the session that validated the OTP within the grace period
+	// gets the original success. A bound OTP is rejected with entity.ErrOTPBindingMismatch
+	// unless the attempt carries the binding it was requested with.
+	Validate(ctx context.Context, params entity.ValidateOTPParams) (*entity.OTP, error)
+
+	// List retrieves a page of up to limit OTPs matching the filter, newest first, starting after
+	// the given cursor or from the most recent OTP if the cursor is empty.
+	// Returns entity.ErrInvalidRequest if the cursor is malformed or the created-at range is empty.
+	List(ctx context.Context, filter entity.OTPFilter, cursor string, limit int) (*entity.OTPPage, error)
+
+	// Resend delivers an OTP of a user again, and returns the OTP that was resent. Depending on the resend
+	// policy it is the same OTP, or a new OTP with a new code superseding it.
+	// Returns entity.ErrOTPNotFound if the OTP does not exist or was issued to another user,
+	// entity.ErrOTPUsed, entity.ErrOTPExpired or entity.ErrOTPRevoked if it is no longer active,
+	// and entity.ErrOTPResendLimit if it has no resends left.
+	Resend(ctx context.Context, params entity.ResendOTPParams) (*entity.OTP, error)
+
+	// Revoke cancels an OTP of a user before it is used, so that it can no longer be validated.
+	// Returns entity.ErrOTPNotFound if the OTP does not exist or was issued to another user, and
+	// entity.ErrOTPUsed, entity.ErrOTPExpired or entity.ErrOTPRevoked if it is no longer active.
+	Revoke(ctx context.Context, params entity.RevokeOTPParams) (*entity.OTP, error)
+
+	// RevokeAllForUser cancels every OTP of a user that can still be validated, and returns the OTPs it revoked.
+	RevokeAllForUser(ctx context.Context, userID string, reason string) ([]*entity.OTP, error)
+}
+
+// UserLockoutUsecase defines the business logic interface for inspecting and clearing
+// the account-level lockout applied after repeated OTP validation failures.
+type UserLockoutUsecase interface {
+	// Get returns the lockout ledger of the specified user.
+	// A user without recorded failures gets an empty, unlocked ledger.
+	Get(ctx context.Context, userID string) (*entity.UserLockout, error)
+
+	// Clear removes any lockout and resets the failure ledger of the specified user.
+	Clear(ctx context.Context, userID string) error
+}
+
+// IdempotencyUsecase defines the business logic interface for requests made with an Idempotency-Key,
+// which are processed at most once and whose responses are replayed to retries.
+type IdempotencyUsecase interface {
+	// Reserve claims an idempotency key for a request with the given hash.
+	// It returns a nil record when the key has been claimed and the request should be processed,
+	// or the stored record when an identical request has already completed and its response should be replayed.
+	// Returns entity.ErrIdempotencyKeyReused if the key was used with a different request, and
+	// entity.ErrIdempotencyRequestInProgress if the original request has not completed yet.
+	Reserve(ctx context.Context, key string, requestHash string) (*entity.IdempotencyRecord, error)
+
+	// Complete stores the response of the request that reserved the key, so it can be replayed.
+	Complete(ctx context.Context, key string, responseStatus int, responseBody []byte) error
+
+	// Release gives up a reserved key without storing a response, so that a retry is processed again.
+	Release(ctx context.Context, key string) error
+}
+
+// WebhookUsecase defines the business logic interface for managing the webhook subscriptions
+// of client applications and inspecting and replaying their deliveries.
+type WebhookUsecase interface {
+	// CreateSubscription subscribes an endpoint to the given event types and generates the secret signing its deliveries.
+	// Returns entity.ErrInvalidRequest if the URL is not an absolute http(s) URL or an event type is unknown.
+	CreateSubscription(ctx context.Context, params entity.CreateWebhookSubscriptionParams) (*entity.WebhookSubscription, error)
+
+	// GetSubscription retrieves a webhook subscription.
+	// Returns entity.ErrWebhookSubscriptionNotFound if the subscription does not exist.
+	GetSubscription(ctx context.Context, id uint64) (*entity.WebhookSubscription, error)
+
+	// ListSubscriptions retrieves the webhook subscriptions of a client application, or of all clients if clientID is empty.
+	ListSubscriptions(ctx context.Context, clientID string) ([]*entity.WebhookSubscription, error)
+
+	// DeleteSubscription removes a webhook subscription together with its deliveries.
+	// Returns entity.ErrWebhookSubscriptionNotFound if the subscription does not exist.
+	DeleteSubscription(ctx context.Context, id uint64) error
+
+	// ListDeliveries retrieves up to limit of the most recent deliveries of a subscription, newest first.
+	// Returns entity.ErrWebhookSubscriptionNotFound if the subscription does not exist.
+	ListDeliveries(ctx context.Context, subscriptionID uint64, limit int) ([]*entity.WebhookDelivery, error)
+
+	// ReplayDelivery schedules a delivery to be attempted again by the next dispatch.
+	// Returns entity.ErrWebhookDeliveryNotFound if the delivery does not exist.
+	ReplayDelivery(ctx context.Context, id uint64) (*entity.WebhookDelivery, error)
+}
+
+// AuditUsecase defines the business logic interface for querying the tamper-evident audit log.
+type AuditUsecase interface {
+	// ListEvents retrieves the audit events of a user within the time range of the filter, oldest first.
+	// Returns entity.ErrInvalidRequest if no user is given or the range is empty.
+	ListEvents(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error)
+}
+
+// OTPTimelineUsecase defines the business logic interface for the lifecycle timeline of an OTP.
+type OTPTimelineUsecase interface {
+	// GetTimeline builds the ordered history of an OTP from its persisted records.
+	// Returns entity.ErrOTPNotFound if no OTP exists with the ID.
+	GetTimeline(ctx context.Context, otpID uint64) (*entity.OTPTimeline, error)
 }
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/labstack/echo/v4"
+)
+
+// Clear the validation lockout of a user
+// (DELETE /admin/users/{user_id}/lockout)
+func (r *RestAPIServer) DeleteAdminUsersUserIdLockout(eCtx echo.Context, userID string) error {
+	if err := r.UserLockoutUsecase.Clear(eCtx.Request().Context(), userID); err != nil {
+		return err
+	}
+
+	return eCtx.NoContent(http.StatusNoContent)
+}
+
+// Inspect the validation lockout of a user
+// (GET /admin/users/{user_id}/lockout)
+func (r *RestAPIServer) GetAdminUsersUserIdLockout(eCtx echo.Context, userID string) error {
+	lockout, err := r.UserLockoutUsecase.Get(eCtx.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusOK, generated.UserLockoutResponse{
+		UserId:         lockout.UserID,
+		FailedAttempts: lockout.FailedAttempts,
+		LastFailedAt:   lockout.LastFailedAt,
+		Locked:         lockout.IsLocked(time.Now()),
+		LockedUntil:    lockout.LockedUntil,
+	})
+}
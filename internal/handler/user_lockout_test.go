@@ -0,0 +1,141 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/handler"
+	usecasemock "github.com/imansohibul/otp-service/internal/handler/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAdminUsersUserIdLockout(t *testing.T) {
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name               string
+		mockSetup          func(*testing.T, *usecasemock.MockUserLockoutUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Get Lockout - Locked User",
+			mockSetup: func(t *testing.T, userLockoutUsecase *usecasemock.MockUserLockoutUsecase) {
+				userLockoutUsecase.EXPECT().
+					Get(gomock.Any(), "user123").
+					Return(&entity.UserLockout{UserID: "user123", LockedUntil: &lockedUntil}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"locked":true`,
+		},
+		{
+			name: "Get Lockout - Unlocked User",
+			mockSetup: func(t *testing.T, userLockoutUsecase *usecasemock.MockUserLockoutUsecase) {
+				userLockoutUsecase.EXPECT().
+					Get(gomock.Any(), "user123").
+					Return(&entity.UserLockout{UserID: "user123", FailedAttempts: 2}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"failed_attempts":2,"locked":false`,
+		},
+		{
+			name: "Get Lockout - Usecase Error",
+			mockSetup: func(t *testing.T, userLockoutUsecase *usecasemock.MockUserLockoutUsecase) {
+				userLockoutUsecase.EXPECT().
+					Get(gomock.Any(), "user123").
+					Return(nil, errors.New("db error"))
+			},
+			expectedError:      errors.New("db error"),
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/users/user123/lockout", nil)
+			rec := httptest.NewRecorder()
+
+			mockUserLockoutUsecase := usecasemock.NewMockUserLockoutUsecase(ctrl)
+			tt.mockSetup(t, mockUserLockoutUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:               e,
+				UserLockoutUsecase: mockUserLockoutUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.GetAdminUsersUserIdLockout(c, "user123")
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestDeleteAdminUsersUserIdLockout(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockSetup          func(*testing.T, *usecasemock.MockUserLockoutUsecase)
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name: "Clear Lockout - Success",
+			mockSetup: func(t *testing.T, userLockoutUsecase *usecasemock.MockUserLockoutUsecase) {
+				userLockoutUsecase.EXPECT().
+					Clear(gomock.Any(), "user123").
+					Return(nil)
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name: "Clear Lockout - Usecase Error",
+			mockSetup: func(t *testing.T, userLockoutUsecase *usecasemock.MockUserLockoutUsecase) {
+				userLockoutUsecase.EXPECT().
+					Clear(gomock.Any(), "user123").
+					Return(errors.New("db error"))
+			},
+			expectedError:      errors.New("db error"),
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/admin/users/user123/lockout", nil)
+			rec := httptest.NewRecorder()
+
+			mockUserLockoutUsecase := usecasemock.NewMockUserLockoutUsecase(ctrl)
+			tt.mockSetup(t, mockUserLockoutUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:               e,
+				UserLockoutUsecase: mockUserLockoutUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.DeleteAdminUsersUserIdLockout(c, "user123")
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultWebhookDeliveriesLimit is the number of deliveries listed when no limit is requested, see api.yml
+const defaultWebhookDeliveriesLimit = 50
+
+// List webhook subscriptions
+// (GET /admin/webhooks)
+func (r *RestAPIServer) GetAdminWebhooks(eCtx echo.Context, params generated.GetAdminWebhooksParams) error {
+	var clientID string
+	if params.ClientId != nil {
+		clientID = *params.ClientId
+	}
+
+	subscriptions, err := r.WebhookUsecase.ListSubscriptions(eCtx.Request().Context(), clientID)
+	if err != nil {
+		return err
+	}
+
+	response := generated.WebhookSubscriptionListResponse{
+		Subscriptions: make([]generated.WebhookSubscription, 0, len(subscriptions)),
+	}
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, toWebhookSubscriptionResponse(subscription))
+	}
+
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// Subscribe an endpoint of a client application to OTP lifecycle events
+// (POST /admin/webhooks)
+func (r *RestAPIServer) PostAdminWebhooks(eCtx echo.Context) error {
+	req := new(generated.PostAdminWebhooksJSONRequestBody)
+	if err := eCtx.Bind(req); err != nil {
+		return eCtx.JSON(http.StatusBadRequest, entity.ErrInvalidRequest)
+	}
+
+	params := entity.CreateWebhookSubscriptionParams{
+		ClientID: req.ClientId,
+		URL:      req.Url,
+	}
+	for _, eventType := range req.EventTypes {
+		params.EventTypes = append(params.EventTypes, entity.EventType(eventType))
+	}
+
+	subscription, err := r.WebhookUsecase.CreateSubscription(eCtx.Request().Context(), params)
+	if err != nil {
+		return err
+	}
+
+	// The secret is only disclosed once, to the admin creating the subscription
+	response := toWebhookSubscriptionResponse(subscription)
+	response.Secret = &subscription.Secret
+
+	return eCtx.JSON(http.StatusCreated, response)
+}
+
+// Replay a webhook delivery
+// (POST /admin/webhooks/deliveries/{delivery_id}/replay)
+func (r *RestAPIServer) PostAdminWebhooksDeliveriesDeliveryIdReplay(eCtx echo.Context, deliveryID int64) error {
+	delivery, err := r.WebhookUsecase.ReplayDelivery(eCtx.Request().Context(), uint64(deliveryID))
+	if err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusAccepted, toWebhookDeliveryResponse(delivery))
+}
+
+// Delete a webhook subscription and its deliveries
+// (DELETE /admin/webhooks/{subscription_id})
+func (r *RestAPIServer) DeleteAdminWebhooksSubscriptionId(eCtx echo.Context, subscriptionID int64) error {
+	if err := r.WebhookUsecase.DeleteSubscription(eCtx.Request().Context(), uint64(subscriptionID)); err != nil {
+		return err
+	}
+
+	return eCtx.NoContent(http.StatusNoContent)
+}
+
+// Get a webhook subscription
+// (GET /admin/webhooks/{subscription_id})
+func (r *RestAPIServer) GetAdminWebhooksSubscriptionId(eCtx echo.Context, subscriptionID int64) error {
+	subscription, err := r.WebhookUsecase.GetSubscription(eCtx.Request().Context(), uint64(subscriptionID))
+	if err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusOK, toWebhookSubscriptionResponse(subscription))
+}
+
+// List the most recent deliveries of a webhook subscription
+// (GET /admin/webhooks/{subscription_id}/deliveries)
+func (r *RestAPIServer) GetAdminWebhooksSubscriptionIdDeliveries(eCtx echo.Context, subscriptionID int64, params generated.GetAdminWebhooksSubscriptionIdDeliveriesParams) error {
+	limit := defaultWebhookDeliveriesLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	deliveries, err := r.WebhookUsecase.ListDeliveries(eCtx.Request().Context(), uint64(subscriptionID), limit)
+	if err != nil {
+		return err
+	}
+
+	response := generated.WebhookDeliveryListResponse{
+		Deliveries: make([]generated.WebhookDelivery, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, toWebhookDeliveryResponse(delivery))
+	}
+
+	return eCtx.JSON(http.StatusOK, response)
+}
+
+// toWebhookSubscriptionResponse maps a webhook subscription to its API representation, without its secret
+func toWebhookSubscriptionResponse(subscription *entity.WebhookSubscription) generated.WebhookSubscription {
+	eventTypes := make([]generated.WebhookEventType, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		eventTypes = append(eventTypes, generated.WebhookEventType(eventType))
+	}
+
+	return generated.WebhookSubscription{
+		Id:         int64(subscription.ID),
+		ClientId:   subscription.ClientID,
+		Url:        subscription.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+// toWebhookDeliveryResponse maps a webhook delivery to its API representation
+func toWebhookDeliveryResponse(delivery *entity.WebhookDelivery) generated.WebhookDelivery {
+	response := generated.WebhookDelivery{
+		Id:             int64(delivery.ID),
+		SubscriptionId: int64(delivery.SubscriptionID),
+		EventId:        int64(delivery.EventID),
+		EventType:      generated.WebhookEventType(delivery.EventType),
+		Status:         generated.WebhookDeliveryStatus(delivery.Status.String()),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	if delivery.ResponseCode != 0 {
+		response.ResponseCode = &delivery.ResponseCode
+	}
+	if delivery.LastError != "" {
+		response.LastError = &delivery.LastError
+	}
+
+	return response
+}
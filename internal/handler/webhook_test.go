@@ -0,0 +1,236 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/generated"
+	"github.com/imansohibul/otp-service/internal/handler"
+	usecasemock "github.com/imansohibul/otp-service/internal/handler/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostAdminWebhooks(t *testing.T) {
+	subscription := &entity.WebhookSubscription{
+		ID:         3,
+		ClientID:   "checkout-app",
+		URL:        "https://checkout.example.com/hooks/otp",
+		EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+		Secret:     "secret",
+		CreatedAt:  time.Now(),
+	}
+
+	tests := []struct {
+		name               string
+		requestBody        string
+		mockSetup          func(*testing.T, *usecasemock.MockWebhookUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:        "Create Subscription - Success",
+			requestBody: `{"client_id":"checkout-app","url":"https://checkout.example.com/hooks/otp","event_types":["otp.validated"]}`,
+			mockSetup: func(t *testing.T, webhookUsecase *usecasemock.MockWebhookUsecase) {
+				webhookUsecase.EXPECT().
+					CreateSubscription(gomock.Any(), entity.CreateWebhookSubscriptionParams{
+						ClientID:   "checkout-app",
+						URL:        "https://checkout.example.com/hooks/otp",
+						EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+					}).
+					Return(subscription, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       `"secret":"secret"`,
+		},
+		{
+			name:        "Create Subscription - Invalid Request",
+			requestBody: `{"client_id":"checkout-app","url":"ftp://checkout.example.com","event_types":["otp.validated"]}`,
+			mockSetup: func(t *testing.T, webhookUsecase *usecasemock.MockWebhookUsecase) {
+				webhookUsecase.EXPECT().
+					CreateSubscription(gomock.Any(), gomock.Any()).
+					Return(nil, entity.ErrInvalidRequest)
+			},
+			expectedError:      entity.ErrInvalidRequest,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(tt.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			mockWebhookUsecase := usecasemock.NewMockWebhookUsecase(ctrl)
+			tt.mockSetup(t, mockWebhookUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:           e,
+				WebhookUsecase: mockWebhookUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.PostAdminWebhooks(c)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestGetAdminWebhooksSubscriptionId(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockSetup          func(*testing.T, *usecasemock.MockWebhookUsecase)
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name: "Get Subscription - Success",
+			mockSetup: func(t *testing.T, webhookUsecase *usecasemock.MockWebhookUsecase) {
+				webhookUsecase.EXPECT().
+					GetSubscription(gomock.Any(), uint64(3)).
+					Return(&entity.WebhookSubscription{ID: 3, ClientID: "checkout-app", Secret: "secret"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Get Subscription - Not Found",
+			mockSetup: func(t *testing.T, webhookUsecase *usecasemock.MockWebhookUsecase) {
+				webhookUsecase.EXPECT().
+					GetSubscription(gomock.Any(), uint64(3)).
+					Return(nil, entity.ErrWebhookSubscriptionNotFound)
+			},
+			expectedError:      entity.ErrWebhookSubscriptionNotFound,
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/webhooks/3", nil)
+			rec := httptest.NewRecorder()
+
+			mockWebhookUsecase := usecasemock.NewMockWebhookUsecase(ctrl)
+			tt.mockSetup(t, mockWebhookUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:           e,
+				WebhookUsecase: mockWebhookUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.GetAdminWebhooksSubscriptionId(c, 3)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "secret")
+		})
+	}
+}
+
+func TestGetAdminWebhooksSubscriptionIdDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/admin/webhooks/3/deliveries", nil)
+	rec := httptest.NewRecorder()
+
+	mockWebhookUsecase := usecasemock.NewMockWebhookUsecase(ctrl)
+	mockWebhookUsecase.EXPECT().
+		ListDeliveries(gomock.Any(), uint64(3), 50).
+		Return([]*entity.WebhookDelivery{
+			{ID: 11, SubscriptionID: 3, Status: entity.OutboxStatusFailed, Attempts: 10, ResponseCode: 503},
+		}, nil)
+
+	server := handler.RestAPIServer{
+		Echo:           e,
+		WebhookUsecase: mockWebhookUsecase,
+	}
+
+	c := e.NewContext(req, rec)
+	err := server.GetAdminWebhooksSubscriptionIdDeliveries(c, 3, generated.GetAdminWebhooksSubscriptionIdDeliveriesParams{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"response_code":503,"status":"failed"`)
+}
+
+func TestPostAdminWebhooksDeliveriesDeliveryIdReplay(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockSetup          func(*testing.T, *usecasemock.MockWebhookUsecase)
+		expectedError      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Replay Delivery - Success",
+			mockSetup: func(t *testing.T, webhookUsecase *usecasemock.MockWebhookUsecase) {
+				webhookUsecase.EXPECT().
+					ReplayDelivery(gomock.Any(), uint64(11)).
+					Return(&entity.WebhookDelivery{ID: 11, Status: entity.OutboxStatusPending}, nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedBody:       `"status":"pending"`,
+		},
+		{
+			name: "Replay Delivery - Usecase Error",
+			mockSetup: func(t *testing.T, webhookUsecase *usecasemock.MockWebhookUsecase) {
+				webhookUsecase.EXPECT().
+					ReplayDelivery(gomock.Any(), uint64(11)).
+					Return(nil, errors.New("db error"))
+			},
+			expectedError:      errors.New("db error"),
+			expectedStatusCode: http.StatusOK, // error is rendered by the HTTP error handler
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/admin/webhooks/deliveries/11/replay", nil)
+			rec := httptest.NewRecorder()
+
+			mockWebhookUsecase := usecasemock.NewMockWebhookUsecase(ctrl)
+			tt.mockSetup(t, mockWebhookUsecase)
+
+			server := handler.RestAPIServer{
+				Echo:           e,
+				WebhookUsecase: mockWebhookUsecase,
+			}
+
+			c := e.NewContext(req, rec)
+			err := server.PostAdminWebhooksDeliveriesDeliveryIdReplay(c, 11)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
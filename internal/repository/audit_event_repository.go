@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// errAuditAppendOutsideTransaction is returned when an audit event is appended without a transaction,
+// in which case concurrent appends could fork the chain
+var errAuditAppendOutsideTransaction = errors.New("audit events must be appended within a transaction")
+
+// auditEventRepository implements the AuditEventRepository interface
+type auditEventRepository struct {
+	db       *sqlx.DB
+	replicas *ReplicaSet
+}
+
+// NewAuditEventRepository creates a new instance of auditEventRepository.
+// Its reads made outside of a transaction are sent to the replicas, if any.
+func NewAuditEventRepository(db *sqlx.DB, replicas *ReplicaSet) *auditEventRepository {
+	return &auditEventRepository{
+		db:       db,
+		replicas: replicas,
+	}
+}
+
+// Append chains the event to the last event of the audit log, inserts it and sets its ID and hashes.
+// It must be called within a transaction: the chain head stays locked until the transaction ends,
+// so that concurrent appends are chained one after the other. This bounds the audited operations
+// of all instances to one append per round trip of that transaction, so it must be the only work of the
+// transaction, never a business transaction holding other locks; see BenchmarkSQLite_AuditEventAppend.
+func (a *auditEventRepository) Append(ctx context.Context, event *entity.AuditEvent) error {
+	if transactionFromContext(ctx) == nil {
+		return errAuditAppendOutsideTransaction
+	}
+
+	const (
+		selectHeadQuery = `SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE`
+		insertQuery     = `
+			INSERT INTO audit_events (actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`
+		updateHeadQuery = `UPDATE audit_chain_head SET last_id = ?, last_hash = ? WHERE id = 1`
+	)
+
+	executor := getExecutor(ctx, a.db)
+
+	var head auditChainHeadRow
+	if err := executor.GetContext(ctx, &head, selectHeadQuery); err != nil {
+		return err
+	}
+
+	event.Chain(head.LastHash)
+	id, err := insertReturningID(
+		ctx,
+		a.db,
+		insertQuery,
+		event.Actor,
+		nullableString(event.ClientID),
+		nullableString(event.IP),
+		event.UserID,
+		nullableInt(int(event.OTPID)),
+		event.Action,
+		event.Outcome,
+		nullableString(event.Reason),
+		event.CreatedAt,
+		event.PrevHash,
+		event.Hash,
+	)
+	if err != nil {
+		return err
+	}
+	event.ID = id
+
+	_, err = executor.ExecContext(ctx, updateHeadQuery, event.ID, event.Hash)
+	return err
+}
+
+// List retrieves up to limit audit events of a user within the time range of the filter, in chain order.
+// Only the bounds of the range that are set are added to the query, as PostgreSQL cannot infer
+// the type of a parameter that is only compared with NULL.
+func (a *auditEventRepository) List(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error) {
+	query := `
+		SELECT id, actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash
+		FROM audit_events
+		WHERE user_id = ?`
+	args := []any{filter.UserID}
+	if filter.From != nil {
+		query += " AND created_at >= ?"
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		query += " AND created_at < ?"
+		args = append(args, *filter.To)
+	}
+	query += `
+		ORDER BY id
+		LIMIT ?`
+	args = append(args, filter.Limit)
+
+	var rows []auditEventRow
+	if err := getReader(ctx, a.db, a.replicas).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	return toAuditEvents(rows), nil
+}
+
+// ListAfter retrieves up to limit audit events following the event with the given ID, in chain order
+func (a *auditEventRepository) ListAfter(ctx context.Context, afterID uint64, limit int) ([]*entity.AuditEvent, error) {
+	const query = `
+		SELECT id, actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash
+		FROM audit_events
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	var rows []auditEventRow
+	if err := getReader(ctx, a.db, a.replicas).SelectContext(ctx, &rows, query, afterID, limit); err != nil {
+		return nil, err
+	}
+
+	return toAuditEvents(rows), nil
+}
+
+// ListByOTPID retrieves the audit events of the operations on an OTP, in chain order
+func (a *auditEventRepository) ListByOTPID(ctx context.Context, otpID uint64) ([]*entity.AuditEvent, error) {
+	const query = `
+		SELECT id, actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash
+		FROM audit_events
+		WHERE otp_id = ?
+		ORDER BY id
+	`
+
+	var rows []auditEventRow
+	if err := getReader(ctx, a.db, a.replicas).SelectContext(ctx, &rows, query, otpID); err != nil {
+		return nil, err
+	}
+
+	return toAuditEvents(rows), nil
+}
+
+// GetChainHead retrieves the pointer to the last event of the audit log
+func (a *auditEventRepository) GetChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	const query = `SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1`
+
+	var row auditChainHeadRow
+	if err := getReader(ctx, a.db, a.replicas).GetContext(ctx, &row, query); err != nil {
+		return nil, err
+	}
+
+	return row.ToEntity(), nil
+}
+
+// toAuditEvents converts audit event rows to entities
+func toAuditEvents(rows []auditEventRow) []*entity.AuditEvent {
+	events := make([]*entity.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, row.ToEntity())
+	}
+	return events
+}
@@ -0,0 +1,140 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditEventColumns = []string{"id", "actor", "client_id", "ip", "user_id", "otp_id", "action", "outcome", "reason", "created_at", "prev_hash", "hash"}
+
+func TestAuditEventRepository_Append(t *testing.T) {
+	createdAt := time.Date(2025, 11, 26, 9, 0, 0, 0, time.UTC)
+	newEvent := func() *entity.AuditEvent {
+		return &entity.AuditEvent{
+			Actor:     entity.AuditActorClient,
+			IP:        "203.0.113.7",
+			UserID:    "user123",
+			OTPID:     42,
+			Action:    entity.AuditActionOTPValidate,
+			Outcome:   entity.AuditOutcomeSuccess,
+			CreatedAt: createdAt,
+		}
+	}
+
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName+"/Should chain the event to the last event and move the chain head", func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewAuditEventRepository(repositoryDependency.mockedDB, nil)
+			txManager := repository.NewTransactionManager(repositoryDependency.mockedDB, repository.RetryPolicy{})
+			defer repositoryDependency.mockedDB.Close()
+
+			expected := newEvent()
+			expected.Chain("previous-hash")
+
+			repositoryDependency.mockedSQL.ExpectBegin()
+			repositoryDependency.mockedSQL.
+				ExpectQuery(regexp.QuoteMeta("SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE")).
+				WillReturnRows(sqlmock.NewRows([]string{"last_id", "last_hash"}).AddRow(6, "previous-hash"))
+			repositoryDependency.expectInsert(
+				regexp.QuoteMeta("INSERT INTO audit_events (actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+				[]driver.Value{entity.AuditActorClient, nil, "203.0.113.7", "user123", 42, entity.AuditActionOTPValidate, entity.AuditOutcomeSuccess, nil, createdAt, "previous-hash", expected.Hash},
+				7, nil,
+			)
+			repositoryDependency.mockedSQL.
+				ExpectExec(regexp.QuoteMeta("UPDATE audit_chain_head SET last_id = ?, last_hash = ? WHERE id = 1")).
+				WithArgs(7, expected.Hash).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			repositoryDependency.mockedSQL.ExpectCommit()
+
+			event := newEvent()
+			err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+				return repo.Append(ctx, event)
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(7), event.ID)
+			assert.Equal(t, "previous-hash", event.PrevHash)
+			assert.Equal(t, expected.Hash, event.Hash)
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("Should refuse to append outside of a transaction", func(t *testing.T) {
+		repositoryDependency := newRepoDependency()
+		repo := repository.NewAuditEventRepository(repositoryDependency.mockedDB, nil)
+		defer repositoryDependency.mockedDB.Close()
+
+		assert.EqualError(t, repo.Append(context.TODO(), newEvent()), "audit events must be appended within a transaction")
+		assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+	})
+}
+
+func TestAuditEventRepository_List(t *testing.T) {
+	from := time.Date(2025, 11, 26, 0, 0, 0, 0, time.UTC)
+	createdAt := from.Add(time.Hour)
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewAuditEventRepository(repositoryDependency.mockedDB, nil)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT id, actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash FROM audit_events WHERE user_id = ? AND created_at >= ? ORDER BY id LIMIT ?")).
+		WithArgs("user123", from, 100).
+		WillReturnRows(sqlmock.NewRows(auditEventColumns).
+			AddRow(7, "client", "checkout-app", "203.0.113.7", "user123", nil, "otp.validate", entity.AuditOutcomeFailure, "otp_not_found", createdAt, "previous-hash", "hash"))
+
+	events, err := repo.List(context.TODO(), entity.AuditEventFilter{UserID: "user123", From: &from, Limit: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.AuditEvent{{
+		ID:        7,
+		Actor:     entity.AuditActorClient,
+		ClientID:  "checkout-app",
+		IP:        "203.0.113.7",
+		UserID:    "user123",
+		Action:    entity.AuditActionOTPValidate,
+		Outcome:   entity.AuditOutcomeFailure,
+		Reason:    "otp_not_found",
+		CreatedAt: createdAt,
+		PrevHash:  "previous-hash",
+		Hash:      "hash",
+	}}, events)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestAuditEventRepository_ListByOTPID(t *testing.T) {
+	createdAt := time.Date(2025, 11, 26, 1, 0, 0, 0, time.UTC)
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewAuditEventRepository(repositoryDependency.mockedDB, nil)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT id, actor, client_id, ip, user_id, otp_id, action, outcome, reason, created_at, prev_hash, hash FROM audit_events WHERE otp_id = ? ORDER BY id")).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows(auditEventColumns).
+			AddRow(7, "client", nil, "203.0.113.7", "user123", 42, "otp.validate", entity.AuditOutcomeSuccess, nil, createdAt, "previous-hash", "hash"))
+
+	events, err := repo.ListByOTPID(context.TODO(), 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.AuditEvent{{
+		ID:        7,
+		Actor:     entity.AuditActorClient,
+		IP:        "203.0.113.7",
+		UserID:    "user123",
+		OTPID:     42,
+		Action:    entity.AuditActionOTPValidate,
+		Outcome:   entity.AuditOutcomeSuccess,
+		CreatedAt: createdAt,
+		PrevHash:  "previous-hash",
+		Hash:      "hash",
+	}}, events)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
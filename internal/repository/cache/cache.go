@@ -0,0 +1,211 @@
+// Package cache caches the lookups of the OTP repository, in a bounded in-process LRU or in a cache shared
+// by the instances of the service.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// Cache stores the last OTP of users, keyed by user ID. A nil OTP records that the user has none.
+//
+// An OTP read from the database before a write must not be cached after the write invalidated it,
+// so Get returns a token to pass to Set, which drops the OTP if the user was invalidated since.
+type Cache interface {
+	// Get returns the cached last OTP of a user and whether it was found,
+	// along with the token to cache it with when it was not.
+	Get(ctx context.Context, userID string) (otp *entity.OTP, found bool, token uint64)
+
+	// Set caches the last OTP of a user, unless it was invalidated since Get returned the token.
+	Set(ctx context.Context, userID string, otp *entity.OTP, token uint64)
+
+	// Invalidate removes the last OTP of a user.
+	Invalidate(ctx context.Context, userID string)
+
+	// InvalidateOTP removes the last OTP of the user the OTP with the given ID belongs to, if it is cached.
+	InvalidateOTP(ctx context.Context, id uint64)
+}
+
+// entry is the last OTP of a user, or the tombstone left by its invalidation
+type entry struct {
+	userID string
+	otp    *entity.OTP
+	// invalidated marks a tombstone, which keeps the OTPs read before the invalidation from being cached
+	invalidated bool
+	// generation is the generation of the last invalidation of the user
+	generation uint64
+	expiresAt  time.Time
+}
+
+// LRU is a Cache holding up to a fixed number of users in process, evicting the least recently used first.
+// It is safe for concurrent use.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // front is the most recently used
+	byOTPID  map[uint64]string
+	// generation counts the invalidations, tokens are the generation at the time of the lookup
+	generation uint64
+	// floor is the generation below which no OTP is cached anymore, as an invalidation it stands for
+	// was forgotten: the entry of its user expired or was evicted, or it was of an OTP that was not cached
+	floor uint64
+}
+
+// NewLRU creates an LRU holding up to capacity users, each for up to ttl
+func NewLRU(capacity int, ttl time.Duration) *LRU {
+	return &LRU{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		byOTPID:  make(map[uint64]string),
+	}
+}
+
+// Get returns the cached last OTP of a user
+func (l *LRU) Get(_ context.Context, userID string) (*entity.OTP, bool, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, found := l.entries[userID]
+	if !found {
+		return nil, false, l.generation
+	}
+
+	e := element.Value.(*entry)
+	if e.invalidated {
+		return nil, false, l.generation
+	}
+	if time.Now().After(e.expiresAt) {
+		l.forget(element)
+		return nil, false, l.generation
+	}
+
+	l.order.MoveToFront(element)
+	if e.otp == nil {
+		return nil, true, l.generation
+	}
+
+	return clone(e.otp), true, l.generation
+}
+
+// Set caches the last OTP of a user, unless it was invalidated since Get returned the token
+func (l *LRU) Set(_ context.Context, userID string, otp *entity.OTP, token uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if token < l.floor {
+		return
+	}
+
+	e := &entry{userID: userID}
+	if element, found := l.entries[userID]; found {
+		previous := element.Value.(*entry)
+		if previous.generation > token {
+			return
+		}
+		e.generation = previous.generation
+		l.remove(element)
+	}
+
+	if otp != nil {
+		e.otp = clone(otp)
+		l.byOTPID[otp.ID] = userID
+	}
+	e.expiresAt = time.Now().Add(l.ttl)
+	l.insert(e)
+}
+
+// Invalidate removes the last OTP of a user
+func (l *LRU) Invalidate(_ context.Context, userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.invalidate(userID)
+}
+
+// InvalidateOTP removes the last OTP of the user the OTP belongs to, if it is cached.
+// When it is not, the OTPs being looked up are not cached, as one of them may be this OTP before its change.
+func (l *LRU) InvalidateOTP(_ context.Context, id uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if userID, found := l.byOTPID[id]; found {
+		l.invalidate(userID)
+		return
+	}
+
+	l.generation++
+	l.floor = l.generation
+}
+
+// Len returns the number of users held, including the tombstones of the invalidated ones
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+// invalidate replaces the entry of a user with a tombstone. It must be called with l.mu held.
+func (l *LRU) invalidate(userID string) {
+	l.generation++
+	if element, found := l.entries[userID]; found {
+		l.remove(element)
+	}
+	l.insert(&entry{
+		userID:      userID,
+		invalidated: true,
+		generation:  l.generation,
+		expiresAt:   time.Now().Add(l.ttl),
+	})
+}
+
+// insert adds an entry as the most recently used, evicting the least recently used ones beyond the capacity.
+// It must be called with l.mu held.
+func (l *LRU) insert(e *entry) {
+	l.entries[e.userID] = l.order.PushFront(e)
+	for l.order.Len() > l.capacity {
+		l.forget(l.order.Back())
+		cacheEvictions.Inc()
+	}
+}
+
+// forget removes an entry that expired or is evicted, along with the last invalidation of its user,
+// so the OTPs looked up before that invalidation are no longer cached. It must be called with l.mu held.
+func (l *LRU) forget(element *list.Element) {
+	if e := element.Value.(*entry); e.generation > l.floor {
+		l.floor = e.generation
+	}
+	l.remove(element)
+}
+
+// remove removes an entry. It must be called with l.mu held.
+func (l *LRU) remove(element *list.Element) {
+	e := l.order.Remove(element).(*entry)
+	delete(l.entries, e.userID)
+	if e.otp != nil && l.byOTPID[e.otp.ID] == e.userID {
+		delete(l.byOTPID, e.otp.ID)
+	}
+}
+
+// clone copies an OTP, so that the cached versions are never shared with the callers
+func clone(otp *entity.OTP) *entity.OTP {
+	copied := *otp
+	if otp.ValidatedAt != nil {
+		validatedAt := *otp.ValidatedAt
+		copied.ValidatedAt = &validatedAt
+	}
+	if otp.RevokedAt != nil {
+		revokedAt := *otp.RevokedAt
+		copied.RevokedAt = &revokedAt
+	}
+
+	return &copied
+}
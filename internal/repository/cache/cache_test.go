@@ -0,0 +1,189 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.TODO()
+	otp := &entity.OTP{ID: 1, UserID: "user123", OTPCode: "123456", Status: entity.OTPStatusCreated}
+
+	tests := []struct {
+		name        string
+		capacity    int
+		ttl         time.Duration
+		run         func(lru *cache.LRU)
+		userID      string
+		expectFound bool
+		expectOTP   *entity.OTP
+	}{
+		{
+			name:   "Should miss a user that was never cached",
+			userID: "user123",
+		},
+		{
+			name: "Should return the cached OTP of a user",
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Set(ctx, "user123", otp, token)
+			},
+			userID:      "user123",
+			expectFound: true,
+			expectOTP:   otp,
+		},
+		{
+			name: "Should return that a user has no OTP",
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Set(ctx, "user123", nil, token)
+			},
+			userID:      "user123",
+			expectFound: true,
+		},
+		{
+			name: "Should miss an invalidated user",
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Set(ctx, "user123", otp, token)
+				lru.Invalidate(ctx, "user123")
+			},
+			userID: "user123",
+		},
+		{
+			name: "Should miss the user of an invalidated OTP",
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Set(ctx, "user123", otp, token)
+				lru.InvalidateOTP(ctx, otp.ID)
+			},
+			userID: "user123",
+		},
+		{
+			name: "Should not cache an OTP looked up before the user was invalidated",
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Invalidate(ctx, "user123")
+				lru.Set(ctx, "user123", otp, token)
+			},
+			userID: "user123",
+		},
+		{
+			name: "Should not cache an OTP looked up before an OTP that was not cached was invalidated",
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.InvalidateOTP(ctx, otp.ID)
+				lru.Set(ctx, "user123", otp, token)
+			},
+			userID: "user123",
+		},
+		{
+			name: "Should cache an OTP looked up after the user was invalidated",
+			run: func(lru *cache.LRU) {
+				lru.Invalidate(ctx, "user123")
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Set(ctx, "user123", otp, token)
+			},
+			userID:      "user123",
+			expectFound: true,
+			expectOTP:   otp,
+		},
+		{
+			name:     "Should evict the least recently used user beyond the capacity",
+			capacity: 2,
+			run: func(lru *cache.LRU) {
+				for _, userID := range []string{"user1", "user2"} {
+					_, _, token := lru.Get(ctx, userID)
+					lru.Set(ctx, userID, nil, token)
+				}
+				lru.Get(ctx, "user1")
+				_, _, token := lru.Get(ctx, "user3")
+				lru.Set(ctx, "user3", nil, token)
+			},
+			userID: "user2",
+		},
+		{
+			name:     "Should keep the recently used users within the capacity",
+			capacity: 2,
+			run: func(lru *cache.LRU) {
+				for _, userID := range []string{"user1", "user2"} {
+					_, _, token := lru.Get(ctx, userID)
+					lru.Set(ctx, userID, nil, token)
+				}
+				lru.Get(ctx, "user1")
+				_, _, token := lru.Get(ctx, "user3")
+				lru.Set(ctx, "user3", nil, token)
+			},
+			userID:      "user1",
+			expectFound: true,
+		},
+		{
+			name:     "Should not cache an OTP looked up before the invalidation of an evicted user",
+			capacity: 1,
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Invalidate(ctx, "user123")
+				lru.Invalidate(ctx, "user456")
+				lru.Set(ctx, "user123", otp, token)
+			},
+			userID: "user123",
+		},
+		{
+			name: "Should miss a user cached for longer than the TTL",
+			ttl:  time.Nanosecond,
+			run: func(lru *cache.LRU) {
+				_, _, token := lru.Get(ctx, "user123")
+				lru.Set(ctx, "user123", otp, token)
+				time.Sleep(time.Millisecond)
+			},
+			userID: "user123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity, ttl := tt.capacity, tt.ttl
+			if capacity == 0 {
+				capacity = 10
+			}
+			if ttl == 0 {
+				ttl = time.Minute
+			}
+			lru := cache.NewLRU(capacity, ttl)
+			if tt.run != nil {
+				tt.run(lru)
+			}
+
+			cached, found, _ := lru.Get(ctx, tt.userID)
+			assert.Equal(t, tt.expectFound, found)
+			assert.Equal(t, tt.expectOTP, cached)
+			assert.LessOrEqual(t, lru.Len(), capacity)
+		})
+	}
+}
+
+func TestLRU_Copies(t *testing.T) {
+	ctx := context.TODO()
+	lru := cache.NewLRU(10, time.Minute)
+	validatedAt := time.Now()
+	otp := &entity.OTP{ID: 1, UserID: "user123", Status: entity.OTPStatusValidated, ValidatedAt: &validatedAt}
+
+	_, _, token := lru.Get(ctx, "user123")
+	lru.Set(ctx, "user123", otp, token)
+	otp.Status = entity.OTPStatusCreated
+
+	// The cached OTP is not changed by the callers
+	cached, found, _ := lru.Get(ctx, "user123")
+	require.True(t, found)
+	assert.Equal(t, entity.OTPStatusValidated, cached.Status)
+	*cached.ValidatedAt = time.Time{}
+
+	cached, _, _ = lru.Get(ctx, "user123")
+	assert.Equal(t, validatedAt, *cached.ValidatedAt)
+}
@@ -0,0 +1,20 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/internal/repository/cache"
+	"github.com/imansohibul/otp-service/internal/repository/memory"
+	"github.com/imansohibul/otp-service/internal/repository/repositorytest"
+	"github.com/imansohibul/otp-service/internal/usecase"
+)
+
+func TestOTPRepository_Conformance(t *testing.T) {
+	repositorytest.RunOTPRepositorySuite(t, func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager) {
+		store := memory.NewStore()
+		lru := cache.NewLRU(100, time.Minute)
+		return cache.NewOTPRepository(memory.NewOTPRepository(store), lru),
+			cache.NewTransactionManager(memory.NewTransactionManager(store, nil), lru)
+	}, repositorytest.Limitations{})
+}
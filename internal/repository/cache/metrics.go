@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered on the default registry, which is served by the /metrics route of the REST API.
+var (
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "otp_cache",
+		Name:      "lookups_total",
+		Help:      "Number of lookups of the last OTP of a user, partitioned by whether the cache held it.",
+	}, []string{"result"})
+
+	cacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "otp_cache",
+		Name:      "evictions_total",
+		Help:      "Number of users evicted from the in-process cache to stay within its capacity or TTL.",
+	})
+)
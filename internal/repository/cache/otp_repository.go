@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+)
+
+// otpRepository decorates an OTPRepository with a cache of the last OTP of users, looked up on every OTP request
+// to enforce the rate limit. The other methods are those of the decorated repository.
+type otpRepository struct {
+	usecase.OTPRepository
+	cache Cache
+}
+
+// NewOTPRepository creates a new instance of otpRepository. Its transactions must be run by the TransactionManager
+// of this package, so that the writes invalidate the cache again once committed.
+func NewOTPRepository(next usecase.OTPRepository, cache Cache) *otpRepository {
+	return &otpRepository{
+		OTPRepository: next,
+		cache:         cache,
+	}
+}
+
+// GetLastByUserID retrieves the most recent OTP of a user created at or after createdFrom from the cache,
+// or from the decorated repository. A user without such an OTP is cached as having none, which holds as long as
+// the lookups are bounded by a creation time that does not go back, like the rate limit window.
+func (o *otpRepository) GetLastByUserID(ctx context.Context, userID string, createdFrom time.Time) (*entity.OTP, error) {
+	otp, found, token := o.cache.Get(ctx, userID)
+	if found {
+		cacheLookups.WithLabelValues("hit").Inc()
+		if otp == nil || otp.CreatedAt.Before(createdFrom) {
+			return nil, entity.ErrOTPNotFound
+		}
+		return otp, nil
+	}
+	cacheLookups.WithLabelValues("miss").Inc()
+
+	otp, err := o.OTPRepository.GetLastByUserID(ctx, userID, createdFrom)
+	if err != nil && !errors.Is(err, entity.ErrOTPNotFound) {
+		return nil, err
+	}
+
+	// Within a transaction, the OTP may not be committed yet, or never be
+	if pendingFromContext(ctx) == nil {
+		o.cache.Set(ctx, userID, otp, token)
+	}
+
+	return otp, err
+}
+
+// Create inserts a new OTP and invalidates the last OTP of its user
+func (o *otpRepository) Create(ctx context.Context, otp *entity.OTP) error {
+	err := o.OTPRepository.Create(ctx, otp)
+	o.invalidate(ctx, otp.UserID)
+	return err
+}
+
+// Update updates an OTP and invalidates the last OTP of its user
+func (o *otpRepository) Update(ctx context.Context, otp *entity.OTP) (bool, error) {
+	updated, err := o.OTPRepository.Update(ctx, otp)
+	o.invalidate(ctx, otp.UserID)
+	return updated, err
+}
+
+// MarkExpired marks OTPs as expired and invalidates them
+func (o *otpRepository) MarkExpired(ctx context.Context, otps []*entity.OTP) error {
+	err := o.OTPRepository.MarkExpired(ctx, otps)
+	ids := make([]uint64, 0, len(otps))
+	for _, otp := range otps {
+		ids = append(ids, otp.ID)
+	}
+	o.invalidateOTPs(ctx, ids...)
+	return err
+}
+
+// IncrementResendCount counts a resend of an OTP and invalidates it
+func (o *otpRepository) IncrementResendCount(ctx context.Context, otp *entity.OTP) (bool, error) {
+	incremented, err := o.OTPRepository.IncrementResendCount(ctx, otp)
+	o.invalidateOTPs(ctx, otp.ID)
+	return incremented, err
+}
+
+// MarkRevoked marks an OTP as revoked and invalidates it
+func (o *otpRepository) MarkRevoked(ctx context.Context, otp *entity.OTP, revokedAt time.Time, reason string) (bool, error) {
+	revoked, err := o.OTPRepository.MarkRevoked(ctx, otp, revokedAt, reason)
+	o.invalidateOTPs(ctx, otp.ID)
+	return revoked, err
+}
+
+// invalidate invalidates the last OTP of a user now, and again once the transaction in the context, if any, ends.
+// The write may have failed partway, so the cache is invalidated whatever its outcome.
+func (o *otpRepository) invalidate(ctx context.Context, userID string) {
+	o.cache.Invalidate(ctx, userID)
+	if pending := pendingFromContext(ctx); pending != nil {
+		pending.addUser(userID)
+	}
+}
+
+// invalidateOTPs invalidates OTPs now, and again once the transaction in the context, if any, ends
+func (o *otpRepository) invalidateOTPs(ctx context.Context, ids ...uint64) {
+	for _, id := range ids {
+		o.cache.InvalidateOTP(ctx, id)
+	}
+	if pending := pendingFromContext(ctx); pending != nil {
+		pending.addOTPs(ids...)
+	}
+}
@@ -0,0 +1,175 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository/cache"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTPRepository_GetLastByUserID(t *testing.T) {
+	var (
+		errDatabase = errors.New("database error")
+		createdAt   = time.Now()
+		createdFrom = createdAt.Add(-time.Minute)
+		lastOTP     = &entity.OTP{ID: 1, UserID: "user123", OTPCode: "123456", Status: entity.OTPStatusCreated, CreatedAt: createdAt}
+		newOTP      = &entity.OTP{UserID: "user123", OTPCode: "654321", Status: entity.OTPStatusCreated}
+	)
+
+	tests := []struct {
+		name       string
+		mockFn     func(next *mock.MockOTPRepository, txManager *mock.MockTransactionManager)
+		run        func(ctx context.Context, repo usecase.OTPRepository, txManager usecase.TransactionManager)
+		lookupFrom time.Time
+		expectOTP  *entity.OTP
+		expectErr  error
+	}{
+		{
+			name: "Should look up the last OTP of a user once",
+			mockFn: func(next *mock.MockOTPRepository, _ *mock.MockTransactionManager) {
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil).Times(1)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+				_, _ = repo.GetLastByUserID(ctx, "user123", createdFrom)
+			},
+			expectOTP: lastOTP,
+		},
+		{
+			name: "Should look up a user without OTP once",
+			mockFn: func(next *mock.MockOTPRepository, _ *mock.MockTransactionManager) {
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(nil, entity.ErrOTPNotFound).Times(1)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+				_, _ = repo.GetLastByUserID(ctx, "user123", createdFrom)
+			},
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name: "Should not return the cached OTP when it was created before the given time",
+			mockFn: func(next *mock.MockOTPRepository, _ *mock.MockTransactionManager) {
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil).Times(1)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+				_, _ = repo.GetLastByUserID(ctx, "user123", createdFrom)
+			},
+			lookupFrom: createdAt.Add(time.Minute),
+			expectErr:  entity.ErrOTPNotFound,
+		},
+		{
+			name: "Should not cache a failed lookup",
+			mockFn: func(next *mock.MockOTPRepository, _ *mock.MockTransactionManager) {
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(nil, errDatabase)
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+				_, _ = repo.GetLastByUserID(ctx, "user123", createdFrom)
+			},
+			expectOTP: lastOTP,
+		},
+		{
+			name: "Should look up the last OTP again once an OTP is created",
+			mockFn: func(next *mock.MockOTPRepository, _ *mock.MockTransactionManager) {
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(nil, entity.ErrOTPNotFound)
+				next.EXPECT().Create(gomock.Any(), newOTP).Return(nil)
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+				_, _ = repo.GetLastByUserID(ctx, "user123", createdFrom)
+				_ = repo.Create(ctx, newOTP)
+			},
+			expectOTP: lastOTP,
+		},
+		{
+			name: "Should look up the last OTP again once it is revoked",
+			mockFn: func(next *mock.MockOTPRepository, _ *mock.MockTransactionManager) {
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil)
+				next.EXPECT().MarkRevoked(gomock.Any(), lastOTP, gomock.Any(), "").Return(true, nil)
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(nil, entity.ErrOTPNotFound)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+				_, _ = repo.GetLastByUserID(ctx, "user123", createdFrom)
+				_, _ = repo.MarkRevoked(ctx, lastOTP, time.Now(), "")
+			},
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name: "Should not cache a lookup made within a transaction",
+			mockFn: func(next *mock.MockOTPRepository, txManager *mock.MockTransactionManager) {
+				txManager.EXPECT().WithTransactionOptions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, _ usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil).Times(2)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, txManager usecase.TransactionManager) {
+				_ = txManager.WithTransaction(ctx, func(ctx context.Context) error {
+					_, err := repo.GetLastByUserID(ctx, "user123", createdFrom)
+					return err
+				})
+			},
+			expectOTP: lastOTP,
+		},
+		{
+			name: "Should invalidate the OTPs written by a transaction once it ends",
+			mockFn: func(next *mock.MockOTPRepository, txManager *mock.MockTransactionManager) {
+				txManager.EXPECT().WithTransactionOptions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, _ usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					},
+				)
+				next.EXPECT().Create(gomock.Any(), newOTP).Return(nil)
+				// A concurrent lookup reads the previous OTP until the transaction commits
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(lastOTP, nil)
+				next.EXPECT().GetLastByUserID(gomock.Any(), "user123", createdFrom).Return(newOTP, nil)
+			},
+			run: func(ctx context.Context, repo usecase.OTPRepository, txManager usecase.TransactionManager) {
+				_ = txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+					if err := repo.Create(txCtx, newOTP); err != nil {
+						return err
+					}
+					_, err := repo.GetLastByUserID(ctx, "user123", createdFrom)
+					return err
+				})
+			},
+			expectOTP: newOTP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var (
+				ctx       = context.TODO()
+				next      = mock.NewMockOTPRepository(ctrl)
+				txManager = mock.NewMockTransactionManager(ctrl)
+				lru       = cache.NewLRU(10, time.Minute)
+				repo      = cache.NewOTPRepository(next, lru)
+			)
+			tt.mockFn(next, txManager)
+			tt.run(ctx, repo, cache.NewTransactionManager(txManager, lru))
+
+			lookupFrom := createdFrom
+			if !tt.lookupFrom.IsZero() {
+				lookupFrom = tt.lookupFrom
+			}
+			otp, err := repo.GetLastByUserID(ctx, "user123", lookupFrom)
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectOTP, otp)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/imansohibul/otp-service/internal/usecase"
+)
+
+// pendingKey is a key used to store the invalidations pending on the end of a transaction in the context
+type pendingKey struct{}
+
+// pending holds the users and OTPs a transaction wrote, to invalidate once it ends: until then,
+// concurrent lookups read their previous version, which they could cache after the writes invalidated it
+type pending struct {
+	mu      sync.Mutex
+	userIDs []string
+	otpIDs  []uint64
+}
+
+func (p *pending) addUser(userID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.userIDs = append(p.userIDs, userID)
+}
+
+func (p *pending) addOTPs(ids ...uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.otpIDs = append(p.otpIDs, ids...)
+}
+
+// invalidate invalidates the users and OTPs the transaction wrote
+func (p *pending) invalidate(ctx context.Context, cache Cache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, userID := range p.userIDs {
+		cache.Invalidate(ctx, userID)
+	}
+	for _, id := range p.otpIDs {
+		cache.InvalidateOTP(ctx, id)
+	}
+}
+
+// transactionManager decorates a TransactionManager to invalidate the cache once the transactions end
+type transactionManager struct {
+	next  usecase.TransactionManager
+	cache Cache
+}
+
+// NewTransactionManager creates a new instance of transactionManager
+func NewTransactionManager(next usecase.TransactionManager, cache Cache) *transactionManager {
+	return &transactionManager{
+		next:  next,
+		cache: cache,
+	}
+}
+
+// WithTransaction executes the provided function within a transaction of the decorated transaction manager
+func (t *transactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.WithTransactionOptions(ctx, usecase.TransactionOptions{}, fn)
+}
+
+// WithTransactionOptions executes the provided function within a transaction of the decorated transaction manager,
+// and invalidates what it wrote once the outermost transaction ends, committed or not
+func (t *transactionManager) WithTransactionOptions(ctx context.Context, opts usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+	if pendingFromContext(ctx) != nil {
+		return t.next.WithTransactionOptions(ctx, opts, fn)
+	}
+
+	pending := &pending{}
+	err := t.next.WithTransactionOptions(context.WithValue(ctx, pendingKey{}, pending), opts, fn)
+	pending.invalidate(context.WithoutCancel(ctx), t.cache)
+
+	return err
+}
+
+// pendingFromContext retrieves the invalidations pending on the end of the transaction in the context
+func pendingFromContext(ctx context.Context) *pending {
+	p, _ := ctx.Value(pendingKey{}).(*pending)
+	return p
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// dialect is the SQL dialect of the database a repository runs on.
+// Queries are written for MySQL with ? placeholders, which are rebound to the bind variables
+// of the database by getExecutor; only the statements MySQL, PostgreSQL and SQLite spell
+// differently branch on the dialect.
+type dialect int8
+
+const (
+	dialectMySQL dialect = iota + 1
+	dialectPostgres
+	dialectSQLite
+)
+
+// dialectOf returns the dialect of the database from the name of its driver
+func dialectOf(db *sqlx.DB) dialect {
+	switch db.DriverName() {
+	case "postgres", "pgx":
+		return dialectPostgres
+	case "sqlite", "sqlite3":
+		return dialectSQLite
+	default:
+		return dialectMySQL
+	}
+}
+
+// reboundExecutor rewrites the ? placeholders of queries into the bind variables
+// of a database that does not support them, e.g. $1, $2... on PostgreSQL
+type reboundExecutor struct {
+	executor ExecerContext
+	bindType int
+}
+
+func (r reboundExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.executor.ExecContext(ctx, sqlx.Rebind(r.bindType, query), args...)
+}
+
+func (r reboundExecutor) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.executor.GetContext(ctx, dest, sqlx.Rebind(r.bindType, query), args...)
+}
+
+func (r reboundExecutor) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return r.executor.SelectContext(ctx, dest, sqlx.Rebind(r.bindType, query), args...)
+}
+
+// lockingClause matches the row locking clauses of SELECT statements
+var lockingClause = regexp.MustCompile(`\s+FOR\s+UPDATE(\s+SKIP\s+LOCKED)?`)
+
+// sqliteExecutor adapts queries to SQLite. SQLite has no row locks, a write transaction locks
+// the whole database instead, so the locking clauses are removed. Times are stored as text and
+// compared as strings, so they are written in UTC to keep their order across time zone changes.
+type sqliteExecutor struct {
+	executor ExecerContext
+}
+
+func (s sqliteExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.executor.ExecContext(ctx, lockingClause.ReplaceAllString(query, ""), utcArgs(args)...)
+}
+
+func (s sqliteExecutor) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return s.executor.GetContext(ctx, dest, lockingClause.ReplaceAllString(query, ""), utcArgs(args)...)
+}
+
+func (s sqliteExecutor) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.executor.SelectContext(ctx, dest, lockingClause.ReplaceAllString(query, ""), utcArgs(args)...)
+}
+
+// utcArgs converts the time arguments of a query to UTC
+func utcArgs(args []any) []any {
+	converted := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				converted[i] = v.UTC()
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+
+	return converted
+}
+
+// insertReturningID executes an INSERT statement and returns the ID of the inserted row.
+// PostgreSQL does not report the last insert ID, so the ID is returned by a RETURNING clause instead.
+func insertReturningID(ctx context.Context, db *sqlx.DB, query string, args ...any) (uint64, error) {
+	executor := getExecutor(ctx, db)
+
+	if dialectOf(db) == dialectPostgres {
+		var id uint64
+		if err := executor.GetContext(ctx, &id, query+" RETURNING id", args...); err != nil {
+			return 0, err
+		}
+		return id, nil
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(id), nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+)
+
+// Names of the encrypted columns, which the blind indexes and the associated data of their values are derived along with
+var (
+	userIDAssociatedData        = []byte("otps.user_id")
+	otpCodeAssociatedData       = []byte("otps.otp_code")
+	webhookSecretAssociatedData = []byte("webhook_subscriptions.secret")
+)
+
+// ColumnEncryptor encrypts the sensitive columns of the stored rows, and derives the blind indexes they are looked up by.
+// It is implemented by the keyring of the crypto package.
+type ColumnEncryptor interface {
+	// CurrentKeyID returns the ID of the data key the new values are encrypted with
+	CurrentKeyID(ctx context.Context) (string, error)
+	// Encrypt encrypts a value with the data key of the given ID
+	Encrypt(ctx context.Context, keyID string, plaintext []byte, associatedData []byte) ([]byte, error)
+	// Decrypt decrypts a value encrypted with the data key of the given ID
+	Decrypt(ctx context.Context, keyID string, ciphertext []byte, associatedData []byte) ([]byte, error)
+	// BlindIndex derives the keyed index of a value
+	BlindIndex(ctx context.Context, value []byte) (string, error)
+}
+
+// userIDIndex returns the value stored in the user_id column of otps for the given user ID:
+// its blind index when the columns are encrypted, the user ID itself otherwise
+func userIDIndex(ctx context.Context, encryptor ColumnEncryptor, userID string) (string, error) {
+	if encryptor == nil {
+		return userID, nil
+	}
+
+	return encryptor.BlindIndex(ctx, indexedValue(userIDAssociatedData, userID))
+}
+
+// otpCodeIndex returns the value stored in the otp_code column of otps for the given code of a user.
+// The blind index of a code is derived along with the user ID, so that equal codes of different users differ.
+func otpCodeIndex(ctx context.Context, encryptor ColumnEncryptor, userID string, otpCode string) (string, error) {
+	if encryptor == nil {
+		return otpCode, nil
+	}
+
+	return encryptor.BlindIndex(ctx, indexedValue(otpCodeAssociatedData, userID, otpCode))
+}
+
+// associatedData returns the associated data of a value of the given column of otps: the column and the blind indexes
+// of the user ID and code of its row, which are unique together, so that a ciphertext cannot be passed off as the value
+// of another column or of another row
+func associatedData(column []byte, userIndex string, otpCodeIndex string) []byte {
+	return indexedValue(column, userIndex, otpCodeIndex)
+}
+
+// webhookSecretAssociatedDataOf returns the associated data of the signing secret of a webhook subscription:
+// the column and the client and URL of the subscription, so that a ciphertext cannot be passed off as the secret
+// of the webhooks of another client or endpoint
+func webhookSecretAssociatedDataOf(clientID string, url string) []byte {
+	return indexedValue(webhookSecretAssociatedData, clientID, url)
+}
+
+// indexedValue joins the column and the values a blind index is derived from, separated by NUL bytes
+func indexedValue(column []byte, values ...string) []byte {
+	value := append([]byte(nil), column...)
+	for _, v := range values {
+		value = append(value, 0)
+		value = append(value, v...)
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// encryptionKeyRepository implements the EncryptionKeyRepository interface of the crypto package.
+// It never runs within the transaction of the context: a key created while encrypting a row must be kept
+// even if the transaction writing the row is rolled back, as the key stays in use in memory.
+type encryptionKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewEncryptionKeyRepository creates a new instance of encryptionKeyRepository
+func NewEncryptionKeyRepository(db *sqlx.DB) *encryptionKeyRepository {
+	return &encryptionKeyRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new encryption key
+func (e *encryptionKeyRepository) Create(ctx context.Context, key *entity.EncryptionKey) error {
+	const query = `
+		INSERT INTO encryption_keys (id, kind, master_key_id, wrapped_key, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	ctx = outsideTransaction(ctx)
+	_, err := getExecutor(ctx, e.db).ExecContext(ctx, query, key.ID, key.Kind, key.MasterKeyID, key.WrappedKey, key.CreatedAt)
+	if err != nil {
+		// Check if the error is a unique constraint violation
+		if isUniqueConstraintViolation(err) {
+			return entity.ErrEncryptionKeyDuplicate
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindByID retrieves an encryption key by its ID
+func (e *encryptionKeyRepository) FindByID(ctx context.Context, id string) (*entity.EncryptionKey, error) {
+	const query = `
+		SELECT id, kind, master_key_id, wrapped_key, created_at
+		FROM encryption_keys
+		WHERE id = ?
+	`
+
+	ctx = outsideTransaction(ctx)
+	var row encryptionKeyRow
+	if err := getExecutor(ctx, e.db).GetContext(ctx, &row, query, id); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrEncryptionKeyNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrEncryptionKeyNotFound
+		}
+		return nil, err
+	}
+
+	return row.ToEntity(), nil
+}
+
+// GetLatest retrieves the encryption key of the given kind created last.
+// Of the keys created within the same second, the one with the greatest ID wins.
+func (e *encryptionKeyRepository) GetLatest(ctx context.Context, kind entity.EncryptionKeyKind) (*entity.EncryptionKey, error) {
+	const query = `
+		SELECT id, kind, master_key_id, wrapped_key, created_at
+		FROM encryption_keys
+		WHERE kind = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	ctx = outsideTransaction(ctx)
+	var row encryptionKeyRow
+	if err := getExecutor(ctx, e.db).GetContext(ctx, &row, query, kind); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrEncryptionKeyNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrEncryptionKeyNotFound
+		}
+		return nil, err
+	}
+
+	return row.ToEntity(), nil
+}
+
+// ListWrappedByOther retrieves the encryption keys wrapped by another master key than the given one, oldest first
+func (e *encryptionKeyRepository) ListWrappedByOther(ctx context.Context, masterKeyID string) ([]*entity.EncryptionKey, error) {
+	const query = `
+		SELECT id, kind, master_key_id, wrapped_key, created_at
+		FROM encryption_keys
+		WHERE master_key_id <> ?
+		ORDER BY created_at, id
+	`
+
+	ctx = outsideTransaction(ctx)
+	var rows []encryptionKeyRow
+	if err := getExecutor(ctx, e.db).SelectContext(ctx, &rows, query, masterKeyID); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*entity.EncryptionKey, 0, len(rows))
+	for i := range rows {
+		keys = append(keys, rows[i].ToEntity())
+	}
+
+	return keys, nil
+}
+
+// UpdateWrapping stores the master key ID and the wrapped key of an encryption key
+func (e *encryptionKeyRepository) UpdateWrapping(ctx context.Context, key *entity.EncryptionKey) error {
+	const query = `
+		UPDATE encryption_keys
+		SET master_key_id = ?, wrapped_key = ?
+		WHERE id = ?
+	`
+
+	ctx = outsideTransaction(ctx)
+	_, err := getExecutor(ctx, e.db).ExecContext(ctx, query, key.MasterKeyID, key.WrappedKey, key.ID)
+
+	return err
+}
@@ -0,0 +1,231 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var encryptionKeyColumns = []string{"id", "kind", "master_key_id", "wrapped_key", "created_at"}
+
+func TestEncryptionKeyRepository_Create(t *testing.T) {
+	now := time.Now()
+	key := &entity.EncryptionKey{
+		ID:          "dk-1",
+		Kind:        entity.EncryptionKeyKindData,
+		MasterKeyID: "master-1",
+		WrappedKey:  []byte("wrapped"),
+		CreatedAt:   now,
+	}
+	expectedQuery := regexp.QuoteMeta("INSERT INTO encryption_keys (id, kind, master_key_id, wrapped_key, created_at) VALUES (?, ?, ?, ?, ?)")
+
+	tests := []struct {
+		name          string
+		mockErr       error
+		expectedError error
+	}{
+		{
+			name: "Should create the key",
+		},
+		{
+			name:          "Should return duplicate error when the key already exists",
+			mockErr:       &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'dk-1' for key 'PRIMARY'"},
+			expectedError: entity.ErrEncryptionKeyDuplicate,
+		},
+		{
+			name:          "Should return error when insert fails",
+			mockErr:       sql.ErrConnDone,
+			expectedError: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependency := newRepoDependency()
+			expectation := dependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs("dk-1", entity.EncryptionKeyKindData, "master-1", []byte("wrapped"), now)
+			if tt.mockErr != nil {
+				expectation.WillReturnError(tt.mockErr)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			repo := repository.NewEncryptionKeyRepository(dependency.mockedDB)
+			assert.Equal(t, tt.expectedError, repo.Create(context.TODO(), key))
+			assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEncryptionKeyRepository_FindByID(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta("SELECT id, kind, master_key_id, wrapped_key, created_at FROM encryption_keys WHERE id = ?")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.EncryptionKey, error)
+	}{
+		{
+			name: "Should return the key",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("blind-index").
+					WillReturnRows(sqlmock.NewRows(encryptionKeyColumns).
+						AddRow("blind-index", "index", "master-1", []byte("wrapped"), now))
+			},
+			assertFn: func(key *entity.EncryptionKey, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, &entity.EncryptionKey{
+					ID:          "blind-index",
+					Kind:        entity.EncryptionKeyKindIndex,
+					MasterKeyID: "master-1",
+					WrappedKey:  []byte("wrapped"),
+					CreatedAt:   now,
+				}, key)
+			},
+		},
+		{
+			name: "Should return not found error when the key does not exist",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("blind-index").
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(key *entity.EncryptionKey, err error) {
+				assert.Nil(t, key)
+				assert.Equal(t, entity.ErrEncryptionKeyNotFound, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependency := newRepoDependency()
+			tt.mockDependency(dependency)
+
+			repo := repository.NewEncryptionKeyRepository(dependency.mockedDB)
+			tt.assertFn(repo.FindByID(context.TODO(), "blind-index"))
+			assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEncryptionKeyRepository_GetLatest(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta(`
+		SELECT id, kind, master_key_id, wrapped_key, created_at
+		FROM encryption_keys
+		WHERE kind = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`)
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.EncryptionKey, error)
+	}{
+		{
+			name: "Should return the key created last",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(entity.EncryptionKeyKindData).
+					WillReturnRows(sqlmock.NewRows(encryptionKeyColumns).
+						AddRow("dk-2", "data", "master-1", []byte("wrapped"), now))
+			},
+			assertFn: func(key *entity.EncryptionKey, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "dk-2", key.ID)
+				assert.Equal(t, entity.EncryptionKeyKindData, key.Kind)
+			},
+		},
+		{
+			name: "Should return not found error when there is no key of the kind",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(entity.EncryptionKeyKindData).
+					WillReturnRows(sqlmock.NewRows(encryptionKeyColumns))
+			},
+			assertFn: func(key *entity.EncryptionKey, err error) {
+				assert.Nil(t, key)
+				assert.Equal(t, entity.ErrEncryptionKeyNotFound, err)
+			},
+		},
+		{
+			name: "Should return error when query fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(entity.EncryptionKeyKindData).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(key *entity.EncryptionKey, err error) {
+				assert.Nil(t, key)
+				assert.Equal(t, sql.ErrConnDone, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependency := newRepoDependency()
+			tt.mockDependency(dependency)
+
+			repo := repository.NewEncryptionKeyRepository(dependency.mockedDB)
+			tt.assertFn(repo.GetLatest(context.TODO(), entity.EncryptionKeyKindData))
+			assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEncryptionKeyRepository_ListWrappedByOther(t *testing.T) {
+	now := time.Now()
+	dependency := newRepoDependency()
+	dependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta(`
+			SELECT id, kind, master_key_id, wrapped_key, created_at
+			FROM encryption_keys
+			WHERE master_key_id <> ?
+			ORDER BY created_at, id
+		`)).
+		WithArgs("master-2").
+		WillReturnRows(sqlmock.NewRows(encryptionKeyColumns).
+			AddRow("blind-index", "index", "master-1", []byte("wrapped-1"), now.Add(-time.Hour)).
+			AddRow("dk-1", "data", "master-1", []byte("wrapped-2"), now))
+
+	repo := repository.NewEncryptionKeyRepository(dependency.mockedDB)
+	keys, err := repo.ListWrappedByOther(context.TODO(), "master-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.EncryptionKey{
+		{ID: "blind-index", Kind: entity.EncryptionKeyKindIndex, MasterKeyID: "master-1", WrappedKey: []byte("wrapped-1"), CreatedAt: now.Add(-time.Hour)},
+		{ID: "dk-1", Kind: entity.EncryptionKeyKindData, MasterKeyID: "master-1", WrappedKey: []byte("wrapped-2"), CreatedAt: now},
+	}, keys)
+	assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestEncryptionKeyRepository_UpdateWrapping(t *testing.T) {
+	dependency := newRepoDependency()
+	dependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("UPDATE encryption_keys SET master_key_id = ?, wrapped_key = ? WHERE id = ?")).
+		WithArgs("master-2", []byte("rewrapped"), "dk-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewEncryptionKeyRepository(dependency.mockedDB)
+	err := repo.UpdateWrapping(context.TODO(), &entity.EncryptionKey{ID: "dk-1", MasterKeyID: "master-2", WrappedKey: []byte("rewrapped")})
+	assert.NoError(t, err)
+	assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+}
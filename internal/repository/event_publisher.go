@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// eventEnvelope is the representation of an outbox event delivered to other services.
+// Consumers should use the ID to discard duplicates, as events are delivered at least once.
+type eventEnvelope struct {
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// newEventEnvelope wraps an outbox event into its delivered representation
+func newEventEnvelope(event *entity.OutboxEvent) eventEnvelope {
+	return eventEnvelope{
+		ID:        event.ID,
+		Type:      string(event.EventType),
+		CreatedAt: event.CreatedAt.UTC(),
+		Payload:   event.Payload,
+	}
+}
+
+// webhookEventPublisher implements the EventPublisher interface by posting events to an HTTP endpoint
+type webhookEventPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookEventPublisher creates a publisher that posts every event as JSON to the given URL
+func NewWebhookEventPublisher(url string, client *http.Client) *webhookEventPublisher {
+	return &webhookEventPublisher{
+		url:    url,
+		client: client,
+	}
+}
+
+// Publish posts the event to the webhook URL. Any response other than 2xx is a failed delivery.
+func (w *webhookEventPublisher) Publish(ctx context.Context, event *entity.OutboxEvent) error {
+	body, err := json.Marshal(newEventEnvelope(event))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", strconv.FormatUint(event.ID, 10))
+	req.Header.Set("X-Event-Type", string(event.EventType))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// fileEventPublisher implements the EventPublisher interface by appending events to a JSON Lines file
+type fileEventPublisher struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileEventPublisher creates a publisher that appends every event as a line of JSON to the given file
+func NewFileEventPublisher(path string) *fileEventPublisher {
+	return &fileEventPublisher{
+		path: path,
+	}
+}
+
+// Publish appends the event to the file, creating the file if it does not exist
+func (f *fileEventPublisher) Publish(ctx context.Context, event *entity.OutboxEvent) error {
+	line, err := json.Marshal(newEventEnvelope(event))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
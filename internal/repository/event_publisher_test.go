@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var dummyOutboxEvent = &entity.OutboxEvent{
+	ID:        7,
+	EventType: entity.EventTypeOTPValidated,
+	Payload:   []byte(`{"type":"otp.validated","user_id":"user123"}`),
+	CreatedAt: time.Date(2025, 11, 24, 9, 0, 0, 0, time.UTC),
+}
+
+const dummyEventEnvelope = `{"id":7,"type":"otp.validated","created_at":"2025-11-24T09:00:00Z","payload":{"type":"otp.validated","user_id":"user123"}}`
+
+func TestWebhookEventPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		assertFn   func(error)
+	}{
+		{
+			name:       "Should post the event to the webhook",
+			statusCode: http.StatusNoContent,
+			assertFn: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:       "Should return error when the webhook does not respond with 2xx",
+			statusCode: http.StatusServiceUnavailable,
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "webhook responded with status 503")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, dummyEventEnvelope, string(body))
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, "7", r.Header.Get("X-Event-Id"))
+				assert.Equal(t, "otp.validated", r.Header.Get("X-Event-Type"))
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			publisher := repository.NewWebhookEventPublisher(server.URL, server.Client())
+			tt.assertFn(publisher.Publish(context.TODO(), dummyOutboxEvent))
+		})
+	}
+}
+
+func TestFileEventPublisher_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher := repository.NewFileEventPublisher(path)
+
+	assert.NoError(t, publisher.Publish(context.TODO(), dummyOutboxEvent))
+	assert.NoError(t, publisher.Publish(context.TODO(), dummyOutboxEvent))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)))
+		assert.JSONEq(t, dummyEventEnvelope, line)
+	}
+}
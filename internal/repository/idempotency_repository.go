@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// idempotencyRepository implements the IdempotencyRepository interface
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+// NewIdempotencyRepository creates a new instance of idempotencyRepository
+func NewIdempotencyRepository(db *sqlx.DB) *idempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new idempotency record into the database.
+// Returns entity.ErrIdempotencyKeyDuplicate if a record with the same key already exists.
+func (i *idempotencyRepository) Create(ctx context.Context, record *entity.IdempotencyRecord) error {
+	const query = `
+		INSERT INTO idempotency_keys (idempotency_key, request_hash, response_status, response_body, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := getExecutor(ctx, i.db).ExecContext(
+		ctx,
+		query,
+		record.Key,
+		record.RequestHash,
+		record.ResponseStatus,
+		record.ResponseBody,
+		record.ExpiresAt,
+	)
+	if err != nil {
+		// Check if the error is a unique constraint violation
+		if isUniqueConstraintViolation(err) {
+			return entity.ErrIdempotencyKeyDuplicate
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindByKey retrieves an idempotency record by its key from the database.
+// Returns entity.ErrIdempotencyRecordNotFound if no record exists for the key.
+func (i *idempotencyRepository) FindByKey(ctx context.Context, key string) (*entity.IdempotencyRecord, error) {
+	const query = `
+		SELECT idempotency_key, request_hash, response_status, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE idempotency_key = ?
+	`
+
+	var recordRow idempotencyRecordRow
+	if err := getExecutor(ctx, i.db).GetContext(ctx, &recordRow, query, key); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrIdempotencyRecordNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrIdempotencyRecordNotFound
+		}
+		return nil, err
+	}
+
+	return recordRow.ToEntity(), nil
+}
+
+// Update stores the response of the original request on an idempotency record, along with when it expires
+func (i *idempotencyRepository) Update(ctx context.Context, record *entity.IdempotencyRecord) error {
+	const query = `
+		UPDATE idempotency_keys
+		SET response_status = ?, response_body = ?, expires_at = ?
+		WHERE idempotency_key = ?
+	`
+	_, err := getExecutor(ctx, i.db).ExecContext(
+		ctx,
+		query,
+		record.ResponseStatus,
+		record.ResponseBody,
+		record.ExpiresAt,
+		record.Key,
+	)
+
+	return err
+}
+
+// DeleteByKey removes an idempotency record from the database
+func (i *idempotencyRepository) DeleteByKey(ctx context.Context, key string) error {
+	const query = `
+		DELETE FROM idempotency_keys
+		WHERE idempotency_key = ?
+	`
+	_, err := getExecutor(ctx, i.db).ExecContext(ctx, query, key)
+
+	return err
+}
+
+// DeleteByKeyIfExpired removes an idempotency record if it expired at the given time,
+// and reports whether it did, so that only one of several concurrent requests claims an expired key again.
+func (i *idempotencyRepository) DeleteByKeyIfExpired(ctx context.Context, key string, now time.Time) (bool, error) {
+	const query = `
+		DELETE FROM idempotency_keys
+		WHERE idempotency_key = ? AND expires_at <= ?
+	`
+	result, err := getExecutor(ctx, i.db).ExecContext(ctx, query, key, now)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteExpiredBefore deletes up to limit idempotency records that expired before the given time,
+// and returns the number of records it deleted.
+func (i *idempotencyRepository) DeleteExpiredBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	// PostgreSQL and SQLite have no DELETE ... LIMIT, so the records to delete are selected first
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE idempotency_key IN (SELECT idempotency_key FROM idempotency_keys WHERE expires_at < ? LIMIT ?)
+	`
+	if dialectOf(i.db) == dialectMySQL {
+		query = `
+			DELETE FROM idempotency_keys
+			WHERE expires_at < ?
+			LIMIT ?
+		`
+	}
+
+	result, err := getExecutor(ctx, i.db).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,247 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepository_Create(t *testing.T) {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	record := &entity.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: "hash-1",
+		ExpiresAt:   expiresAt,
+	}
+
+	expectedQuery := regexp.QuoteMeta(`
+		INSERT INTO idempotency_keys (idempotency_key, request_hash, response_status, response_body, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+
+	tests := []struct {
+		name          string
+		mockErr       error
+		expectedError error
+	}{
+		{
+			name: "Should successfully create a new idempotency record",
+		},
+		{
+			name:          "Should return duplicate error when key already exists",
+			mockErr:       &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'key-1' for key 'PRIMARY'"},
+			expectedError: entity.ErrIdempotencyKeyDuplicate,
+		},
+		{
+			name:          "Should return duplicate error when key already exists on PostgreSQL",
+			mockErr:       &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "idempotency_keys_pkey"`},
+			expectedError: entity.ErrIdempotencyKeyDuplicate,
+		},
+		{
+			name:          "Should return error when DB fails",
+			mockErr:       sql.ErrConnDone,
+			expectedError: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewIdempotencyRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			expectation := repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs("key-1", "hash-1", 0, []byte(nil), expiresAt)
+			if tt.mockErr != nil {
+				expectation.WillReturnError(tt.mockErr)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			assert.Equal(t, tt.expectedError, repo.Create(context.TODO(), record))
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyRepository_FindByKey(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta(`
+		SELECT idempotency_key, request_hash, response_status, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE idempotency_key = ?
+	`)
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*testing.T, *entity.IdempotencyRecord, error)
+	}{
+		{
+			name: "Should return idempotency record successfully",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("key-1").
+					WillReturnRows(sqlmock.NewRows([]string{
+						"idempotency_key", "request_hash", "response_status", "response_body", "created_at", "expires_at",
+					}).AddRow(
+						"key-1", "hash-1", 200, []byte(`{"otp":"123456"}`), now, now.Add(10*time.Minute),
+					))
+			},
+			assertFn: func(t *testing.T, record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "hash-1", record.RequestHash)
+				assert.Equal(t, 200, record.ResponseStatus)
+				assert.Equal(t, []byte(`{"otp":"123456"}`), record.ResponseBody)
+			},
+		},
+		{
+			name: "Should return ErrIdempotencyRecordNotFound when no row found",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("key-1").
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(t *testing.T, record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Equal(t, entity.ErrIdempotencyRecordNotFound, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewIdempotencyRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			record, err := repo.FindByKey(context.TODO(), "key-1")
+			tt.assertFn(t, record, err)
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyRepository_Update(t *testing.T) {
+	expiresAt := time.Date(2025, 11, 19, 8, 40, 0, 0, time.UTC)
+	expectedQuery := regexp.QuoteMeta(`
+		UPDATE idempotency_keys
+		SET response_status = ?, response_body = ?, expires_at = ?
+		WHERE idempotency_key = ?
+	`)
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewIdempotencyRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(expectedQuery).
+		WithArgs(200, []byte(`{}`), expiresAt, "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Update(context.TODO(), &entity.IdempotencyRecord{Key: "key-1", ResponseStatus: 200, ResponseBody: []byte(`{}`), ExpiresAt: expiresAt})
+	assert.NoError(t, err)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_DeleteByKey(t *testing.T) {
+	expectedQuery := regexp.QuoteMeta(`
+		DELETE FROM idempotency_keys
+		WHERE idempotency_key = ?
+	`)
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewIdempotencyRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(expectedQuery).
+		WithArgs("key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.DeleteByKey(context.TODO(), "key-1"))
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_DeleteExpiredBefore(t *testing.T) {
+	before := time.Now()
+
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName, func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewIdempotencyRepository(repositoryDependency.mockedDB)
+			defer repositoryDependency.mockedDB.Close()
+
+			expectedQuery := regexp.QuoteMeta("DELETE FROM idempotency_keys WHERE expires_at < ? LIMIT ?")
+			if repositoryDependency.isPostgres() {
+				expectedQuery = regexp.QuoteMeta("DELETE FROM idempotency_keys WHERE idempotency_key IN (SELECT idempotency_key FROM idempotency_keys WHERE expires_at < ? LIMIT ?)")
+			}
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs(before, 500).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs(before, 500).
+				WillReturnError(sql.ErrConnDone)
+
+			purged, err := repo.DeleteExpiredBefore(context.TODO(), before, 500)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), purged)
+
+			purged, err = repo.DeleteExpiredBefore(context.TODO(), before, 500)
+			assert.Equal(t, sql.ErrConnDone, err)
+			assert.Zero(t, purged)
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyRepository_DeleteByKeyIfExpired(t *testing.T) {
+	expectedQuery := regexp.QuoteMeta(`
+		DELETE FROM idempotency_keys
+		WHERE idempotency_key = ? AND expires_at <= ?
+	`)
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewIdempotencyRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(expectedQuery).
+		WithArgs("key-1", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repositoryDependency.mockedSQL.
+		ExpectExec(expectedQuery).
+		WithArgs("key-1", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deleted, err := repo.DeleteByKeyIfExpired(context.TODO(), "key-1", now)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	// The record has been replaced by a concurrent request since it was read
+	deleted, err = repo.DeleteByKeyIfExpired(context.TODO(), "key-1", now)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// jobLeaseRepository implements the JobLeaseRepository interface
+type jobLeaseRepository struct {
+	db *sqlx.DB
+}
+
+// NewJobLeaseRepository creates a new instance of jobLeaseRepository
+func NewJobLeaseRepository(db *sqlx.DB) *jobLeaseRepository {
+	return &jobLeaseRepository{
+		db: db,
+	}
+}
+
+// TryAcquire acquires or renews the lease of a job for the given holder until expiresAt,
+// and reports whether the holder owns the lease afterwards. The lease is taken over
+// from another holder only once it has lapsed at the given time.
+func (j *jobLeaseRepository) TryAcquire(ctx context.Context, jobName string, holderID string, now time.Time, expiresAt time.Time) (bool, error) {
+	const (
+		// The assignments are evaluated from left to right, so expires_at is only
+		// moved forward when holder_id was (or already is) the acquiring holder.
+		mysqlQuery = `
+			INSERT INTO job_leases (job_name, holder_id, expires_at)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				holder_id = IF(holder_id = VALUES(holder_id) OR expires_at <= ?, VALUES(holder_id), holder_id),
+				expires_at = IF(holder_id = VALUES(holder_id), VALUES(expires_at), expires_at)
+		`
+		// The lease is only updated when it is held by the acquiring holder or has lapsed.
+		// SQLite accepts the upsert syntax of PostgreSQL.
+		postgresQuery = `
+			INSERT INTO job_leases (job_name, holder_id, expires_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (job_name) DO UPDATE SET holder_id = EXCLUDED.holder_id, expires_at = EXCLUDED.expires_at
+			WHERE job_leases.holder_id = EXCLUDED.holder_id OR job_leases.expires_at <= ?
+		`
+	)
+	query := mysqlQuery
+	if dialectOf(j.db) != dialectMySQL {
+		query = postgresQuery
+	}
+	if _, err := getExecutor(ctx, j.db).ExecContext(ctx, query, jobName, holderID, expiresAt, now); err != nil {
+		return false, err
+	}
+
+	const selectQuery = `
+		SELECT holder_id
+		FROM job_leases
+		WHERE job_name = ?
+	`
+	var currentHolderID string
+	if err := getExecutor(ctx, j.db).GetContext(ctx, &currentHolderID, selectQuery, jobName); err != nil {
+		return false, err
+	}
+
+	return currentHolderID == holderID, nil
+}
+
+// Release gives up the lease of a job if it is held by the given holder,
+// so another instance can take over without waiting for it to lapse.
+func (j *jobLeaseRepository) Release(ctx context.Context, jobName string, holderID string) error {
+	const query = `
+		DELETE FROM job_leases
+		WHERE job_name = ? AND holder_id = ?
+	`
+	_, err := getExecutor(ctx, j.db).ExecContext(ctx, query, jobName, holderID)
+
+	return err
+}
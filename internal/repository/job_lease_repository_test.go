@@ -0,0 +1,137 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobLeaseRepository_TryAcquire(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(30 * time.Second)
+
+	expectedUpsertQuery := regexp.QuoteMeta(`
+		INSERT INTO job_leases (job_name, holder_id, expires_at)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			holder_id = IF(holder_id = VALUES(holder_id) OR expires_at <= ?, VALUES(holder_id), holder_id),
+			expires_at = IF(holder_id = VALUES(holder_id), VALUES(expires_at), expires_at)
+	`)
+	expectedSelectQuery := regexp.QuoteMeta("SELECT holder_id FROM job_leases WHERE job_name = ?")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(bool, error)
+	}{
+		{
+			name: "Should report the lease as acquired when the holder owns it",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedUpsertQuery).
+					WithArgs("sweeper", "instance-1", expiresAt, now).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				dependency.mockedSQL.
+					ExpectQuery(expectedSelectQuery).
+					WithArgs("sweeper").
+					WillReturnRows(sqlmock.NewRows([]string{"holder_id"}).AddRow("instance-1"))
+			},
+			assertFn: func(acquired bool, err error) {
+				assert.Nil(t, err)
+				assert.True(t, acquired)
+			},
+		},
+		{
+			name: "Should report the lease as not acquired when another holder owns it",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedUpsertQuery).
+					WithArgs("sweeper", "instance-1", expiresAt, now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				dependency.mockedSQL.
+					ExpectQuery(expectedSelectQuery).
+					WithArgs("sweeper").
+					WillReturnRows(sqlmock.NewRows([]string{"holder_id"}).AddRow("instance-2"))
+			},
+			assertFn: func(acquired bool, err error) {
+				assert.Nil(t, err)
+				assert.False(t, acquired)
+			},
+		},
+		{
+			name: "Should return error when upsert fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedUpsertQuery).
+					WithArgs("sweeper", "instance-1", expiresAt, now).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(acquired bool, err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+				assert.False(t, acquired)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewJobLeaseRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			tt.assertFn(repo.TryAcquire(context.TODO(), "sweeper", "instance-1", now, expiresAt))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestJobLeaseRepository_TryAcquire_Postgres(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(30 * time.Second)
+
+	repositoryDependency := newRepoDependencyFor("postgres")
+	repo := repository.NewJobLeaseRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta(`
+			INSERT INTO job_leases (job_name, holder_id, expires_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (job_name) DO UPDATE SET holder_id = EXCLUDED.holder_id, expires_at = EXCLUDED.expires_at
+			WHERE job_leases.holder_id = EXCLUDED.holder_id OR job_leases.expires_at <= ?
+		`)).
+		WithArgs("sweeper", "instance-1", expiresAt, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT holder_id FROM job_leases WHERE job_name = ?")).
+		WithArgs("sweeper").
+		WillReturnRows(sqlmock.NewRows([]string{"holder_id"}).AddRow("instance-2"))
+
+	acquired, err := repo.TryAcquire(context.TODO(), "sweeper", "instance-1", now, expiresAt)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestJobLeaseRepository_Release(t *testing.T) {
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewJobLeaseRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("DELETE FROM job_leases WHERE job_name = ? AND holder_id = ?")).
+		WithArgs("sweeper", "instance-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Release(context.TODO(), "sweeper", "instance-1"))
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
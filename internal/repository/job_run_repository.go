@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// jobRunRepository implements the JobRunRepository interface
+type jobRunRepository struct {
+	db *sqlx.DB
+}
+
+// NewJobRunRepository creates a new instance of jobRunRepository
+func NewJobRunRepository(db *sqlx.DB) *jobRunRepository {
+	return &jobRunRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new job run into the database and sets the ID of the given run
+func (j *jobRunRepository) Create(ctx context.Context, run *entity.JobRun) error {
+	const query = `
+		INSERT INTO job_runs (job_name, holder_id, status, started_at)
+		VALUES (?, ?, ?, ?)
+	`
+	id, err := insertReturningID(ctx, j.db, query, run.JobName, run.HolderID, run.Status, run.StartedAt)
+	if err != nil {
+		return err
+	}
+	run.ID = id
+
+	return nil
+}
+
+// Update stores the outcome of a job run
+func (j *jobRunRepository) Update(ctx context.Context, run *entity.JobRun) error {
+	const query = `
+		UPDATE job_runs
+		SET status = ?, error = ?, finished_at = ?
+		WHERE id = ?
+	`
+	_, err := getExecutor(ctx, j.db).ExecContext(
+		ctx,
+		query,
+		run.Status,
+		nullableString(run.Error),
+		run.FinishedAt,
+		run.ID,
+	)
+
+	return err
+}
+
+// DeleteStartedBefore deletes up to limit runs of a job started before the given time,
+// and returns the number of runs it deleted.
+func (j *jobRunRepository) DeleteStartedBefore(ctx context.Context, jobName string, before time.Time, limit int) (int64, error) {
+	// PostgreSQL and SQLite have no DELETE ... LIMIT, so the runs to delete are selected first
+	query := `
+		DELETE FROM job_runs
+		WHERE id IN (SELECT id FROM job_runs WHERE job_name = ? AND started_at < ? LIMIT ?)
+	`
+	if dialectOf(j.db) == dialectMySQL {
+		query = `
+			DELETE FROM job_runs
+			WHERE job_name = ? AND started_at < ?
+			LIMIT ?
+		`
+	}
+
+	result, err := getExecutor(ctx, j.db).ExecContext(ctx, query, jobName, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobRunRepository_Create(t *testing.T) {
+	startedAt := time.Now()
+	expectedQuery := regexp.QuoteMeta("INSERT INTO job_runs (job_name, holder_id, status, started_at) VALUES (?, ?, ?, ?)")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.JobRun, error)
+	}{
+		{
+			name: "Should create the job run and set its ID",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs("sweeper", "instance-1", entity.JobRunStatusRunning, startedAt).
+					WillReturnResult(sqlmock.NewResult(9, 1))
+			},
+			assertFn: func(run *entity.JobRun, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(9), run.ID)
+			},
+		},
+		{
+			name: "Should return error when insert fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs("sweeper", "instance-1", entity.JobRunStatusRunning, startedAt).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(run *entity.JobRun, err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+				assert.Zero(t, run.ID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewJobRunRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			run := &entity.JobRun{
+				JobName:   "sweeper",
+				HolderID:  "instance-1",
+				Status:    entity.JobRunStatusRunning,
+				StartedAt: startedAt,
+			}
+			tt.assertFn(run, repo.Create(context.TODO(), run))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestJobRunRepository_Update(t *testing.T) {
+	finishedAt := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewJobRunRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("UPDATE job_runs SET status = ?, error = ?, finished_at = ? WHERE id = ?")).
+		WithArgs(entity.JobRunStatusFailed, "db error", finishedAt, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Update(context.TODO(), &entity.JobRun{
+		ID:         9,
+		Status:     entity.JobRunStatusFailed,
+		Error:      "db error",
+		FinishedAt: &finishedAt,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestJobRunRepository_DeleteStartedBefore(t *testing.T) {
+	before := time.Now()
+
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName, func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewJobRunRepository(repositoryDependency.mockedDB)
+			defer repositoryDependency.mockedDB.Close()
+
+			expectedQuery := regexp.QuoteMeta("DELETE FROM job_runs WHERE job_name = ? AND started_at < ? LIMIT ?")
+			if repositoryDependency.isPostgres() {
+				expectedQuery = regexp.QuoteMeta("DELETE FROM job_runs WHERE id IN (SELECT id FROM job_runs WHERE job_name = ? AND started_at < ? LIMIT ?)")
+			}
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs("sweeper", before, 100).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs("sweeper", before, 100).
+				WillReturnError(sql.ErrConnDone)
+
+			purged, err := repo.DeleteStartedBefore(context.TODO(), "sweeper", before, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), purged)
+
+			purged, err = repo.DeleteStartedBefore(context.TODO(), "sweeper", before, 100)
+			assert.Equal(t, sql.ErrConnDone, err)
+			assert.Zero(t, purged)
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/imansohibul/otp-service/internal/repository/memory"
+	"github.com/imansohibul/otp-service/internal/repository/repositorytest"
+	"github.com/imansohibul/otp-service/internal/usecase"
+)
+
+func TestOTPRepository_Conformance(t *testing.T) {
+	repositorytest.RunOTPRepositorySuite(t, func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager) {
+		store := memory.NewStore()
+		return memory.NewOTPRepository(store), memory.NewTransactionManager(store, nil)
+	}, repositorytest.Limitations{})
+}
@@ -0,0 +1,350 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// otpRepository is the in-memory implementation of the OTPRepository interface
+type otpRepository struct {
+	store *Store
+}
+
+// NewOTPRepository creates a new instance of otpRepository
+func NewOTPRepository(store *Store) *otpRepository {
+	return &otpRepository{
+		store: store,
+	}
+}
+
+// Create stores a new OTP and sets the ID of the given OTP. The creation time is set by the store,
+// like the column default of the database. Returns entity.ErrOTPDuplicate if the user already has an OTP with the same code.
+func (o *otpRepository) Create(ctx context.Context, otp *entity.OTP) error {
+	return o.store.run(ctx, func(tx *transaction) error {
+		// Like a unique index, a concurrent transaction creating the same code blocks until it ends
+		if err := o.store.lock(ctx, tx, lockKey{userID: otp.UserID, otpCode: otp.OTPCode}); err != nil {
+			return err
+		}
+		for _, existing := range o.store.all(tx) {
+			if existing.UserID == otp.UserID && existing.OTPCode == otp.OTPCode {
+				return entity.ErrOTPDuplicate
+			}
+		}
+
+		o.store.lastID++
+		created := clone(otp)
+		created.ID = o.store.lastID
+		created.CreatedAt = time.Now()
+		created.ValidatedAt = nil
+		created.ValidatedSessionHash = ""
+		created.RevokedAt = nil
+		created.RevokeReason = ""
+		if err := o.store.lock(ctx, tx, lockKey{id: created.ID}); err != nil {
+			return err
+		}
+		o.store.put(tx, created)
+		otp.ID = created.ID
+
+		return nil
+	})
+}
+
+// FindByUserIDAndCode retrieves an OTP by user ID and OTP code
+func (o *otpRepository) FindByUserIDAndCode(ctx context.Context, userID string, otpCode string) (*entity.OTP, error) {
+	return o.find(ctx, func(otps []*entity.OTP) *entity.OTP {
+		for _, otp := range otps {
+			if otp.UserID == userID && otp.OTPCode == otpCode {
+				return otp
+			}
+		}
+		return nil
+	})
+}
+
+// Update stores the status, validated_at and validated_session_hash fields of an OTP, provided it is still created.
+// Returns false if the OTP was validated, expired or revoked in the meantime.
+func (o *otpRepository) Update(ctx context.Context, otp *entity.OTP) (bool, error) {
+	var updated bool
+	err := o.update(ctx, otp.ID, func(stored *entity.OTP) bool {
+		if stored.Status != entity.OTPStatusCreated {
+			return false
+		}
+		stored.Status = otp.Status
+		stored.ValidatedAt = otp.ValidatedAt
+		stored.ValidatedSessionHash = otp.ValidatedSessionHash
+		updated = true
+		return true
+	})
+
+	return updated, err
+}
+
+// GetLastByUserID retrieves the most recent OTP of a user created at or after createdFrom.
+// Returns entity.ErrOTPNotFound if no such OTP exists for the user.
+func (o *otpRepository) GetLastByUserID(ctx context.Context, userID string, createdFrom time.Time) (*entity.OTP, error) {
+	return o.find(ctx, func(otps []*entity.OTP) *entity.OTP {
+		var last *entity.OTP
+		for _, otp := range otps {
+			if otp.UserID == userID && !otp.CreatedAt.Before(createdFrom) && (last == nil || !otp.CreatedAt.Before(last.CreatedAt)) {
+				last = otp
+			}
+		}
+		return last
+	})
+}
+
+// FindByID retrieves an OTP by its ID. Returns entity.ErrOTPNotFound if no OTP exists with the ID.
+func (o *otpRepository) FindByID(ctx context.Context, id uint64) (*entity.OTP, error) {
+	return o.find(ctx, func(otps []*entity.OTP) *entity.OTP {
+		for _, otp := range otps {
+			if otp.ID == id {
+				return otp
+			}
+		}
+		return nil
+	})
+}
+
+// FindNextByUserID retrieves the first OTP issued to the user of the given OTP after it.
+// Returns entity.ErrOTPNotFound if no later OTP exists for the user.
+func (o *otpRepository) FindNextByUserID(ctx context.Context, after *entity.OTP) (*entity.OTP, error) {
+	return o.find(ctx, func(otps []*entity.OTP) *entity.OTP {
+		for _, otp := range otps {
+			if otp.UserID == after.UserID && otp.ID > after.ID {
+				return otp
+			}
+		}
+		return nil
+	})
+}
+
+// List retrieves up to limit OTPs matching the filter with an ID lower than beforeID, newest first.
+// A zero beforeID starts from the most recent OTP.
+func (o *otpRepository) List(ctx context.Context, filter entity.OTPFilter, beforeID uint64, limit int) ([]*entity.OTP, error) {
+	return o.list(ctx, func(otps []*entity.OTP) []*entity.OTP {
+		var matching []*entity.OTP
+		for i := len(otps) - 1; i >= 0 && len(matching) < limit; i-- {
+			otp := otps[i]
+			switch {
+			case beforeID != 0 && otp.ID >= beforeID,
+				filter.UserID != "" && otp.UserID != filter.UserID,
+				filter.Status != 0 && otp.Status != filter.Status,
+				filter.Purpose != "" && otp.Purpose != filter.Purpose,
+				filter.CreatedFrom != nil && otp.CreatedAt.Before(*filter.CreatedFrom),
+				filter.CreatedTo != nil && !otp.CreatedAt.Before(*filter.CreatedTo):
+				continue
+			}
+			matching = append(matching, otp)
+		}
+		return matching
+	})
+}
+
+// ListExpirable retrieves and locks up to limit OTPs that are still created but expired at the given time.
+// OTPs locked by a concurrent transaction are skipped. It must be called within a transaction.
+// The creation time bounds are not needed to narrow the search of the OTPs in memory.
+func (o *otpRepository) ListExpirable(ctx context.Context, now time.Time, createdFrom time.Time, createdTo time.Time, limit int) ([]*entity.OTP, error) {
+	var expirable []*entity.OTP
+	err := o.store.run(ctx, func(tx *transaction) error {
+		for _, otp := range o.store.all(tx) {
+			if len(expirable) == limit {
+				break
+			}
+			if otp.Status != entity.OTPStatusCreated || otp.ExpiresAt.After(now) || o.store.isLockedByOther(tx, otp.ID) {
+				continue
+			}
+			// The row is free, so locking it does not wait
+			if err := o.store.lock(ctx, tx, lockKey{id: otp.ID}); err != nil {
+				return err
+			}
+			expirable = append(expirable, clone(otp))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expirable, nil
+}
+
+// MarkExpired marks the given OTPs as expired
+func (o *otpRepository) MarkExpired(ctx context.Context, otps []*entity.OTP) error {
+	return o.store.run(ctx, func(tx *transaction) error {
+		for _, otp := range otps {
+			if _, err := o.updateLocked(ctx, tx, otp.ID, func(stored *entity.OTP) bool {
+				stored.Status = entity.OTPStatusExpired
+				return true
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListActiveByUserID retrieves the OTPs of a user created at or after createdFrom that are still created
+// and not expired at the given time, oldest first.
+func (o *otpRepository) ListActiveByUserID(ctx context.Context, userID string, now time.Time, createdFrom time.Time) ([]*entity.OTP, error) {
+	return o.list(ctx, func(otps []*entity.OTP) []*entity.OTP {
+		var active []*entity.OTP
+		for _, otp := range otps {
+			if otp.UserID == userID && otp.Status == entity.OTPStatusCreated && otp.ExpiresAt.After(now) && !otp.CreatedAt.Before(createdFrom) {
+				active = append(active, otp)
+			}
+		}
+		return active
+	})
+}
+
+// IncrementResendCount counts a resend of the given OTP, provided it is still created
+// and has resends left. Returns false otherwise.
+func (o *otpRepository) IncrementResendCount(ctx context.Context, otp *entity.OTP) (bool, error) {
+	var incremented bool
+	err := o.update(ctx, otp.ID, func(stored *entity.OTP) bool {
+		if stored.Status != entity.OTPStatusCreated || stored.ResendCount >= stored.ResendLimit {
+			return false
+		}
+		stored.ResendCount++
+		incremented = true
+		return true
+	})
+
+	return incremented, err
+}
+
+// MarkRevoked marks the given OTP as revoked at the given time, provided it is still created.
+// Returns false if the OTP was validated, expired or revoked in the meantime.
+func (o *otpRepository) MarkRevoked(ctx context.Context, otp *entity.OTP, revokedAt time.Time, reason string) (bool, error) {
+	var revoked bool
+	err := o.update(ctx, otp.ID, func(stored *entity.OTP) bool {
+		if stored.Status != entity.OTPStatusCreated {
+			return false
+		}
+		stored.Status = entity.OTPStatusRevoked
+		stored.RevokedAt = &revokedAt
+		stored.RevokeReason = reason
+		revoked = true
+		return true
+	})
+
+	return revoked, err
+}
+
+// DeleteExpiredBefore deletes up to limit OTPs that expired before the given time,
+// and returns the number of OTPs it deleted.
+// The creation time bounds are not needed to narrow the search of the OTPs in memory.
+func (o *otpRepository) DeleteExpiredBefore(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error) {
+	var deleted int64
+	err := o.store.run(ctx, func(tx *transaction) error {
+		for _, otp := range o.store.all(tx) {
+			if deleted == int64(limit) {
+				break
+			}
+			if !otp.ExpiresAt.Before(before) {
+				continue
+			}
+			if err := o.store.lock(ctx, tx, lockKey{id: otp.ID}); err != nil {
+				return err
+			}
+			// The OTP may have changed while waiting for the lock
+			if current, found := o.store.get(tx, otp.ID); !found || !current.ExpiresAt.Before(before) {
+				continue
+			}
+			tx.otps[otp.ID] = nil
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// CountByStatus counts the stored OTPs of each status. Statuses without OTPs are omitted.
+func (o *otpRepository) CountByStatus(ctx context.Context) (map[entity.OTPStatus]int64, error) {
+	counts := make(map[entity.OTPStatus]int64)
+	err := o.store.run(ctx, func(tx *transaction) error {
+		for _, otp := range o.store.all(tx) {
+			counts[otp.Status]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// find returns a copy of the OTP selected among the OTPs seen by the transaction in ctx, in ID order.
+// Returns entity.ErrOTPNotFound if none is selected.
+func (o *otpRepository) find(ctx context.Context, selectOTP func(otps []*entity.OTP) *entity.OTP) (*entity.OTP, error) {
+	var found *entity.OTP
+	err := o.store.run(ctx, func(tx *transaction) error {
+		if otp := selectOTP(o.store.all(tx)); otp != nil {
+			found = clone(otp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, entity.ErrOTPNotFound
+	}
+
+	return found, nil
+}
+
+// list returns copies of the OTPs selected among the OTPs seen by the transaction in ctx, in ID order
+func (o *otpRepository) list(ctx context.Context, selectOTPs func(otps []*entity.OTP) []*entity.OTP) ([]*entity.OTP, error) {
+	otps := []*entity.OTP{}
+	err := o.store.run(ctx, func(tx *transaction) error {
+		for _, otp := range selectOTPs(o.store.all(tx)) {
+			otps = append(otps, clone(otp))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return otps, nil
+}
+
+// update locks the OTP with the given ID and applies change to a copy of it, which is stored if change returns true.
+// Like an UPDATE statement, nothing happens if no OTP exists with the ID.
+func (o *otpRepository) update(ctx context.Context, id uint64, change func(stored *entity.OTP) bool) error {
+	return o.store.run(ctx, func(tx *transaction) error {
+		_, err := o.updateLocked(ctx, tx, id, change)
+		return err
+	})
+}
+
+// updateLocked is update within a transaction, with the store locked. It reports whether the OTP was changed.
+func (o *otpRepository) updateLocked(ctx context.Context, tx *transaction, id uint64, change func(stored *entity.OTP) bool) (bool, error) {
+	if _, found := o.store.get(tx, id); !found {
+		return false, nil
+	}
+	if err := o.store.lock(ctx, tx, lockKey{id: id}); err != nil {
+		return false, err
+	}
+
+	// Read the OTP again, another transaction may have changed it while waiting for the lock
+	stored, found := o.store.get(tx, id)
+	if !found {
+		return false, nil
+	}
+	changed := clone(stored)
+	if !change(changed) {
+		return false, nil
+	}
+	o.store.put(tx, changed)
+
+	return true, nil
+}
@@ -0,0 +1,251 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository/memory"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOTP(userID string, code string, expiresAt time.Time) *entity.OTP {
+	return &entity.OTP{
+		UserID:      userID,
+		OTPCode:     code,
+		Status:      entity.OTPStatusCreated,
+		ExpiresAt:   expiresAt,
+		ResendLimit: 1,
+	}
+}
+
+func TestOTPRepository_Create(t *testing.T) {
+	var repo usecase.OTPRepository = memory.NewOTPRepository(memory.NewStore())
+	ctx := context.TODO()
+	expiresAt := time.Now().Add(time.Minute)
+
+	first := newOTP("user123", "123456", expiresAt)
+	require.NoError(t, repo.Create(ctx, first))
+	assert.Equal(t, uint64(1), first.ID)
+
+	t.Run("Should return duplicate error when the user already has the code", func(t *testing.T) {
+		assert.ErrorIs(t, repo.Create(ctx, newOTP("user123", "123456", expiresAt)), entity.ErrOTPDuplicate)
+	})
+
+	t.Run("Should allow the same code for another user", func(t *testing.T) {
+		otp := newOTP("user456", "123456", expiresAt)
+		require.NoError(t, repo.Create(ctx, otp))
+		assert.Equal(t, uint64(2), otp.ID)
+	})
+
+	t.Run("Should not share the stored OTP with the caller", func(t *testing.T) {
+		first.Status = entity.OTPStatusValidated
+
+		stored, err := repo.FindByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.OTPStatusCreated, stored.Status)
+		assert.False(t, stored.CreatedAt.IsZero())
+	})
+}
+
+func TestOTPRepository_Find(t *testing.T) {
+	var repo usecase.OTPRepository = memory.NewOTPRepository(memory.NewStore())
+	ctx := context.TODO()
+	expiresAt := time.Now().Add(time.Minute)
+	createdFrom := time.Now().Add(-time.Minute)
+
+	first := newOTP("user123", "111111", expiresAt)
+	other := newOTP("user456", "222222", expiresAt)
+	last := newOTP("user123", "333333", expiresAt)
+	for _, otp := range []*entity.OTP{first, other, last} {
+		require.NoError(t, repo.Create(ctx, otp))
+	}
+
+	tests := []struct {
+		name       string
+		find       func() (*entity.OTP, error)
+		expectedID uint64
+		expectErr  error
+	}{
+		{
+			name:       "Should find the OTP by user ID and code",
+			find:       func() (*entity.OTP, error) { return repo.FindByUserIDAndCode(ctx, "user456", "222222") },
+			expectedID: other.ID,
+		},
+		{
+			name:      "Should return not found when the code belongs to another user",
+			find:      func() (*entity.OTP, error) { return repo.FindByUserIDAndCode(ctx, "user123", "222222") },
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name:       "Should return the last OTP of the user",
+			find:       func() (*entity.OTP, error) { return repo.GetLastByUserID(ctx, "user123", createdFrom) },
+			expectedID: last.ID,
+		},
+		{
+			name:      "Should return not found when the user has no OTP",
+			find:      func() (*entity.OTP, error) { return repo.GetLastByUserID(ctx, "unknown", createdFrom) },
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name:      "Should return not found when the last OTP was created before the given time",
+			find:      func() (*entity.OTP, error) { return repo.GetLastByUserID(ctx, "user123", expiresAt) },
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name:       "Should return the next OTP of the user",
+			find:       func() (*entity.OTP, error) { return repo.FindNextByUserID(ctx, first) },
+			expectedID: last.ID,
+		},
+		{
+			name:      "Should return not found when no OTP follows",
+			find:      func() (*entity.OTP, error) { return repo.FindNextByUserID(ctx, last) },
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name:      "Should return not found for an unknown ID",
+			find:      func() (*entity.OTP, error) { return repo.FindByID(ctx, 99) },
+			expectErr: entity.ErrOTPNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otp, err := tt.find()
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, otp.ID)
+		})
+	}
+}
+
+func TestOTPRepository_List(t *testing.T) {
+	var repo usecase.OTPRepository = memory.NewOTPRepository(memory.NewStore())
+	ctx := context.TODO()
+	expiresAt := time.Now().Add(time.Minute)
+
+	for i, userID := range []string{"user123", "user456", "user123", "user123"} {
+		otp := newOTP(userID, string(rune('1'+i))+"00000", expiresAt)
+		require.NoError(t, repo.Create(ctx, otp))
+	}
+	_, err := repo.MarkRevoked(ctx, &entity.OTP{ID: 4}, time.Now(), "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		filter      entity.OTPFilter
+		beforeID    uint64
+		expectedIDs []uint64
+	}{
+		{
+			name:        "Should list the OTPs of the user newest first",
+			filter:      entity.OTPFilter{UserID: "user123"},
+			expectedIDs: []uint64{4, 3},
+		},
+		{
+			name:        "Should continue before the given ID",
+			filter:      entity.OTPFilter{UserID: "user123"},
+			beforeID:    3,
+			expectedIDs: []uint64{1},
+		},
+		{
+			name:        "Should filter by status",
+			filter:      entity.OTPFilter{Status: entity.OTPStatusCreated},
+			expectedIDs: []uint64{3, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otps, err := repo.List(ctx, tt.filter, tt.beforeID, 2)
+			require.NoError(t, err)
+
+			ids := make([]uint64, 0, len(otps))
+			for _, otp := range otps {
+				ids = append(ids, otp.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestOTPRepository_Updates(t *testing.T) {
+	var repo usecase.OTPRepository = memory.NewOTPRepository(memory.NewStore())
+	ctx := context.TODO()
+	now := time.Now()
+
+	active := newOTP("user123", "111111", now.Add(time.Minute))
+	expired := newOTP("user123", "222222", now.Add(-time.Minute))
+	for _, otp := range []*entity.OTP{active, expired} {
+		require.NoError(t, repo.Create(ctx, otp))
+	}
+
+	t.Run("Should only count the resends left", func(t *testing.T) {
+		incremented, err := repo.IncrementResendCount(ctx, active)
+		require.NoError(t, err)
+		assert.True(t, incremented)
+
+		incremented, err = repo.IncrementResendCount(ctx, active)
+		require.NoError(t, err)
+		assert.False(t, incremented)
+	})
+
+	t.Run("Should list the active OTPs of the user", func(t *testing.T) {
+		otps, err := repo.ListActiveByUserID(ctx, "user123", now, now.Add(-time.Minute))
+		require.NoError(t, err)
+		if assert.Len(t, otps, 1) {
+			assert.Equal(t, active.ID, otps[0].ID)
+		}
+	})
+
+	t.Run("Should store the validation of the OTP", func(t *testing.T) {
+		validatedAt := now
+		active.Status = entity.OTPStatusValidated
+		active.ValidatedAt = &validatedAt
+		active.ValidatedSessionHash = "session-hash"
+		updated, err := repo.Update(ctx, active)
+		require.NoError(t, err)
+		assert.True(t, updated)
+
+		stored, err := repo.FindByID(ctx, active.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.OTPStatusValidated, stored.Status)
+		assert.Equal(t, "session-hash", stored.ValidatedSessionHash)
+	})
+
+	t.Run("Should only revoke a created OTP", func(t *testing.T) {
+		revoked, err := repo.MarkRevoked(ctx, active, now, "sim swap")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		revoked, err = repo.MarkRevoked(ctx, expired, now, "sim swap")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		stored, err := repo.FindByID(ctx, expired.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "sim swap", stored.RevokeReason)
+	})
+
+	t.Run("Should count the OTPs by status", func(t *testing.T) {
+		counts, err := repo.CountByStatus(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[entity.OTPStatus]int64{entity.OTPStatusValidated: 1, entity.OTPStatusRevoked: 1}, counts)
+	})
+
+	t.Run("Should delete the OTPs expired before the given time", func(t *testing.T) {
+		deleted, err := repo.DeleteExpiredBefore(ctx, now, time.Time{}, now, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		_, err = repo.FindByID(ctx, expired.ID)
+		assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+	})
+}
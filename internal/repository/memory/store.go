@@ -0,0 +1,245 @@
+// Package memory implements the OTP repository and the transaction manager in memory,
+// for tests and for running the service without a database.
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// lockWaitTimeout is how long a transaction waits for a row locked by another transaction,
+// the default innodb_lock_wait_timeout of MySQL
+const lockWaitTimeout = 50 * time.Second
+
+// ErrLockWaitTimeout is returned when a row stays locked by another transaction for longer than the lock wait timeout
+var ErrLockWaitTimeout = errors.New("lock wait timeout exceeded")
+
+// Store holds the OTPs of the in-memory repository. Like InnoDB, a transaction reads its own changes
+// and the changes committed by other transactions, and the rows it changes or locks stay locked
+// until it commits or rolls back, so that a concurrent transaction changing them waits.
+type Store struct {
+	mu     sync.Mutex
+	otps   map[uint64]*entity.OTP // committed OTPs
+	lastID uint64
+	// locks maps the locked rows and unique keys to the transaction holding them
+	locks map[lockKey]*transaction
+	// released is closed, and replaced, whenever a transaction releases its locks
+	released chan struct{}
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		otps:     make(map[uint64]*entity.OTP),
+		locks:    make(map[lockKey]*transaction),
+		released: make(chan struct{}),
+	}
+}
+
+// lockKey identifies a locked row by its ID, or a unique key by the user ID and OTP code
+type lockKey struct {
+	id      uint64
+	userID  string
+	otpCode string
+}
+
+// transaction holds the uncommitted changes and the locks of a transaction
+type transaction struct {
+	// otps maps the IDs of the OTPs the transaction created or changed to their new version, nil if deleted
+	otps  map[uint64]*entity.OTP
+	locks []lockKey
+	done  bool
+}
+
+// begin starts a transaction
+func (s *Store) begin() *transaction {
+	return &transaction{otps: make(map[uint64]*entity.OTP)}
+}
+
+// commit applies the changes of a transaction and releases its locks
+func (s *Store) commit(tx *transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apply(tx)
+}
+
+// apply applies the changes of a transaction and releases its locks. It must be called with s.mu held.
+func (s *Store) apply(tx *transaction) {
+	for id, otp := range tx.otps {
+		if otp == nil {
+			delete(s.otps, id)
+			continue
+		}
+		s.otps[id] = otp
+	}
+	s.release(tx)
+}
+
+// rollback discards the changes of a transaction and releases its locks
+func (s *Store) rollback(tx *transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.release(tx)
+}
+
+// savepoint returns a copy of the changes of a transaction, to roll them back to later
+func (s *Store) savepoint(tx *transaction) map[uint64]*entity.OTP {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The stored versions are never changed in place, so copying the map is enough
+	otps := make(map[uint64]*entity.OTP, len(tx.otps))
+	for id, otp := range tx.otps {
+		otps[id] = otp
+	}
+
+	return otps
+}
+
+// rollbackTo discards the changes a transaction made since a savepoint.
+// Like InnoDB, it keeps the locks the transaction took since then.
+func (s *Store) rollbackTo(tx *transaction, savepoint map[uint64]*entity.OTP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx.otps = savepoint
+}
+
+// release releases the locks of a transaction and wakes up the transactions waiting for them.
+// It must be called with s.mu held.
+func (s *Store) release(tx *transaction) {
+	tx.done = true
+	if len(tx.locks) == 0 {
+		return
+	}
+
+	for _, key := range tx.locks {
+		delete(s.locks, key)
+	}
+	tx.locks = nil
+	close(s.released)
+	s.released = make(chan struct{})
+}
+
+// run calls fn with s.mu held within the transaction in ctx, or within a transaction
+// committed right after fn returns, like a statement outside of a transaction
+func (s *Store) run(ctx context.Context, fn func(tx *transaction) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := transactionFromContext(ctx)
+	if tx == nil {
+		tx = s.begin()
+		if err := fn(tx); err != nil {
+			s.release(tx)
+			return err
+		}
+		s.apply(tx)
+		return nil
+	}
+
+	if tx.done {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+
+	return fn(tx)
+}
+
+// lock locks a row or unique key for a transaction, waiting while another transaction holds it.
+// It must be called with s.mu held, which it releases while waiting, so the rows must be read after it returns.
+func (s *Store) lock(ctx context.Context, tx *transaction, key lockKey) error {
+	timeout := time.NewTimer(lockWaitTimeout)
+	defer timeout.Stop()
+
+	for {
+		holder, locked := s.locks[key]
+		if !locked {
+			s.locks[key] = tx
+			tx.locks = append(tx.locks, key)
+			return nil
+		}
+		if holder == tx {
+			return nil
+		}
+
+		released := s.released
+		s.mu.Unlock()
+		select {
+		case <-released:
+			s.mu.Lock()
+		case <-timeout.C:
+			s.mu.Lock()
+			return ErrLockWaitTimeout
+		case <-ctx.Done():
+			s.mu.Lock()
+			return ctx.Err()
+		}
+	}
+}
+
+// isLockedByOther reports whether a row is locked by another transaction. It must be called with s.mu held.
+func (s *Store) isLockedByOther(tx *transaction, id uint64) bool {
+	holder, locked := s.locks[lockKey{id: id}]
+	return locked && holder != tx
+}
+
+// get returns the OTP with the given ID as seen by a transaction. It must be called with s.mu held.
+func (s *Store) get(tx *transaction, id uint64) (*entity.OTP, bool) {
+	if otp, changed := tx.otps[id]; changed {
+		return otp, otp != nil
+	}
+
+	otp, found := s.otps[id]
+	return otp, found
+}
+
+// put stores a new version of an OTP in a transaction. It must be called with s.mu held.
+func (s *Store) put(tx *transaction, otp *entity.OTP) {
+	tx.otps[otp.ID] = otp
+}
+
+// all returns the OTPs seen by a transaction, in ID order. It must be called with s.mu held.
+func (s *Store) all(tx *transaction) []*entity.OTP {
+	ids := make([]uint64, 0, len(s.otps)+len(tx.otps))
+	for id := range s.otps {
+		if _, changed := tx.otps[id]; !changed {
+			ids = append(ids, id)
+		}
+	}
+	for id, otp := range tx.otps {
+		if otp != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	otps := make([]*entity.OTP, 0, len(ids))
+	for _, id := range ids {
+		otp, _ := s.get(tx, id)
+		otps = append(otps, otp)
+	}
+
+	return otps
+}
+
+// clone copies an OTP, so that the stored versions are never shared with the callers
+func clone(otp *entity.OTP) *entity.OTP {
+	copied := *otp
+	if otp.ValidatedAt != nil {
+		validatedAt := *otp.ValidatedAt
+		copied.ValidatedAt = &validatedAt
+	}
+	if otp.RevokedAt != nil {
+		revokedAt := *otp.RevokedAt
+		copied.RevokedAt = &revokedAt
+	}
+
+	return &copied
+}
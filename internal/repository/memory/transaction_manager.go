@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/imansohibul/otp-service/internal/usecase"
+)
+
+// txKey is a key used to store the transaction in the context
+type txKey struct{}
+
+// TransactionRunner runs a function within a transaction of another store,
+// such as the transaction manager of the SQL repositories
+type TransactionRunner interface {
+	WithTransactionOptions(ctx context.Context, opts usecase.TransactionOptions, fn func(ctx context.Context) error) error
+}
+
+// transactionManager is the in-memory implementation of the TransactionManager interface
+type transactionManager struct {
+	store *Store
+	next  TransactionRunner
+}
+
+// NewTransactionManager creates a new instance of transactionManager.
+// When next is not nil, every transaction of the store also runs within a transaction of next,
+// and the changes to the store are only committed once the transaction of next is committed.
+func NewTransactionManager(store *Store, next TransactionRunner) *transactionManager {
+	return &transactionManager{
+		store: store,
+		next:  next,
+	}
+}
+
+// WithTransaction starts a new transaction and executes the provided function within that transaction.
+// The changes are rolled back if the function returns an error.
+func (t *transactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.WithTransactionOptions(ctx, usecase.TransactionOptions{}, fn)
+}
+
+// WithTransactionOptions starts a new transaction and executes the provided function within that transaction,
+// or within a savepoint of the transaction in the context. The options are only passed on to next:
+// whatever the isolation level, the in-memory transactions read the changes committed by other transactions.
+func (t *transactionManager) WithTransactionOptions(ctx context.Context, opts usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+	if tx := transactionFromContext(ctx); tx != nil {
+		return t.withSavepoint(ctx, tx, opts, fn)
+	}
+
+	if t.next == nil {
+		tx := t.store.begin()
+		if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+			t.store.rollback(tx)
+			return err
+		}
+		t.store.commit(tx)
+		return nil
+	}
+
+	// next runs the function again when its transaction fails on a transient error,
+	// so every attempt runs within a transaction of the store of its own
+	var tx *transaction
+	err := t.next.WithTransactionOptions(ctx, opts, func(ctx context.Context) error {
+		if tx != nil {
+			t.store.rollback(tx)
+		}
+		tx = t.store.begin()
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil {
+		if tx != nil {
+			t.store.rollback(tx)
+		}
+		return err
+	}
+
+	t.store.commit(tx)
+
+	return nil
+}
+
+// withSavepoint executes fn within a savepoint of a transaction,
+// which is rolled back to the savepoint if fn returns an error
+func (t *transactionManager) withSavepoint(ctx context.Context, tx *transaction, opts usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+	savepoint := t.store.savepoint(tx)
+
+	var err error
+	if t.next != nil {
+		err = t.next.WithTransactionOptions(ctx, opts, fn)
+	} else {
+		err = fn(ctx)
+	}
+	if err != nil {
+		t.store.rollbackTo(tx, savepoint)
+		return err
+	}
+
+	return nil
+}
+
+// transactionFromContext retrieves the transaction from the context
+func transactionFromContext(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(txKey{}).(*transaction)
+	return tx
+}
@@ -0,0 +1,208 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository/memory"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRunner is a transaction runner whose transactions fail to commit
+type failingRunner struct {
+	err error
+}
+
+func (f failingRunner) WithTransactionOptions(ctx context.Context, _ usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return f.err
+}
+
+func TestTransactionManager_WithTransaction(t *testing.T) {
+	errCallback := errors.New("callback failed")
+	expiresAt := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		next        memory.TransactionRunner
+		callbackErr error
+		expectErr   error
+		expectFound bool
+	}{
+		{
+			name:        "Should commit the changes when the callback succeeds",
+			expectFound: true,
+		},
+		{
+			name:        "Should roll back the changes when the callback fails",
+			callbackErr: errCallback,
+			expectErr:   errCallback,
+		},
+		{
+			name:      "Should roll back the changes when the next transaction fails to commit",
+			next:      failingRunner{err: errCallback},
+			expectErr: errCallback,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore()
+			var (
+				repo      usecase.OTPRepository      = memory.NewOTPRepository(store)
+				txManager usecase.TransactionManager = memory.NewTransactionManager(store, tt.next)
+			)
+
+			err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+				if err := repo.Create(ctx, newOTP("user123", "123456", expiresAt)); err != nil {
+					return err
+				}
+
+				// The transaction sees its own changes
+				_, err := repo.FindByUserIDAndCode(ctx, "user123", "123456")
+				require.NoError(t, err)
+
+				// Other transactions do not see them before they are committed
+				_, err = repo.FindByUserIDAndCode(context.TODO(), "user123", "123456")
+				assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+
+				return tt.callbackErr
+			})
+			assert.ErrorIs(t, err, tt.expectErr)
+
+			_, err = repo.FindByUserIDAndCode(context.TODO(), "user123", "123456")
+			if tt.expectFound {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+			}
+		})
+	}
+}
+
+// retryingRunner is a transaction runner whose first transaction fails on a transient error and is run again
+type retryingRunner struct{}
+
+func (retryingRunner) WithTransactionOptions(ctx context.Context, _ usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+func TestTransactionManager_Retry(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOTPRepository(store)
+	txManager := memory.NewTransactionManager(store, retryingRunner{})
+	expiresAt := time.Now().Add(time.Minute)
+
+	// Only the changes of the last attempt are committed
+	attempt := 0
+	err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+		attempt++
+		return repo.Create(ctx, newOTP("user123", fmt.Sprintf("%06d", attempt), expiresAt))
+	})
+	require.NoError(t, err)
+
+	_, err = repo.FindByUserIDAndCode(context.TODO(), "user123", "000001")
+	assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+	_, err = repo.FindByUserIDAndCode(context.TODO(), "user123", "000002")
+	assert.NoError(t, err)
+}
+
+func TestTransactionManager_RowLocks(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Should skip the OTPs locked by another transaction", func(t *testing.T) {
+		store := memory.NewStore()
+		repo := memory.NewOTPRepository(store)
+		txManager := memory.NewTransactionManager(store, nil)
+		for _, code := range []string{"111111", "222222"} {
+			require.NoError(t, repo.Create(context.TODO(), newOTP("user123", code, now.Add(-time.Minute))))
+		}
+
+		locked := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+				otps, err := repo.ListExpirable(ctx, now, time.Time{}, now, 1)
+				assert.NoError(t, err)
+				assert.Len(t, otps, 1)
+				close(locked)
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			})
+		}()
+		<-locked
+
+		err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			otps, err := repo.ListExpirable(ctx, now, time.Time{}, now, 10)
+			require.NoError(t, err)
+			if assert.Len(t, otps, 1) {
+				assert.Equal(t, uint64(2), otps[0].ID)
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		<-done
+	})
+
+	t.Run("Should wait for the transaction holding the row to end", func(t *testing.T) {
+		store := memory.NewStore()
+		repo := memory.NewOTPRepository(store)
+		txManager := memory.NewTransactionManager(store, nil)
+		otp := newOTP("user123", "111111", now.Add(time.Minute))
+		require.NoError(t, repo.Create(context.TODO(), otp))
+
+		locked := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+				revoked, err := repo.MarkRevoked(ctx, otp, now, "")
+				assert.NoError(t, err)
+				assert.True(t, revoked)
+				close(locked)
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			})
+		}()
+		<-locked
+
+		// The resend waits for the revocation to commit, then finds the OTP revoked
+		incremented, err := repo.IncrementResendCount(context.TODO(), otp)
+		require.NoError(t, err)
+		assert.False(t, incremented)
+		<-done
+	})
+
+	t.Run("Should stop waiting when the context is done", func(t *testing.T) {
+		store := memory.NewStore()
+		repo := memory.NewOTPRepository(store)
+		txManager := memory.NewTransactionManager(store, nil)
+		otp := newOTP("user123", "111111", now.Add(time.Minute))
+		require.NoError(t, repo.Create(context.TODO(), otp))
+
+		err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			if _, err := repo.MarkRevoked(ctx, otp, now, ""); err != nil {
+				return err
+			}
+
+			waitCtx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+			defer cancel()
+			_, err := repo.IncrementResendCount(waitCtx, otp)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
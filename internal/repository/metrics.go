@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered on the default registry, which is served by the /metrics route of the REST API.
+var (
+	transactionRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "db",
+		Name:      "transaction_retries_total",
+		Help:      "Number of transactions run again after failing on a transient error, partitioned by reason.",
+	}, []string{"reason"})
+
+	transactionRollbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "db",
+		Name:      "transaction_rollbacks_total",
+		Help:      "Number of transactions and savepoints rolled back, partitioned by scope.",
+	}, []string{"scope"})
+
+	replicaReads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "db",
+		Name:      "replica_reads_total",
+		Help:      "Number of reads sent to a read replica, partitioned by whether the replica served them or they fell back to the primary.",
+	}, []string{"target"})
+
+	healthyReplicas = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "otp_service",
+		Subsystem: "db",
+		Name:      "healthy_replicas",
+		Help:      "Number of read replicas that answered the last health check.",
+	})
+)
@@ -0,0 +1,160 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/imansohibul/otp-service/db/migrate"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/imansohibul/otp-service/internal/repository/repositorytest"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMySQLDB starts an in-process MySQL compatible server with an empty database,
+// and opens it with the MySQL migrations applied
+func newMySQLDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db := newEmptyMySQLDB(t)
+	require.NoError(t, migrate.Up(context.TODO(), db.DB, migrate.MySQL()))
+
+	return db
+}
+
+// newEmptyMySQLDB starts an in-process MySQL compatible server with an empty database, and opens it
+func newEmptyMySQLDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	// The server logs every connection
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	// The foreign keys need the primary keys to be indexed, like InnoDB does
+	database := memory.NewDatabase("otp_service")
+	database.EnablePrimaryKeyIndexes()
+	provider := memory.NewDBProvider(database)
+	engine := sqle.NewDefault(provider)
+	config := server.Config{Protocol: "tcp", Address: "127.0.0.1:0"}
+	srv, err := server.NewServer(config, engine, gms.NewContext, memory.NewSessionBuilder(provider), nil)
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(func() { srv.Close() })
+
+	// The migrations hold several statements
+	dsn := "root@tcp(" + srv.Listener.Addr().String() + ")/otp_service?parseTime=true&multiStatements=true"
+	db, err := sqlx.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestMySQL_OTPRepository(t *testing.T) {
+	repositorytest.RunOTPRepositorySuite(t, func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager) {
+		db := newMySQLDB(t)
+		return repository.NewOTPRepository(db, nil, nil), repository.NewTransactionManager(db, repository.RetryPolicy{})
+	}, repositorytest.Limitations{
+		// Unlike InnoDB, the in-process server accepts FOR UPDATE SKIP LOCKED without locking any row
+		RowLocks:   "the in-process MySQL server does not lock rows",
+		Savepoints: "the in-process MySQL server does not support savepoints",
+		// The codes are reserved within a transaction, whose inserts the server does not check against the concurrent ones
+		WriteConflicts: "the in-process MySQL server does not detect conflicting inserts of concurrent transactions",
+	})
+}
+
+func TestMySQL_EncryptedOTPRepository(t *testing.T) {
+	repositorytest.RunOTPRepositorySuite(t, func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager) {
+		db := newMySQLDB(t)
+		return repository.NewOTPRepository(db, nil, newKeyring(t, db)), repository.NewTransactionManager(db, repository.RetryPolicy{})
+	}, repositorytest.Limitations{
+		RowLocks:       "the in-process MySQL server does not lock rows",
+		Savepoints:     "the in-process MySQL server does not support savepoints",
+		WriteConflicts: "the in-process MySQL server does not detect conflicting inserts of concurrent transactions",
+	})
+}
+
+func TestMySQL_ReencryptStale(t *testing.T) {
+	db := newMySQLDB(t)
+	ctx := context.TODO()
+	plaintextRepo := repository.NewOTPRepository(db, nil, nil)
+	encryptedRepo := repository.NewOTPRepository(db, nil, newKeyring(t, db))
+
+	otp := &entity.OTP{
+		UserID:    "user123",
+		OTPCode:   "123456",
+		Status:    entity.OTPStatusCreated,
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}
+	require.NoError(t, plaintextRepo.Create(ctx, otp))
+
+	reencrypted, err := encryptedRepo.ReencryptStale(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reencrypted)
+
+	// The reservation of the code is indexed along with the OTP, which is then found by it
+	found, err := encryptedRepo.FindByUserIDAndCode(ctx, "user123", "123456")
+	require.NoError(t, err)
+	assert.Equal(t, otp.ID, found.ID)
+
+	var plaintextReservations int
+	require.NoError(t, db.Get(&plaintextReservations, "SELECT COUNT(*) FROM otp_codes WHERE user_id = 'user123'"))
+	assert.Zero(t, plaintextReservations)
+
+	// The code cannot be issued twice to the user once encrypted
+	err = encryptedRepo.Create(ctx, &entity.OTP{UserID: "user123", OTPCode: "123456", Status: entity.OTPStatusCreated, ExpiresAt: otp.ExpiresAt})
+	assert.Equal(t, entity.ErrOTPDuplicate, err)
+}
+
+func TestMySQL_OTPPartitionRepository(t *testing.T) {
+	db := newMySQLDB(t)
+	ctx := context.TODO()
+	repo := repository.NewOTPPartitionRepository(db)
+
+	// The in-process server accepts the partitioning statements but does not partition the tables,
+	// so this only checks them against the schema of the migrations
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	require.NoError(t, repo.AddPartitions(ctx, []time.Time{tomorrow}))
+	require.NoError(t, repo.DropPartitions(ctx, []entity.OTPPartition{{Name: "p_before_" + tomorrow.Format("20060102"), Before: tomorrow}}))
+
+	_, err := repo.ListPartitions(ctx)
+	require.NoError(t, err)
+}
+
+func TestMySQL_Migrate(t *testing.T) {
+	db := newMySQLDB(t)
+	ctx := context.TODO()
+
+	// The migrations can all be rolled back and applied again
+	require.NoError(t, migrate.Down(ctx, db.DB, migrate.MySQL(), 0))
+	require.ErrorIs(t, migrate.Check(ctx, db.DB, migrate.MySQL()), migrate.ErrSchemaOutdated)
+
+	require.NoError(t, migrate.Up(ctx, db.DB, migrate.MySQL()))
+	require.NoError(t, migrate.Check(ctx, db.DB, migrate.MySQL()))
+}
+
+func TestMySQL_MigrateFailure(t *testing.T) {
+	db := newEmptyMySQLDB(t)
+	ctx := context.TODO()
+
+	migrations := fstest.MapFS{
+		"1_create_a.up.sql": {Data: []byte(`CREATE TABLE a (id BIGINT PRIMARY KEY)`)},
+		"2_broken.up.sql":   {Data: []byte(`CREATE TABLE b (id BIGINT PRIMARY KEY); CREATE TABLE`)},
+	}
+	require.Error(t, migrate.Up(ctx, db.DB, migrations))
+
+	// MySQL commits the table created before the failure, so the schema is left dirty to be fixed manually
+	status, err := migrate.GetStatus(ctx, db.DB, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, &migrate.Status{Version: 2, Dirty: true, Latest: 2}, status)
+	assert.ErrorIs(t, migrate.Check(ctx, db.DB, migrations), migrate.ErrSchemaDirty)
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// futurePartition is the last partition of the otps table, holding the OTPs created after the other ones
+	futurePartition = "p_future"
+	// codeReleaseBatchSize is the maximum number of codes or IDs of dropped OTPs released by a single statement
+	codeReleaseBatchSize = 1000
+)
+
+// otpPartitionRepository implements the OTPPartitionRepository interface on MySQL,
+// whose otps table is partitioned by range of creation time
+type otpPartitionRepository struct {
+	db *sqlx.DB
+}
+
+// NewOTPPartitionRepository creates a new instance of otpPartitionRepository
+func NewOTPPartitionRepository(db *sqlx.DB) *otpPartitionRepository {
+	return &otpPartitionRepository{
+		db: db,
+	}
+}
+
+// ListPartitions retrieves the partitions of the otps table but the last one, in creation time order
+func (r *otpPartitionRepository) ListPartitions(ctx context.Context) ([]entity.OTPPartition, error) {
+	const query = `
+		SELECT PARTITION_NAME AS name, PARTITION_DESCRIPTION AS description
+		FROM information_schema.PARTITIONS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'otps' AND PARTITION_NAME IS NOT NULL
+		ORDER BY PARTITION_ORDINAL_POSITION
+	`
+	var rows []struct {
+		Name        string         `db:"name"`
+		Description sql.NullString `db:"description"`
+	}
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+
+	partitions := make([]entity.OTPPartition, 0, len(rows))
+	for _, row := range rows {
+		if row.Name == futurePartition {
+			continue
+		}
+
+		// The bounds are Unix times, as the table is partitioned by UNIX_TIMESTAMP(created_at)
+		seconds, err := strconv.ParseInt(row.Description.String, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound %q of partition %s: %w", row.Description.String, row.Name, err)
+		}
+		partitions = append(partitions, entity.OTPPartition{
+			Name:   row.Name,
+			Before: time.Unix(seconds, 0).UTC(),
+		})
+	}
+
+	return partitions, nil
+}
+
+// AddPartitions splits the last partition into a partition for each of the given bounds and the last partition.
+// The last partition is expected to be empty, or the OTPs it holds are copied to the new partitions.
+func (r *otpPartitionRepository) AddPartitions(ctx context.Context, bounds []time.Time) error {
+	definitions := make([]string, 0, len(bounds)+1)
+	for _, before := range bounds {
+		definitions = append(definitions, fmt.Sprintf("PARTITION %s VALUES LESS THAN (%d)", partitionName(before), before.Unix()))
+	}
+	definitions = append(definitions, "PARTITION "+futurePartition+" VALUES LESS THAN (MAXVALUE)")
+
+	query := "ALTER TABLE otps REORGANIZE PARTITION " + futurePartition + " INTO (" + strings.Join(definitions, ", ") + ")"
+	_, err := getExecutor(ctx, r.db).ExecContext(ctx, query)
+	return err
+}
+
+// DropPartitions drops the given partitions, then releases the codes and the IDs of the OTPs they held
+// in batches, so that no single statement locks a large part of the otp_codes or otp_ids tables
+func (r *otpPartitionRepository) DropPartitions(ctx context.Context, partitions []entity.OTPPartition) error {
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(partitions))
+	var before time.Time
+	for _, partition := range partitions {
+		names = append(names, partition.Name)
+		if partition.Before.After(before) {
+			before = partition.Before
+		}
+	}
+
+	query := "ALTER TABLE otps DROP PARTITION " + strings.Join(names, ", ")
+	if _, err := getExecutor(ctx, r.db).ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	// The codes and the IDs are stored with the creation time of their OTP, and the dropped partitions are the oldest ones
+	releaseQueries := []string{
+		`
+			DELETE FROM otp_codes
+			WHERE created_at < ?
+			LIMIT ?
+		`,
+		`
+			DELETE FROM otp_ids
+			WHERE created_at < ?
+			LIMIT ?
+		`,
+	}
+	for _, releaseQuery := range releaseQueries {
+		if err := r.releaseBefore(ctx, releaseQuery, before); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseBefore runs the given release query in batches until it deletes fewer rows than a batch
+func (r *otpPartitionRepository) releaseBefore(ctx context.Context, releaseQuery string, before time.Time) error {
+	for {
+		result, err := getExecutor(ctx, r.db).ExecContext(ctx, releaseQuery, before, codeReleaseBatchSize)
+		if err != nil {
+			return err
+		}
+
+		released, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if released < codeReleaseBatchSize {
+			return nil
+		}
+	}
+}
+
+// partitionName returns the name of the partition of the OTPs created before the given day
+func partitionName(before time.Time) string {
+	return "p_before_" + before.UTC().Format("20060102")
+}
@@ -0,0 +1,179 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTPPartitionRepository_ListPartitions(t *testing.T) {
+	expectedQuery := regexp.QuoteMeta(`
+		SELECT PARTITION_NAME AS name, PARTITION_DESCRIPTION AS description
+		FROM information_schema.PARTITIONS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'otps' AND PARTITION_NAME IS NOT NULL
+		ORDER BY PARTITION_ORDINAL_POSITION
+	`)
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func([]entity.OTPPartition, error)
+	}{
+		{
+			name: "Should return the bounded partitions in order",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"name", "description"}).
+						AddRow("p_before_20261018", "1792281600").
+						AddRow("p_before_20261019", "1792368000").
+						AddRow("p_future", "MAXVALUE"))
+			},
+			assertFn: func(partitions []entity.OTPPartition, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []entity.OTPPartition{
+					{Name: "p_before_20261018", Before: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+					{Name: "p_before_20261019", Before: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+				}, partitions)
+			},
+		},
+		{
+			name: "Should return error when a bound is not a Unix time",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"name", "description"}).
+						AddRow("p_before_20261018", "'2026-10-18'"))
+			},
+			assertFn: func(partitions []entity.OTPPartition, err error) {
+				assert.ErrorContains(t, err, `invalid bound "'2026-10-18'" of partition p_before_20261018`)
+				assert.Nil(t, partitions)
+			},
+		},
+		{
+			name: "Should return error when query fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(partitions []entity.OTPPartition, err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+				assert.Nil(t, partitions)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependency := newRepoDependency()
+			tt.mockDependency(dependency)
+
+			repo := repository.NewOTPPartitionRepository(dependency.mockedDB)
+			tt.assertFn(repo.ListPartitions(context.TODO()))
+			assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOTPPartitionRepository_AddPartitions(t *testing.T) {
+	dependency := newRepoDependency()
+	dependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("ALTER TABLE otps REORGANIZE PARTITION p_future INTO (" +
+			"PARTITION p_before_20261019 VALUES LESS THAN (1792368000), " +
+			"PARTITION p_before_20261020 VALUES LESS THAN (1792454400), " +
+			"PARTITION p_future VALUES LESS THAN (MAXVALUE))")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewOTPPartitionRepository(dependency.mockedDB)
+	err := repo.AddPartitions(context.TODO(), []time.Time{
+		time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestOTPPartitionRepository_DropPartitions(t *testing.T) {
+	partitions := []entity.OTPPartition{
+		{Name: "p_before_20261018", Before: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{Name: "p_before_20261019", Before: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+	}
+	expectedDropQuery := regexp.QuoteMeta("ALTER TABLE otps DROP PARTITION p_before_20261018, p_before_20261019")
+	expectedReleaseQuery := regexp.QuoteMeta("DELETE FROM otp_codes WHERE created_at < ? LIMIT ?")
+	expectedIDReleaseQuery := regexp.QuoteMeta("DELETE FROM otp_ids WHERE created_at < ? LIMIT ?")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(error)
+	}{
+		{
+			name: "Should drop the partitions and release their codes and IDs until a batch is not full",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedDropQuery).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				dependency.mockedSQL.
+					ExpectExec(expectedReleaseQuery).
+					WithArgs(partitions[1].Before, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 1000))
+				dependency.mockedSQL.
+					ExpectExec(expectedReleaseQuery).
+					WithArgs(partitions[1].Before, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				dependency.mockedSQL.
+					ExpectExec(expectedIDReleaseQuery).
+					WithArgs(partitions[1].Before, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			assertFn: func(err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "Should not release the codes when dropping the partitions fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedDropQuery).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+			},
+		},
+		{
+			name: "Should return error when releasing the codes fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedDropQuery).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				dependency.mockedSQL.
+					ExpectExec(expectedReleaseQuery).
+					WithArgs(partitions[1].Before, 1000).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependency := newRepoDependency()
+			tt.mockDependency(dependency)
+
+			repo := repository.NewOTPPartitionRepository(dependency.mockedDB)
+			tt.assertFn(repo.DropPartitions(context.TODO(), partitions))
+			assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/imansohibul/otp-service/entity"
 	"github.com/jmoiron/sqlx"
@@ -10,29 +13,53 @@ import (
 
 // otpRepository implements the OTPRepository interface
 type otpRepository struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	replicas  *ReplicaSet
+	encryptor ColumnEncryptor
 }
 
-// NewOTPRepository creates a new instance of otpRepository
-func NewOTPRepository(db *sqlx.DB) *otpRepository {
+// NewOTPRepository creates a new instance of otpRepository.
+// Its reads made outside of a transaction are sent to the replicas, if any.
+// The user IDs and codes of the OTPs are encrypted with the encryptor, if any.
+func NewOTPRepository(db *sqlx.DB, replicas *ReplicaSet, encryptor ColumnEncryptor) *otpRepository {
 	return &otpRepository{
-		db: db,
+		db:        db,
+		replicas:  replicas,
+		encryptor: encryptor,
 	}
 }
 
-// Create inserts a new OTP into the database
+// Create inserts a new OTP into the database and sets the ID of the given OTP
 func (o *otpRepository) Create(ctx context.Context, otp *entity.OTP) error {
+	row, err := newOTPRow(ctx, otp, o.encryptor)
+	if err != nil {
+		return err
+	}
+
+	if dialectOf(o.db) == dialectMySQL {
+		return o.createPartitioned(ctx, otp, row)
+	}
+
 	const query = `
-		INSERT INTO otps (user_id, otp_code, status, expires_at)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO otps (user_id, otp_code, status, expires_at, binding_hash, purpose, client_id, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := getExecutor(ctx, o.db).ExecContext(
+	id, err := insertReturningID(
 		ctx,
+		o.db,
 		query,
-		otp.UserID,
-		otp.OTPCode,
-		otp.Status,
-		otp.ExpiresAt,
+		row.UserID,
+		row.OTPCode,
+		row.Status,
+		row.ExpiresAt,
+		row.BindingHash,
+		row.Purpose,
+		row.ClientID,
+		row.ResendCount,
+		row.ResendLimit,
+		nullableBytes(row.UserIDCiphertext),
+		nullableBytes(row.OTPCodeCiphertext),
+		row.KeyID,
 	)
 	if err != nil {
 		// Check if the error is a unique constraint violation
@@ -41,20 +68,121 @@ func (o *otpRepository) Create(ctx context.Context, otp *entity.OTP) error {
 		}
 		return err
 	}
+	otp.ID = id
 
-	return err
+	return nil
 }
 
-// FindByUserIDAndCode retrieves an OTP by user ID and OTP code from the database
+// createPartitioned inserts a new OTP into the otps table of MySQL, which is partitioned by creation time and
+// cannot enforce the uniqueness of the codes of a user, so the code is first reserved in otp_codes.
+// The OTP is created at the time the code was reserved, which locates the partition of the OTP from its code,
+// and from its ID once mapped in otp_ids.
+func (o *otpRepository) createPartitioned(ctx context.Context, otp *entity.OTP, row *otpRow) error {
+	const (
+		reserveQuery = `
+			INSERT INTO otp_codes (user_id, otp_code, created_at)
+			VALUES (?, ?, CURRENT_TIMESTAMP)
+		`
+		insertQuery = `
+			INSERT INTO otps (user_id, otp_code, status, created_at, expires_at, binding_hash, purpose, client_id, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id)
+			SELECT user_id, otp_code, ?, created_at, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			FROM otp_codes
+			WHERE user_id = ? AND otp_code = ?
+		`
+		mapQuery = `
+			INSERT INTO otp_ids (id, created_at)
+			SELECT ?, created_at
+			FROM otp_codes
+			WHERE user_id = ? AND otp_code = ?
+		`
+	)
+
+	var id uint64
+	err := withinTransaction(ctx, o.db, func(ctx context.Context) error {
+		if _, err := getExecutor(ctx, o.db).ExecContext(ctx, reserveQuery, row.UserID, row.OTPCode); err != nil {
+			// Check if the error is a unique constraint violation
+			if isUniqueConstraintViolation(err) {
+				return entity.ErrOTPDuplicate
+			}
+			return err
+		}
+
+		var err error
+		id, err = insertReturningID(
+			ctx,
+			o.db,
+			insertQuery,
+			row.Status,
+			row.ExpiresAt,
+			row.BindingHash,
+			row.Purpose,
+			row.ClientID,
+			row.ResendCount,
+			row.ResendLimit,
+			nullableBytes(row.UserIDCiphertext),
+			nullableBytes(row.OTPCodeCiphertext),
+			row.KeyID,
+			row.UserID,
+			row.OTPCode,
+		)
+		if err != nil {
+			return err
+		}
+
+		// The ID of the OTP is mapped to its creation time, which locates its partition
+		_, err = getExecutor(ctx, o.db).ExecContext(ctx, mapQuery, id, row.UserID, row.OTPCode)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	otp.ID = id
+
+	return nil
+}
+
+// FindByUserIDAndCode retrieves an OTP by user ID and OTP code from the database.
+// On MySQL, the creation time of the OTP is first read from otp_codes, so that only its partition is searched.
 func (o *otpRepository) FindByUserIDAndCode(ctx context.Context, userID string, otpCode string) (*entity.OTP, error) {
-	const query = `
-		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at
-		FROM otps
-		WHERE user_id = ? AND otp_code = ?
-	`
+	const (
+		query = `
+			SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+			FROM otps
+			WHERE user_id = ? AND otp_code = ?
+		`
+		createdAtQuery = `
+			SELECT created_at
+			FROM otp_codes
+			WHERE user_id = ? AND otp_code = ?
+		`
+	)
+
+	userIndex, err := userIDIndex(ctx, o.encryptor, userID)
+	if err != nil {
+		return nil, err
+	}
+	codeIndex, err := otpCodeIndex(ctx, o.encryptor, userID, otpCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both queries are sent to the same database, so that a lagging replica does not miss the OTP of a code
+	reader := getReader(ctx, o.db, o.replicas)
+	partitionedQuery, args := query, []any{userIndex, codeIndex}
+	if dialectOf(o.db) == dialectMySQL {
+		var createdAt time.Time
+		if err := reader.GetContext(ctx, &createdAt, createdAtQuery, userIndex, codeIndex); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, entity.ErrOTPNotFound
+			}
+			return nil, err
+		}
+		partitionedQuery += " AND created_at = ?"
+		args = append(args, createdAt)
+	}
 
 	var otpRow otpRow
-	if err := getExecutor(ctx, o.db).GetContext(ctx, &otpRow, query, userID, otpCode); err != nil {
+	if err := reader.GetContext(ctx, &otpRow, partitionedQuery, args...); err != nil {
 		// Check if the error is sql.ErrNoRows to return entity.ErrNotFound
 		if err == sql.ErrNoRows {
 			return nil, entity.ErrOTPNotFound
@@ -62,41 +190,56 @@ func (o *otpRepository) FindByUserIDAndCode(ctx context.Context, userID string,
 		return nil, err
 	}
 
-	return otpRow.ToEntity(), nil
+	return otpRow.ToEntity(ctx, o.encryptor)
 }
 
-// Update updates an OTP record in the database
-func (o *otpRepository) Update(ctx context.Context, otp *entity.OTP) error {
-	const query = `
+// Update updates an OTP record in the database, provided it is still created.
+// Returns false if the OTP was validated, expired or revoked in the meantime.
+// On MySQL, the update of an OTP read from the database only searches the partition of its creation time.
+func (o *otpRepository) Update(ctx context.Context, otp *entity.OTP) (bool, error) {
+	query := `
 		UPDATE otps
-		SET status = ?, validated_at = ?
-		WHERE id = ?
-	`
-	_, err := getExecutor(ctx, o.db).ExecContext(
-		ctx,
-		query,
-		otp.Status,
-		otp.ValidatedAt,
-		otp.ID,
-	)
+		SET status = ?, validated_at = ?, validated_session_hash = ?
+		WHERE id = ? AND status = ?`
+	args := []any{otp.Status, otp.ValidatedAt, nullableString(otp.ValidatedSessionHash), otp.ID, entity.OTPStatusCreated}
+	if dialectOf(o.db) == dialectMySQL && !otp.CreatedAt.IsZero() {
+		query += " AND created_at = ?"
+		args = append(args, otp.CreatedAt)
+	}
 
-	return err
+	result, err := getExecutor(ctx, o.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
 }
 
-// GetLastByUserID retrieves the most recent OTP for a specific user,
-// ordered by creation timestamp descending. Returns entity.ErrOTPNotFound
-// if no OTP exists for the user.
-func (o *otpRepository) GetLastByUserID(ctx context.Context, userID string) (*entity.OTP, error) {
+// GetLastByUserID retrieves the most recent OTP for a specific user created at or after the given time,
+// ordered by creation timestamp descending. Of the OTPs created within the same second,
+// the last one inserted wins. Returns entity.ErrOTPNotFound if no such OTP exists for the user.
+// On MySQL, the creation time bound limits the search to the partitions of the recent OTPs.
+func (o *otpRepository) GetLastByUserID(ctx context.Context, userID string, createdFrom time.Time) (*entity.OTP, error) {
 	const query = `
-		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
 		FROM otps
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		WHERE user_id = ? AND created_at >= ?
+		ORDER BY created_at DESC, id DESC
 		LIMIT 1
 	`
 
+	userIndex, err := userIDIndex(ctx, o.encryptor, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	var otpRow otpRow
-	if err := getExecutor(ctx, o.db).GetContext(ctx, &otpRow, query, userID); err != nil {
+	if err := getReader(ctx, o.db, o.replicas).GetContext(ctx, &otpRow, query, userIndex, createdFrom); err != nil {
 		// Check if the error is sql.ErrNoRows to return entity.ErrOTPNotFound
 		if err == sql.ErrNoRows {
 			return nil, entity.ErrOTPNotFound
@@ -104,5 +247,494 @@ func (o *otpRepository) GetLastByUserID(ctx context.Context, userID string) (*en
 		return nil, err
 	}
 
-	return otpRow.ToEntity(), nil
+	return otpRow.ToEntity(ctx, o.encryptor)
+}
+
+// FindByID retrieves an OTP by its ID. Returns entity.ErrOTPNotFound if no OTP exists with the ID.
+// On MySQL, the creation time of the OTP is first read from otp_codes, so that only its partition is searched.
+func (o *otpRepository) FindByID(ctx context.Context, id uint64) (*entity.OTP, error) {
+	const (
+		query = `
+			SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+			FROM otps
+			WHERE id = ?
+		`
+		createdAtQuery = `
+			SELECT created_at
+			FROM otp_ids
+			WHERE id = ?
+		`
+	)
+
+	// Both queries are sent to the same database, so that a lagging replica does not miss the OTP of an ID
+	reader := getReader(ctx, o.db, o.replicas)
+	partitionedQuery, args := query, []any{id}
+	if dialectOf(o.db) == dialectMySQL {
+		var createdAt time.Time
+		if err := reader.GetContext(ctx, &createdAt, createdAtQuery, id); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, entity.ErrOTPNotFound
+			}
+			return nil, err
+		}
+		partitionedQuery += " AND created_at = ?"
+		args = append(args, createdAt)
+	}
+
+	var otpRow otpRow
+	if err := reader.GetContext(ctx, &otpRow, partitionedQuery, args...); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrOTPNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrOTPNotFound
+		}
+		return nil, err
+	}
+
+	return otpRow.ToEntity(ctx, o.encryptor)
+}
+
+// FindNextByUserID retrieves the first OTP issued to a user after the given OTP.
+// Returns entity.ErrOTPNotFound if no later OTP exists for the user.
+// On MySQL, a later OTP is created no earlier than the given one, so only the partitions from its creation time are searched.
+func (o *otpRepository) FindNextByUserID(ctx context.Context, after *entity.OTP) (*entity.OTP, error) {
+	userIndex, err := userIDIndex(ctx, o.encryptor, after.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+		FROM otps
+		WHERE user_id = ? AND id > ?`
+	args := []any{userIndex, after.ID}
+	if dialectOf(o.db) == dialectMySQL && !after.CreatedAt.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, after.CreatedAt)
+	}
+	query += `
+		ORDER BY id
+		LIMIT 1`
+
+	var otpRow otpRow
+	if err := getReader(ctx, o.db, o.replicas).GetContext(ctx, &otpRow, query, args...); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrOTPNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrOTPNotFound
+		}
+		return nil, err
+	}
+
+	return otpRow.ToEntity(ctx, o.encryptor)
+}
+
+// List retrieves up to limit OTPs matching the filter with an ID lower than beforeID, newest first.
+// A zero beforeID starts from the most recent OTP. Only the conditions of the filter that are set
+// are added to the query, so that it can use the index of the most selective one.
+func (o *otpRepository) List(ctx context.Context, filter entity.OTPFilter, beforeID uint64, limit int) ([]*entity.OTP, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.UserID != "" {
+		userIndex, err := userIDIndex(ctx, o.encryptor, filter.UserID)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, userIndex)
+	}
+	if filter.Status != 0 {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Purpose != "" {
+		conditions = append(conditions, "purpose = ?")
+		args = append(args, filter.Purpose)
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *filter.CreatedTo)
+	}
+	if beforeID != 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, beforeID)
+	}
+
+	query := `
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+		FROM otps`
+	if len(conditions) > 0 {
+		query += `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+		ORDER BY id DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	var rows []otpRow
+	if err := getReader(ctx, o.db, o.replicas).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	return o.toEntities(ctx, rows)
+}
+
+// ListExpirable retrieves and locks up to limit OTPs that are still created but expired at the given time,
+// among the OTPs created within the given creation time bounds. OTPs locked by a concurrent transaction are skipped.
+// It must be called within a transaction. On MySQL, the creation time bounds limit the search to the partitions
+// of the OTPs that can have expired.
+func (o *otpRepository) ListExpirable(ctx context.Context, now time.Time, createdFrom time.Time, createdTo time.Time, limit int) ([]*entity.OTP, error) {
+	query := `
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+		FROM otps
+		WHERE status = ? AND expires_at <= ?`
+	args := []any{entity.OTPStatusCreated, now}
+	if dialectOf(o.db) == dialectMySQL {
+		query += " AND created_at >= ? AND created_at <= ?"
+		args = append(args, createdFrom, createdTo)
+	}
+	query += `
+		ORDER BY id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`
+	args = append(args, limit)
+
+	var rows []otpRow
+	if err := getExecutor(ctx, o.db).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	return o.toEntities(ctx, rows)
+}
+
+// ListActiveByUserID retrieves the OTPs of a user created at or after createdFrom that are still created
+// and not expired at the given time, oldest first. On MySQL, the creation time bound limits the search
+// to the partitions of the recent OTPs.
+func (o *otpRepository) ListActiveByUserID(ctx context.Context, userID string, now time.Time, createdFrom time.Time) ([]*entity.OTP, error) {
+	const query = `
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+		FROM otps
+		WHERE user_id = ? AND status = ? AND expires_at > ? AND created_at >= ?
+		ORDER BY id
+	`
+
+	userIndex, err := userIDIndex(ctx, o.encryptor, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []otpRow
+	if err := getReader(ctx, o.db, o.replicas).SelectContext(ctx, &rows, query, userIndex, entity.OTPStatusCreated, now, createdFrom); err != nil {
+		return nil, err
+	}
+
+	return o.toEntities(ctx, rows)
+}
+
+// MarkRevoked marks the given OTP as revoked at the given time, provided it is still created.
+// Returns false if the OTP was validated, expired or revoked in the meantime.
+// On MySQL, the update of an OTP read from the database only searches the partition of its creation time.
+func (o *otpRepository) MarkRevoked(ctx context.Context, otp *entity.OTP, revokedAt time.Time, reason string) (bool, error) {
+	query := `
+		UPDATE otps
+		SET status = ?, revoked_at = ?, revoke_reason = ?
+		WHERE id = ? AND status = ?`
+	args := []any{entity.OTPStatusRevoked, revokedAt, nullableString(reason), otp.ID, entity.OTPStatusCreated}
+	if dialectOf(o.db) == dialectMySQL && !otp.CreatedAt.IsZero() {
+		query += " AND created_at = ?"
+		args = append(args, otp.CreatedAt)
+	}
+
+	result, err := getExecutor(ctx, o.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// IncrementResendCount counts a resend of the given OTP, provided it is still created
+// and has resends left. Returns false otherwise.
+// On MySQL, the update of an OTP read from the database only searches the partition of its creation time.
+func (o *otpRepository) IncrementResendCount(ctx context.Context, otp *entity.OTP) (bool, error) {
+	query := `
+		UPDATE otps
+		SET resend_count = resend_count + 1
+		WHERE id = ? AND status = ? AND resend_count < resend_limit`
+	args := []any{otp.ID, entity.OTPStatusCreated}
+	if dialectOf(o.db) == dialectMySQL && !otp.CreatedAt.IsZero() {
+		query += " AND created_at = ?"
+		args = append(args, otp.CreatedAt)
+	}
+
+	result, err := getExecutor(ctx, o.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// MarkExpired marks the given OTPs as expired.
+// On MySQL, the update only searches the partitions between the creation times of the OTPs.
+func (o *otpRepository) MarkExpired(ctx context.Context, otps []*entity.OTP) error {
+	if len(otps) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(otps))
+	createdFrom, createdTo := otps[0].CreatedAt, otps[0].CreatedAt
+	for _, otp := range otps {
+		ids = append(ids, otp.ID)
+		if otp.CreatedAt.Before(createdFrom) {
+			createdFrom = otp.CreatedAt
+		}
+		if otp.CreatedAt.After(createdTo) {
+			createdTo = otp.CreatedAt
+		}
+	}
+
+	query := `
+		UPDATE otps
+		SET status = ?
+		WHERE id IN (?)`
+	args := []any{entity.OTPStatusExpired, ids}
+	if dialectOf(o.db) == dialectMySQL && !createdFrom.IsZero() {
+		query += " AND created_at BETWEEN ? AND ?"
+		args = append(args, createdFrom, createdTo)
+	}
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return err
+	}
+
+	_, err = getExecutor(ctx, o.db).ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// DeleteExpiredBefore deletes up to limit OTPs that expired before the given time among the OTPs created
+// within the given creation time bounds, unbounded below when createdFrom is zero,
+// and returns the number of OTPs it deleted.
+func (o *otpRepository) DeleteExpiredBefore(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error) {
+	if dialectOf(o.db) == dialectMySQL {
+		return o.deletePartitionedExpiredBefore(ctx, before, createdFrom, createdTo, limit)
+	}
+
+	// PostgreSQL and SQLite have no DELETE ... LIMIT, so the OTPs to delete are selected first
+	const query = `
+		DELETE FROM otps
+		WHERE id IN (SELECT id FROM otps WHERE expires_at < ? LIMIT ?)
+	`
+	result, err := getExecutor(ctx, o.db).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// deletePartitionedExpiredBefore deletes up to limit OTPs that expired before the given time from the otps table
+// of MySQL, along with the reservations of their codes in otp_codes and the mappings of their IDs in otp_ids.
+// The creation time bounds limit the search to the partitions of the OTPs that can have expired.
+func (o *otpRepository) deletePartitionedExpiredBefore(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error) {
+	selectQuery := `
+		SELECT id, user_id, otp_code
+		FROM otps
+		WHERE expires_at < ? AND created_at <= ?`
+	selectArgs := []any{before, createdTo}
+	if !createdFrom.IsZero() {
+		selectQuery += " AND created_at >= ?"
+		selectArgs = append(selectArgs, createdFrom)
+	}
+	selectQuery += `
+		LIMIT ?`
+	selectArgs = append(selectArgs, limit)
+
+	var deleted int64
+	err := withinTransaction(ctx, o.db, func(ctx context.Context) error {
+		executor := getExecutor(ctx, o.db)
+
+		var rows []struct {
+			ID      uint64 `db:"id"`
+			UserID  string `db:"user_id"`
+			OTPCode string `db:"otp_code"`
+		}
+		if err := executor.SelectContext(ctx, &rows, selectQuery, selectArgs...); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]uint64, 0, len(rows))
+		codes := make([]any, 0, 2*len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+			codes = append(codes, row.UserID, row.OTPCode)
+		}
+
+		query, args, err := sqlx.In(`DELETE FROM otps WHERE id IN (?)`, ids)
+		if err != nil {
+			return err
+		}
+		result, err := executor.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if deleted, err = result.RowsAffected(); err != nil {
+			return err
+		}
+
+		// The codes are released once their OTP is deleted, so that they can be issued again
+		releaseQuery := `DELETE FROM otp_codes WHERE (user_id, otp_code) IN (` +
+			strings.TrimSuffix(strings.Repeat("(?, ?), ", len(rows)), ", ") + `)`
+		if _, err := executor.ExecContext(ctx, releaseQuery, codes...); err != nil {
+			return err
+		}
+
+		query, args, err = sqlx.In(`DELETE FROM otp_ids WHERE id IN (?)`, ids)
+		if err != nil {
+			return err
+		}
+		_, err = executor.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// ReencryptStale encrypts up to limit OTPs stored in plaintext or encrypted with a previous data key
+// with the current data key, and returns the number of OTPs it encrypted again.
+// On MySQL, the reservations of the codes of the OTPs stored in plaintext are indexed along with them.
+func (o *otpRepository) ReencryptStale(ctx context.Context, limit int) (int64, error) {
+	const (
+		selectQuery = `
+			SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+			FROM otps
+			WHERE key_id IS NULL OR key_id <> ?
+			ORDER BY id
+			LIMIT ?
+		`
+		reserveQuery = `
+			UPDATE otp_codes
+			SET user_id = ?, otp_code = ?
+			WHERE user_id = ? AND otp_code = ?
+		`
+	)
+	if o.encryptor == nil {
+		return 0, errors.New("encryption is not configured")
+	}
+
+	keyID, err := o.encryptor.CurrentKeyID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var reencrypted int64
+	err = withinTransaction(ctx, o.db, func(ctx context.Context) error {
+		executor := getExecutor(ctx, o.db)
+
+		var rows []otpRow
+		if err := executor.SelectContext(ctx, &rows, selectQuery, keyID, limit); err != nil {
+			return err
+		}
+
+		for i := range rows {
+			otp, err := rows[i].ToEntity(ctx, o.encryptor)
+			if err != nil {
+				return err
+			}
+			row, err := newOTPRow(ctx, otp, o.encryptor)
+			if err != nil {
+				return err
+			}
+
+			query := `
+				UPDATE otps
+				SET user_id = ?, otp_code = ?, user_id_ciphertext = ?, otp_code_ciphertext = ?, key_id = ?
+				WHERE id = ?`
+			args := []any{row.UserID, row.OTPCode, row.UserIDCiphertext, row.OTPCodeCiphertext, row.KeyID, row.ID}
+			if dialectOf(o.db) == dialectMySQL {
+				query += " AND created_at = ?"
+				args = append(args, row.CreatedAt)
+			}
+			if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+
+			// The blind index key is never rotated, so only the codes reserved in plaintext are indexed again
+			indexed := row.UserID != rows[i].UserID || row.OTPCode != rows[i].OTPCode
+			if dialectOf(o.db) == dialectMySQL && indexed {
+				if _, err := executor.ExecContext(ctx, reserveQuery, row.UserID, row.OTPCode, rows[i].UserID, rows[i].OTPCode); err != nil {
+					return err
+				}
+			}
+		}
+		reencrypted = int64(len(rows))
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return reencrypted, nil
+}
+
+// CountByStatus counts the stored OTPs of each status. Statuses without OTPs are omitted.
+func (o *otpRepository) CountByStatus(ctx context.Context) (map[entity.OTPStatus]int64, error) {
+	const query = `
+		SELECT status, COUNT(*) AS count
+		FROM otps
+		GROUP BY status
+	`
+
+	var rows []struct {
+		Status entity.OTPStatus `db:"status"`
+		Count  int64            `db:"count"`
+	}
+	if err := getReader(ctx, o.db, o.replicas).SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[entity.OTPStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+// toEntities converts the given rows to entity.OTP
+func (o *otpRepository) toEntities(ctx context.Context, rows []otpRow) ([]*entity.OTP, error) {
+	otps := make([]*entity.OTP, 0, len(rows))
+	for i := range rows {
+		otp, err := rows[i].ToEntity(ctx, o.encryptor)
+		if err != nil {
+			return nil, err
+		}
+		otps = append(otps, otp)
+	}
+
+	return otps, nil
 }
@@ -3,6 +3,8 @@ package repository_test
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
@@ -15,6 +17,46 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// expectCreateOTP expects the creation of an OTP inserted with the given arguments, which is assigned the given ID
+// or fails with the given error. On MySQL, the code of the OTP is first reserved in otp_codes, which fails instead
+// on a unique constraint violation, and the OTP is inserted with the time of the reservation within a transaction,
+// which then maps the ID of the OTP to the time of the reservation.
+func (r *repositoryDependency) expectCreateOTP(args []driver.Value, id int64, err error) {
+	if r.isPostgres() {
+		r.expectInsert(regexp.QuoteMeta("INSERT INTO otps (user_id, otp_code, status, expires_at, binding_hash, purpose, client_id, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"), args, id, err)
+		return
+	}
+
+	r.mockedSQL.ExpectBegin()
+	reservation := r.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("INSERT INTO otp_codes (user_id, otp_code, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)")).
+		WithArgs(args[0], args[1])
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		reservation.WillReturnError(err)
+		r.mockedSQL.ExpectRollback()
+		return
+	}
+	reservation.WillReturnResult(sqlmock.NewResult(0, 1))
+
+	insertArgs := append(append([]driver.Value{}, args[2:]...), args[0], args[1])
+	r.expectInsert(regexp.QuoteMeta(`
+		INSERT INTO otps (user_id, otp_code, status, created_at, expires_at, binding_hash, purpose, client_id, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id)
+		SELECT user_id, otp_code, ?, created_at, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		FROM otp_codes
+		WHERE user_id = ? AND otp_code = ?
+	`), insertArgs, id, err)
+	if err != nil {
+		r.mockedSQL.ExpectRollback()
+		return
+	}
+	r.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("INSERT INTO otp_ids (id, created_at) SELECT ?, created_at FROM otp_codes WHERE user_id = ? AND otp_code = ?")).
+		WithArgs(id, args[0], args[1]).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	r.mockedSQL.ExpectCommit()
+}
+
 func TestOTPRepository_Create(t *testing.T) {
 	type Input struct {
 		ctx context.Context
@@ -31,7 +73,19 @@ func TestOTPRepository_Create(t *testing.T) {
 		ExpiresAt: expiresAt,
 	}
 
-	expectedQuery := regexp.QuoteMeta("INSERT INTO otps (user_id, otp_code, status, expires_at) VALUES (?, ?, ?, ?)")
+	anotherOTP := &entity.OTP{
+		UserID:      "user456",
+		OTPCode:     "654321",
+		Status:      entity.OTPStatusCreated,
+		ExpiresAt:   expiresAt,
+		BindingHash: "binding-hash",
+		Purpose:     "login",
+		ClientID:    "client-a",
+		ResendCount: 1,
+		ResendLimit: 3,
+	}
+
+	dummyOTPArgs := []driver.Value{"user123", "123456", entity.OTPStatusCreated, expiresAt, nil, nil, nil, 0, 0, nil, nil, nil}
 
 	tests := []struct {
 		name           string
@@ -46,11 +100,7 @@ func TestOTPRepository_Create(t *testing.T) {
 				otp: &dummyOTP,
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectExec(expectedQuery).
-					WithArgs("user123", "123456", entity.OTPStatusCreated, expiresAt).
-					WillReturnResult(sqlmock.NewResult(1, 1)).
-					WillReturnError(nil)
+				dependency.expectCreateOTP(dummyOTPArgs, 1, nil)
 			},
 			assertFn: func(err error) {
 				assert.Nil(t, err)
@@ -60,22 +110,14 @@ func TestOTPRepository_Create(t *testing.T) {
 			name: "Should successfully create OTP with different values",
 			input: Input{
 				ctx: context.TODO(),
-				otp: &entity.OTP{
-					UserID:    "user456",
-					OTPCode:   "654321",
-					Status:    entity.OTPStatusCreated,
-					ExpiresAt: expiresAt,
-				},
+				otp: anotherOTP,
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectExec(expectedQuery).
-					WithArgs("user456", "654321", entity.OTPStatusCreated, expiresAt).
-					WillReturnResult(sqlmock.NewResult(2, 1)).
-					WillReturnError(nil)
+				dependency.expectCreateOTP([]driver.Value{"user456", "654321", entity.OTPStatusCreated, expiresAt, "binding-hash", "login", "client-a", 1, 3, nil, nil, nil}, 2, nil)
 			},
 			assertFn: func(err error) {
 				assert.Nil(t, err)
+				assert.Equal(t, uint64(2), anotherOTP.ID)
 			},
 		},
 		{
@@ -85,13 +127,7 @@ func TestOTPRepository_Create(t *testing.T) {
 				otp: &dummyOTP,
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectExec(expectedQuery).
-					WithArgs("user123", "123456", entity.OTPStatusCreated, expiresAt).
-					WillReturnError(&mysql.MySQLError{
-						Number:  1062,
-						Message: "Duplicate entry 'user123-123456' for key 'unique_user_otp'",
-					})
+				dependency.expectCreateOTP(dummyOTPArgs, 0, dependency.uniqueViolation())
 			},
 			assertFn: func(err error) {
 				assert.NotNil(t, err)
@@ -105,10 +141,7 @@ func TestOTPRepository_Create(t *testing.T) {
 				otp: &dummyOTP,
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectExec(expectedQuery).
-					WithArgs("user123", "123456", entity.OTPStatusCreated, expiresAt).
-					WillReturnError(sqlmock.ErrCancelled)
+				dependency.expectCreateOTP(dummyOTPArgs, 0, sqlmock.ErrCancelled)
 			},
 			assertFn: func(err error) {
 				assert.NotNil(t, err)
@@ -122,10 +155,7 @@ func TestOTPRepository_Create(t *testing.T) {
 				otp: &dummyOTP,
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectExec(expectedQuery).
-					WithArgs("user123", "123456", entity.OTPStatusCreated, expiresAt).
-					WillReturnError(sql.ErrConnDone)
+				dependency.expectCreateOTP(dummyOTPArgs, 0, sql.ErrConnDone)
 			},
 			assertFn: func(err error) {
 				assert.NotNil(t, err)
@@ -139,10 +169,7 @@ func TestOTPRepository_Create(t *testing.T) {
 				otp: &dummyOTP,
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectExec(expectedQuery).
-					WithArgs("user123", "123456", entity.OTPStatusCreated, expiresAt).
-					WillReturnError(sql.ErrTxDone)
+				dependency.expectCreateOTP(dummyOTPArgs, 0, sql.ErrTxDone)
 			},
 			assertFn: func(err error) {
 				assert.NotNil(t, err)
@@ -151,23 +178,25 @@ func TestOTPRepository_Create(t *testing.T) {
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			var (
-				ctrl                 = gomock.NewController(t)
-				repositoryDependency = newRepoDependency()
-				repo                 = repository.NewOTPRepository(repositoryDependency.mockedDB)
-			)
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				var (
+					ctrl                 = gomock.NewController(t)
+					repositoryDependency = newRepoDependencyFor(driverName)
+					repo                 = repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+				)
 
-			defer ctrl.Finish()
-			defer repositoryDependency.mockedDB.Close()
+				defer ctrl.Finish()
+				defer repositoryDependency.mockedDB.Close()
 
-			tt.mockDependency(repositoryDependency)
-			tt.assertFn(repo.Create(tt.input.ctx, tt.input.otp))
+				tt.mockDependency(repositoryDependency)
+				tt.assertFn(repo.Create(tt.input.ctx, tt.input.otp))
 
-			// Verify all expectations were met
-			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
-		})
+				// Verify all expectations were met
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
 	}
 }
 
@@ -179,11 +208,26 @@ func TestOTPRepository_FindByUserIDAndCode(t *testing.T) {
 	}
 
 	now := time.Now()
+	createdAt := now.Truncate(time.Second)
 	expectedQuery := regexp.QuoteMeta(`
-		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
 		FROM otps
 		WHERE user_id = ? AND otp_code = ?
 	`)
+	createdAtQuery := regexp.QuoteMeta(`SELECT created_at FROM otp_codes WHERE user_id = ? AND otp_code = ?`)
+
+	// expectOTPQuery expects the query of the OTP with the given code, which on MySQL is only searched
+	// in the partition of the creation time reserved with its code
+	expectOTPQuery := func(dependency *repositoryDependency, otpCode string) *sqlmock.ExpectedQuery {
+		if dependency.isPostgres() {
+			return dependency.mockedSQL.ExpectQuery(expectedQuery).WithArgs("user123", otpCode)
+		}
+		dependency.mockedSQL.
+			ExpectQuery(createdAtQuery).
+			WithArgs("user123", otpCode).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+		return dependency.mockedSQL.ExpectQuery(expectedQuery+regexp.QuoteMeta(" AND created_at = ?")).WithArgs("user123", otpCode, createdAt)
+	}
 
 	tests := []struct {
 		name           string
@@ -199,13 +243,11 @@ func TestOTPRepository_FindByUserIDAndCode(t *testing.T) {
 				otpCode: "123456",
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectQuery(expectedQuery).
-					WithArgs("user123", "123456").
+				expectOTPQuery(dependency, "123456").
 					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at",
+						"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at", "validated_session_hash", "binding_hash", "purpose",
 					}).AddRow(
-						1, "user123", "123456", entity.OTPStatusCreated, now, now.Add(5*time.Minute), nil,
+						1, "user123", "123456", entity.OTPStatusCreated, now, now.Add(5*time.Minute), nil, nil, "binding-hash", "login",
 					))
 			},
 			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
@@ -213,6 +255,8 @@ func TestOTPRepository_FindByUserIDAndCode(t *testing.T) {
 				assert.NotNil(t, otp)
 				assert.Equal(t, "user123", otp.UserID)
 				assert.Equal(t, "123456", otp.OTPCode)
+				assert.Equal(t, "binding-hash", otp.BindingHash)
+				assert.Equal(t, "login", otp.Purpose)
 			},
 		},
 		{
@@ -223,9 +267,7 @@ func TestOTPRepository_FindByUserIDAndCode(t *testing.T) {
 				otpCode: "000000",
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectQuery(expectedQuery).
-					WithArgs("user123", "000000").
+				expectOTPQuery(dependency, "000000").
 					WillReturnError(sql.ErrNoRows)
 			},
 			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
@@ -241,9 +283,7 @@ func TestOTPRepository_FindByUserIDAndCode(t *testing.T) {
 				otpCode: "123456",
 			},
 			mockDependency: func(dependency *repositoryDependency) {
-				dependency.mockedSQL.
-					ExpectQuery(expectedQuery).
-					WithArgs("user123", "123456").
+				expectOTPQuery(dependency, "123456").
 					WillReturnError(sql.ErrConnDone)
 			},
 			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
@@ -251,21 +291,45 @@ func TestOTPRepository_FindByUserIDAndCode(t *testing.T) {
 				assert.Equal(t, sql.ErrConnDone, err)
 			},
 		},
+		{
+			name: "Should return ErrOTPNotFound when the code is not reserved",
+			input: Input{
+				ctx:     context.TODO(),
+				userID:  "user123",
+				otpCode: "000000",
+			},
+			mockDependency: func(dependency *repositoryDependency) {
+				query := expectedQuery
+				if !dependency.isPostgres() {
+					query = createdAtQuery
+				}
+				dependency.mockedSQL.
+					ExpectQuery(query).
+					WithArgs("user123", "000000").
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repositoryDependency := newRepoDependency()
-			repo := repository.NewOTPRepository(repositoryDependency.mockedDB)
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
 
-			defer repositoryDependency.mockedDB.Close()
+				defer repositoryDependency.mockedDB.Close()
 
-			tt.mockDependency(repositoryDependency)
-			otp, err := repo.FindByUserIDAndCode(tt.input.ctx, tt.input.userID, tt.input.otpCode)
-			tt.assertFn(t, otp, err)
+				tt.mockDependency(repositoryDependency)
+				otp, err := repo.FindByUserIDAndCode(tt.input.ctx, tt.input.userID, tt.input.otpCode)
+				tt.assertFn(t, otp, err)
 
-			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
-		})
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
 	}
 }
 
@@ -277,25 +341,54 @@ func TestOTPRepository_Update(t *testing.T) {
 
 	now := time.Now()
 	dummyOTP := &entity.OTP{
-		ID:          1,
-		UserID:      "user123",
-		OTPCode:     "123456",
-		Status:      entity.OTPStatusValidated,
-		ValidatedAt: &now,
+		ID:                   1,
+		UserID:               "user123",
+		OTPCode:              "123456",
+		Status:               entity.OTPStatusValidated,
+		ValidatedAt:          &now,
+		ValidatedSessionHash: "session-hash",
 	}
 
 	expectedQuery := regexp.QuoteMeta(`
 		UPDATE otps
-		SET status = ?, validated_at = ?
-		WHERE id = ?
+		SET status = ?, validated_at = ?, validated_session_hash = ?
+		WHERE id = ? AND status = ?
 	`)
 
+	createdAt := now.Truncate(time.Second)
+	readOTP := *dummyOTP
+	readOTP.CreatedAt = createdAt
+
 	tests := []struct {
 		name           string
 		mockDependency func(*repositoryDependency)
-		assertFn       func(error)
+		assertFn       func(bool, error)
 		input          Input
 	}{
+		{
+			name: "Should update an OTP read from the database within the partition of its creation time on MySQL",
+			input: Input{
+				ctx: context.TODO(),
+				otp: &readOTP,
+			},
+			mockDependency: func(dependency *repositoryDependency) {
+				if dependency.isPostgres() {
+					dependency.mockedSQL.
+						ExpectExec(expectedQuery).
+						WithArgs(entity.OTPStatusValidated, now, "session-hash", 1, entity.OTPStatusCreated).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					return
+				}
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery+regexp.QuoteMeta(" AND created_at = ?")).
+					WithArgs(entity.OTPStatusValidated, now, "session-hash", 1, entity.OTPStatusCreated, createdAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			assertFn: func(updated bool, err error) {
+				assert.Nil(t, err)
+				assert.True(t, updated)
+			},
+		},
 		{
 			name: "Should update OTP successfully",
 			input: Input{
@@ -305,11 +398,29 @@ func TestOTPRepository_Update(t *testing.T) {
 			mockDependency: func(dependency *repositoryDependency) {
 				dependency.mockedSQL.
 					ExpectExec(expectedQuery).
-					WithArgs(entity.OTPStatusValidated, now, 1).
+					WithArgs(entity.OTPStatusValidated, now, "session-hash", 1, entity.OTPStatusCreated).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
-			assertFn: func(err error) {
+			assertFn: func(updated bool, err error) {
+				assert.Nil(t, err)
+				assert.True(t, updated)
+			},
+		},
+		{
+			name: "Should not update an OTP that is no longer created",
+			input: Input{
+				ctx: context.TODO(),
+				otp: dummyOTP,
+			},
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(entity.OTPStatusValidated, now, "session-hash", 1, entity.OTPStatusCreated).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			assertFn: func(updated bool, err error) {
 				assert.Nil(t, err)
+				assert.False(t, updated)
 			},
 		},
 		{
@@ -321,29 +432,31 @@ func TestOTPRepository_Update(t *testing.T) {
 			mockDependency: func(dependency *repositoryDependency) {
 				dependency.mockedSQL.
 					ExpectExec(expectedQuery).
-					WithArgs(entity.OTPStatusValidated, now, 1).
+					WithArgs(entity.OTPStatusValidated, now, "session-hash", 1, entity.OTPStatusCreated).
 					WillReturnError(sql.ErrConnDone)
 			},
-			assertFn: func(err error) {
+			assertFn: func(updated bool, err error) {
+				assert.False(t, updated)
 				assert.NotNil(t, err)
 				assert.Equal(t, sql.ErrConnDone, err)
 			},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repositoryDependency := newRepoDependency()
-			repo := repository.NewOTPRepository(repositoryDependency.mockedDB)
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
 
-			defer repositoryDependency.mockedDB.Close()
+				defer repositoryDependency.mockedDB.Close()
 
-			tt.mockDependency(repositoryDependency)
-			err := repo.Update(tt.input.ctx, tt.input.otp)
-			tt.assertFn(err)
+				tt.mockDependency(repositoryDependency)
+				tt.assertFn(repo.Update(tt.input.ctx, tt.input.otp))
 
-			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
-		})
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
 	}
 }
 
@@ -354,11 +467,12 @@ func TestOTPRepository_GetLastByUserID(t *testing.T) {
 	}
 
 	now := time.Now()
+	createdFrom := now.Add(-time.Minute)
 	expectedQuery := regexp.QuoteMeta(`
-		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
 		FROM otps
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		WHERE user_id = ? AND created_at >= ?
+		ORDER BY created_at DESC, id DESC
 		LIMIT 1
 	`)
 
@@ -377,11 +491,11 @@ func TestOTPRepository_GetLastByUserID(t *testing.T) {
 			mockDependency: func(dependency *repositoryDependency) {
 				dependency.mockedSQL.
 					ExpectQuery(expectedQuery).
-					WithArgs("user123").
+					WithArgs("user123", createdFrom).
 					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at",
+						"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at", "validated_session_hash", "binding_hash",
 					}).AddRow(
-						1, "user123", "123456", entity.OTPStatusCreated, now, now.Add(2*time.Minute), nil,
+						1, "user123", "123456", entity.OTPStatusCreated, now, now.Add(2*time.Minute), nil, nil, "binding-hash",
 					))
 			},
 			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
@@ -401,7 +515,7 @@ func TestOTPRepository_GetLastByUserID(t *testing.T) {
 			mockDependency: func(dependency *repositoryDependency) {
 				dependency.mockedSQL.
 					ExpectQuery(expectedQuery).
-					WithArgs("user999").
+					WithArgs("user999", createdFrom).
 					WillReturnError(sql.ErrNoRows)
 			},
 			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
@@ -418,7 +532,7 @@ func TestOTPRepository_GetLastByUserID(t *testing.T) {
 			mockDependency: func(dependency *repositoryDependency) {
 				dependency.mockedSQL.
 					ExpectQuery(expectedQuery).
-					WithArgs("user123").
+					WithArgs("user123", createdFrom).
 					WillReturnError(sql.ErrConnDone)
 			},
 			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
@@ -428,16 +542,644 @@ func TestOTPRepository_GetLastByUserID(t *testing.T) {
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repositoryDependency := newRepoDependency()
-			repo := repository.NewOTPRepository(repositoryDependency.mockedDB)
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+
+				defer repositoryDependency.mockedDB.Close()
+
+				tt.mockDependency(repositoryDependency)
+				otp, err := repo.GetLastByUserID(tt.input.ctx, tt.input.userID, createdFrom)
+				tt.assertFn(t, otp, err)
+
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestOTPRepository_FindByID(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta(`
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+		FROM otps
+		WHERE id = ?
+	`)
+	createdAtQuery := regexp.QuoteMeta(`SELECT created_at FROM otp_ids WHERE id = ?`)
+
+	// expectQuery expects the query of the OTP, which is only searched in the partition of its creation time on MySQL
+	expectQuery := func(dependency *repositoryDependency) *sqlmock.ExpectedQuery {
+		if dependency.isPostgres() {
+			return dependency.mockedSQL.ExpectQuery(expectedQuery).WithArgs(42)
+		}
+		dependency.mockedSQL.
+			ExpectQuery(createdAtQuery).
+			WithArgs(42).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+		return dependency.mockedSQL.ExpectQuery(expectedQuery+regexp.QuoteMeta(" AND created_at = ?")).WithArgs(42, now)
+	}
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*testing.T, *entity.OTP, error)
+	}{
+		{
+			name: "Should return the OTP successfully",
+			mockDependency: func(dependency *repositoryDependency) {
+				expectQuery(dependency).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at", "validated_session_hash", "binding_hash",
+					}).AddRow(
+						42, "user123", "123456", entity.OTPStatusValidated, now, now.Add(2*time.Minute), now, nil, nil,
+					))
+			},
+			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(42), otp.ID)
+				assert.Equal(t, entity.OTPStatusValidated, otp.Status)
+			},
+		},
+		{
+			name: "Should return ErrOTPNotFound when no OTP exists with the ID",
+			mockDependency: func(dependency *repositoryDependency) {
+				if dependency.isPostgres() {
+					dependency.mockedSQL.
+						ExpectQuery(expectedQuery).
+						WithArgs(42).
+						WillReturnError(sql.ErrNoRows)
+					return
+				}
+				// No ID was mapped
+				dependency.mockedSQL.
+					ExpectQuery(createdAtQuery).
+					WithArgs(42).
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
+	}
+
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+				defer repositoryDependency.mockedDB.Close()
+
+				tt.mockDependency(repositoryDependency)
+				otp, err := repo.FindByID(context.TODO(), 42)
+				tt.assertFn(t, otp, err)
+
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestOTPRepository_FindNextByUserID(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta(`
+		SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+		FROM otps
+		WHERE user_id = ? AND id > ?
+	`)
+	orderQuery := regexp.QuoteMeta("ORDER BY id LIMIT 1")
+
+	// expectQuery expects the query of the next OTP, which is only searched from the partition of the given OTP on MySQL
+	expectQuery := func(dependency *repositoryDependency) *sqlmock.ExpectedQuery {
+		if dependency.isPostgres() {
+			return dependency.mockedSQL.ExpectQuery(expectedQuery+orderQuery).WithArgs("user123", 42)
+		}
+		return dependency.mockedSQL.ExpectQuery(expectedQuery+regexp.QuoteMeta(" AND created_at >= ? ")+orderQuery).WithArgs("user123", 42, now)
+	}
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*testing.T, *entity.OTP, error)
+	}{
+		{
+			name: "Should return the next OTP of the user",
+			mockDependency: func(dependency *repositoryDependency) {
+				expectQuery(dependency).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at", "validated_session_hash", "binding_hash",
+					}).AddRow(
+						43, "user123", "654321", entity.OTPStatusCreated, now, now.Add(2*time.Minute), nil, nil, nil,
+					))
+			},
+			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(43), otp.ID)
+			},
+		},
+		{
+			name: "Should return ErrOTPNotFound when no later OTP exists",
+			mockDependency: func(dependency *repositoryDependency) {
+				expectQuery(dependency).
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(t *testing.T, otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
+	}
+
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+				defer repositoryDependency.mockedDB.Close()
+
+				tt.mockDependency(repositoryDependency)
+				otp, err := repo.FindNextByUserID(context.TODO(), &entity.OTP{ID: 42, UserID: "user123", CreatedAt: now})
+				tt.assertFn(t, otp, err)
+
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestOTPRepository_List(t *testing.T) {
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	columns := []string{"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at", "validated_session_hash", "binding_hash", "purpose"}
+
+	tests := []struct {
+		name           string
+		filter         entity.OTPFilter
+		beforeID       uint64
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*testing.T, []*entity.OTP, error)
+	}{
+		{
+			name: "Should list the most recent OTPs without a filter",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(regexp.QuoteMeta(`
+						SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+						FROM otps
+						ORDER BY id DESC
+						LIMIT ?
+					`)).
+					WithArgs(50).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(43, "user123", "654321", entity.OTPStatusCreated, now, now.Add(2*time.Minute), nil, nil, nil, "login").
+						AddRow(42, "user456", "123456", entity.OTPStatusExpired, now, now.Add(2*time.Minute), nil, nil, nil, nil))
+			},
+			assertFn: func(t *testing.T, otps []*entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, otps, 2)
+				assert.Equal(t, uint64(43), otps[0].ID)
+				assert.Equal(t, "login", otps[0].Purpose)
+			},
+		},
+		{
+			name: "Should only add the conditions of the filter that are set",
+			filter: entity.OTPFilter{
+				UserID:      "user123",
+				Status:      entity.OTPStatusCreated,
+				Purpose:     "login",
+				CreatedFrom: &from,
+				CreatedTo:   &now,
+			},
+			beforeID: 43,
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(regexp.QuoteMeta(`
+						SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+						FROM otps
+						WHERE user_id = ? AND status = ? AND purpose = ? AND created_at >= ? AND created_at < ? AND id < ?
+						ORDER BY id DESC
+						LIMIT ?
+					`)).
+					WithArgs("user123", entity.OTPStatusCreated, "login", from, now, 43, 50).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			assertFn: func(t *testing.T, otps []*entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, otps)
+			},
+		},
+		{
+			name:   "Should return error when DB fails",
+			filter: entity.OTPFilter{UserID: "user123"},
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(regexp.QuoteMeta("FROM otps WHERE user_id = ? ORDER BY id DESC LIMIT ?")).
+					WithArgs("user123", 50).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(t *testing.T, otps []*entity.OTP, err error) {
+				assert.Nil(t, otps)
+				assert.Equal(t, sql.ErrConnDone, err)
+			},
+		},
+	}
+
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+				defer repositoryDependency.mockedDB.Close()
+
+				tt.mockDependency(repositoryDependency)
+				otps, err := repo.List(context.TODO(), tt.filter, tt.beforeID, 50)
+				tt.assertFn(t, otps, err)
+
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
 
+func TestOTPRepository_ListExpirable(t *testing.T) {
+	var (
+		now         = time.Now()
+		createdFrom = now.Add(-24 * time.Hour)
+		createdTo   = now.Add(-time.Minute)
+	)
+
+	// On MySQL, the creation time bounds let the query only search the partitions of the OTPs that can have expired
+	expectedQueryAndArgs := func(dependency *repositoryDependency) (string, []driver.Value) {
+		if dependency.isPostgres() {
+			return regexp.QuoteMeta(`
+				SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+				FROM otps
+				WHERE status = ? AND expires_at <= ?
+				ORDER BY id
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			`), []driver.Value{entity.OTPStatusCreated, now, 500}
+		}
+		return regexp.QuoteMeta(`
+			SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+			FROM otps
+			WHERE status = ? AND expires_at <= ? AND created_at >= ? AND created_at <= ?
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		`), []driver.Value{entity.OTPStatusCreated, now, createdFrom, createdTo, 500}
+	}
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func([]*entity.OTP, error)
+	}{
+		{
+			name: "Should return the expirable OTPs",
+			mockDependency: func(dependency *repositoryDependency) {
+				expectedQuery, expectedArgs := expectedQueryAndArgs(dependency)
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(expectedArgs...).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "otp_code", "status", "created_at", "expires_at", "validated_at", "validated_session_hash", "binding_hash"}).
+						AddRow(1, "user123", "123456", entity.OTPStatusCreated, now, now, nil, nil, nil).
+						AddRow(2, "user456", "654321", entity.OTPStatusCreated, now, now, nil, nil, nil))
+			},
+			assertFn: func(otps []*entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Len(t, otps, 2)
+				assert.Equal(t, uint64(2), otps[1].ID)
+				assert.Equal(t, "user456", otps[1].UserID)
+			},
+		},
+		{
+			name: "Should return error when query fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				expectedQuery, expectedArgs := expectedQueryAndArgs(dependency)
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(expectedArgs...).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(otps []*entity.OTP, err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+				assert.Nil(t, otps)
+			},
+		},
+	}
+
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+
+				defer repositoryDependency.mockedDB.Close()
+
+				tt.mockDependency(repositoryDependency)
+				tt.assertFn(repo.ListExpirable(context.TODO(), now, createdFrom, createdTo, 500))
+
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestOTPRepository_ListActiveByUserID(t *testing.T) {
+	now := time.Now()
+
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName, func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
 			defer repositoryDependency.mockedDB.Close()
 
-			tt.mockDependency(repositoryDependency)
-			otp, err := repo.GetLastByUserID(tt.input.ctx, tt.input.userID)
-			tt.assertFn(t, otp, err)
+			repositoryDependency.mockedSQL.
+				ExpectQuery(regexp.QuoteMeta(`
+					SELECT id, user_id, otp_code, status, created_at, expires_at, validated_at, validated_session_hash, binding_hash, purpose, client_id, revoked_at, revoke_reason, resend_count, resend_limit, user_id_ciphertext, otp_code_ciphertext, key_id
+					FROM otps
+					WHERE user_id = ? AND status = ? AND expires_at > ? AND created_at >= ?
+					ORDER BY id
+				`)).
+				WithArgs("user123", entity.OTPStatusCreated, now, now.Add(-time.Hour)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "otp_code", "status", "created_at", "expires_at"}).
+					AddRow(1, "user123", "123456", entity.OTPStatusCreated, now, now.Add(time.Minute)).
+					AddRow(2, "user123", "654321", entity.OTPStatusCreated, now, now.Add(time.Minute)))
+
+			otps, err := repo.ListActiveByUserID(context.TODO(), "user123", now, now.Add(-time.Hour))
+			assert.NoError(t, err)
+			assert.Len(t, otps, 2)
+			assert.Equal(t, uint64(2), otps[1].ID)
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOTPRepository_MarkRevoked(t *testing.T) {
+	now := time.Now()
+	createdAt := now.Truncate(time.Second)
+	expectedQuery := regexp.QuoteMeta("UPDATE otps SET status = ?, revoked_at = ?, revoke_reason = ? WHERE id = ? AND status = ?")
+
+	tests := []struct {
+		name           string
+		otp            *entity.OTP
+		reason         string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(bool, error)
+	}{
+		{
+			name:   "Should revoke an OTP read from the database within the partition of its creation time on MySQL",
+			otp:    &entity.OTP{ID: 1, CreatedAt: createdAt},
+			reason: "sim_swap",
+			mockDependency: func(dependency *repositoryDependency) {
+				if dependency.isPostgres() {
+					dependency.mockedSQL.
+						ExpectExec(expectedQuery).
+						WithArgs(entity.OTPStatusRevoked, now, sql.NullString{String: "sim_swap", Valid: true}, 1, entity.OTPStatusCreated).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					return
+				}
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery+regexp.QuoteMeta(" AND created_at = ?")).
+					WithArgs(entity.OTPStatusRevoked, now, sql.NullString{String: "sim_swap", Valid: true}, 1, entity.OTPStatusCreated, createdAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			assertFn: func(revoked bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, revoked)
+			},
+		},
+		{
+			name:   "Should revoke a created OTP",
+			otp:    &entity.OTP{ID: 1},
+			reason: "sim_swap",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(entity.OTPStatusRevoked, now, sql.NullString{String: "sim_swap", Valid: true}, 1, entity.OTPStatusCreated).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			assertFn: func(revoked bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, revoked)
+			},
+		},
+		{
+			name: "Should return false when the OTP is no longer created",
+			otp:  &entity.OTP{ID: 1},
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(entity.OTPStatusRevoked, now, sql.NullString{}, 1, entity.OTPStatusCreated).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			assertFn: func(revoked bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, revoked)
+			},
+		},
+		{
+			name: "Should return error when update fails",
+			otp:  &entity.OTP{ID: 1},
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(entity.OTPStatusRevoked, now, sql.NullString{}, 1, entity.OTPStatusCreated).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(revoked bool, err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+				assert.False(t, revoked)
+			},
+		},
+	}
+
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+
+				defer repositoryDependency.mockedDB.Close()
+
+				tt.mockDependency(repositoryDependency)
+				tt.assertFn(repo.MarkRevoked(context.TODO(), tt.otp, now, tt.reason))
+
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestOTPRepository_IncrementResendCount(t *testing.T) {
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName, func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+			defer repositoryDependency.mockedDB.Close()
+
+			// The OTP read from the database is only searched in the partition of its creation time on MySQL
+			createdAt := time.Now().Truncate(time.Second)
+			otp := &entity.OTP{ID: 1, CreatedAt: createdAt}
+			expectedQuery := regexp.QuoteMeta("UPDATE otps SET resend_count = resend_count + 1 WHERE id = ? AND status = ? AND resend_count < resend_limit")
+			expectedArgs := []driver.Value{1, entity.OTPStatusCreated}
+			if !repositoryDependency.isPostgres() {
+				expectedQuery += regexp.QuoteMeta(" AND created_at = ?")
+				expectedArgs = append(expectedArgs, createdAt)
+			}
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs(expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs(expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			incremented, err := repo.IncrementResendCount(context.TODO(), otp)
+			assert.NoError(t, err)
+			assert.True(t, incremented)
+
+			// The OTP has no resends left, or is no longer created
+			incremented, err = repo.IncrementResendCount(context.TODO(), otp)
+			assert.NoError(t, err)
+			assert.False(t, incremented)
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOTPRepository_MarkExpired(t *testing.T) {
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName, func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+			defer repositoryDependency.mockedDB.Close()
+
+			// The OTPs are only searched in the partitions between their creation times on MySQL
+			createdAt := time.Now().Truncate(time.Second)
+			otps := []*entity.OTP{{ID: 1, CreatedAt: createdAt.Add(time.Minute)}, {ID: 2, CreatedAt: createdAt}}
+			expectedQuery := regexp.QuoteMeta("UPDATE otps SET status = ? WHERE id IN (?, ?)")
+			expectedArgs := []driver.Value{entity.OTPStatusExpired, 1, 2}
+			if !repositoryDependency.isPostgres() {
+				expectedQuery += regexp.QuoteMeta(" AND created_at BETWEEN ? AND ?")
+				expectedArgs = append(expectedArgs, createdAt, createdAt.Add(time.Minute))
+			}
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs(expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			assert.NoError(t, repo.MarkExpired(context.TODO(), otps))
+			assert.NoError(t, repo.MarkExpired(context.TODO(), nil))
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOTPRepository_DeleteExpiredBefore(t *testing.T) {
+	var (
+		before      = time.Now().Add(-24 * time.Hour)
+		createdFrom = before.Add(-time.Hour)
+		createdTo   = before.Add(-time.Minute)
+	)
+
+	tests := []struct {
+		name              string
+		createdFrom       time.Time
+		expectedSelect    string
+		expectedSelectArg []driver.Value
+	}{
+		{
+			name:              "bounded creation time",
+			createdFrom:       createdFrom,
+			expectedSelect:    "SELECT id, user_id, otp_code FROM otps WHERE expires_at < ? AND created_at <= ? AND created_at >= ? LIMIT ?",
+			expectedSelectArg: []driver.Value{before, createdTo, createdFrom, 500},
+		},
+		{
+			name:              "creation time unbounded below",
+			expectedSelect:    "SELECT id, user_id, otp_code FROM otps WHERE expires_at < ? AND created_at <= ? LIMIT ?",
+			expectedSelectArg: []driver.Value{before, createdTo, 500},
+		},
+	}
+
+	for _, driverName := range testedDriverNames {
+		for _, tt := range tests {
+			t.Run(driverName+"/"+tt.name, func(t *testing.T) {
+				repositoryDependency := newRepoDependencyFor(driverName)
+				repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+				defer repositoryDependency.mockedDB.Close()
+
+				if repositoryDependency.isPostgres() {
+					repositoryDependency.mockedSQL.
+						ExpectExec(regexp.QuoteMeta("DELETE FROM otps WHERE id IN (SELECT id FROM otps WHERE expires_at < ? LIMIT ?)")).
+						WithArgs(before, 500).
+						WillReturnResult(sqlmock.NewResult(0, 2))
+				} else {
+					// The codes and the IDs of the deleted OTPs are released along with them, and the creation time
+					// bounds let the query only search the partitions of the OTPs that can have expired
+					repositoryDependency.mockedSQL.ExpectBegin()
+					repositoryDependency.mockedSQL.
+						ExpectQuery(regexp.QuoteMeta(tt.expectedSelect)).
+						WithArgs(tt.expectedSelectArg...).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "otp_code"}).
+							AddRow(1, "user123", "111111").
+							AddRow(2, "user456", "222222"))
+					repositoryDependency.mockedSQL.
+						ExpectExec(regexp.QuoteMeta("DELETE FROM otps WHERE id IN (?, ?)")).
+						WithArgs(1, 2).
+						WillReturnResult(sqlmock.NewResult(0, 2))
+					repositoryDependency.mockedSQL.
+						ExpectExec(regexp.QuoteMeta("DELETE FROM otp_codes WHERE (user_id, otp_code) IN ((?, ?), (?, ?))")).
+						WithArgs("user123", "111111", "user456", "222222").
+						WillReturnResult(sqlmock.NewResult(0, 2))
+					repositoryDependency.mockedSQL.
+						ExpectExec(regexp.QuoteMeta("DELETE FROM otp_ids WHERE id IN (?, ?)")).
+						WithArgs(1, 2).
+						WillReturnResult(sqlmock.NewResult(0, 2))
+					repositoryDependency.mockedSQL.ExpectCommit()
+				}
+
+				purged, err := repo.DeleteExpiredBefore(context.TODO(), before, tt.createdFrom, createdTo, 500)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), purged)
+				assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestOTPRepository_CountByStatus(t *testing.T) {
+	for _, driverName := range testedDriverNames {
+		t.Run(driverName, func(t *testing.T) {
+			repositoryDependency := newRepoDependencyFor(driverName)
+			repo := repository.NewOTPRepository(repositoryDependency.mockedDB, nil, nil)
+			defer repositoryDependency.mockedDB.Close()
+
+			expectedQuery := regexp.QuoteMeta("SELECT status, COUNT(*) AS count FROM otps GROUP BY status")
+			repositoryDependency.mockedSQL.
+				ExpectQuery(expectedQuery).
+				WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+					AddRow(entity.OTPStatusCreated, 12).
+					AddRow(entity.OTPStatusValidated, 40))
+			repositoryDependency.mockedSQL.
+				ExpectQuery(expectedQuery).
+				WillReturnError(sql.ErrConnDone)
+
+			counts, err := repo.CountByStatus(context.TODO())
+			assert.NoError(t, err)
+			assert.Equal(t, map[entity.OTPStatus]int64{
+				entity.OTPStatusCreated:   12,
+				entity.OTPStatusValidated: 40,
+			}, counts)
+
+			counts, err = repo.CountByStatus(context.TODO())
+			assert.Nil(t, counts)
+			assert.Equal(t, sql.ErrConnDone, err)
 
 			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
 		})
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// outboxRepository implements the OutboxRepository interface
+type outboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository creates a new instance of outboxRepository
+func NewOutboxRepository(db *sqlx.DB) *outboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new event into the outbox and sets the ID of the given event
+func (o *outboxRepository) Create(ctx context.Context, event *entity.OutboxEvent) error {
+	const query = `
+		INSERT INTO outbox (event_type, otp_id, client_id, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := insertReturningID(
+		ctx,
+		o.db,
+		query,
+		event.EventType,
+		nullableInt(int(event.OTPID)),
+		nullableString(event.ClientID),
+		event.Payload,
+		event.Status,
+		event.Attempts,
+		event.NextAttemptAt,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	event.ID = id
+
+	return nil
+}
+
+// ListDue retrieves up to limit pending events whose next attempt is due at the given time,
+// oldest first
+func (o *outboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.OutboxEvent, error) {
+	const query = `
+		SELECT id, event_type, otp_id, client_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+		FROM outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	var rows []outboxEventRow
+	if err := getExecutor(ctx, o.db).SelectContext(ctx, &rows, query, entity.OutboxStatusPending, now, limit); err != nil {
+		return nil, err
+	}
+
+	return toOutboxEvents(rows), nil
+}
+
+// ListByOTPID retrieves the events describing an OTP, oldest first
+func (o *outboxRepository) ListByOTPID(ctx context.Context, otpID uint64) ([]*entity.OutboxEvent, error) {
+	const query = `
+		SELECT id, event_type, otp_id, client_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+		FROM outbox
+		WHERE otp_id = ?
+		ORDER BY id
+	`
+
+	var rows []outboxEventRow
+	if err := getExecutor(ctx, o.db).SelectContext(ctx, &rows, query, otpID); err != nil {
+		return nil, err
+	}
+
+	return toOutboxEvents(rows), nil
+}
+
+// Update stores the delivery status of an outbox event
+func (o *outboxRepository) Update(ctx context.Context, event *entity.OutboxEvent) error {
+	const query = `
+		UPDATE outbox
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`
+	_, err := getExecutor(ctx, o.db).ExecContext(
+		ctx,
+		query,
+		event.Status,
+		event.Attempts,
+		event.NextAttemptAt,
+		nullableString(event.LastError),
+		event.DeliveredAt,
+		event.ID,
+	)
+
+	return err
+}
+
+// toOutboxEvents converts outbox event rows to entities
+func toOutboxEvents(rows []outboxEventRow) []*entity.OutboxEvent {
+	events := make([]*entity.OutboxEvent, 0, len(rows))
+	for i := range rows {
+		events = append(events, rows[i].ToEntity())
+	}
+	return events
+}
@@ -0,0 +1,167 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRepository_Create(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta("INSERT INTO outbox (event_type, otp_id, client_id, payload, status, attempts, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.OutboxEvent, error)
+	}{
+		{
+			name: "Should create the event and set its ID",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(entity.EventTypeOTPCreated, 42, "client-a", []byte(`{"type":"otp.created"}`), entity.OutboxStatusPending, 0, now, now).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			assertFn: func(event *entity.OutboxEvent, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(5), event.ID)
+			},
+		},
+		{
+			name: "Should return error when insert fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(entity.EventTypeOTPCreated, 42, "client-a", []byte(`{"type":"otp.created"}`), entity.OutboxStatusPending, 0, now, now).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(event *entity.OutboxEvent, err error) {
+				assert.Equal(t, sql.ErrConnDone, err)
+				assert.Zero(t, event.ID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewOutboxRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			event := &entity.OutboxEvent{
+				EventType:     entity.EventTypeOTPCreated,
+				OTPID:         42,
+				ClientID:      "client-a",
+				Payload:       []byte(`{"type":"otp.created"}`),
+				Status:        entity.OutboxStatusPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			}
+			tt.assertFn(event, repo.Create(context.TODO(), event))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOutboxRepository_ListDue(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewOutboxRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta(`
+			SELECT id, event_type, otp_id, client_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+			FROM outbox
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY id
+			LIMIT ?
+		`)).
+		WithArgs(entity.OutboxStatusPending, now, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "otp_id", "payload", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at"}).
+			AddRow(5, "otp.created", 42, []byte(`{"type":"otp.created"}`), entity.OutboxStatusPending, 2, now, "timeout", now, nil))
+
+	events, err := repo.ListDue(context.TODO(), now, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.OutboxEvent{{
+		ID:            5,
+		EventType:     entity.EventTypeOTPCreated,
+		OTPID:         42,
+		Payload:       []byte(`{"type":"otp.created"}`),
+		Status:        entity.OutboxStatusPending,
+		Attempts:      2,
+		NextAttemptAt: now,
+		LastError:     "timeout",
+		CreatedAt:     now,
+	}}, events)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_ListByOTPID(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewOutboxRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta(`
+			SELECT id, event_type, otp_id, client_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+			FROM outbox
+			WHERE otp_id = ?
+			ORDER BY id
+		`)).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "otp_id", "payload", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at"}).
+			AddRow(5, "otp.created", 42, []byte(`{"type":"otp.created"}`), entity.OutboxStatusDelivered, 0, now, nil, now, now))
+
+	events, err := repo.ListByOTPID(context.TODO(), 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.OutboxEvent{{
+		ID:            5,
+		EventType:     entity.EventTypeOTPCreated,
+		OTPID:         42,
+		Payload:       []byte(`{"type":"otp.created"}`),
+		Status:        entity.OutboxStatusDelivered,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		DeliveredAt:   &now,
+	}}, events)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Update(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewOutboxRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("UPDATE outbox SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, delivered_at = ? WHERE id = ?")).
+		WithArgs(entity.OutboxStatusDelivered, 1, now, "timeout", now, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Update(context.TODO(), &entity.OutboxEvent{
+		ID:            5,
+		Status:        entity.OutboxStatusDelivered,
+		Attempts:      1,
+		NextAttemptAt: now,
+		LastError:     "timeout",
+		DeliveredAt:   &now,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// replicaPingTimeout is how long a replica has to answer a health check
+const replicaPingTimeout = 2 * time.Second
+
+// replica is a read replica of the primary database
+type replica struct {
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet holds the read replicas of the primary database. The reads of the repositories made outside
+// of a transaction are spread over the healthy replicas, and sent to the primary when none is healthy.
+// A nil ReplicaSet has no replica.
+type ReplicaSet struct {
+	replicas      []*replica
+	next          atomic.Uint64
+	checkInterval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReplicaSet creates a new instance of ReplicaSet, whose replicas are checked every checkInterval once started.
+// The replicas are considered healthy until a health check or a query fails to reach them.
+func NewReplicaSet(checkInterval time.Duration, dbs ...*sqlx.DB) *ReplicaSet {
+	replicas := make([]*replica, 0, len(dbs))
+	for _, db := range dbs {
+		r := &replica{db: db}
+		r.healthy.Store(true)
+		replicas = append(replicas, r)
+	}
+
+	return &ReplicaSet{
+		replicas:      replicas,
+		checkInterval: checkInterval,
+	}
+}
+
+// Start checks the health of the replicas in the background until Shutdown is called
+func (r *ReplicaSet) Start() {
+	if r == nil || len(r.replicas) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.CheckHealth(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops the health checks and waits until they have stopped or the given context is done
+func (r *ReplicaSet) Shutdown(ctx context.Context) error {
+	if r == nil || r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckHealth pings every replica, and marks the replicas that do not answer as unhealthy
+// until they answer again
+func (r *ReplicaSet) CheckHealth(ctx context.Context) {
+	healthyCount := 0
+	for i, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := replica.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Info().Int("replica", i).Msg("Read replica is healthy again")
+			} else {
+				log.Warn().Err(err).Int("replica", i).Msg("Read replica is unhealthy, its reads are sent to the primary")
+			}
+		}
+		if healthy {
+			healthyCount++
+		}
+	}
+	healthyReplicas.Set(float64(healthyCount))
+}
+
+// pick returns the next healthy replica in turn, or nil when none is healthy
+func (r *ReplicaSet) pick() *replica {
+	if r == nil {
+		return nil
+	}
+
+	for range r.replicas {
+		replica := r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
+		if replica.healthy.Load() {
+			return replica
+		}
+	}
+
+	return nil
+}
+
+// getReader retrieves the executor of a read-only query. Outside of a transaction, and unless the context
+// asks to read its own writes, the query is sent to a healthy replica, falling back to the primary.
+func getReader(ctx context.Context, db *sqlx.DB, replicas *ReplicaSet) ExecerContext {
+	if transactionFromContext(ctx) != nil || usecase.ReadsYourWrites(ctx) {
+		return getExecutor(ctx, db)
+	}
+
+	replica := replicas.pick()
+	if replica == nil {
+		return getExecutor(ctx, db)
+	}
+
+	return replicaExecutor{
+		replica:  replica,
+		executor: getExecutor(ctx, replica.db),
+		primary:  getExecutor(ctx, db),
+	}
+}
+
+// replicaExecutor runs the queries on a replica, and on the primary when the replica cannot be reached,
+// in which case the replica is marked unhealthy until the next health check reaches it
+type replicaExecutor struct {
+	replica  *replica
+	executor ExecerContext
+	primary  ExecerContext
+}
+
+func (r replicaExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	// Writes are never sent to a replica
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+func (r replicaExecutor) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	err := r.executor.GetContext(ctx, dest, query, args...)
+	if r.unreachable(ctx, err) {
+		return r.primary.GetContext(ctx, dest, query, args...)
+	}
+
+	r.countServed(err)
+	return err
+}
+
+func (r replicaExecutor) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	err := r.executor.SelectContext(ctx, dest, query, args...)
+	if r.unreachable(ctx, err) {
+		return r.primary.SelectContext(ctx, dest, query, args...)
+	}
+
+	r.countServed(err)
+	return err
+}
+
+// countServed counts a read the replica answered, which a row not found is as well,
+// so that failed queries do not pass for reads served by the replica
+func (r replicaExecutor) countServed(err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	replicaReads.WithLabelValues("replica").Inc()
+}
+
+// unreachable reports whether a query failed to reach the replica, and marks it unhealthy if so
+func (r replicaExecutor) unreachable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || !isConnectionError(err) {
+		return false
+	}
+
+	r.replica.healthy.Store(false)
+	replicaReads.WithLabelValues("primary_fallback").Inc()
+	log.Warn().Err(err).Msg("Read replica is unreachable, its reads are sent to the primary")
+
+	return true
+}
+
+// isConnectionError checks if the error is a failure to reach the database rather than a failure of the query
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn)
+}
@@ -0,0 +1,174 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMonitoredRepoDependency creates a mocked database whose pings are expected like queries
+func newMonitoredRepoDependency(t *testing.T) *repositoryDependency {
+	mockDB, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	return &repositoryDependency{
+		mockedDB:  sqlx.NewDb(mockDB, "sqlmock"),
+		mockedSQL: sqlMock,
+	}
+}
+
+func TestReplicaSet_Routing(t *testing.T) {
+	countQuery := regexp.QuoteMeta(`SELECT status, COUNT(*) AS count FROM otps GROUP BY status`)
+	countRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"status", "count"}).AddRow(entity.OTPStatusCreated, 3)
+	}
+	errUnreachable := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name     string
+		ctx      func(ctx context.Context) context.Context
+		inTx     bool
+		mockFn   func(primary, replica sqlmock.Sqlmock)
+		assertFn func(t *testing.T, counts map[entity.OTPStatus]int64, err error)
+	}{
+		{
+			name: "Should read from the replica outside of a transaction",
+			mockFn: func(primary, replica sqlmock.Sqlmock) {
+				replica.ExpectQuery(countQuery).WillReturnRows(countRows())
+			},
+		},
+		{
+			name: "Should read from the primary when the context reads its own writes",
+			ctx:  usecase.WithReadYourWrites,
+			mockFn: func(primary, replica sqlmock.Sqlmock) {
+				primary.ExpectQuery(countQuery).WillReturnRows(countRows())
+			},
+		},
+		{
+			name: "Should read from the primary within a transaction",
+			inTx: true,
+			mockFn: func(primary, replica sqlmock.Sqlmock) {
+				primary.ExpectBegin()
+				primary.ExpectQuery(countQuery).WillReturnRows(countRows())
+				primary.ExpectCommit()
+			},
+		},
+		{
+			name: "Should fall back to the primary when the replica is unreachable",
+			mockFn: func(primary, replica sqlmock.Sqlmock) {
+				replica.ExpectQuery(countQuery).WillReturnError(errUnreachable)
+				primary.ExpectQuery(countQuery).WillReturnRows(countRows())
+			},
+		},
+		{
+			name: "Should return the errors of the queries the replica failed",
+			mockFn: func(primary, replica sqlmock.Sqlmock) {
+				replica.ExpectQuery(countQuery).WillReturnError(errors.New("query failed"))
+			},
+			assertFn: func(t *testing.T, counts map[entity.OTPStatus]int64, err error) {
+				assert.EqualError(t, err, "query failed")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := newMonitoredRepoDependency(t)
+			replica := newMonitoredRepoDependency(t)
+			repo := repository.NewOTPRepository(primary.mockedDB, repository.NewReplicaSet(time.Minute, replica.mockedDB), nil)
+			txManager := repository.NewTransactionManager(primary.mockedDB, repository.RetryPolicy{})
+			tt.mockFn(primary.mockedSQL, replica.mockedSQL)
+
+			ctx := context.TODO()
+			if tt.ctx != nil {
+				ctx = tt.ctx(ctx)
+			}
+
+			var (
+				counts map[entity.OTPStatus]int64
+				err    error
+			)
+			if tt.inTx {
+				err = txManager.WithTransaction(ctx, func(ctx context.Context) error {
+					counts, err = repo.CountByStatus(ctx)
+					return err
+				})
+			} else {
+				counts, err = repo.CountByStatus(ctx)
+			}
+
+			if tt.assertFn != nil {
+				tt.assertFn(t, counts, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, map[entity.OTPStatus]int64{entity.OTPStatusCreated: 3}, counts)
+			}
+			assert.NoError(t, primary.mockedSQL.ExpectationsWereMet())
+			assert.NoError(t, replica.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReplicaSet_Health(t *testing.T) {
+	countQuery := regexp.QuoteMeta(`SELECT status, COUNT(*) AS count FROM otps GROUP BY status`)
+	countRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"status", "count"}).AddRow(entity.OTPStatusCreated, 3)
+	}
+
+	primary := newMonitoredRepoDependency(t)
+	healthy := newMonitoredRepoDependency(t)
+	unhealthy := newMonitoredRepoDependency(t)
+	replicas := repository.NewReplicaSet(time.Minute, healthy.mockedDB, unhealthy.mockedDB)
+	repo := repository.NewOTPRepository(primary.mockedDB, replicas, nil)
+
+	// The reads are spread over the replicas that answer the health checks
+	healthy.mockedSQL.ExpectPing()
+	unhealthy.mockedSQL.ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicas.CheckHealth(context.TODO())
+	for range 2 {
+		healthy.mockedSQL.ExpectQuery(countQuery).WillReturnRows(countRows())
+		_, err := repo.CountByStatus(context.TODO())
+		require.NoError(t, err)
+	}
+
+	// The reads go to the primary while no replica answers
+	healthy.mockedSQL.ExpectPing().WillReturnError(errors.New("connection refused"))
+	unhealthy.mockedSQL.ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicas.CheckHealth(context.TODO())
+	primary.mockedSQL.ExpectQuery(countQuery).WillReturnRows(countRows())
+	_, err := repo.CountByStatus(context.TODO())
+	require.NoError(t, err)
+
+	// A replica answering again serves the reads again
+	healthy.mockedSQL.ExpectPing()
+	unhealthy.mockedSQL.ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicas.CheckHealth(context.TODO())
+	healthy.mockedSQL.ExpectQuery(countQuery).WillReturnRows(countRows())
+	_, err = repo.CountByStatus(context.TODO())
+	require.NoError(t, err)
+
+	// A replica a query fails to reach is left out until the next health check reaches it
+	healthy.mockedSQL.ExpectQuery(countQuery).WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")})
+	primary.mockedSQL.ExpectQuery(countQuery).WillReturnRows(countRows())
+	primary.mockedSQL.ExpectQuery(countQuery).WillReturnRows(countRows())
+	for range 2 {
+		_, err = repo.CountByStatus(context.TODO())
+		require.NoError(t, err)
+	}
+
+	for _, dependency := range []*repositoryDependency{primary, healthy, unhealthy} {
+		assert.NoError(t, dependency.mockedSQL.ExpectationsWereMet())
+	}
+}
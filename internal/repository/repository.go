@@ -3,7 +3,10 @@ package repository
 import (
 	"errors"
 
+	"github.com/glebarez/go-sqlite"
 	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // Note: Repositories are separated by domain to follow the Single Responsibility Principle.
@@ -13,9 +16,58 @@ import (
 
 // isUniqueConstraintViolation checks if the error is a unique constraint violation
 func isUniqueConstraintViolation(err error) bool {
- var mysqlErr *mysql.MySQLError
-    if errors.As(err, &mysqlErr) {
-        return mysqlErr.Number == 1062 // ER_DUP_ENTRY
-    }
-    return false
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062 // ER_DUP_ENTRY
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505" // unique_violation
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+
+	return false
+}
+
+// retryableError reports whether the error is transient, so that the transaction it failed can be run again,
+// and the reason it is transient
+func retryableError(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1213: // ER_LOCK_DEADLOCK
+			return "deadlock", true
+		case 1205: // ER_LOCK_WAIT_TIMEOUT
+			return "lock_wait_timeout", true
+		}
+		return "", false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40P01": // deadlock_detected
+			return "deadlock", true
+		case "40001": // serialization_failure
+			return "serialization_failure", true
+		case "55P03": // lock_not_available
+			return "lock_wait_timeout", true
+		}
+		return "", false
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// The extended codes of SQLITE_BUSY keep it in their lowest byte
+		if sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY {
+			return "busy", true
+		}
+	}
+
+	return "", false
 }
@@ -1,12 +1,24 @@
 package repository_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/imansohibul/otp-service/internal/crypto"
+	"github.com/imansohibul/otp-service/internal/repository"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
 )
 
 type repositoryDependency struct {
@@ -23,7 +35,99 @@ func newRepoDependency() *repositoryDependency {
 	}
 }
 
+// testedDriverNames are the database drivers of the dialects the repositories are tested against
+var testedDriverNames = []string{"mysql", "postgres"}
+
+// newRepoDependencyFor creates a mocked database of the given driver. The expected queries are written with
+// ? placeholders for every driver; on PostgreSQL they must have been rebound to $1, $2... in order.
+func newRepoDependencyFor(driverName string) *repositoryDependency {
+	queryMatcher := sqlmock.QueryMatcherRegexp
+	if driverName == "postgres" {
+		queryMatcher = postgresQueryMatcher
+	}
+
+	mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(queryMatcher))
+	return &repositoryDependency{
+		mockedDB:  sqlx.NewDb(mockDB, driverName),
+		mockedSQL: sqlMock,
+	}
+}
+
+// isPostgres reports whether the mocked database is a PostgreSQL database
+func (r *repositoryDependency) isPostgres() bool {
+	return r.mockedDB.DriverName() == "postgres"
+}
+
+// expectInsert expects an INSERT of a row that is assigned the given ID, which is read from the last
+// insert ID on MySQL and returned by a RETURNING clause on PostgreSQL, or that fails with the given error
+func (r *repositoryDependency) expectInsert(query string, args []driver.Value, id int64, err error) {
+	if r.isPostgres() {
+		expectation := r.mockedSQL.ExpectQuery(query + regexp.QuoteMeta(" RETURNING id")).WithArgs(args...)
+		if err != nil {
+			expectation.WillReturnError(err)
+			return
+		}
+		expectation.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		return
+	}
+
+	expectation := r.mockedSQL.ExpectExec(query).WithArgs(args...)
+	if err != nil {
+		expectation.WillReturnError(err)
+		return
+	}
+	expectation.WillReturnResult(sqlmock.NewResult(id, 1))
+}
+
+// uniqueViolation returns the error of the database driver for a violated unique constraint
+func (r *repositoryDependency) uniqueViolation() error {
+	if r.isPostgres() {
+		return &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "uq_otp_user_code"`}
+	}
+	return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'user123-123456' for key 'uq_otp_user_code'"}
+}
+
+// bindVariableRegexp matches the bind variables of a PostgreSQL query
+var bindVariableRegexp = regexp.MustCompile(`\$\d+`)
+
+// postgresQueryMatcher matches a PostgreSQL query against an expectation written with ? placeholders,
+// after checking that the query has no ? placeholder left and numbers its bind variables in order
+var postgresQueryMatcher = sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+	if strings.Contains(actualSQL, "?") {
+		return fmt.Errorf("query %q has placeholders that were not rebound", actualSQL)
+	}
+
+	var (
+		position int
+		err      error
+	)
+	query := bindVariableRegexp.ReplaceAllStringFunc(actualSQL, func(bindVariable string) string {
+		position++
+		if bindVariable != "$"+strconv.Itoa(position) && err == nil {
+			err = fmt.Errorf("query %q has bind variable %s at position %d", actualSQL, bindVariable, position)
+		}
+		return "?"
+	})
+	if err != nil {
+		return err
+	}
+
+	return sqlmock.QueryMatcherRegexp.Match(expectedSQL, query)
+})
+
 func TestRepository(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Repository Suite")
 }
+
+// newKeyring opens a keyring storing its keys in the given database, wrapped by a local master key
+func newKeyring(t *testing.T, db *sqlx.DB) *crypto.Keyring {
+	t.Helper()
+
+	kms, err := crypto.NewLocalKMS(map[string][]byte{"master-1": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	keyring := crypto.NewKeyring(kms, "master-1", repository.NewEncryptionKeyRepository(db))
+	require.NoError(t, keyring.Load(context.TODO()))
+
+	return keyring
+}
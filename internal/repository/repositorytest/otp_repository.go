@@ -0,0 +1,754 @@
+// Package repositorytest provides behavioural test suites that every implementation
+// of the repository interfaces of the usecase package must pass, whatever stores the data.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrency is the number of goroutines racing each other in the concurrency cases
+const concurrency = 8
+
+// OTPRepositoryFactory creates an OTP repository over an empty store, and the transaction manager of that store.
+// It is called once per test case, and should release the store with t.Cleanup.
+type OTPRepositoryFactory func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager)
+
+// Limitations lists the guarantees a store is known not to provide. The cases checking them are skipped
+// with the given reason, so that a limitation is always stated rather than hidden by a missing case.
+type Limitations struct {
+	// RowLocks is why the store lets concurrent transactions claim the same rows with ListExpirable, if it does
+	RowLocks string
+	// Savepoints is why the store cannot roll back a transaction nested in another one, if it cannot
+	Savepoints string
+	// WriteConflicts is why the store lets concurrent transactions insert the same key, if it does
+	WriteConflicts string
+}
+
+// RunOTPRepositorySuite checks that the OTP repositories created by newRepository honour
+// the contract of usecase.OTPRepository, including under concurrent use and within transactions.
+// Times are compared to the second, the precision of the MySQL columns.
+func RunOTPRepositorySuite(t *testing.T, newRepository OTPRepositoryFactory, limitations Limitations) {
+	tests := []struct {
+		name       string
+		run        func(t *testing.T, repo usecase.OTPRepository, txManager usecase.TransactionManager)
+		limitation string
+	}{
+		{name: "Create", run: testCreate},
+		{name: "Find", run: testFind},
+		{name: "GetLastByUserID", run: testGetLastByUserID},
+		{name: "List", run: testList},
+		{name: "Update", run: testUpdate},
+		{name: "IncrementResendCount", run: testIncrementResendCount},
+		{name: "MarkRevoked", run: testMarkRevoked},
+		{name: "Expiry", run: testExpiry},
+		{name: "DeleteExpiredBefore", run: testDeleteExpiredBefore},
+		{name: "CountByStatus", run: testCountByStatus},
+		{name: "Transaction", run: testTransaction},
+		{name: "NestedTransaction", run: testNestedTransaction, limitation: limitations.Savepoints},
+		{name: "ConcurrentCreate", run: testConcurrentCreate, limitation: limitations.WriteConflicts},
+		{name: "ConcurrentIncrementResendCount", run: testConcurrentIncrementResendCount},
+		{name: "ConcurrentExpiry", run: testConcurrentExpiry, limitation: limitations.RowLocks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.limitation != "" {
+				t.Skip(tt.limitation)
+			}
+
+			repo, txManager := newRepository(t)
+			tt.run(t, repo, txManager)
+		})
+	}
+}
+
+// now returns the current time truncated to the precision of the stores
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// newOTP returns a created OTP of a user, ready to be stored
+func newOTP(userID string, code string, expiresAt time.Time) *entity.OTP {
+	return &entity.OTP{
+		UserID:      userID,
+		OTPCode:     code,
+		Status:      entity.OTPStatusCreated,
+		ExpiresAt:   expiresAt,
+		ResendLimit: 1,
+	}
+}
+
+// create stores the OTPs, in order, and sets their creation time as stored,
+// so that the updates of the OTPs are bounded to their partition like those of the usecases
+func create(t *testing.T, repo usecase.OTPRepository, otps ...*entity.OTP) {
+	t.Helper()
+
+	for _, otp := range otps {
+		require.NoError(t, repo.Create(context.TODO(), otp))
+
+		stored, err := repo.FindByID(context.TODO(), otp.ID)
+		require.NoError(t, err)
+		otp.CreatedAt = stored.CreatedAt
+	}
+}
+
+// idsOf returns the IDs of the OTPs, in order
+func idsOf(otps []*entity.OTP) []uint64 {
+	ids := make([]uint64, 0, len(otps))
+	for _, otp := range otps {
+		ids = append(ids, otp.ID)
+	}
+
+	return ids
+}
+
+func testCreate(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	expiresAt := now().Add(time.Minute)
+
+	otp := newOTP("user123", "123456", expiresAt)
+	otp.BindingHash = "binding-hash"
+	otp.Purpose = "login"
+	otp.ResendCount = 1
+	otp.ResendLimit = 3
+	require.NoError(t, repo.Create(ctx, otp))
+	require.NotZero(t, otp.ID)
+
+	t.Run("Should store every field of the OTP", func(t *testing.T) {
+		stored, err := repo.FindByID(ctx, otp.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, otp.ID, stored.ID)
+		assert.Equal(t, "user123", stored.UserID)
+		assert.Equal(t, "123456", stored.OTPCode)
+		assert.Equal(t, entity.OTPStatusCreated, stored.Status)
+		assert.False(t, stored.CreatedAt.IsZero())
+		assert.True(t, expiresAt.Equal(stored.ExpiresAt), "expected expires at %s, got %s", expiresAt, stored.ExpiresAt)
+		assert.Nil(t, stored.ValidatedAt)
+		assert.Equal(t, "binding-hash", stored.BindingHash)
+		assert.Equal(t, "login", stored.Purpose)
+		assert.Nil(t, stored.RevokedAt)
+		assert.Equal(t, 1, stored.ResendCount)
+		assert.Equal(t, 3, stored.ResendLimit)
+	})
+
+	t.Run("Should return duplicate error when the user already has the code", func(t *testing.T) {
+		assert.ErrorIs(t, repo.Create(ctx, newOTP("user123", "123456", expiresAt)), entity.ErrOTPDuplicate)
+	})
+
+	t.Run("Should allow the same code for another user", func(t *testing.T) {
+		other := newOTP("user456", "123456", expiresAt)
+		require.NoError(t, repo.Create(ctx, other))
+		assert.Greater(t, other.ID, otp.ID)
+	})
+}
+
+func testFind(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	expiresAt := now().Add(time.Minute)
+
+	first := newOTP("user123", "111111", expiresAt)
+	other := newOTP("user456", "222222", expiresAt)
+	last := newOTP("user123", "333333", expiresAt)
+	create(t, repo, first, other, last)
+
+	tests := []struct {
+		name       string
+		find       func() (*entity.OTP, error)
+		expectedID uint64
+		expectErr  error
+	}{
+		{
+			name:       "Should find the OTP by user ID and code",
+			find:       func() (*entity.OTP, error) { return repo.FindByUserIDAndCode(ctx, "user456", "222222") },
+			expectedID: other.ID,
+		},
+		{
+			name:      "Should return not found when the code belongs to another user",
+			find:      func() (*entity.OTP, error) { return repo.FindByUserIDAndCode(ctx, "user123", "222222") },
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name:       "Should find the OTP by ID",
+			find:       func() (*entity.OTP, error) { return repo.FindByID(ctx, first.ID) },
+			expectedID: first.ID,
+		},
+		{
+			name:      "Should return not found for an unknown ID",
+			find:      func() (*entity.OTP, error) { return repo.FindByID(ctx, last.ID+100) },
+			expectErr: entity.ErrOTPNotFound,
+		},
+		{
+			name:       "Should return the next OTP of the user",
+			find:       func() (*entity.OTP, error) { return repo.FindNextByUserID(ctx, first) },
+			expectedID: last.ID,
+		},
+		{
+			name:      "Should return not found when no OTP of the user follows",
+			find:      func() (*entity.OTP, error) { return repo.FindNextByUserID(ctx, other) },
+			expectErr: entity.ErrOTPNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otp, err := tt.find()
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				assert.Nil(t, otp)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, otp.ID)
+		})
+	}
+}
+
+func testGetLastByUserID(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	expiresAt := now().Add(time.Minute)
+	createdFrom := now().Add(-time.Minute)
+
+	t.Run("Should return not found when the user has no OTP", func(t *testing.T) {
+		_, err := repo.GetLastByUserID(ctx, "user123", createdFrom)
+		assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+	})
+
+	// The OTPs are usually created within the same second, so the stores must break ties on the creation order
+	otps := []*entity.OTP{
+		newOTP("user123", "111111", expiresAt),
+		newOTP("user123", "222222", expiresAt),
+		newOTP("user456", "333333", expiresAt),
+		newOTP("user123", "444444", expiresAt.Add(-2*time.Minute)),
+	}
+	create(t, repo, otps...)
+
+	t.Run("Should return the OTP created last, whatever its expiry", func(t *testing.T) {
+		otp, err := repo.GetLastByUserID(ctx, "user123", createdFrom)
+		require.NoError(t, err)
+		assert.Equal(t, otps[3].ID, otp.ID)
+	})
+
+	t.Run("Should ignore the OTPs of other users", func(t *testing.T) {
+		otp, err := repo.GetLastByUserID(ctx, "user456", createdFrom)
+		require.NoError(t, err)
+		assert.Equal(t, otps[2].ID, otp.ID)
+	})
+
+	t.Run("Should ignore the OTPs created before the given time", func(t *testing.T) {
+		_, err := repo.GetLastByUserID(ctx, "user123", now().Add(time.Minute))
+		assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+	})
+}
+
+func testList(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	expiresAt := now().Add(time.Minute)
+
+	otps := []*entity.OTP{
+		newOTP("user123", "111111", expiresAt),
+		newOTP("user456", "222222", expiresAt),
+		newOTP("user123", "333333", expiresAt),
+		newOTP("user123", "444444", expiresAt),
+	}
+	otps[2].Purpose = "login"
+	create(t, repo, otps...)
+	_, err := repo.MarkRevoked(ctx, otps[3], now(), "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		filter      entity.OTPFilter
+		beforeID    uint64
+		limit       int
+		expectedIDs []uint64
+	}{
+		{
+			name:        "Should list the OTPs newest first, up to the limit",
+			limit:       3,
+			expectedIDs: []uint64{otps[3].ID, otps[2].ID, otps[1].ID},
+		},
+		{
+			name:        "Should continue before the given ID",
+			beforeID:    otps[2].ID,
+			limit:       10,
+			expectedIDs: []uint64{otps[1].ID, otps[0].ID},
+		},
+		{
+			name:        "Should filter by user",
+			filter:      entity.OTPFilter{UserID: "user123"},
+			limit:       10,
+			expectedIDs: []uint64{otps[3].ID, otps[2].ID, otps[0].ID},
+		},
+		{
+			name:        "Should filter by status",
+			filter:      entity.OTPFilter{Status: entity.OTPStatusRevoked},
+			limit:       10,
+			expectedIDs: []uint64{otps[3].ID},
+		},
+		{
+			name:        "Should filter by purpose",
+			filter:      entity.OTPFilter{Purpose: "login"},
+			limit:       10,
+			expectedIDs: []uint64{otps[2].ID},
+		},
+		{
+			name:        "Should return an empty list when nothing matches",
+			filter:      entity.OTPFilter{UserID: "unknown"},
+			limit:       10,
+			expectedIDs: []uint64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otps, err := repo.List(ctx, tt.filter, tt.beforeID, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedIDs, idsOf(otps))
+		})
+	}
+}
+
+func testUpdate(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	validatedAt := now()
+
+	otp := newOTP("user123", "123456", validatedAt.Add(time.Minute))
+	create(t, repo, otp)
+
+	otp.Status = entity.OTPStatusValidated
+	otp.ValidatedAt = &validatedAt
+	otp.ValidatedSessionHash = "session-hash"
+	updated, err := repo.Update(ctx, otp)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// An OTP no longer created is left alone
+	otp.Status = entity.OTPStatusExpired
+	updated, err = repo.Update(ctx, otp)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	stored, err := repo.FindByID(ctx, otp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OTPStatusValidated, stored.Status)
+	if assert.NotNil(t, stored.ValidatedAt) {
+		assert.True(t, validatedAt.Equal(*stored.ValidatedAt), "expected validated at %s, got %s", validatedAt, *stored.ValidatedAt)
+	}
+	assert.Equal(t, "session-hash", stored.ValidatedSessionHash)
+}
+
+func testIncrementResendCount(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	expiresAt := now().Add(time.Minute)
+
+	otp := newOTP("user123", "111111", expiresAt)
+	otp.ResendLimit = 2
+	validated := newOTP("user123", "222222", expiresAt)
+	validated.Status = entity.OTPStatusValidated
+	create(t, repo, otp, validated)
+
+	tests := []struct {
+		name     string
+		otp      *entity.OTP
+		expected bool
+	}{
+		{name: "Should count the first resend", otp: otp, expected: true},
+		{name: "Should count the last resend", otp: otp, expected: true},
+		{name: "Should not count a resend over the limit", otp: otp},
+		{name: "Should not count the resend of an OTP that is not created", otp: validated},
+		{name: "Should not count the resend of an unknown OTP", otp: &entity.OTP{ID: validated.ID + 100, CreatedAt: validated.CreatedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incremented, err := repo.IncrementResendCount(ctx, tt.otp)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, incremented)
+		})
+	}
+
+	stored, err := repo.FindByID(ctx, otp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.ResendCount)
+}
+
+func testMarkRevoked(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	revokedAt := now()
+
+	otp := newOTP("user123", "111111", revokedAt.Add(time.Minute))
+	create(t, repo, otp)
+
+	revoked, err := repo.MarkRevoked(ctx, otp, revokedAt, "sim swap")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	stored, err := repo.FindByID(ctx, otp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OTPStatusRevoked, stored.Status)
+	assert.Equal(t, "sim swap", stored.RevokeReason)
+	if assert.NotNil(t, stored.RevokedAt) {
+		assert.True(t, revokedAt.Equal(*stored.RevokedAt), "expected revoked at %s, got %s", revokedAt, *stored.RevokedAt)
+	}
+
+	t.Run("Should not revoke an OTP that is no longer created", func(t *testing.T) {
+		revoked, err := repo.MarkRevoked(ctx, otp, revokedAt, "again")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("Should not revoke an unknown OTP", func(t *testing.T) {
+		revoked, err := repo.MarkRevoked(ctx, &entity.OTP{ID: otp.ID + 100, CreatedAt: otp.CreatedAt}, revokedAt, "")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+}
+
+func testExpiry(t *testing.T, repo usecase.OTPRepository, txManager usecase.TransactionManager) {
+	ctx := context.TODO()
+	current := now()
+	createdFrom, createdTo := current.Add(-time.Minute), current.Add(time.Minute)
+
+	otps := []*entity.OTP{
+		newOTP("user123", "111111", current.Add(-3*time.Minute)),
+		newOTP("user123", "222222", current.Add(time.Minute)),
+		newOTP("user123", "333333", current.Add(-2*time.Minute)),
+		newOTP("user123", "444444", current.Add(-time.Minute)),
+		newOTP("user123", "555555", current.Add(2*time.Minute)),
+	}
+	otps[2].Status = entity.OTPStatusValidated
+	create(t, repo, otps...)
+
+	t.Run("Should list the active OTPs of the user, oldest first", func(t *testing.T) {
+		active, err := repo.ListActiveByUserID(ctx, "user123", current, createdFrom)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{otps[1].ID, otps[4].ID}, idsOf(active))
+
+		active, err = repo.ListActiveByUserID(ctx, "user456", current, createdFrom)
+		require.NoError(t, err)
+		assert.Empty(t, active)
+
+		active, err = repo.ListActiveByUserID(ctx, "user123", current, current.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, active)
+	})
+
+	t.Run("Should list the created OTPs expired at the given time, up to the limit", func(t *testing.T) {
+		err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			expirable, err := repo.ListExpirable(ctx, current, createdFrom, createdTo, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{otps[0].ID}, idsOf(expirable))
+
+			expirable, err = repo.ListExpirable(ctx, current, createdFrom, createdTo, 10)
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{otps[0].ID, otps[3].ID}, idsOf(expirable))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should not list the OTPs marked expired", func(t *testing.T) {
+		err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			return repo.MarkExpired(ctx, []*entity.OTP{otps[0], otps[3]})
+		})
+		require.NoError(t, err)
+
+		stored, err := repo.FindByID(ctx, otps[3].ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.OTPStatusExpired, stored.Status)
+
+		err = txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			expirable, err := repo.ListExpirable(ctx, current, createdFrom, createdTo, 10)
+			require.NoError(t, err)
+			assert.Empty(t, expirable)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func testDeleteExpiredBefore(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	current := now()
+
+	otps := []*entity.OTP{
+		newOTP("user123", "111111", current.Add(-2*time.Hour)),
+		newOTP("user123", "222222", current.Add(time.Minute)),
+		newOTP("user456", "333333", current.Add(-3*time.Hour)),
+		newOTP("user456", "444444", current.Add(-time.Hour)),
+	}
+	otps[2].Status = entity.OTPStatusValidated
+	create(t, repo, otps...)
+
+	deleted, err := repo.DeleteExpiredBefore(ctx, current.Add(-90*time.Minute), time.Time{}, current.Add(time.Minute), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = repo.DeleteExpiredBefore(ctx, current.Add(-90*time.Minute), current.Add(-time.Minute), current.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	for i, expectFound := range []bool{false, true, false, true} {
+		_, err := repo.FindByID(ctx, otps[i].ID)
+		if expectFound {
+			assert.NoError(t, err, "OTP %d", i)
+		} else {
+			assert.ErrorIs(t, err, entity.ErrOTPNotFound, "OTP %d", i)
+		}
+	}
+}
+
+func testCountByStatus(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	ctx := context.TODO()
+	expiresAt := now().Add(time.Minute)
+
+	counts, err := repo.CountByStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	otps := []*entity.OTP{
+		newOTP("user123", "111111", expiresAt),
+		newOTP("user123", "222222", expiresAt),
+		newOTP("user123", "333333", expiresAt),
+	}
+	otps[2].Status = entity.OTPStatusValidated
+	create(t, repo, otps...)
+
+	counts, err = repo.CountByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[entity.OTPStatus]int64{entity.OTPStatusCreated: 2, entity.OTPStatusValidated: 1}, counts)
+}
+
+func testTransaction(t *testing.T, repo usecase.OTPRepository, txManager usecase.TransactionManager) {
+	errCallback := errors.New("callback failed")
+	expiresAt := now().Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		callbackErr error
+		expectFound bool
+	}{
+		{
+			name:        "Should commit the changes when the callback succeeds",
+			expectFound: true,
+		},
+		{
+			name:        "Should roll back the changes when the callback fails",
+			callbackErr: errCallback,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			code := fmt.Sprintf("22222%d", i)
+			revokedAt := now()
+			existing := newOTP("user123", fmt.Sprintf("11111%d", i), expiresAt)
+			create(t, repo, existing)
+
+			err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+				if err := repo.Create(ctx, newOTP("user123", code, expiresAt)); err != nil {
+					return err
+				}
+				if _, err := repo.MarkRevoked(ctx, existing, revokedAt, ""); err != nil {
+					return err
+				}
+
+				// The transaction sees its own changes
+				_, err := repo.FindByUserIDAndCode(ctx, "user123", code)
+				require.NoError(t, err)
+				stored, err := repo.FindByID(ctx, existing.ID)
+				require.NoError(t, err)
+				assert.Equal(t, entity.OTPStatusRevoked, stored.Status)
+
+				return tt.callbackErr
+			})
+			assert.ErrorIs(t, err, tt.callbackErr)
+
+			_, err = repo.FindByUserIDAndCode(ctx, "user123", code)
+			stored, findErr := repo.FindByID(ctx, existing.ID)
+			require.NoError(t, findErr)
+			if tt.expectFound {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.OTPStatusRevoked, stored.Status)
+			} else {
+				assert.ErrorIs(t, err, entity.ErrOTPNotFound)
+				assert.Equal(t, entity.OTPStatusCreated, stored.Status)
+			}
+		})
+	}
+}
+
+func testNestedTransaction(t *testing.T, repo usecase.OTPRepository, txManager usecase.TransactionManager) {
+	ctx := context.TODO()
+	errNested := errors.New("nested failed")
+	expiresAt := now().Add(time.Minute)
+
+	err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, newOTP("user123", "111111", expiresAt)); err != nil {
+			return err
+		}
+
+		// Only the changes of the failed nested transaction are rolled back
+		err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, newOTP("user123", "222222", expiresAt)); err != nil {
+				return err
+			}
+			return errNested
+		})
+		require.ErrorIs(t, err, errNested)
+		_, err = repo.FindByUserIDAndCode(ctx, "user123", "222222")
+		require.ErrorIs(t, err, entity.ErrOTPNotFound)
+
+		return txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			return repo.Create(ctx, newOTP("user123", "333333", expiresAt))
+		})
+	})
+	require.NoError(t, err)
+
+	for code, expectErr := range map[string]error{"111111": nil, "222222": entity.ErrOTPNotFound, "333333": nil} {
+		_, err := repo.FindByUserIDAndCode(ctx, "user123", code)
+		assert.ErrorIs(t, err, expectErr, code)
+	}
+}
+
+func testConcurrentCreate(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	expiresAt := now().Add(time.Minute)
+
+	var (
+		wg         sync.WaitGroup
+		created    = make(chan uint64, concurrency)
+		duplicates = make(chan struct{}, concurrency)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			otp := newOTP("user123", "123456", expiresAt)
+			err := repo.Create(context.TODO(), otp)
+			switch {
+			case err == nil:
+				created <- otp.ID
+			case errors.Is(err, entity.ErrOTPDuplicate):
+				duplicates <- struct{}{}
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Exactly one request wins, the others are told the code exists
+	require.Len(t, created, 1)
+	assert.Len(t, duplicates, concurrency-1)
+
+	otp, err := repo.FindByUserIDAndCode(context.TODO(), "user123", "123456")
+	require.NoError(t, err)
+	assert.Equal(t, <-created, otp.ID)
+}
+
+func testConcurrentIncrementResendCount(t *testing.T, repo usecase.OTPRepository, _ usecase.TransactionManager) {
+	const resendLimit = 3
+
+	otp := newOTP("user123", "123456", now().Add(time.Minute))
+	otp.ResendLimit = resendLimit
+	create(t, repo, otp)
+
+	var (
+		wg          sync.WaitGroup
+		incremented = make(chan struct{}, concurrency)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ok, err := repo.IncrementResendCount(context.TODO(), otp)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if ok {
+				incremented <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// No resend is counted twice, and none goes over the limit
+	assert.Len(t, incremented, resendLimit)
+
+	stored, err := repo.FindByID(context.TODO(), otp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, resendLimit, stored.ResendCount)
+}
+
+func testConcurrentExpiry(t *testing.T, repo usecase.OTPRepository, txManager usecase.TransactionManager) {
+	const total = 10
+	current := now()
+
+	for i := 0; i < total; i++ {
+		create(t, repo, newOTP("user123", fmt.Sprintf("%06d", i), current.Add(-time.Minute)))
+	}
+
+	// Like the expiry sweepers of several replicas, each worker claims batches of OTPs until none is left
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed = make(map[uint64]int)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				var batch []*entity.OTP
+				err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+					var err error
+					if batch, err = repo.ListExpirable(ctx, current, current.Add(-time.Minute), current.Add(time.Minute), 2); err != nil || len(batch) == 0 {
+						return err
+					}
+					return repo.MarkExpired(ctx, batch)
+				})
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if len(batch) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, otp := range batch {
+					claimed[otp.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every OTP is claimed by exactly one worker
+	assert.Len(t, claimed, total)
+	for id, claims := range claimed {
+		assert.Equal(t, 1, claims, "OTP %d", id)
+	}
+
+	counts, err := repo.CountByStatus(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, map[entity.OTPStatus]int64{entity.OTPStatusExpired: total}, counts)
+}
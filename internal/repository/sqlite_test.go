@@ -0,0 +1,363 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/imansohibul/otp-service/db/migrate"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/imansohibul/otp-service/internal/repository/repositorytest"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSQLiteDB opens a migrated SQLite database in a temporary file
+func newSQLiteDB(t testing.TB) *sqlx.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "otp-service.db") +
+		"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate"
+	db, err := sqlx.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, migrate.Up(context.TODO(), db.DB, migrate.SQLite()))
+
+	return db
+}
+
+func TestSQLite_Migrate(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	// Migrating again is a no-op
+	assert.NoError(t, migrate.Up(context.TODO(), db.DB, migrate.SQLite()))
+
+	var version uint64
+	assert.NoError(t, db.Get(&version, "SELECT version FROM schema_migrations"))
+	assert.Equal(t, uint64(20251205090000), version)
+}
+
+// BenchmarkSQLite_AuditEventAppend measures how many audit events can be appended per second by concurrent requests.
+// Every append holds the lock of the chain head for its own transaction, so the appends run one at a time
+// and their throughput is the inverse of the duration of that transaction, whatever the concurrency.
+func BenchmarkSQLite_AuditEventAppend(b *testing.B) {
+	var (
+		db        = newSQLiteDB(b)
+		auditRepo = repository.NewAuditEventRepository(db, nil)
+		txManager = repository.NewTransactionManager(db, repository.RetryPolicy{})
+	)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			event := &entity.AuditEvent{
+				Actor:     entity.AuditActorClient,
+				UserID:    "user123",
+				Action:    entity.AuditActionOTPValidate,
+				Outcome:   entity.AuditOutcomeFailure,
+				Reason:    "otp_not_found",
+				CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+			}
+			err := txManager.WithTransaction(context.TODO(), func(ctx context.Context) error {
+				return auditRepo.Append(ctx, event)
+			})
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestSQLite_OTPRepository(t *testing.T) {
+	repositorytest.RunOTPRepositorySuite(t, func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager) {
+		db := newSQLiteDB(t)
+		return repository.NewOTPRepository(db, nil, nil), repository.NewTransactionManager(db, repository.RetryPolicy{})
+	}, repositorytest.Limitations{})
+}
+
+func TestSQLite_EncryptedOTPRepository(t *testing.T) {
+	repositorytest.RunOTPRepositorySuite(t, func(t *testing.T) (usecase.OTPRepository, usecase.TransactionManager) {
+		db := newSQLiteDB(t)
+		return repository.NewOTPRepository(db, nil, newKeyring(t, db)), repository.NewTransactionManager(db, repository.RetryPolicy{})
+	}, repositorytest.Limitations{})
+}
+
+func TestSQLite_ReencryptStale(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.TODO()
+	keyring := newKeyring(t, db)
+	plaintextRepo := repository.NewOTPRepository(db, nil, nil)
+	encryptedRepo := repository.NewOTPRepository(db, nil, keyring)
+
+	// storedOTP reads the columns of the OTP as stored
+	storedOTP := func(t *testing.T, id uint64) (userID string, otpCode string, keyID *string) {
+		row := db.QueryRowx("SELECT user_id, otp_code, key_id FROM otps WHERE id = ?", id)
+		require.NoError(t, row.Scan(&userID, &otpCode, &keyID))
+		return userID, otpCode, keyID
+	}
+
+	otp := &entity.OTP{
+		UserID:    "user123",
+		OTPCode:   "123456",
+		Status:    entity.OTPStatusCreated,
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}
+	require.NoError(t, plaintextRepo.Create(ctx, otp))
+
+	t.Run("Should not find the OTPs stored in plaintext by their user ID and code", func(t *testing.T) {
+		_, err := encryptedRepo.FindByUserIDAndCode(ctx, "user123", "123456")
+		assert.Equal(t, entity.ErrOTPNotFound, err)
+	})
+
+	t.Run("Should encrypt the OTPs stored in plaintext", func(t *testing.T) {
+		reencrypted, err := encryptedRepo.ReencryptStale(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), reencrypted)
+
+		userID, otpCode, keyID := storedOTP(t, otp.ID)
+		assert.NotEqual(t, "user123", userID)
+		assert.NotEqual(t, "123456", otpCode)
+		currentKeyID, err := keyring.CurrentKeyID(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &currentKeyID, keyID)
+
+		found, err := encryptedRepo.FindByUserIDAndCode(ctx, "user123", "123456")
+		require.NoError(t, err)
+		assert.Equal(t, otp.ID, found.ID)
+		assert.Equal(t, "user123", found.UserID)
+		assert.Equal(t, "123456", found.OTPCode)
+	})
+
+	t.Run("Should encrypt the OTPs again with the rotated data key", func(t *testing.T) {
+		rotated, err := keyring.RotateDataKey(ctx, 0)
+		require.NoError(t, err)
+		require.True(t, rotated)
+		userID, otpCode, previousKeyID := storedOTP(t, otp.ID)
+
+		reencrypted, err := encryptedRepo.ReencryptStale(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), reencrypted)
+
+		// The blind indexes do not change with the data key
+		reencryptedUserID, reencryptedOTPCode, keyID := storedOTP(t, otp.ID)
+		assert.Equal(t, userID, reencryptedUserID)
+		assert.Equal(t, otpCode, reencryptedOTPCode)
+		assert.NotEqual(t, previousKeyID, keyID)
+
+		last, err := encryptedRepo.GetLastByUserID(ctx, "user123", otp.CreatedAt)
+		require.NoError(t, err)
+		assert.Equal(t, "123456", last.OTPCode)
+
+		reencrypted, err = encryptedRepo.ReencryptStale(ctx, 10)
+		require.NoError(t, err)
+		assert.Zero(t, reencrypted)
+	})
+
+	t.Run("Should not read the encrypted OTPs without encryption", func(t *testing.T) {
+		_, err := plaintextRepo.FindByID(ctx, otp.ID)
+		assert.EqualError(t, err, "otp is encrypted but encryption is not configured")
+
+		_, err = plaintextRepo.ReencryptStale(ctx, 10)
+		assert.EqualError(t, err, "encryption is not configured")
+	})
+}
+
+func TestSQLite_EncryptedOTPBinding(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.TODO()
+	repo := repository.NewOTPRepository(db, nil, newKeyring(t, db))
+
+	otps := []*entity.OTP{
+		{UserID: "user123", OTPCode: "111111", Status: entity.OTPStatusCreated, ExpiresAt: time.Now().Add(5 * time.Minute)},
+		{UserID: "user456", OTPCode: "222222", Status: entity.OTPStatusCreated, ExpiresAt: time.Now().Add(5 * time.Minute)},
+	}
+	for _, otp := range otps {
+		require.NoError(t, repo.Create(ctx, otp))
+	}
+
+	// The ciphertexts are encrypted with the same data key, but bound to their column and row
+	_, err := db.Exec("UPDATE otps SET otp_code_ciphertext = user_id_ciphertext WHERE id = ?", otps[0].ID)
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE otps SET user_id_ciphertext = (SELECT user_id_ciphertext FROM otps WHERE id = ?) WHERE id = ?", otps[0].ID, otps[1].ID)
+	require.NoError(t, err)
+
+	_, err = repo.FindByID(ctx, otps[0].ID)
+	assert.ErrorContains(t, err, "failed to decrypt code of otp")
+
+	_, err = repo.FindByID(ctx, otps[1].ID)
+	assert.ErrorContains(t, err, "failed to decrypt user ID of otp")
+}
+
+func TestSQLite_EncryptedWebhookSecrets(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.TODO()
+	keyring := newKeyring(t, db)
+	plaintextRepo := repository.NewWebhookSubscriptionRepository(db, nil)
+	encryptedRepo := repository.NewWebhookSubscriptionRepository(db, keyring)
+
+	// storedSecret reads the secret of the subscription as stored
+	storedSecret := func(t *testing.T, id uint64) (secret string, keyID *string) {
+		row := db.QueryRowx("SELECT secret, key_id FROM webhook_subscriptions WHERE id = ?", id)
+		require.NoError(t, row.Scan(&secret, &keyID))
+		return secret, keyID
+	}
+
+	plaintext := &entity.WebhookSubscription{
+		ClientID:   "client-1",
+		URL:        "https://client.example/hooks",
+		EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+		Secret:     "plaintext-secret",
+	}
+	require.NoError(t, plaintextRepo.Create(ctx, plaintext))
+
+	t.Run("Should store the secret of a new subscription encrypted", func(t *testing.T) {
+		subscription := &entity.WebhookSubscription{
+			ClientID:   "client-1",
+			URL:        "https://other.example/hooks",
+			EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+			Secret:     "encrypted-secret",
+		}
+		require.NoError(t, encryptedRepo.Create(ctx, subscription))
+
+		secret, keyID := storedSecret(t, subscription.ID)
+		assert.Empty(t, secret)
+		assert.NotNil(t, keyID)
+
+		found, err := encryptedRepo.FindByID(ctx, subscription.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "encrypted-secret", found.Secret)
+	})
+
+	t.Run("Should encrypt the secrets stored in plaintext", func(t *testing.T) {
+		reencrypted, err := encryptedRepo.ReencryptStaleSecrets(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), reencrypted)
+
+		secret, keyID := storedSecret(t, plaintext.ID)
+		assert.Empty(t, secret)
+		currentKeyID, err := keyring.CurrentKeyID(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &currentKeyID, keyID)
+
+		subscriptions, err := encryptedRepo.ListByClientIDAndEventType(ctx, "client-1", entity.EventTypeOTPValidated)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+		assert.Equal(t, "plaintext-secret", subscriptions[0].Secret)
+		assert.Equal(t, "encrypted-secret", subscriptions[1].Secret)
+	})
+
+	t.Run("Should encrypt the secrets again with the rotated data key", func(t *testing.T) {
+		rotated, err := keyring.RotateDataKey(ctx, 0)
+		require.NoError(t, err)
+		require.True(t, rotated)
+
+		reencrypted, err := encryptedRepo.ReencryptStaleSecrets(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), reencrypted)
+
+		found, err := encryptedRepo.FindByID(ctx, plaintext.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "plaintext-secret", found.Secret)
+
+		reencrypted, err = encryptedRepo.ReencryptStaleSecrets(ctx, 10)
+		require.NoError(t, err)
+		assert.Zero(t, reencrypted)
+	})
+
+	t.Run("Should bind the secret to its subscription", func(t *testing.T) {
+		_, err := db.Exec("UPDATE webhook_subscriptions SET url = ? WHERE id = ?", "https://attacker.example/hooks", plaintext.ID)
+		require.NoError(t, err)
+
+		_, err = encryptedRepo.FindByID(ctx, plaintext.ID)
+		assert.ErrorContains(t, err, "failed to decrypt secret of webhook subscription")
+	})
+
+	t.Run("Should not read the encrypted secrets without encryption", func(t *testing.T) {
+		_, err := plaintextRepo.ListByClientID(ctx, "client-1")
+		assert.EqualError(t, err, "webhook secret is encrypted but encryption is not configured")
+
+		_, err = plaintextRepo.ReencryptStaleSecrets(ctx, 10)
+		assert.EqualError(t, err, "encryption is not configured")
+	})
+}
+
+func TestSQLite_UpsertRepositories(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.TODO()
+	now := time.Now()
+
+	t.Run("Should count the failed attempts of a user", func(t *testing.T) {
+		repo := repository.NewUserLockoutRepository(db)
+
+		_, err := repo.IncrementFailedAttempts(ctx, "user123", now)
+		require.NoError(t, err)
+		lockout, err := repo.IncrementFailedAttempts(ctx, "user123", now)
+		require.NoError(t, err)
+		assert.Equal(t, 2, lockout.FailedAttempts)
+	})
+
+	t.Run("Should only grant a lease to its holder until it lapses", func(t *testing.T) {
+		repo := repository.NewJobLeaseRepository(db)
+
+		acquired, err := repo.TryAcquire(ctx, "sweeper", "instance-1", now, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.True(t, acquired)
+
+		acquired, err = repo.TryAcquire(ctx, "sweeper", "instance-2", now, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.False(t, acquired)
+
+		acquired, err = repo.TryAcquire(ctx, "sweeper", "instance-2", now.Add(2*time.Minute), now.Add(3*time.Minute))
+		require.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("Should deliver an event once per subscription", func(t *testing.T) {
+		subscriptions := repository.NewWebhookSubscriptionRepository(db, nil)
+		deliveries := repository.NewWebhookDeliveryRepository(db)
+
+		subscription := &entity.WebhookSubscription{
+			ClientID:   "client-1",
+			URL:        "https://client.example/hooks",
+			EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+			Secret:     "secret",
+		}
+		require.NoError(t, subscriptions.Create(ctx, subscription))
+
+		subscribed, err := subscriptions.ListByClientIDAndEventType(ctx, "client-1", entity.EventTypeOTPValidated)
+		require.NoError(t, err)
+		assert.Len(t, subscribed, 1)
+
+		// The events of the OTPs of other clients are not delivered to the subscription
+		subscribed, err = subscriptions.ListByClientIDAndEventType(ctx, "client-2", entity.EventTypeOTPValidated)
+		require.NoError(t, err)
+		assert.Empty(t, subscribed)
+
+		newDelivery := func() *entity.WebhookDelivery {
+			return &entity.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				EventID:        7,
+				EventType:      entity.EventTypeOTPValidated,
+				Payload:        []byte(`{}`),
+				Status:         entity.OutboxStatusPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			}
+		}
+
+		delivery := newDelivery()
+		require.NoError(t, deliveries.Create(ctx, delivery))
+		assert.NotZero(t, delivery.ID)
+
+		delivery = newDelivery()
+		require.NoError(t, deliveries.Create(ctx, delivery))
+		assert.Zero(t, delivery.ID)
+	})
+}
@@ -3,7 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
 
+	"github.com/imansohibul/otp-service/internal/usecase"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -17,46 +22,201 @@ type ExecerContext interface {
 // txKey is a key used to store the transaction in the context
 type txKey struct{}
 
+// transaction is a transaction stored in the context, with the number of savepoints it is nested in
+type transaction struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+// RetryPolicy configures how transactions failing on a deadlock or a lock wait timeout are run again
+type RetryPolicy struct {
+	// MaxAttempts is how many times a transaction is run at most, it is not run again when lower than 2
+	MaxAttempts int
+	// BackoffBase is the delay before the second attempt, doubled for every following attempt
+	// and randomized to spread out the transactions that failed together
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
 // transactionManager is implementation of TransactionManager interface
 type transactionManager struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	retry RetryPolicy
 }
 
 // NewTransactionManger creates a new instance of transactionManager
-func NewTransactionManager(db *sqlx.DB) *transactionManager {
-	return &transactionManager{db: db}
+func NewTransactionManager(db *sqlx.DB, retry RetryPolicy) *transactionManager {
+	return &transactionManager{
+		db:    db,
+		retry: retry,
+	}
 }
 
 // WithTransaction starts a new transaction and executes the provided function within that transaction.
 func (t *transactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
-	tx, err := t.db.BeginTxx(ctx, &sql.TxOptions{})
+	return t.WithTransactionOptions(ctx, usecase.TransactionOptions{}, fn)
+}
+
+// WithTransactionOptions starts a new transaction with the given options and executes the provided function
+// within that transaction, which is run again when it fails on a transient error. Within a transaction,
+// it executes the function within a savepoint instead, and the error is left to the outermost transaction to retry.
+func (t *transactionManager) WithTransactionOptions(ctx context.Context, opts usecase.TransactionOptions, fn func(ctx context.Context) error) error {
+	if current := currentTransaction(ctx); current != nil {
+		return t.withSavepoint(ctx, current, fn)
+	}
+
+	txOptions := &sql.TxOptions{
+		Isolation: isolationLevel(opts.Isolation),
+		ReadOnly:  opts.ReadOnly,
+	}
+	for attempt := 1; ; attempt++ {
+		err := t.run(ctx, txOptions, fn)
+		reason, retryable := retryableError(err)
+		if !retryable || attempt >= t.retry.MaxAttempts {
+			return err
+		}
+
+		transactionRetries.WithLabelValues(reason).Inc()
+		timer := time.NewTimer(t.retryDelay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// run executes fn within a new transaction
+func (t *transactionManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := t.db.BeginTxx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
 	defer tx.Rollback() // Rollback the transaction if not committed
 
-	ctx = context.WithValue(ctx, txKey{}, tx)
+	ctx = context.WithValue(ctx, txKey{}, &transaction{tx: tx})
 	if err := fn(ctx); err != nil {
+		transactionRollbacks.WithLabelValues("transaction").Inc()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// withSavepoint executes fn within a savepoint of the current transaction,
+// which is rolled back to the savepoint if fn returns an error
+func (t *transactionManager) withSavepoint(ctx context.Context, current *transaction, fn func(ctx context.Context) error) error {
+	nested := &transaction{tx: current.tx, savepoints: current.savepoints + 1}
+	savepoint := fmt.Sprintf("sp_%d", nested.savepoints)
+	if _, err := current.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, nested)); err != nil {
+		transactionRollbacks.WithLabelValues("savepoint").Inc()
+		// A deadlock may have rolled back the whole transaction already, along with the savepoint
+		if _, rollbackErr := current.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	_, err := current.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return err
+}
+
+// retryDelay returns how long to wait before the next attempt, at least half of the exponential backoff
+func (t *transactionManager) retryDelay(attempt int) time.Duration {
+	delay := t.retry.BackoffBase
+	for i := 1; i < attempt && delay < t.retry.BackoffMax; i++ {
+		delay *= 2
+	}
+	if t.retry.BackoffMax > 0 && delay > t.retry.BackoffMax {
+		delay = t.retry.BackoffMax
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isolationLevel maps an isolation level to the one of database/sql.
+// SQLite ignores it, as its transactions are always serializable.
+func isolationLevel(level usecase.IsolationLevel) sql.IsolationLevel {
+	switch level {
+	case usecase.IsolationReadCommitted:
+		return sql.LevelReadCommitted
+	case usecase.IsolationRepeatableRead:
+		return sql.LevelRepeatableRead
+	case usecase.IsolationSerializable:
+		return sql.LevelSerializable
+	default:
+		return sql.LevelDefault
+	}
+}
+
+// withinTransaction executes fn within the transaction of the context, or within a new transaction
+// when there is none, for the repositories writing several rows that must be written together
+func withinTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if currentTransaction(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // Rollback the transaction if not committed
+
+	if err := fn(context.WithValue(ctx, txKey{}, &transaction{tx: tx})); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
-// transactionFromContext retrieves the transaction from the context
+// outsideTransaction returns a context without the transaction of ctx,
+// for the writes that must not be rolled back along with it
+func outsideTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, (*transaction)(nil))
+}
+
+// currentTransaction retrieves the transaction from the context
+func currentTransaction(ctx context.Context) *transaction {
+	current, _ := ctx.Value(txKey{}).(*transaction)
+	return current
+}
+
+// transactionFromContext retrieves the database transaction from the context
 func transactionFromContext(ctx context.Context) *sqlx.Tx {
-	tx, _ := ctx.Value(txKey{}).(*sqlx.Tx)
-	return tx
+	if current := currentTransaction(ctx); current != nil {
+		return current.tx
+	}
+	return nil
 }
 
 // getExecutor retrieves the executor from the context
 // If a transaction is present in the context, it returns the transaction executor.
 // Otherwise, it returns the database executor.
+// On a database that does not support ? placeholders, the executor rebinds them,
+// and on SQLite it adapts the queries to SQLite.
 func getExecutor(ctx context.Context, db *sqlx.DB) ExecerContext {
+	var executor ExecerContext = db
 	if tx := transactionFromContext(ctx); tx != nil {
-		return tx
+		executor = tx
+	}
+
+	if dialectOf(db) == dialectSQLite {
+		return sqliteExecutor{executor: executor}
+	}
+
+	if bindType := sqlx.BindType(db.DriverName()); bindType != sqlx.QUESTION && bindType != sqlx.UNKNOWN {
+		return reboundExecutor{executor: executor, bindType: bindType}
 	}
 
-	return db
+	return executor
 }
@@ -3,18 +3,32 @@ package repository_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestTransactionManager_WithTransaction(t *testing.T) {
+	var (
+		errDeadlock        = &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+		errLockWaitTimeout = &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}
+		errSerialization   = &pq.Error{Code: "40001"}
+		retry              = repository.RetryPolicy{MaxAttempts: 3, BackoffBase: time.Millisecond, BackoffMax: 2 * time.Millisecond}
+	)
+
 	tests := []struct {
 		name          string
+		retry         repository.RetryPolicy
 		mockSetup     func(mock sqlmock.Sqlmock)
-		txFunc        func(ctx context.Context) error
+		txErrors      []error // errors returned by the successive calls of the function
+		expectedCalls int
 		expectedError error
 	}{
 		{
@@ -23,9 +37,7 @@ func TestTransactionManager_WithTransaction(t *testing.T) {
 				mock.ExpectBegin()
 				mock.ExpectCommit()
 			},
-			txFunc: func(ctx context.Context) error {
-				return nil
-			},
+			expectedCalls: 1,
 			expectedError: nil,
 		},
 		{
@@ -34,9 +46,8 @@ func TestTransactionManager_WithTransaction(t *testing.T) {
 				mock.ExpectBegin()
 				mock.ExpectRollback()
 			},
-			txFunc: func(ctx context.Context) error {
-				return sql.ErrTxDone
-			},
+			txErrors:      []error{sql.ErrTxDone},
+			expectedCalls: 1,
 			expectedError: sql.ErrTxDone,
 		},
 		{
@@ -44,22 +55,88 @@ func TestTransactionManager_WithTransaction(t *testing.T) {
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
 			},
-			txFunc: func(ctx context.Context) error {
-				return nil
-			},
 			expectedError: sql.ErrConnDone,
 		},
+		{
+			name:  "should run the transaction again after a deadlock",
+			retry: retry,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			txErrors:      []error{errDeadlock, errLockWaitTimeout},
+			expectedCalls: 3,
+			expectedError: nil,
+		},
+		{
+			name:  "should run the transaction again after a serialization failure",
+			retry: retry,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			txErrors:      []error{errSerialization},
+			expectedCalls: 2,
+			expectedError: nil,
+		},
+		{
+			name:  "should give up after the maximum number of attempts",
+			retry: retry,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				for range 3 {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				}
+			},
+			txErrors:      []error{errDeadlock, errDeadlock, errDeadlock},
+			expectedCalls: 3,
+			expectedError: errDeadlock,
+		},
+		{
+			name: "should not run the transaction again without a retry policy",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			txErrors:      []error{errDeadlock},
+			expectedCalls: 1,
+			expectedError: errDeadlock,
+		},
+		{
+			name:  "should not run the transaction again after an error that is not transient",
+			retry: retry,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			txErrors:      []error{sql.ErrNoRows},
+			expectedCalls: 1,
+			expectedError: sql.ErrNoRows,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			repoDependency := newRepoDependency()
-			txManager := repository.NewTransactionManager(repoDependency.mockedDB)
+			txManager := repository.NewTransactionManager(repoDependency.mockedDB, tt.retry)
 
 			tt.mockSetup(repoDependency.mockedSQL)
 
-			err := txManager.WithTransaction(ctx, tt.txFunc)
+			calls := 0
+			err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+				calls++
+				if calls <= len(tt.txErrors) {
+					return tt.txErrors[calls-1]
+				}
+				return nil
+			})
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
@@ -67,6 +144,76 @@ func TestTransactionManager_WithTransaction(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.NoError(t, repoDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTransactionManager_Nested(t *testing.T) {
+	errNested := errors.New("nested failed")
+
+	tests := []struct {
+		name          string
+		mockSetup     func(mock sqlmock.Sqlmock)
+		nestedErr     error
+		expectedError error
+	}{
+		{
+			name: "should release the savepoint when the nested function succeeds",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^SAVEPOINT sp_1$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^SAVEPOINT sp_2$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^RELEASE SAVEPOINT sp_2$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^RELEASE SAVEPOINT sp_1$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "should roll back to the savepoint when the nested function fails",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^SAVEPOINT sp_1$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^SAVEPOINT sp_2$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^ROLLBACK TO SAVEPOINT sp_2$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^ROLLBACK TO SAVEPOINT sp_1$`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			nestedErr:     errNested,
+			expectedError: errNested,
+		},
+		{
+			name: "should return the error when the savepoint cannot be created",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^SAVEPOINT sp_1$`).WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			expectedError: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repoDependency := newRepoDependency()
+			txManager := repository.NewTransactionManager(repoDependency.mockedDB, repository.RetryPolicy{})
+
+			tt.mockSetup(repoDependency.mockedSQL)
+
+			// The options of nested transactions are ignored
+			readOnly := usecase.TransactionOptions{Isolation: usecase.IsolationSerializable, ReadOnly: true}
+			err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+				return txManager.WithTransactionOptions(ctx, readOnly, func(ctx context.Context) error {
+					return txManager.WithTransaction(ctx, func(ctx context.Context) error {
+						return tt.nestedErr
+					})
+				})
+			})
+
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.NoError(t, repoDependency.mockedSQL.ExpectationsWereMet())
 		})
 	}
 }
@@ -2,6 +2,11 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/imansohibul/otp-service/entity"
@@ -13,30 +18,388 @@ import (
 // This allows flexibility in how data is stored or queried, without coupling the repository to business-layer concerns.
 // It also makes it easier to switch database drivers or ORMs, as changes in the data access layer won’t leak into the domain layer.
 
-// otpRow represents the OTP table row structure for database operations
+// otpRow represents the OTP table row structure for database operations.
+// When the columns are encrypted, user_id and otp_code hold the blind indexes of the user ID and the code,
+// which are stored encrypted in the ciphertext columns with the data key of key_id.
 type otpRow struct {
-	ID          uint64     `db:"id"`
-	UserID      string     `db:"user_id"`
-	OTPCode     string     `db:"otp_code"`
-	Status      int        `db:"status"`
-	CreatedAt   time.Time  `db:"created_at"`
-	ExpiresAt   time.Time  `db:"expires_at"`
-	ValidatedAt *time.Time `db:"validated_at"` // Nullable field
-}
-
-// ToEntity converts otpRow to entity.OTP
-func (r *otpRow) ToEntity() *entity.OTP {
-	return &entity.OTP{
+	ID                   uint64         `db:"id"`
+	UserID               string         `db:"user_id"`
+	OTPCode              string         `db:"otp_code"`
+	Status               int            `db:"status"`
+	CreatedAt            time.Time      `db:"created_at"`
+	ExpiresAt            time.Time      `db:"expires_at"`
+	ValidatedAt          *time.Time     `db:"validated_at"`           // Nullable field
+	ValidatedSessionHash sql.NullString `db:"validated_session_hash"` // Nullable field
+	BindingHash          sql.NullString `db:"binding_hash"`           // Nullable field
+	Purpose              sql.NullString `db:"purpose"`                // Nullable field
+	ClientID             sql.NullString `db:"client_id"`              // Nullable field
+	RevokedAt            *time.Time     `db:"revoked_at"`             // Nullable field
+	RevokeReason         sql.NullString `db:"revoke_reason"`          // Nullable field
+	ResendCount          int            `db:"resend_count"`
+	ResendLimit          int            `db:"resend_limit"`
+	UserIDCiphertext     []byte         `db:"user_id_ciphertext"`  // Nullable field
+	OTPCodeCiphertext    []byte         `db:"otp_code_ciphertext"` // Nullable field
+	KeyID                sql.NullString `db:"key_id"`              // Nullable field
+}
+
+// newOTPRow converts entity.OTP to otpRow, encrypting its user ID and code with the current data key
+// when an encryptor is given
+func newOTPRow(ctx context.Context, otp *entity.OTP, encryptor ColumnEncryptor) (*otpRow, error) {
+	row := &otpRow{
+		ID:                   otp.ID,
+		UserID:               otp.UserID,
+		OTPCode:              otp.OTPCode,
+		Status:               int(otp.Status),
+		CreatedAt:            otp.CreatedAt,
+		ExpiresAt:            otp.ExpiresAt,
+		ValidatedAt:          otp.ValidatedAt,
+		ValidatedSessionHash: nullableString(otp.ValidatedSessionHash),
+		BindingHash:          nullableString(otp.BindingHash),
+		Purpose:              nullableString(otp.Purpose),
+		ClientID:             nullableString(otp.ClientID),
+		RevokedAt:            otp.RevokedAt,
+		RevokeReason:         nullableString(otp.RevokeReason),
+		ResendCount:          otp.ResendCount,
+		ResendLimit:          otp.ResendLimit,
+	}
+	if encryptor == nil {
+		return row, nil
+	}
+
+	keyID, err := encryptor.CurrentKeyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if row.UserID, err = userIDIndex(ctx, encryptor, otp.UserID); err != nil {
+		return nil, err
+	}
+	if row.OTPCode, err = otpCodeIndex(ctx, encryptor, otp.UserID, otp.OTPCode); err != nil {
+		return nil, err
+	}
+	userIDData := associatedData(userIDAssociatedData, row.UserID, row.OTPCode)
+	if row.UserIDCiphertext, err = encryptor.Encrypt(ctx, keyID, []byte(otp.UserID), userIDData); err != nil {
+		return nil, err
+	}
+	otpCodeData := associatedData(otpCodeAssociatedData, row.UserID, row.OTPCode)
+	if row.OTPCodeCiphertext, err = encryptor.Encrypt(ctx, keyID, []byte(otp.OTPCode), otpCodeData); err != nil {
+		return nil, err
+	}
+	row.KeyID = nullableString(keyID)
+
+	return row, nil
+}
+
+// ToEntity converts otpRow to entity.OTP, decrypting its user ID and code if they are encrypted
+func (r *otpRow) ToEntity(ctx context.Context, encryptor ColumnEncryptor) (*entity.OTP, error) {
+	otp := &entity.OTP{
+		ID:                   r.ID,
+		UserID:               r.UserID,
+		OTPCode:              r.OTPCode,
+		Status:               entity.OTPStatus(r.Status),
+		CreatedAt:            r.CreatedAt,
+		ExpiresAt:            r.ExpiresAt,
+		ValidatedAt:          r.ValidatedAt,
+		ValidatedSessionHash: r.ValidatedSessionHash.String,
+		BindingHash:          r.BindingHash.String,
+		Purpose:              r.Purpose.String,
+		ClientID:             r.ClientID.String,
+		RevokedAt:            r.RevokedAt,
+		RevokeReason:         r.RevokeReason.String,
+		ResendCount:          r.ResendCount,
+		ResendLimit:          r.ResendLimit,
+	}
+	if !r.KeyID.Valid {
+		return otp, nil
+	}
+	if encryptor == nil {
+		return nil, errors.New("otp is encrypted but encryption is not configured")
+	}
+
+	// The user_id and otp_code columns of an encrypted row hold the blind indexes its values are bound to
+	userID, err := encryptor.Decrypt(ctx, r.KeyID.String, r.UserIDCiphertext, associatedData(userIDAssociatedData, r.UserID, r.OTPCode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt user ID of otp %d: %w", r.ID, err)
+	}
+	otpCode, err := encryptor.Decrypt(ctx, r.KeyID.String, r.OTPCodeCiphertext, associatedData(otpCodeAssociatedData, r.UserID, r.OTPCode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt code of otp %d: %w", r.ID, err)
+	}
+	otp.UserID, otp.OTPCode = string(userID), string(otpCode)
+
+	return otp, nil
+}
+
+// userLockoutRow represents the user_lockouts table row structure for database operations
+type userLockoutRow struct {
+	UserID         string     `db:"user_id"`
+	FailedAttempts int        `db:"failed_attempts"`
+	LastFailedAt   *time.Time `db:"last_failed_at"` // Nullable field
+	LockedUntil    *time.Time `db:"locked_until"`   // Nullable field
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// ToEntity converts userLockoutRow to entity.UserLockout
+func (r *userLockoutRow) ToEntity() *entity.UserLockout {
+	return &entity.UserLockout{
+		UserID:         r.UserID,
+		FailedAttempts: r.FailedAttempts,
+		LastFailedAt:   r.LastFailedAt,
+		LockedUntil:    r.LockedUntil,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
+
+// idempotencyRecordRow represents the idempotency_keys table row structure for database operations
+type idempotencyRecordRow struct {
+	Key            string    `db:"idempotency_key"`
+	RequestHash    string    `db:"request_hash"`
+	ResponseStatus int       `db:"response_status"`
+	ResponseBody   []byte    `db:"response_body"` // Nullable field
+	CreatedAt      time.Time `db:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at"`
+}
+
+// ToEntity converts idempotencyRecordRow to entity.IdempotencyRecord
+func (r *idempotencyRecordRow) ToEntity() *entity.IdempotencyRecord {
+	return &entity.IdempotencyRecord{
+		Key:            r.Key,
+		RequestHash:    r.RequestHash,
+		ResponseStatus: r.ResponseStatus,
+		ResponseBody:   r.ResponseBody,
+		CreatedAt:      r.CreatedAt,
+		ExpiresAt:      r.ExpiresAt,
+	}
+}
+
+// outboxEventRow represents the outbox table row structure for database operations
+type outboxEventRow struct {
+	ID            uint64         `db:"id"`
+	EventType     string         `db:"event_type"`
+	OTPID         sql.NullInt64  `db:"otp_id"`    // Nullable field
+	ClientID      sql.NullString `db:"client_id"` // Nullable field
+	Payload       []byte         `db:"payload"`
+	Status        int            `db:"status"`
+	Attempts      int            `db:"attempts"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	LastError     sql.NullString `db:"last_error"` // Nullable field
+	CreatedAt     time.Time      `db:"created_at"`
+	DeliveredAt   *time.Time     `db:"delivered_at"` // Nullable field
+}
+
+// ToEntity converts outboxEventRow to entity.OutboxEvent
+func (r *outboxEventRow) ToEntity() *entity.OutboxEvent {
+	return &entity.OutboxEvent{
+		ID:            r.ID,
+		EventType:     entity.EventType(r.EventType),
+		OTPID:         uint64(r.OTPID.Int64),
+		ClientID:      r.ClientID.String,
+		Payload:       r.Payload,
+		Status:        entity.OutboxStatus(r.Status),
+		Attempts:      r.Attempts,
+		NextAttemptAt: r.NextAttemptAt,
+		LastError:     r.LastError.String,
+		CreatedAt:     r.CreatedAt,
+		DeliveredAt:   r.DeliveredAt,
+	}
+}
+
+// webhookSubscriptionRow represents the webhook_subscriptions table row structure for database operations
+type webhookSubscriptionRow struct {
+	ID               uint64         `db:"id"`
+	ClientID         string         `db:"client_id"`
+	URL              string         `db:"url"`
+	EventTypes       []byte         `db:"event_types"` // JSON array of event types
+	Secret           string         `db:"secret"`
+	SecretCiphertext []byte         `db:"secret_ciphertext"` // Nullable field
+	KeyID            sql.NullString `db:"key_id"`            // Nullable field
+	CreatedAt        time.Time      `db:"created_at"`
+}
+
+// encryptSecret encrypts the signing secret of the row with the current data key when an encryptor is given,
+// leaving the secret column empty
+func (r *webhookSubscriptionRow) encryptSecret(ctx context.Context, encryptor ColumnEncryptor) error {
+	if encryptor == nil {
+		return nil
+	}
+
+	keyID, err := encryptor.CurrentKeyID(ctx)
+	if err != nil {
+		return err
+	}
+	secret := r.Secret
+	if !r.KeyID.Valid {
+		r.Secret = ""
+	} else if secret, err = r.decryptSecret(ctx, encryptor); err != nil {
+		return err
+	}
+
+	if r.SecretCiphertext, err = encryptor.Encrypt(ctx, keyID, []byte(secret), webhookSecretAssociatedDataOf(r.ClientID, r.URL)); err != nil {
+		return err
+	}
+	r.KeyID = nullableString(keyID)
+
+	return nil
+}
+
+// decryptSecret returns the signing secret of the row, decrypting it if it is encrypted
+func (r *webhookSubscriptionRow) decryptSecret(ctx context.Context, encryptor ColumnEncryptor) (string, error) {
+	if !r.KeyID.Valid {
+		return r.Secret, nil
+	}
+	if encryptor == nil {
+		return "", errors.New("webhook secret is encrypted but encryption is not configured")
+	}
+
+	secret, err := encryptor.Decrypt(ctx, r.KeyID.String, r.SecretCiphertext, webhookSecretAssociatedDataOf(r.ClientID, r.URL))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret of webhook subscription %d: %w", r.ID, err)
+	}
+
+	return string(secret), nil
+}
+
+// ToEntity converts webhookSubscriptionRow to entity.WebhookSubscription, decrypting its secret if it is encrypted
+func (r *webhookSubscriptionRow) ToEntity(ctx context.Context, encryptor ColumnEncryptor) (*entity.WebhookSubscription, error) {
+	var eventTypes []entity.EventType
+	if err := json.Unmarshal(r.EventTypes, &eventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := r.decryptSecret(ctx, encryptor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.WebhookSubscription{
+		ID:         r.ID,
+		ClientID:   r.ClientID,
+		URL:        r.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		CreatedAt:  r.CreatedAt,
+	}, nil
+}
+
+// webhookDeliveryRow represents the webhook_deliveries table row structure for database operations
+type webhookDeliveryRow struct {
+	ID             uint64         `db:"id"`
+	SubscriptionID uint64         `db:"subscription_id"`
+	EventID        uint64         `db:"event_id"`
+	EventType      string         `db:"event_type"`
+	Payload        []byte         `db:"payload"`
+	Status         int            `db:"status"`
+	Attempts       int            `db:"attempts"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at"`
+	ResponseCode   sql.NullInt64  `db:"response_code"` // Nullable field
+	LastError      sql.NullString `db:"last_error"`    // Nullable field
+	CreatedAt      time.Time      `db:"created_at"`
+	DeliveredAt    *time.Time     `db:"delivered_at"` // Nullable field
+}
+
+// ToEntity converts webhookDeliveryRow to entity.WebhookDelivery
+func (r *webhookDeliveryRow) ToEntity() *entity.WebhookDelivery {
+	return &entity.WebhookDelivery{
+		ID:             r.ID,
+		SubscriptionID: r.SubscriptionID,
+		EventID:        r.EventID,
+		EventType:      entity.EventType(r.EventType),
+		Payload:        r.Payload,
+		Status:         entity.OutboxStatus(r.Status),
+		Attempts:       r.Attempts,
+		NextAttemptAt:  r.NextAttemptAt,
+		ResponseCode:   int(r.ResponseCode.Int64),
+		LastError:      r.LastError.String,
+		CreatedAt:      r.CreatedAt,
+		DeliveredAt:    r.DeliveredAt,
+	}
+}
+
+// auditEventRow represents the audit_events table row structure for database operations
+type auditEventRow struct {
+	ID        uint64         `db:"id"`
+	Actor     string         `db:"actor"`
+	ClientID  sql.NullString `db:"client_id"` // Nullable field
+	IP        sql.NullString `db:"ip"`        // Nullable field
+	UserID    string         `db:"user_id"`
+	OTPID     sql.NullInt64  `db:"otp_id"` // Nullable field
+	Action    string         `db:"action"`
+	Outcome   int            `db:"outcome"`
+	Reason    sql.NullString `db:"reason"` // Nullable field
+	CreatedAt time.Time      `db:"created_at"`
+	PrevHash  string         `db:"prev_hash"`
+	Hash      string         `db:"hash"`
+}
+
+// ToEntity converts auditEventRow to entity.AuditEvent
+func (r *auditEventRow) ToEntity() *entity.AuditEvent {
+	return &entity.AuditEvent{
+		ID:        r.ID,
+		Actor:     r.Actor,
+		ClientID:  r.ClientID.String,
+		IP:        r.IP.String,
+		UserID:    r.UserID,
+		OTPID:     uint64(r.OTPID.Int64),
+		Action:    entity.AuditAction(r.Action),
+		Outcome:   entity.AuditOutcome(r.Outcome),
+		Reason:    r.Reason.String,
+		CreatedAt: r.CreatedAt,
+		PrevHash:  r.PrevHash,
+		Hash:      r.Hash,
+	}
+}
+
+// auditChainHeadRow represents the audit_chain_head table row structure for database operations
+type auditChainHeadRow struct {
+	LastID   uint64 `db:"last_id"`
+	LastHash string `db:"last_hash"`
+}
+
+// ToEntity converts auditChainHeadRow to entity.AuditChainHead
+func (r *auditChainHeadRow) ToEntity() *entity.AuditChainHead {
+	return &entity.AuditChainHead{
+		LastID:   r.LastID,
+		LastHash: r.LastHash,
+	}
+}
+
+// encryptionKeyRow represents the encryption_keys table row structure for database operations
+type encryptionKeyRow struct {
+	ID          string    `db:"id"`
+	Kind        string    `db:"kind"`
+	MasterKeyID string    `db:"master_key_id"`
+	WrappedKey  []byte    `db:"wrapped_key"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// ToEntity converts encryptionKeyRow to entity.EncryptionKey
+func (r *encryptionKeyRow) ToEntity() *entity.EncryptionKey {
+	return &entity.EncryptionKey{
 		ID:          r.ID,
-		UserID:      r.UserID,
-		OTPCode:     r.OTPCode,
-		Status:      entity.OTPStatus(r.Status),
+		Kind:        entity.EncryptionKeyKind(r.Kind),
+		MasterKeyID: r.MasterKeyID,
+		WrappedKey:  r.WrappedKey,
 		CreatedAt:   r.CreatedAt,
-		ExpiresAt:   r.ExpiresAt,
-		ValidatedAt: r.ValidatedAt,
 	}
 }
 
+// nullableString maps an empty string to a NULL column value
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullableBytes maps empty bytes to a NULL column value
+func nullableBytes(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+// nullableInt maps a zero integer to a NULL column value
+func nullableInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
 // QueryOption type to represent query modifiers
 type QueryOption string
 
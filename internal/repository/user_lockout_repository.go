@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// userLockoutRepository implements the UserLockoutRepository interface
+type userLockoutRepository struct {
+	db *sqlx.DB
+}
+
+// NewUserLockoutRepository creates a new instance of userLockoutRepository
+func NewUserLockoutRepository(db *sqlx.DB) *userLockoutRepository {
+	return &userLockoutRepository{
+		db: db,
+	}
+}
+
+// FindByUserID retrieves the lockout ledger of a user from the database.
+// Returns entity.ErrUserLockoutNotFound if the user has no recorded failures.
+func (u *userLockoutRepository) FindByUserID(ctx context.Context, userID string) (*entity.UserLockout, error) {
+	const query = `
+		SELECT user_id, failed_attempts, last_failed_at, locked_until, updated_at
+		FROM user_lockouts
+		WHERE user_id = ?
+	`
+
+	var lockoutRow userLockoutRow
+	if err := getExecutor(ctx, u.db).GetContext(ctx, &lockoutRow, query, userID); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrUserLockoutNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrUserLockoutNotFound
+		}
+		return nil, err
+	}
+
+	return lockoutRow.ToEntity(), nil
+}
+
+// IncrementFailedAttempts atomically increments the failed attempts counter of a user,
+// creating the ledger if it does not exist yet, and returns the updated ledger.
+func (u *userLockoutRepository) IncrementFailedAttempts(ctx context.Context, userID string, failedAt time.Time) (*entity.UserLockout, error) {
+	const (
+		mysqlQuery = `
+			INSERT INTO user_lockouts (user_id, failed_attempts, last_failed_at)
+			VALUES (?, 1, ?)
+			ON DUPLICATE KEY UPDATE failed_attempts = failed_attempts + 1, last_failed_at = VALUES(last_failed_at)
+		`
+		// SQLite accepts the upsert syntax of PostgreSQL
+		postgresQuery = `
+			INSERT INTO user_lockouts (user_id, failed_attempts, last_failed_at)
+			VALUES (?, 1, ?)
+			ON CONFLICT (user_id) DO UPDATE SET failed_attempts = user_lockouts.failed_attempts + 1, last_failed_at = EXCLUDED.last_failed_at
+		`
+	)
+	query := mysqlQuery
+	if dialectOf(u.db) != dialectMySQL {
+		query = postgresQuery
+	}
+	if _, err := getExecutor(ctx, u.db).ExecContext(ctx, query, userID, failedAt); err != nil {
+		return nil, err
+	}
+
+	return u.FindByUserID(ctx, userID)
+}
+
+// Lock locks the user out until the given time and resets the failed attempts counter.
+func (u *userLockoutRepository) Lock(ctx context.Context, userID string, lockedUntil time.Time) error {
+	const query = `
+		UPDATE user_lockouts
+		SET failed_attempts = 0, locked_until = ?
+		WHERE user_id = ?
+	`
+	_, err := getExecutor(ctx, u.db).ExecContext(ctx, query, lockedUntil, userID)
+
+	return err
+}
+
+// DeleteByUserID removes the lockout ledger of a user, clearing both
+// the failed attempts counter and any active lock.
+func (u *userLockoutRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	const query = `
+		DELETE FROM user_lockouts
+		WHERE user_id = ?
+	`
+	_, err := getExecutor(ctx, u.db).ExecContext(ctx, query, userID)
+
+	return err
+}
@@ -0,0 +1,259 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserLockoutRepository_FindByUserID(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta(`
+		SELECT user_id, failed_attempts, last_failed_at, locked_until, updated_at
+		FROM user_lockouts
+		WHERE user_id = ?
+	`)
+
+	tests := []struct {
+		name           string
+		userID         string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*testing.T, *entity.UserLockout, error)
+	}{
+		{
+			name:   "Should return user lockout successfully",
+			userID: "user123",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("user123").
+					WillReturnRows(sqlmock.NewRows([]string{
+						"user_id", "failed_attempts", "last_failed_at", "locked_until", "updated_at",
+					}).AddRow(
+						"user123", 3, now, nil, now,
+					))
+			},
+			assertFn: func(t *testing.T, lockout *entity.UserLockout, err error) {
+				assert.Nil(t, err)
+				assert.NotNil(t, lockout)
+				assert.Equal(t, "user123", lockout.UserID)
+				assert.Equal(t, 3, lockout.FailedAttempts)
+				assert.Nil(t, lockout.LockedUntil)
+			},
+		},
+		{
+			name:   "Should return ErrUserLockoutNotFound when no row found",
+			userID: "user999",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("user999").
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(t *testing.T, lockout *entity.UserLockout, err error) {
+				assert.Nil(t, lockout)
+				assert.Equal(t, entity.ErrUserLockoutNotFound, err)
+			},
+		},
+		{
+			name:   "Should return error when DB fails",
+			userID: "user123",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs("user123").
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(t *testing.T, lockout *entity.UserLockout, err error) {
+				assert.Nil(t, lockout)
+				assert.Equal(t, sql.ErrConnDone, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewUserLockoutRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			lockout, err := repo.FindByUserID(context.TODO(), tt.userID)
+			tt.assertFn(t, lockout, err)
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserLockoutRepository_IncrementFailedAttempts(t *testing.T) {
+	now := time.Now()
+	expectedUpsert := regexp.QuoteMeta(`
+		INSERT INTO user_lockouts (user_id, failed_attempts, last_failed_at)
+		VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE failed_attempts = failed_attempts + 1, last_failed_at = VALUES(last_failed_at)
+	`)
+	expectedSelect := regexp.QuoteMeta(`
+		SELECT user_id, failed_attempts, last_failed_at, locked_until, updated_at
+		FROM user_lockouts
+		WHERE user_id = ?
+	`)
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*testing.T, *entity.UserLockout, error)
+	}{
+		{
+			name: "Should increment failed attempts and return the updated ledger",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedUpsert).
+					WithArgs("user123", now).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				dependency.mockedSQL.
+					ExpectQuery(expectedSelect).
+					WithArgs("user123").
+					WillReturnRows(sqlmock.NewRows([]string{
+						"user_id", "failed_attempts", "last_failed_at", "locked_until", "updated_at",
+					}).AddRow(
+						"user123", 2, now, nil, now,
+					))
+			},
+			assertFn: func(t *testing.T, lockout *entity.UserLockout, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 2, lockout.FailedAttempts)
+			},
+		},
+		{
+			name: "Should return error when upsert fails",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedUpsert).
+					WithArgs("user123", now).
+					WillReturnError(sql.ErrConnDone)
+			},
+			assertFn: func(t *testing.T, lockout *entity.UserLockout, err error) {
+				assert.Nil(t, lockout)
+				assert.Equal(t, sql.ErrConnDone, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewUserLockoutRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			lockout, err := repo.IncrementFailedAttempts(context.TODO(), "user123", now)
+			tt.assertFn(t, lockout, err)
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserLockoutRepository_IncrementFailedAttempts_Postgres(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependencyFor("postgres")
+	repo := repository.NewUserLockoutRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta(`
+			INSERT INTO user_lockouts (user_id, failed_attempts, last_failed_at)
+			VALUES (?, 1, ?)
+			ON CONFLICT (user_id) DO UPDATE SET failed_attempts = user_lockouts.failed_attempts + 1, last_failed_at = EXCLUDED.last_failed_at
+		`)).
+		WithArgs("user123", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT user_id, failed_attempts, last_failed_at, locked_until, updated_at FROM user_lockouts WHERE user_id = ?")).
+		WithArgs("user123").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"user_id", "failed_attempts", "last_failed_at", "locked_until", "updated_at",
+		}).AddRow(
+			"user123", 2, now, nil, now,
+		))
+
+	lockout, err := repo.IncrementFailedAttempts(context.TODO(), "user123", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lockout.FailedAttempts)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestUserLockoutRepository_Lock(t *testing.T) {
+	lockedUntil := time.Now().Add(15 * time.Minute)
+	expectedQuery := regexp.QuoteMeta(`
+		UPDATE user_lockouts
+		SET failed_attempts = 0, locked_until = ?
+		WHERE user_id = ?
+	`)
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewUserLockoutRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(expectedQuery).
+		WithArgs(lockedUntil, "user123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Lock(context.TODO(), "user123", lockedUntil))
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestUserLockoutRepository_DeleteByUserID(t *testing.T) {
+	expectedQuery := regexp.QuoteMeta(`
+		DELETE FROM user_lockouts
+		WHERE user_id = ?
+	`)
+
+	tests := []struct {
+		name          string
+		mockErr       error
+		expectedError error
+	}{
+		{
+			name: "Should delete user lockout successfully",
+		},
+		{
+			name:          "Should return error when delete fails",
+			mockErr:       sql.ErrConnDone,
+			expectedError: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewUserLockoutRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			expectation := repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs("user123")
+			if tt.mockErr != nil {
+				expectation.WillReturnError(tt.mockErr)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			assert.Equal(t, tt.expectedError, repo.DeleteByUserID(context.TODO(), "user123"))
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// webhookDeliveryRepository implements the WebhookDeliveryRepository interface
+type webhookDeliveryRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebhookDeliveryRepository creates a new instance of webhookDeliveryRepository
+func NewWebhookDeliveryRepository(db *sqlx.DB) *webhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new webhook delivery into the database and sets the ID of the given delivery.
+// A delivery of the same event to the same subscription is only inserted once, in which case the ID is left unset.
+func (w *webhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	const (
+		mysqlQuery = `
+			INSERT IGNORE INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`
+		// PostgreSQL and SQLite have no INSERT IGNORE, and return no row when the delivery already exists
+		postgresQuery = `
+			INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (subscription_id, event_id) DO NOTHING
+			RETURNING id
+		`
+	)
+	args := []any{
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+	}
+
+	if dialectOf(w.db) != dialectMySQL {
+		err := getExecutor(ctx, w.db).GetContext(ctx, &delivery.ID, postgresQuery, args...)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	result, err := getExecutor(ctx, w.db).ExecContext(ctx, mysqlQuery, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	delivery.ID = uint64(id)
+
+	return nil
+}
+
+// FindByID retrieves a webhook delivery by its ID.
+// Returns entity.ErrWebhookDeliveryNotFound if no delivery exists with the ID.
+func (w *webhookDeliveryRepository) FindByID(ctx context.Context, id uint64) (*entity.WebhookDelivery, error) {
+	const query = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE id = ?
+	`
+
+	var row webhookDeliveryRow
+	if err := getExecutor(ctx, w.db).GetContext(ctx, &row, query, id); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrWebhookDeliveryNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	return row.ToEntity(), nil
+}
+
+// ListDue retrieves up to limit pending deliveries whose next attempt is due at the given time, oldest first
+func (w *webhookDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	const query = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	var rows []webhookDeliveryRow
+	if err := getExecutor(ctx, w.db).SelectContext(ctx, &rows, query, entity.OutboxStatusPending, now, limit); err != nil {
+		return nil, err
+	}
+
+	return toWebhookDeliveries(rows), nil
+}
+
+// ListBySubscriptionID retrieves up to limit of the most recent deliveries of a subscription, newest first
+func (w *webhookDeliveryRepository) ListBySubscriptionID(ctx context.Context, subscriptionID uint64, limit int) ([]*entity.WebhookDelivery, error) {
+	const query = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	var rows []webhookDeliveryRow
+	if err := getExecutor(ctx, w.db).SelectContext(ctx, &rows, query, subscriptionID, limit); err != nil {
+		return nil, err
+	}
+
+	return toWebhookDeliveries(rows), nil
+}
+
+// ListByEventID retrieves the deliveries of an outbox event to every subscription, oldest first
+func (w *webhookDeliveryRepository) ListByEventID(ctx context.Context, eventID uint64) ([]*entity.WebhookDelivery, error) {
+	const query = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE event_id = ?
+		ORDER BY id
+	`
+
+	var rows []webhookDeliveryRow
+	if err := getExecutor(ctx, w.db).SelectContext(ctx, &rows, query, eventID); err != nil {
+		return nil, err
+	}
+
+	return toWebhookDeliveries(rows), nil
+}
+
+// Update stores the delivery status of a webhook delivery
+func (w *webhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, response_code = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`
+	_, err := getExecutor(ctx, w.db).ExecContext(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		nullableInt(delivery.ResponseCode),
+		nullableString(delivery.LastError),
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+
+	return err
+}
+
+// toWebhookDeliveries converts webhook delivery rows to entities
+func toWebhookDeliveries(rows []webhookDeliveryRow) []*entity.WebhookDelivery {
+	deliveries := make([]*entity.WebhookDelivery, 0, len(rows))
+	for i := range rows {
+		deliveries = append(deliveries, rows[i].ToEntity())
+	}
+
+	return deliveries
+}
@@ -0,0 +1,252 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var webhookDeliveryColumns = []string{"id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "response_code", "last_error", "created_at", "delivered_at"}
+
+func TestWebhookDeliveryRepository_Create(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta("INSERT IGNORE INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.WebhookDelivery, error)
+	}{
+		{
+			name: "Should create the delivery and set its ID",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(3, 7, entity.EventTypeOTPValidated, []byte(`{}`), entity.OutboxStatusPending, 0, now, now).
+					WillReturnResult(sqlmock.NewResult(11, 1))
+			},
+			assertFn: func(delivery *entity.WebhookDelivery, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(11), delivery.ID)
+			},
+		},
+		{
+			name: "Should leave the ID unset when the event was already delivered to the subscription",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectExec(expectedQuery).
+					WithArgs(3, 7, entity.EventTypeOTPValidated, []byte(`{}`), entity.OutboxStatusPending, 0, now, now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			assertFn: func(delivery *entity.WebhookDelivery, err error) {
+				assert.Nil(t, err)
+				assert.Zero(t, delivery.ID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewWebhookDeliveryRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			delivery := &entity.WebhookDelivery{
+				SubscriptionID: 3,
+				EventID:        7,
+				EventType:      entity.EventTypeOTPValidated,
+				Payload:        []byte(`{}`),
+				Status:         entity.OutboxStatusPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			}
+			tt.assertFn(delivery, repo.Create(context.TODO(), delivery))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookDeliveryRepository_Create_Postgres(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING id
+	`)
+
+	repositoryDependency := newRepoDependencyFor("postgres")
+	repo := repository.NewWebhookDeliveryRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(expectedQuery).
+		WithArgs(3, 7, entity.EventTypeOTPValidated, []byte(`{}`), entity.OutboxStatusPending, 0, now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	// The event was already delivered to the subscription
+	repositoryDependency.mockedSQL.
+		ExpectQuery(expectedQuery).
+		WithArgs(3, 7, entity.EventTypeOTPValidated, []byte(`{}`), entity.OutboxStatusPending, 0, now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	newDelivery := func() *entity.WebhookDelivery {
+		return &entity.WebhookDelivery{
+			SubscriptionID: 3,
+			EventID:        7,
+			EventType:      entity.EventTypeOTPValidated,
+			Payload:        []byte(`{}`),
+			Status:         entity.OutboxStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+	}
+
+	delivery := newDelivery()
+	assert.NoError(t, repo.Create(context.TODO(), delivery))
+	assert.Equal(t, uint64(11), delivery.ID)
+
+	delivery = newDelivery()
+	assert.NoError(t, repo.Create(context.TODO(), delivery))
+	assert.Zero(t, delivery.ID)
+
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryRepository_FindByID(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta("SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE id = ?")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.WebhookDelivery, error)
+	}{
+		{
+			name: "Should return the delivery",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(11).
+					WillReturnRows(sqlmock.NewRows(webhookDeliveryColumns).
+						AddRow(11, 3, 7, "otp.validated", []byte(`{}`), entity.OutboxStatusFailed, 10, now, 503, "webhook responded with status 503", now, nil))
+			},
+			assertFn: func(delivery *entity.WebhookDelivery, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, &entity.WebhookDelivery{
+					ID:             11,
+					SubscriptionID: 3,
+					EventID:        7,
+					EventType:      entity.EventTypeOTPValidated,
+					Payload:        []byte(`{}`),
+					Status:         entity.OutboxStatusFailed,
+					Attempts:       10,
+					NextAttemptAt:  now,
+					ResponseCode:   503,
+					LastError:      "webhook responded with status 503",
+					CreatedAt:      now,
+				}, delivery)
+			},
+		},
+		{
+			name: "Should return not found error when delivery does not exist",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(11).
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(delivery *entity.WebhookDelivery, err error) {
+				assert.Nil(t, delivery)
+				assert.Equal(t, entity.ErrWebhookDeliveryNotFound, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewWebhookDeliveryRepository(repositoryDependency.mockedDB)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			tt.assertFn(repo.FindByID(context.TODO(), 11))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookDeliveryRepository_ListDue(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewWebhookDeliveryRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?")).
+		WithArgs(entity.OutboxStatusPending, now, 100).
+		WillReturnRows(sqlmock.NewRows(webhookDeliveryColumns).
+			AddRow(11, 3, 7, "otp.validated", []byte(`{}`), entity.OutboxStatusPending, 0, now, nil, nil, now, nil))
+
+	deliveries, err := repo.ListDue(context.TODO(), now, 100)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Zero(t, deliveries[0].ResponseCode)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryRepository_ListByEventID(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewWebhookDeliveryRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE event_id = ? ORDER BY id")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(webhookDeliveryColumns).
+			AddRow(11, 3, 7, "otp.created", []byte(`{}`), entity.OutboxStatusPending, 2, now, 503, "unexpected status code 503", now, nil))
+
+	deliveries, err := repo.ListByEventID(context.TODO(), 7)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, 503, deliveries[0].ResponseCode)
+	assert.Equal(t, "unexpected status code 503", deliveries[0].LastError)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryRepository_Update(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewWebhookDeliveryRepository(repositoryDependency.mockedDB)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, response_code = ?, last_error = ?, delivered_at = ? WHERE id = ?")).
+		WithArgs(entity.OutboxStatusDelivered, 0, now, 204, nil, now, 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Update(context.TODO(), &entity.WebhookDelivery{
+		ID:            11,
+		Status:        entity.OutboxStatusDelivered,
+		NextAttemptAt: now,
+		ResponseCode:  204,
+		DeliveredAt:   &now,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
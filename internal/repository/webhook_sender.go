@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// webhookSender implements the WebhookSender interface by posting signed events to the subscribed endpoints
+type webhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender creates a sender that posts webhook deliveries with the given client
+func NewWebhookSender(client *http.Client) *webhookSender {
+	return &webhookSender{
+		client: client,
+	}
+}
+
+// Send posts the event of a delivery to the URL of the subscription, signed with its secret.
+// It returns the HTTP status code of the response, or zero if no response was received.
+// Any response other than 2xx is a failed delivery.
+func (w *webhookSender) Send(ctx context.Context, subscription *entity.WebhookSubscription, delivery *entity.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(eventEnvelope{
+		ID:        delivery.EventID,
+		Type:      string(delivery.EventType),
+		CreatedAt: delivery.CreatedAt.UTC(),
+		Payload:   delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature of a webhook delivery: the hex encoded HMAC-SHA256,
+// keyed with the subscription secret, of the timestamp and the body joined by a dot.
+// Receivers recompute it to authenticate the delivery, and reject stale timestamps to prevent replays.
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
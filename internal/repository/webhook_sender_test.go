@@ -0,0 +1,76 @@
+package repository_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSender_Send(t *testing.T) {
+	delivery := &entity.WebhookDelivery{
+		ID:        11,
+		EventID:   7,
+		EventType: entity.EventTypeOTPValidated,
+		Payload:   []byte(`{"type":"otp.validated","user_id":"user123"}`),
+		CreatedAt: time.Date(2025, 11, 24, 9, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		assertFn   func(int, error)
+	}{
+		{
+			name:       "Should post the signed event to the subscription URL",
+			statusCode: http.StatusOK,
+			assertFn: func(responseCode int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, responseCode)
+			},
+		},
+		{
+			name:       "Should return the response code and an error when the endpoint does not respond with 2xx",
+			statusCode: http.StatusGone,
+			assertFn: func(responseCode int, err error) {
+				assert.EqualError(t, err, "webhook responded with status 410")
+				assert.Equal(t, http.StatusGone, responseCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, dummyEventEnvelope, string(body))
+				assert.Equal(t, "11", r.Header.Get("X-Webhook-Id"))
+				assert.Equal(t, "otp.validated", r.Header.Get("X-Webhook-Event"))
+
+				timestamp := r.Header.Get("X-Webhook-Timestamp")
+				assert.NotEmpty(t, timestamp)
+				assert.Equal(t, repository.SignWebhookPayload("secret", timestamp, body), r.Header.Get("X-Webhook-Signature"))
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			subscription := &entity.WebhookSubscription{URL: server.URL, Secret: "secret"}
+			sender := repository.NewWebhookSender(server.Client())
+			tt.assertFn(sender.Send(context.TODO(), subscription, delivery))
+		})
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		repository.SignWebhookPayload("secret", "1700000000", []byte("{}")),
+	)
+}
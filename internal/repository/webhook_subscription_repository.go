@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// webhookSubscriptionRepository implements the WebhookSubscriptionRepository interface
+type webhookSubscriptionRepository struct {
+	db        *sqlx.DB
+	encryptor ColumnEncryptor
+}
+
+// NewWebhookSubscriptionRepository creates a new instance of webhookSubscriptionRepository.
+// When encryptor is not nil, the signing secrets of the subscriptions are stored encrypted.
+func NewWebhookSubscriptionRepository(db *sqlx.DB, encryptor ColumnEncryptor) *webhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{
+		db:        db,
+		encryptor: encryptor,
+	}
+}
+
+// Create inserts a new webhook subscription into the database and sets the ID of the given subscription
+func (w *webhookSubscriptionRepository) Create(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	const query = `
+		INSERT INTO webhook_subscriptions (client_id, url, event_types, secret, secret_ciphertext, key_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	row := webhookSubscriptionRow{
+		ClientID: subscription.ClientID,
+		URL:      subscription.URL,
+		Secret:   subscription.Secret,
+	}
+	if err := row.encryptSecret(ctx, w.encryptor); err != nil {
+		return err
+	}
+
+	id, err := insertReturningID(
+		ctx,
+		w.db,
+		query,
+		row.ClientID,
+		row.URL,
+		eventTypes,
+		row.Secret,
+		nullableBytes(row.SecretCiphertext),
+		row.KeyID,
+	)
+	if err != nil {
+		return err
+	}
+	subscription.ID = id
+
+	return nil
+}
+
+// FindByID retrieves a webhook subscription by its ID.
+// Returns entity.ErrWebhookSubscriptionNotFound if no subscription exists with the ID.
+func (w *webhookSubscriptionRepository) FindByID(ctx context.Context, id uint64) (*entity.WebhookSubscription, error) {
+	const query = `
+		SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at
+		FROM webhook_subscriptions
+		WHERE id = ?
+	`
+
+	var row webhookSubscriptionRow
+	if err := getExecutor(ctx, w.db).GetContext(ctx, &row, query, id); err != nil {
+		// Check if the error is sql.ErrNoRows to return entity.ErrWebhookSubscriptionNotFound
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrWebhookSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	return row.ToEntity(ctx, w.encryptor)
+}
+
+// ListByClientID retrieves the webhook subscriptions of a client, or of every client if clientID is empty
+func (w *webhookSubscriptionRepository) ListByClientID(ctx context.Context, clientID string) ([]*entity.WebhookSubscription, error) {
+	const query = `
+		SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at
+		FROM webhook_subscriptions
+		WHERE ? = '' OR client_id = ?
+		ORDER BY id
+	`
+
+	var rows []webhookSubscriptionRow
+	if err := getExecutor(ctx, w.db).SelectContext(ctx, &rows, query, clientID, clientID); err != nil {
+		return nil, err
+	}
+
+	return toWebhookSubscriptions(ctx, rows, w.encryptor)
+}
+
+// ListByClientIDAndEventType retrieves the webhook subscriptions of a client that subscribe to the given event type
+func (w *webhookSubscriptionRepository) ListByClientIDAndEventType(ctx context.Context, clientID string, eventType entity.EventType) ([]*entity.WebhookSubscription, error) {
+	const (
+		mysqlQuery = `
+			SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at
+			FROM webhook_subscriptions
+			WHERE client_id = ? AND JSON_CONTAINS(event_types, JSON_QUOTE(?))
+			ORDER BY id
+		`
+		postgresQuery = `
+			SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at
+			FROM webhook_subscriptions
+			WHERE client_id = ? AND event_types @> to_jsonb(?::text)
+			ORDER BY id
+		`
+		sqliteQuery = `
+			SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at
+			FROM webhook_subscriptions
+			WHERE client_id = ? AND EXISTS (SELECT 1 FROM json_each(CAST(event_types AS TEXT)) WHERE value = ?)
+			ORDER BY id
+		`
+	)
+	var query string
+	switch dialectOf(w.db) {
+	case dialectPostgres:
+		query = postgresQuery
+	case dialectSQLite:
+		query = sqliteQuery
+	default:
+		query = mysqlQuery
+	}
+
+	var rows []webhookSubscriptionRow
+	if err := getExecutor(ctx, w.db).SelectContext(ctx, &rows, query, clientID, eventType); err != nil {
+		return nil, err
+	}
+
+	return toWebhookSubscriptions(ctx, rows, w.encryptor)
+}
+
+// DeleteByID removes a webhook subscription together with its deliveries.
+// Returns entity.ErrWebhookSubscriptionNotFound if no subscription exists with the ID.
+func (w *webhookSubscriptionRepository) DeleteByID(ctx context.Context, id uint64) error {
+	const query = `
+		DELETE FROM webhook_subscriptions
+		WHERE id = ?
+	`
+	result, err := getExecutor(ctx, w.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// ReencryptStaleSecrets encrypts up to limit signing secrets stored in plaintext or encrypted with a previous data key
+// with the current data key, and returns the number of secrets it encrypted again.
+func (w *webhookSubscriptionRepository) ReencryptStaleSecrets(ctx context.Context, limit int) (int64, error) {
+	const (
+		selectQuery = `
+			SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at
+			FROM webhook_subscriptions
+			WHERE key_id IS NULL OR key_id <> ?
+			ORDER BY id
+			LIMIT ?
+		`
+		updateQuery = `
+			UPDATE webhook_subscriptions
+			SET secret = ?, secret_ciphertext = ?, key_id = ?
+			WHERE id = ?
+		`
+	)
+	if w.encryptor == nil {
+		return 0, errors.New("encryption is not configured")
+	}
+
+	keyID, err := w.encryptor.CurrentKeyID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var reencrypted int64
+	err = withinTransaction(ctx, w.db, func(ctx context.Context) error {
+		executor := getExecutor(ctx, w.db)
+
+		var rows []webhookSubscriptionRow
+		if err := executor.SelectContext(ctx, &rows, selectQuery, keyID, limit); err != nil {
+			return err
+		}
+
+		for i := range rows {
+			if err := rows[i].encryptSecret(ctx, w.encryptor); err != nil {
+				return err
+			}
+			if _, err := executor.ExecContext(ctx, updateQuery, rows[i].Secret, rows[i].SecretCiphertext, rows[i].KeyID, rows[i].ID); err != nil {
+				return err
+			}
+		}
+
+		reencrypted = int64(len(rows))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return reencrypted, nil
+}
+
+// toWebhookSubscriptions converts webhook subscription rows to entities
+func toWebhookSubscriptions(ctx context.Context, rows []webhookSubscriptionRow, encryptor ColumnEncryptor) ([]*entity.WebhookSubscription, error) {
+	subscriptions := make([]*entity.WebhookSubscription, 0, len(rows))
+	for i := range rows {
+		subscription, err := rows[i].ToEntity(ctx, encryptor)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
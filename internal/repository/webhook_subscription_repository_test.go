@@ -0,0 +1,179 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var webhookSubscriptionColumns = []string{"id", "client_id", "url", "event_types", "secret", "secret_ciphertext", "key_id", "created_at"}
+
+func TestWebhookSubscriptionRepository_Create(t *testing.T) {
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewWebhookSubscriptionRepository(repositoryDependency.mockedDB, nil)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_subscriptions (client_id, url, event_types, secret, secret_ciphertext, key_id) VALUES (?, ?, ?, ?, ?, ?)")).
+		WithArgs("client-1", "https://client.example/hooks", []byte(`["otp.validated","user.locked"]`), "secret", nil, sql.NullString{}).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	subscription := &entity.WebhookSubscription{
+		ClientID:   "client-1",
+		URL:        "https://client.example/hooks",
+		EventTypes: []entity.EventType{entity.EventTypeOTPValidated, entity.EventTypeUserLocked},
+		Secret:     "secret",
+	}
+	assert.NoError(t, repo.Create(context.TODO(), subscription))
+	assert.Equal(t, uint64(3), subscription.ID)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestWebhookSubscriptionRepository_FindByID(t *testing.T) {
+	now := time.Now()
+	expectedQuery := regexp.QuoteMeta("SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at FROM webhook_subscriptions WHERE id = ?")
+
+	tests := []struct {
+		name           string
+		mockDependency func(*repositoryDependency)
+		assertFn       func(*entity.WebhookSubscription, error)
+	}{
+		{
+			name: "Should return the subscription",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(webhookSubscriptionColumns).
+						AddRow(3, "client-1", "https://client.example/hooks", []byte(`["otp.validated"]`), "secret", nil, nil, now))
+			},
+			assertFn: func(subscription *entity.WebhookSubscription, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, &entity.WebhookSubscription{
+					ID:         3,
+					ClientID:   "client-1",
+					URL:        "https://client.example/hooks",
+					EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+					Secret:     "secret",
+					CreatedAt:  now,
+				}, subscription)
+			},
+		},
+		{
+			name: "Should return not found error when subscription does not exist",
+			mockDependency: func(dependency *repositoryDependency) {
+				dependency.mockedSQL.
+					ExpectQuery(expectedQuery).
+					WithArgs(3).
+					WillReturnError(sql.ErrNoRows)
+			},
+			assertFn: func(subscription *entity.WebhookSubscription, err error) {
+				assert.Nil(t, subscription)
+				assert.Equal(t, entity.ErrWebhookSubscriptionNotFound, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewWebhookSubscriptionRepository(repositoryDependency.mockedDB, nil)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			tt.mockDependency(repositoryDependency)
+			tt.assertFn(repo.FindByID(context.TODO(), 3))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookSubscriptionRepository_ListByClientIDAndEventType(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependency()
+	repo := repository.NewWebhookSubscriptionRepository(repositoryDependency.mockedDB, nil)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at FROM webhook_subscriptions WHERE client_id = ? AND JSON_CONTAINS(event_types, JSON_QUOTE(?)) ORDER BY id")).
+		WithArgs("client-1", entity.EventTypeOTPValidated).
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionColumns).
+			AddRow(3, "client-1", "https://client.example/hooks", []byte(`["otp.validated"]`), "secret", nil, nil, now).
+			AddRow(4, "client-1", "https://other.example/hooks", []byte(`["otp.created","otp.validated"]`), "other", nil, nil, now))
+
+	subscriptions, err := repo.ListByClientIDAndEventType(context.TODO(), "client-1", entity.EventTypeOTPValidated)
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+	assert.Equal(t, []entity.EventType{entity.EventTypeOTPCreated, entity.EventTypeOTPValidated}, subscriptions[1].EventTypes)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestWebhookSubscriptionRepository_ListByClientIDAndEventType_Postgres(t *testing.T) {
+	now := time.Now()
+
+	repositoryDependency := newRepoDependencyFor("postgres")
+	repo := repository.NewWebhookSubscriptionRepository(repositoryDependency.mockedDB, nil)
+	defer repositoryDependency.mockedDB.Close()
+
+	repositoryDependency.mockedSQL.
+		ExpectQuery(regexp.QuoteMeta("SELECT id, client_id, url, event_types, secret, secret_ciphertext, key_id, created_at FROM webhook_subscriptions WHERE client_id = ? AND event_types @> to_jsonb(?::text) ORDER BY id")).
+		WithArgs("client-1", entity.EventTypeOTPValidated).
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionColumns).
+			AddRow(3, "client-1", "https://client.example/hooks", []byte(`["otp.validated"]`), "secret", nil, nil, now))
+
+	subscriptions, err := repo.ListByClientIDAndEventType(context.TODO(), "client-1", entity.EventTypeOTPValidated)
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 1)
+	assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+}
+
+func TestWebhookSubscriptionRepository_DeleteByID(t *testing.T) {
+	expectedQuery := regexp.QuoteMeta("DELETE FROM webhook_subscriptions WHERE id = ?")
+
+	tests := []struct {
+		name     string
+		affected int64
+		assertFn func(error)
+	}{
+		{
+			name:     "Should delete the subscription",
+			affected: 1,
+			assertFn: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:     "Should return not found error when subscription does not exist",
+			affected: 0,
+			assertFn: func(err error) {
+				assert.Equal(t, entity.ErrWebhookSubscriptionNotFound, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositoryDependency := newRepoDependency()
+			repo := repository.NewWebhookSubscriptionRepository(repositoryDependency.mockedDB, nil)
+
+			defer repositoryDependency.mockedDB.Close()
+
+			repositoryDependency.mockedSQL.
+				ExpectExec(expectedQuery).
+				WithArgs(3).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			tt.assertFn(repo.DeleteByID(context.TODO(), 3))
+
+			assert.NoError(t, repositoryDependency.mockedSQL.ExpectationsWereMet())
+		})
+	}
+}
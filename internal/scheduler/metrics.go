@@ -0,0 +1,23 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered on the default registry, which is served by the /metrics route of the REST API.
+var (
+	schedulerLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "otp_service",
+		Subsystem: "scheduler",
+		Name:      "leader",
+		Help:      "Whether this instance holds the lease of a job (1) or not (0).",
+	}, []string{"job"})
+
+	schedulerJobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "scheduler",
+		Name:      "job_runs_total",
+		Help:      "Number of job runs on this instance, partitioned by job and result.",
+	}, []string{"job", "result"})
+)
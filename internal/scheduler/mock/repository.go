@@ -0,0 +1,132 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	entity "github.com/imansohibul/otp-service/entity"
+)
+
+// MockJobLeaseRepository is a mock of JobLeaseRepository interface.
+type MockJobLeaseRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobLeaseRepositoryMockRecorder
+}
+
+// MockJobLeaseRepositoryMockRecorder is the mock recorder for MockJobLeaseRepository.
+type MockJobLeaseRepositoryMockRecorder struct {
+	mock *MockJobLeaseRepository
+}
+
+// NewMockJobLeaseRepository creates a new mock instance.
+func NewMockJobLeaseRepository(ctrl *gomock.Controller) *MockJobLeaseRepository {
+	mock := &MockJobLeaseRepository{ctrl: ctrl}
+	mock.recorder = &MockJobLeaseRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobLeaseRepository) EXPECT() *MockJobLeaseRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method.
+func (m *MockJobLeaseRepository) Release(ctx context.Context, jobName, holderID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, jobName, holderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockJobLeaseRepositoryMockRecorder) Release(ctx, jobName, holderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockJobLeaseRepository)(nil).Release), ctx, jobName, holderID)
+}
+
+// TryAcquire mocks base method.
+func (m *MockJobLeaseRepository) TryAcquire(ctx context.Context, jobName, holderID string, now, expiresAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryAcquire", ctx, jobName, holderID, now, expiresAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryAcquire indicates an expected call of TryAcquire.
+func (mr *MockJobLeaseRepositoryMockRecorder) TryAcquire(ctx, jobName, holderID, now, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryAcquire", reflect.TypeOf((*MockJobLeaseRepository)(nil).TryAcquire), ctx, jobName, holderID, now, expiresAt)
+}
+
+// MockJobRunRepository is a mock of JobRunRepository interface.
+type MockJobRunRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRunRepositoryMockRecorder
+}
+
+// MockJobRunRepositoryMockRecorder is the mock recorder for MockJobRunRepository.
+type MockJobRunRepositoryMockRecorder struct {
+	mock *MockJobRunRepository
+}
+
+// NewMockJobRunRepository creates a new mock instance.
+func NewMockJobRunRepository(ctrl *gomock.Controller) *MockJobRunRepository {
+	mock := &MockJobRunRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRunRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRunRepository) EXPECT() *MockJobRunRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockJobRunRepository) Create(ctx context.Context, run *entity.JobRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockJobRunRepositoryMockRecorder) Create(ctx, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockJobRunRepository)(nil).Create), ctx, run)
+}
+
+// DeleteStartedBefore mocks base method.
+func (m *MockJobRunRepository) DeleteStartedBefore(ctx context.Context, jobName string, before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStartedBefore", ctx, jobName, before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStartedBefore indicates an expected call of DeleteStartedBefore.
+func (mr *MockJobRunRepositoryMockRecorder) DeleteStartedBefore(ctx, jobName, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStartedBefore", reflect.TypeOf((*MockJobRunRepository)(nil).DeleteStartedBefore), ctx, jobName, before, limit)
+}
+
+// Update mocks base method.
+func (m *MockJobRunRepository) Update(ctx context.Context, run *entity.JobRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockJobRunRepositoryMockRecorder) Update(ctx, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockJobRunRepository)(nil).Update), ctx, run)
+}
@@ -0,0 +1,38 @@
+package scheduler
+
+// This file contains the interfaces for the repository layer used by the scheduler.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+
+import (
+	"context"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+//go:generate mockgen -destination=mock/repository.go -package=mock -source=repository.go
+
+// JobLeaseRepository defines the interface for the leases that elect a single instance to run each job
+type JobLeaseRepository interface {
+	// TryAcquire acquires or renews the lease of a job for the given holder until expiresAt,
+	// and reports whether the holder owns the lease afterwards. A lease held by another
+	// holder is only taken over once it has lapsed at the given time.
+	TryAcquire(ctx context.Context, jobName string, holderID string, now time.Time, expiresAt time.Time) (bool, error)
+
+	// Release gives up the lease of a job if it is held by the given holder.
+	Release(ctx context.Context, jobName string, holderID string) error
+}
+
+// JobRunRepository defines the interface for the history of job runs
+type JobRunRepository interface {
+	// Create inserts a new job run and sets its ID.
+	Create(ctx context.Context, run *entity.JobRun) error
+
+	// Update stores the outcome of a job run.
+	Update(ctx context.Context, run *entity.JobRun) error
+
+	// DeleteStartedBefore deletes up to limit runs of a job started before the given time,
+	// and returns the number of runs it deleted.
+	DeleteStartedBefore(ctx context.Context, jobName string, before time.Time, limit int) (int64, error)
+}
@@ -0,0 +1,280 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// releaseTimeout bounds how long releasing the leases may take during shutdown.
+	releaseTimeout = 5 * time.Second
+	// runPurgeBatchSize is the maximum number of runs of a job deleted after each of its runs,
+	// which drains a backlog of runs past the retention period over a few runs.
+	runPurgeBatchSize = 100
+)
+
+// Job is a periodic background job that runs on a single instance at a time.
+type Job struct {
+	// Name identifies the job across instances; it is the name of the job lease.
+	Name string
+	// Interval is the time between the starts of two runs of the job.
+	// Due runs are started on lease renewal, so it is rounded up to a multiple of the renew interval.
+	Interval time.Duration
+	// Run runs the job once. The context is cancelled when the lease is lost or on shutdown.
+	Run func(ctx context.Context) error
+}
+
+// Config configures the leader election of the scheduler.
+type Config struct {
+	// HolderID identifies this instance as lease holder; it must be unique across instances.
+	HolderID string
+	// LeaseDuration is how long a lease stays valid without renewal,
+	// which bounds how long a job is left without runner when its holder dies.
+	LeaseDuration time.Duration
+	// RenewInterval is how often leases are acquired or renewed; it must be shorter than LeaseDuration.
+	RenewInterval time.Duration
+	// RunRetention is how long the history of a job run is kept; zero keeps it forever.
+	RunRetention time.Duration
+}
+
+// Scheduler runs registered jobs periodically, electing a single runner per job
+// across all instances through leases that the runner keeps renewing.
+type Scheduler struct {
+	config     Config
+	leaseRepo  JobLeaseRepository
+	jobRunRepo JobRunRepository
+
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler without jobs.
+func NewScheduler(cfg Config, leaseRepo JobLeaseRepository, jobRunRepo JobRunRepository) *Scheduler {
+	return &Scheduler{
+		config:     cfg,
+		leaseRepo:  leaseRepo,
+		jobRunRepo: jobRunRepo,
+	}
+}
+
+// Register adds a job to the scheduler. Jobs must be registered before Start is called.
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job in the background until Shutdown is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	log.Info().Str("holder_id", s.config.HolderID).Int("jobs", len(s.jobs)).Msg("Starting job scheduler")
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			newJobRunner(s, job).loop(ctx)
+		}(job)
+	}
+}
+
+// Shutdown stops every job, cancelling runs in progress, releases the held leases
+// and waits until everything has stopped or the given context is done.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jobRunner keeps the lease of a single job and runs the job while it holds the lease.
+type jobRunner struct {
+	scheduler *Scheduler
+	job       Job
+	leader    bool
+	lastRunAt time.Time
+}
+
+func newJobRunner(s *Scheduler, job Job) *jobRunner {
+	return &jobRunner{
+		scheduler: s,
+		job:       job,
+	}
+}
+
+// loop renews the lease every renew interval and runs the job whenever
+// this instance is the leader and the job is due.
+func (r *jobRunner) loop(ctx context.Context) {
+	ticker := time.NewTicker(r.scheduler.config.RenewInterval)
+	defer ticker.Stop()
+	defer r.release()
+
+	for {
+		if r.renewLease(ctx) && time.Since(r.lastRunAt) >= r.job.Interval {
+			r.lastRunAt = time.Now()
+			r.run(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renewLease acquires or renews the lease of the job and reports whether this instance holds it.
+func (r *jobRunner) renewLease(ctx context.Context) bool {
+	now := time.Now()
+	leader, err := r.scheduler.leaseRepo.TryAcquire(
+		ctx,
+		r.job.Name,
+		r.scheduler.config.HolderID,
+		now,
+		now.Add(r.scheduler.config.LeaseDuration),
+	)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Str("job", r.job.Name).Msg("failed to renew job lease")
+		}
+		// Without a confirmed renewal the lease may lapse, so act as a follower
+		leader = false
+	}
+
+	if leader != r.leader {
+		log.Info().Str("job", r.job.Name).Bool("leader", leader).Msg("job leadership changed")
+		r.leader = leader
+		if leader {
+			schedulerLeader.WithLabelValues(r.job.Name).Set(1)
+		} else {
+			schedulerLeader.WithLabelValues(r.job.Name).Set(0)
+		}
+	}
+
+	return leader
+}
+
+// run runs the job once, renewing the lease while it runs and cancelling the run if the lease is lost.
+func (r *jobRunner) run(ctx context.Context) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(r.scheduler.config.RenewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if !r.renewLease(runCtx) {
+					log.Warn().Str("job", r.job.Name).Msg("job lease lost, cancelling run")
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	jobRun := r.startRun(ctx)
+	err := r.job.Run(runCtx)
+
+	close(stop)
+	<-stopped
+
+	r.finishRun(jobRun, err)
+	r.purgeRuns()
+}
+
+// startRun records the start of a job run in the history.
+func (r *jobRunner) startRun(ctx context.Context) *entity.JobRun {
+	jobRun := &entity.JobRun{
+		JobName:   r.job.Name,
+		HolderID:  r.scheduler.config.HolderID,
+		Status:    entity.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := r.scheduler.jobRunRepo.Create(ctx, jobRun); err != nil {
+		log.Error().Err(err).Str("job", r.job.Name).Msg("failed to record job run start")
+	}
+
+	return jobRun
+}
+
+// finishRun records the outcome of a job run in the history and the metrics.
+// It does not use the job context, so that runs cancelled by shutdown are still recorded.
+func (r *jobRunner) finishRun(jobRun *entity.JobRun, runErr error) {
+	finishedAt := time.Now()
+	jobRun.FinishedAt = &finishedAt
+	jobRun.Status = entity.JobRunStatusSucceeded
+	if runErr != nil {
+		jobRun.Status = entity.JobRunStatusFailed
+		jobRun.Error = runErr.Error()
+		log.Error().Err(runErr).Str("job", r.job.Name).Msg("job run failed")
+	}
+	schedulerJobRuns.WithLabelValues(r.job.Name, jobRun.Status.String()).Inc()
+
+	if jobRun.ID == 0 {
+		// The start of the run was not recorded, so there is nothing to update
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	if err := r.scheduler.jobRunRepo.Update(ctx, jobRun); err != nil {
+		log.Error().Err(err).Str("job", r.job.Name).Msg("failed to record job run outcome")
+	}
+}
+
+// purgeRuns deletes a batch of the runs of the job past the retention period from the history.
+// Like finishRun, it does not use the job context.
+func (r *jobRunner) purgeRuns() {
+	if r.scheduler.config.RunRetention <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	before := time.Now().Add(-r.scheduler.config.RunRetention)
+	if _, err := r.scheduler.jobRunRepo.DeleteStartedBefore(ctx, r.job.Name, before, runPurgeBatchSize); err != nil {
+		log.Error().Err(err).Str("job", r.job.Name).Msg("failed to purge job run history")
+	}
+}
+
+// release gives up the lease of the job if this instance holds it,
+// so another instance can take over without waiting for the lease to lapse.
+func (r *jobRunner) release() {
+	if !r.leader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	if err := r.scheduler.leaseRepo.Release(ctx, r.job.Name, r.scheduler.config.HolderID); err != nil {
+		log.Error().Err(err).Str("job", r.job.Name).Msg("failed to release job lease")
+	}
+	schedulerLeader.WithLabelValues(r.job.Name).Set(0)
+}
@@ -0,0 +1,255 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/scheduler"
+	"github.com/imansohibul/otp-service/internal/scheduler/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var schedulerConfig = scheduler.Config{
+	HolderID:      "instance-1",
+	LeaseDuration: 300 * time.Millisecond,
+	RenewInterval: 10 * time.Millisecond,
+}
+
+type schedulerDependency struct {
+	leaseRepo  *mock.MockJobLeaseRepository
+	jobRunRepo *mock.MockJobRunRepository
+}
+
+func TestScheduler(t *testing.T) {
+	tests := []struct {
+		name           string
+		jobErr         error
+		mockDependency func(dep *schedulerDependency)
+		expectRun      bool
+	}{
+		{
+			name: "should run the job and record a succeeded run while holding the lease",
+			mockDependency: func(dep *schedulerDependency) {
+				dep.leaseRepo.EXPECT().
+					TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+					Return(true, nil).
+					AnyTimes()
+				dep.jobRunRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+						assert.Equal(t, "sweeper", run.JobName)
+						assert.Equal(t, "instance-1", run.HolderID)
+						assert.Equal(t, entity.JobRunStatusRunning, run.Status)
+						run.ID = 1
+						return nil
+					})
+				dep.jobRunRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+						assert.Equal(t, entity.JobRunStatusSucceeded, run.Status)
+						assert.NotNil(t, run.FinishedAt)
+						return nil
+					})
+				dep.leaseRepo.EXPECT().
+					Release(gomock.Any(), "sweeper", "instance-1").
+					Return(nil)
+			},
+			expectRun: true,
+		},
+		{
+			name:   "should record a failed run with its error",
+			jobErr: errors.New("db error"),
+			mockDependency: func(dep *schedulerDependency) {
+				dep.leaseRepo.EXPECT().
+					TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+					Return(true, nil).
+					AnyTimes()
+				dep.jobRunRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+						run.ID = 1
+						return nil
+					})
+				dep.jobRunRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+						assert.Equal(t, entity.JobRunStatusFailed, run.Status)
+						assert.Equal(t, "db error", run.Error)
+						return nil
+					})
+				dep.leaseRepo.EXPECT().
+					Release(gomock.Any(), "sweeper", "instance-1").
+					Return(nil)
+			},
+			expectRun: true,
+		},
+		{
+			name: "should not run the job while another instance holds the lease",
+			mockDependency: func(dep *schedulerDependency) {
+				dep.leaseRepo.EXPECT().
+					TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+					Return(false, nil).
+					AnyTimes()
+			},
+		},
+		{
+			name: "should not run the job if the lease cannot be renewed",
+			mockDependency: func(dep *schedulerDependency) {
+				dep.leaseRepo.EXPECT().
+					TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+					Return(false, errors.New("db error")).
+					AnyTimes()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := &schedulerDependency{
+				leaseRepo:  mock.NewMockJobLeaseRepository(ctrl),
+				jobRunRepo: mock.NewMockJobRunRepository(ctrl),
+			}
+			tt.mockDependency(dep)
+
+			ran := make(chan struct{}, 1)
+			s := scheduler.NewScheduler(schedulerConfig, dep.leaseRepo, dep.jobRunRepo)
+			s.Register(scheduler.Job{
+				Name:     "sweeper",
+				Interval: time.Hour,
+				Run: func(ctx context.Context) error {
+					ran <- struct{}{}
+					return tt.jobErr
+				},
+			})
+			s.Start()
+
+			select {
+			case <-ran:
+				assert.True(t, tt.expectRun, "job should not have run")
+			case <-time.After(100 * time.Millisecond):
+				assert.False(t, tt.expectRun, "job should have run")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			assert.NoError(t, s.Shutdown(ctx))
+		})
+	}
+}
+
+func TestScheduler_CancelsRunWhenLeaseIsLost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	leaseRepo := mock.NewMockJobLeaseRepository(ctrl)
+	jobRunRepo := mock.NewMockJobRunRepository(ctrl)
+
+	gomock.InOrder(
+		leaseRepo.EXPECT().
+			TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+			Return(true, nil),
+		leaseRepo.EXPECT().
+			TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+			Return(false, nil).
+			AnyTimes(),
+	)
+	jobRunRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+			run.ID = 1
+			return nil
+		})
+	jobRunRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+			assert.Equal(t, entity.JobRunStatusFailed, run.Status)
+			assert.Equal(t, context.Canceled.Error(), run.Error)
+			return nil
+		})
+
+	cancelled := make(chan struct{})
+	s := scheduler.NewScheduler(schedulerConfig, leaseRepo, jobRunRepo)
+	s.Register(scheduler.Job{
+		Name:     "sweeper",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+	})
+	s.Start()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("run was not cancelled after losing the lease")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+}
+
+func TestScheduler_PurgesRunsPastRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	leaseRepo := mock.NewMockJobLeaseRepository(ctrl)
+	jobRunRepo := mock.NewMockJobRunRepository(ctrl)
+
+	leaseRepo.EXPECT().
+		TryAcquire(gomock.Any(), "sweeper", "instance-1", gomock.Any(), gomock.Any()).
+		Return(true, nil).
+		AnyTimes()
+	leaseRepo.EXPECT().
+		Release(gomock.Any(), "sweeper", "instance-1").
+		Return(nil)
+	jobRunRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, run *entity.JobRun) error {
+			run.ID = 1
+			return nil
+		})
+	jobRunRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	purged := make(chan struct{})
+	jobRunRepo.EXPECT().
+		DeleteStartedBefore(gomock.Any(), "sweeper", gomock.Any(), 100).
+		DoAndReturn(func(ctx context.Context, jobName string, before time.Time, limit int) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Second)
+			close(purged)
+			return 1, nil
+		})
+
+	cfg := schedulerConfig
+	cfg.RunRetention = 24 * time.Hour
+	s := scheduler.NewScheduler(cfg, leaseRepo, jobRunRepo)
+	s.Register(scheduler.Job{
+		Name:     "sweeper",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			return nil
+		},
+	})
+	s.Start()
+
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("job run history was not purged after the run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/rs/zerolog/log"
+)
+
+// auditVerifyBatchSize is the number of audit events read at once while verifying the chain
+const auditVerifyBatchSize = 1000
+
+// auditReasonInternalError is the reason of operations that failed with an unexpected error
+const auditReasonInternalError = "internal_error"
+
+type auditLog struct {
+	txManager TransactionManager
+	auditRepo AuditEventRepository
+}
+
+func NewAuditLog(txManager TransactionManager, auditRepo AuditEventRepository) *auditLog {
+	return &auditLog{
+		txManager: txManager,
+		auditRepo: auditRepo,
+	}
+}
+
+// Record appends an event to the audit log, attributed to the request metadata stored in ctx.
+// The event is recorded in its own transaction, even if ctx is cancelled once the audited
+// operation completed, and a failure to record it is logged rather than returned.
+// It must be called after the transaction of the audited operation ended, as appends
+// lock the chain head until their transaction ends.
+func (a *auditLog) Record(ctx context.Context, event entity.AuditEvent) {
+	metadata := entity.RequestMetadataFromContext(ctx)
+	event.Actor = metadata.Actor
+	event.ClientID = metadata.ClientID
+	event.IP = metadata.IP
+	// The database stores microseconds, which must survive the round trip for the hash to verify
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	err := a.txManager.WithTransaction(context.WithoutCancel(ctx), func(ctx context.Context) error {
+		return a.auditRepo.Append(ctx, &event)
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("action", string(event.Action)).
+			Str("user_id", event.UserID).
+			Str("outcome", event.Outcome.String()).
+			Msg("Failed to record audit event")
+	}
+}
+
+// ListEvents retrieves the audit events of a user within the time range of the filter, in chain order.
+// Returns entity.ErrInvalidRequest if no user is given or the range is empty.
+func (a *auditLog) ListEvents(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error) {
+	if filter.UserID == "" {
+		return nil, entity.ErrInvalidRequest
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, entity.ErrInvalidRequest
+	}
+
+	return a.auditRepo.List(ctx, filter)
+}
+
+// VerifyChain walks the audit log from its first event and checks that every event
+// is chained to the one before it and still matches its hash, and that the last event
+// is the one the chain head points at, which detects events removed from the end.
+func (a *auditLog) VerifyChain(ctx context.Context) (*entity.AuditChainVerification, error) {
+	// The chain head and the events are read from the primary, as read replicas may lag behind it and each other
+	ctx = WithReadYourWrites(ctx)
+
+	head, err := a.auditRepo.GetChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+
+	var (
+		result   = &entity.AuditChainVerification{}
+		lastID   uint64
+		prevHash string
+	)
+	for {
+		events, err := a.auditRepo.ListAfter(ctx, lastID, auditVerifyBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit events after %d: %w", lastID, err)
+		}
+
+		for _, event := range events {
+			switch {
+			case event.PrevHash != prevHash:
+				result.BrokenAtID, result.Reason = event.ID, "previous hash does not match the preceding event"
+				return result, nil
+			case event.ComputeHash() != event.Hash:
+				result.BrokenAtID, result.Reason = event.ID, "hash does not match the event"
+				return result, nil
+			}
+
+			result.Checked++
+			lastID, prevHash = event.ID, event.Hash
+			// Events appended after the chain head was read are verified by the next run
+			if lastID == head.LastID {
+				break
+			}
+		}
+
+		if lastID == head.LastID || len(events) < auditVerifyBatchSize {
+			break
+		}
+	}
+
+	if lastID != head.LastID || prevHash != head.LastHash {
+		result.Reason = "last event does not match the chain head"
+		return result, nil
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// newAuditEvent describes the outcome of an operation for the audit log,
+// with the error code of a domain error as the reason of a failure
+func newAuditEvent(action entity.AuditAction, userID string, otpID uint64, err error) entity.AuditEvent {
+	event := entity.AuditEvent{
+		UserID:  userID,
+		OTPID:   otpID,
+		Action:  action,
+		Outcome: entity.AuditOutcomeSuccess,
+	}
+	if err != nil {
+		event.Outcome = entity.AuditOutcomeFailure
+		event.Reason = auditReasonInternalError
+
+		var domainErr *entity.DomainError
+		if errors.As(err, &domainErr) {
+			event.Reason = domainErr.Code
+		}
+	}
+
+	return event
+}
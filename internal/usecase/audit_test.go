@@ -0,0 +1,201 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditChain returns a valid chain of audit events with IDs 1 to n
+func auditChain(n int) []*entity.AuditEvent {
+	var (
+		events   []*entity.AuditEvent
+		prevHash string
+	)
+	for i := 1; i <= n; i++ {
+		event := &entity.AuditEvent{
+			ID:        uint64(i),
+			Actor:     entity.AuditActorClient,
+			UserID:    "user-1",
+			Action:    entity.AuditActionOTPValidate,
+			Outcome:   entity.AuditOutcomeSuccess,
+			CreatedAt: time.Date(2025, 11, 26, 9, 0, i, 0, time.UTC),
+		}
+		event.Chain(prevHash)
+		prevHash = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestAuditLog_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	txManager := mock.NewMockTransactionManager(ctrl)
+	auditRepo := mock.NewMockAuditEventRepository(ctrl)
+	runInTransaction(txManager)
+
+	auditRepo.EXPECT().
+		Append(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, event *entity.AuditEvent) error {
+			assert.Equal(t, entity.AuditActorAdmin, event.Actor)
+			assert.Equal(t, "support-console", event.ClientID)
+			assert.Equal(t, "203.0.113.7", event.IP)
+			assert.Equal(t, entity.AuditActionLockoutClear, event.Action)
+			assert.Equal(t, time.UTC, event.CreatedAt.Location())
+			assert.Zero(t, event.CreatedAt.Nanosecond()%int(time.Microsecond))
+			return errors.New("db error") // logged, not returned
+		})
+
+	ctx := entity.ContextWithRequestMetadata(context.Background(), entity.RequestMetadata{
+		Actor:    entity.AuditActorAdmin,
+		ClientID: "support-console",
+		IP:       "203.0.113.7",
+	})
+	usecase.NewAuditLog(txManager, auditRepo).Record(ctx, entity.AuditEvent{
+		UserID:  "user-1",
+		Action:  entity.AuditActionLockoutClear,
+		Outcome: entity.AuditOutcomeSuccess,
+	})
+}
+
+func TestAuditLog_ListEvents(t *testing.T) {
+	from := time.Now()
+	to := from.Add(-time.Hour)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usc := usecase.NewAuditLog(mock.NewMockTransactionManager(ctrl), mock.NewMockAuditEventRepository(ctrl))
+
+	events, err := usc.ListEvents(context.Background(), entity.AuditEventFilter{UserID: "user-1", From: &from, To: &to})
+	assert.Nil(t, events)
+	assert.Equal(t, entity.ErrInvalidRequest, err)
+}
+
+func TestAuditLog_VerifyChain(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockDependency func(auditRepo *mock.MockAuditEventRepository)
+		assertFn       func(result *entity.AuditChainVerification, err error)
+	}{
+		{
+			name: "should accept an intact chain",
+			mockDependency: func(auditRepo *mock.MockAuditEventRepository) {
+				events := auditChain(3)
+				auditRepo.EXPECT().
+					GetChainHead(gomock.Any()).
+					Return(&entity.AuditChainHead{LastID: 3, LastHash: events[2].Hash}, nil)
+				auditRepo.EXPECT().
+					ListAfter(gomock.Any(), uint64(0), gomock.Any()).
+					Return(events, nil)
+			},
+			assertFn: func(result *entity.AuditChainVerification, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, &entity.AuditChainVerification{Checked: 3, Valid: true}, result)
+			},
+		},
+		{
+			name: "should accept an empty audit log",
+			mockDependency: func(auditRepo *mock.MockAuditEventRepository) {
+				auditRepo.EXPECT().
+					GetChainHead(gomock.Any()).
+					Return(&entity.AuditChainHead{}, nil)
+				auditRepo.EXPECT().
+					ListAfter(gomock.Any(), uint64(0), gomock.Any()).
+					Return(nil, nil)
+			},
+			assertFn: func(result *entity.AuditChainVerification, err error) {
+				assert.Nil(t, err)
+				assert.True(t, result.Valid)
+			},
+		},
+		{
+			name: "should detect a modified event",
+			mockDependency: func(auditRepo *mock.MockAuditEventRepository) {
+				events := auditChain(3)
+				events[1].Outcome = entity.AuditOutcomeFailure
+				auditRepo.EXPECT().
+					GetChainHead(gomock.Any()).
+					Return(&entity.AuditChainHead{LastID: 3, LastHash: events[2].Hash}, nil)
+				auditRepo.EXPECT().
+					ListAfter(gomock.Any(), uint64(0), gomock.Any()).
+					Return(events, nil)
+			},
+			assertFn: func(result *entity.AuditChainVerification, err error) {
+				assert.Nil(t, err)
+				assert.False(t, result.Valid)
+				assert.Equal(t, uint64(2), result.BrokenAtID)
+				assert.Equal(t, int64(1), result.Checked)
+			},
+		},
+		{
+			name: "should detect a deleted event",
+			mockDependency: func(auditRepo *mock.MockAuditEventRepository) {
+				events := auditChain(3)
+				auditRepo.EXPECT().
+					GetChainHead(gomock.Any()).
+					Return(&entity.AuditChainHead{LastID: 3, LastHash: events[2].Hash}, nil)
+				auditRepo.EXPECT().
+					ListAfter(gomock.Any(), uint64(0), gomock.Any()).
+					Return([]*entity.AuditEvent{events[0], events[2]}, nil)
+			},
+			assertFn: func(result *entity.AuditChainVerification, err error) {
+				assert.Nil(t, err)
+				assert.False(t, result.Valid)
+				assert.Equal(t, uint64(3), result.BrokenAtID)
+			},
+		},
+		{
+			name: "should detect events deleted from the end of the chain",
+			mockDependency: func(auditRepo *mock.MockAuditEventRepository) {
+				events := auditChain(3)
+				auditRepo.EXPECT().
+					GetChainHead(gomock.Any()).
+					Return(&entity.AuditChainHead{LastID: 3, LastHash: events[2].Hash}, nil)
+				auditRepo.EXPECT().
+					ListAfter(gomock.Any(), uint64(0), gomock.Any()).
+					Return(events[:2], nil)
+			},
+			assertFn: func(result *entity.AuditChainVerification, err error) {
+				assert.Nil(t, err)
+				assert.False(t, result.Valid)
+				assert.Zero(t, result.BrokenAtID)
+				assert.Equal(t, "last event does not match the chain head", result.Reason)
+			},
+		},
+		{
+			name: "should return error if the chain head cannot be read",
+			mockDependency: func(auditRepo *mock.MockAuditEventRepository) {
+				auditRepo.EXPECT().
+					GetChainHead(gomock.Any()).
+					Return(nil, errors.New("db error"))
+			},
+			assertFn: func(result *entity.AuditChainVerification, err error) {
+				assert.Nil(t, result)
+				assert.EqualError(t, err, "failed to get audit chain head: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			auditRepo := mock.NewMockAuditEventRepository(ctrl)
+			tt.mockDependency(auditRepo)
+
+			usc := usecase.NewAuditLog(mock.NewMockTransactionManager(ctrl), auditRepo)
+			tt.assertFn(usc.VerifyChain(context.Background()))
+		})
+	}
+}
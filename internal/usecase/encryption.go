@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// EncryptionPolicy configures the rotation of the keys the stored OTPs and webhook secrets are encrypted with.
+type EncryptionPolicy struct {
+	// DataKeyMaxAge is how long a data key encrypts the new OTPs before it is rotated.
+	DataKeyMaxAge time.Duration
+	// BatchSize is the maximum number of OTPs or webhook secrets encrypted again within a single transaction.
+	BatchSize int
+}
+
+type encryptionUsecase struct {
+	keyManager                  KeyManager
+	otpEncryptionRepo           OTPEncryptionRepository
+	webhookSecretEncryptionRepo WebhookSecretEncryptionRepository
+	policy                      EncryptionPolicy
+}
+
+func NewEncryptionUsecase(
+	keyManager KeyManager,
+	otpEncryptionRepo OTPEncryptionRepository,
+	webhookSecretEncryptionRepo WebhookSecretEncryptionRepository,
+	policy EncryptionPolicy,
+) *encryptionUsecase {
+	return &encryptionUsecase{
+		keyManager:                  keyManager,
+		otpEncryptionRepo:           otpEncryptionRepo,
+		webhookSecretEncryptionRepo: webhookSecretEncryptionRepo,
+		policy:                      policy,
+	}
+}
+
+// RotateKeys rotates the data key past its maximum age, wraps the keys wrapped by a previous master key
+// with the current one, then encrypts the OTPs and webhook secrets stored in plaintext or with a previous data key
+// again in batches until none is left. The rotation done so far is returned along with any error.
+func (u *encryptionUsecase) RotateKeys(ctx context.Context) (*entity.KeyRotation, error) {
+	rotation := &entity.KeyRotation{}
+
+	var err error
+	if rotation.DataKeyRotated, err = u.keyManager.RotateDataKey(ctx, u.policy.DataKeyMaxAge); err != nil {
+		return rotation, err
+	}
+	if rotation.Rewrapped, err = u.keyManager.RewrapKeys(ctx); err != nil {
+		return rotation, err
+	}
+	if rotation.Reencrypted, err = u.inBatches(ctx, u.otpEncryptionRepo.ReencryptStale); err != nil {
+		return rotation, err
+	}
+	if rotation.ReencryptedSecrets, err = u.inBatches(ctx, u.webhookSecretEncryptionRepo.ReencryptStaleSecrets); err != nil {
+		return rotation, err
+	}
+
+	return rotation, nil
+}
+
+// inBatches runs the given re-encryption until it encrypts fewer values than the batch size,
+// and returns the total number of values it encrypted again.
+func (u *encryptionUsecase) inBatches(ctx context.Context, reencrypt func(ctx context.Context, limit int) (int64, error)) (int64, error) {
+	// No batch would ever encrypt fewer values than a batch size below one
+	if u.policy.BatchSize <= 0 {
+		return 0, errors.New("encryption batch size must be positive")
+	}
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		reencrypted, err := reencrypt(ctx, u.policy.BatchSize)
+		total += reencrypted
+		if err != nil {
+			return total, err
+		}
+
+		if reencrypted < int64(u.policy.BatchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var encryptionPolicy = usecase.EncryptionPolicy{
+	DataKeyMaxAge: 30 * 24 * time.Hour,
+	BatchSize:     2,
+}
+
+func TestEncryptionUsecase_RotateKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockDependency func(keyManager *mock.MockKeyManager, otpEncryptionRepo *mock.MockOTPEncryptionRepository, webhookSecretEncryptionRepo *mock.MockWebhookSecretEncryptionRepository)
+		assertFn       func(rotation *entity.KeyRotation, err error)
+	}{
+		{
+			name: "should rotate the data key, rewrap the keys and encrypt the OTPs and webhook secrets again until a batch is not full",
+			mockDependency: func(keyManager *mock.MockKeyManager, otpEncryptionRepo *mock.MockOTPEncryptionRepository, webhookSecretEncryptionRepo *mock.MockWebhookSecretEncryptionRepository) {
+				gomock.InOrder(
+					keyManager.EXPECT().RotateDataKey(gomock.Any(), encryptionPolicy.DataKeyMaxAge).Return(true, nil),
+					keyManager.EXPECT().RewrapKeys(gomock.Any()).Return(1, nil),
+					otpEncryptionRepo.EXPECT().ReencryptStale(gomock.Any(), 2).Return(int64(2), nil),
+					otpEncryptionRepo.EXPECT().ReencryptStale(gomock.Any(), 2).Return(int64(1), nil),
+					webhookSecretEncryptionRepo.EXPECT().ReencryptStaleSecrets(gomock.Any(), 2).Return(int64(2), nil),
+					webhookSecretEncryptionRepo.EXPECT().ReencryptStaleSecrets(gomock.Any(), 2).Return(int64(0), nil),
+				)
+			},
+			assertFn: func(rotation *entity.KeyRotation, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, &entity.KeyRotation{DataKeyRotated: true, Rewrapped: 1, Reencrypted: 3, ReencryptedSecrets: 2}, rotation)
+			},
+		},
+		{
+			name: "should do nothing else when the keys are up to date",
+			mockDependency: func(keyManager *mock.MockKeyManager, otpEncryptionRepo *mock.MockOTPEncryptionRepository, webhookSecretEncryptionRepo *mock.MockWebhookSecretEncryptionRepository) {
+				keyManager.EXPECT().RotateDataKey(gomock.Any(), encryptionPolicy.DataKeyMaxAge).Return(false, nil)
+				keyManager.EXPECT().RewrapKeys(gomock.Any()).Return(0, nil)
+				otpEncryptionRepo.EXPECT().ReencryptStale(gomock.Any(), 2).Return(int64(0), nil)
+				webhookSecretEncryptionRepo.EXPECT().ReencryptStaleSecrets(gomock.Any(), 2).Return(int64(0), nil)
+			},
+			assertFn: func(rotation *entity.KeyRotation, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, &entity.KeyRotation{}, rotation)
+			},
+		},
+		{
+			name: "should not encrypt the OTPs again if rotating the data key fails",
+			mockDependency: func(keyManager *mock.MockKeyManager, otpEncryptionRepo *mock.MockOTPEncryptionRepository, webhookSecretEncryptionRepo *mock.MockWebhookSecretEncryptionRepository) {
+				keyManager.EXPECT().RotateDataKey(gomock.Any(), encryptionPolicy.DataKeyMaxAge).Return(false, errors.New("kms error"))
+			},
+			assertFn: func(rotation *entity.KeyRotation, err error) {
+				assert.EqualError(t, err, "kms error")
+				assert.Equal(t, &entity.KeyRotation{}, rotation)
+			},
+		},
+		{
+			name: "should return the rotation done so far if encrypting the OTPs again fails",
+			mockDependency: func(keyManager *mock.MockKeyManager, otpEncryptionRepo *mock.MockOTPEncryptionRepository, webhookSecretEncryptionRepo *mock.MockWebhookSecretEncryptionRepository) {
+				keyManager.EXPECT().RotateDataKey(gomock.Any(), encryptionPolicy.DataKeyMaxAge).Return(true, nil)
+				keyManager.EXPECT().RewrapKeys(gomock.Any()).Return(0, nil)
+				gomock.InOrder(
+					otpEncryptionRepo.EXPECT().ReencryptStale(gomock.Any(), 2).Return(int64(2), nil),
+					otpEncryptionRepo.EXPECT().ReencryptStale(gomock.Any(), 2).Return(int64(0), errors.New("db error")),
+				)
+			},
+			assertFn: func(rotation *entity.KeyRotation, err error) {
+				assert.EqualError(t, err, "db error")
+				assert.Equal(t, &entity.KeyRotation{DataKeyRotated: true, Reencrypted: 2}, rotation)
+			},
+		},
+		{
+			name: "should return the rotation done so far if encrypting the webhook secrets again fails",
+			mockDependency: func(keyManager *mock.MockKeyManager, otpEncryptionRepo *mock.MockOTPEncryptionRepository, webhookSecretEncryptionRepo *mock.MockWebhookSecretEncryptionRepository) {
+				keyManager.EXPECT().RotateDataKey(gomock.Any(), encryptionPolicy.DataKeyMaxAge).Return(true, nil)
+				keyManager.EXPECT().RewrapKeys(gomock.Any()).Return(0, nil)
+				otpEncryptionRepo.EXPECT().ReencryptStale(gomock.Any(), 2).Return(int64(1), nil)
+				webhookSecretEncryptionRepo.EXPECT().ReencryptStaleSecrets(gomock.Any(), 2).Return(int64(0), errors.New("db error"))
+			},
+			assertFn: func(rotation *entity.KeyRotation, err error) {
+				assert.EqualError(t, err, "db error")
+				assert.Equal(t, &entity.KeyRotation{DataKeyRotated: true, Reencrypted: 1}, rotation)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			keyManager := mock.NewMockKeyManager(ctrl)
+			otpEncryptionRepo := mock.NewMockOTPEncryptionRepository(ctrl)
+			webhookSecretEncryptionRepo := mock.NewMockWebhookSecretEncryptionRepository(ctrl)
+			tt.mockDependency(keyManager, otpEncryptionRepo, webhookSecretEncryptionRepo)
+
+			usc := usecase.NewEncryptionUsecase(keyManager, otpEncryptionRepo, webhookSecretEncryptionRepo, encryptionPolicy)
+			tt.assertFn(usc.RotateKeys(context.Background()))
+		})
+	}
+}
+
+func TestEncryptionUsecase_RejectsNonPositiveBatchSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyManager := mock.NewMockKeyManager(ctrl)
+	keyManager.EXPECT().RotateDataKey(gomock.Any(), encryptionPolicy.DataKeyMaxAge).Return(false, nil)
+	keyManager.EXPECT().RewrapKeys(gomock.Any()).Return(0, nil)
+
+	policy := encryptionPolicy
+	policy.BatchSize = 0
+
+	usc := usecase.NewEncryptionUsecase(keyManager, mock.NewMockOTPEncryptionRepository(ctrl), mock.NewMockWebhookSecretEncryptionRepository(ctrl), policy)
+	rotation, err := usc.RotateKeys(context.Background())
+	assert.EqualError(t, err, "encryption batch size must be positive")
+	assert.Equal(t, &entity.KeyRotation{}, rotation)
+}
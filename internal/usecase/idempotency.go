@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+type idempotencyUsecase struct {
+	idempotencyRepo  IdempotencyRepository
+	replayWindow     time.Duration
+	reservationLease time.Duration
+}
+
+// NewIdempotencyUsecase creates a new instance of idempotencyUsecase. The responses are replayed for the
+// replay window once stored, and a key reserved by a request that never completes, e.g. because the instance
+// crashed, is given to a retry once the reservation lease lapsed.
+func NewIdempotencyUsecase(idempotencyRepo IdempotencyRepository, replayWindow time.Duration, reservationLease time.Duration) *idempotencyUsecase {
+	return &idempotencyUsecase{
+		idempotencyRepo:  idempotencyRepo,
+		replayWindow:     replayWindow,
+		reservationLease: reservationLease,
+	}
+}
+
+// Reserve claims an idempotency key for a request with the given hash.
+// It returns a nil record when the key has been claimed and the request should be processed,
+// or the stored record when an identical request has already completed and its response should be replayed.
+// Returns entity.ErrIdempotencyKeyReused if the key was used with a different request, and
+// entity.ErrIdempotencyRequestInProgress if the original request has not completed yet.
+// The reservation only guards the key for the reservation lease until the response is stored.
+func (i *idempotencyUsecase) Reserve(ctx context.Context, key string, requestHash string) (*entity.IdempotencyRecord, error) {
+	now := time.Now()
+	record := &entity.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.reservationLease),
+	}
+
+	err := i.idempotencyRepo.Create(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, entity.ErrIdempotencyKeyDuplicate) {
+		return nil, err
+	}
+
+	existing, err := i.idempotencyRepo.FindByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expired record no longer guards the key, so it is claimed again, whether its replay window ended or
+	// the request that reserved it did not complete within the lease. Only the request that deletes the expired
+	// record claims it, and a concurrent retry that finds it already replaced waits for it instead.
+	if existing.IsExpired(now) {
+		deleted, err := i.idempotencyRepo.DeleteByKeyIfExpired(ctx, key, now)
+		if err != nil {
+			return nil, err
+		}
+		if !deleted {
+			return nil, entity.ErrIdempotencyRequestInProgress
+		}
+		if err := i.idempotencyRepo.Create(ctx, record); err != nil {
+			if errors.Is(err, entity.ErrIdempotencyKeyDuplicate) {
+				return nil, entity.ErrIdempotencyRequestInProgress
+			}
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, entity.ErrIdempotencyKeyReused
+	}
+	if !existing.IsCompleted() {
+		return nil, entity.ErrIdempotencyRequestInProgress
+	}
+
+	return existing, nil
+}
+
+// Complete stores the response of the request that reserved the key, so it can be replayed for the replay window.
+func (i *idempotencyUsecase) Complete(ctx context.Context, key string, responseStatus int, responseBody []byte) error {
+	return i.idempotencyRepo.Update(ctx, &entity.IdempotencyRecord{
+		Key:            key,
+		ResponseStatus: responseStatus,
+		ResponseBody:   responseBody,
+		ExpiresAt:      time.Now().Add(i.replayWindow),
+	})
+}
+
+// Release gives up a reserved key without storing a response, so that a retry is processed again.
+func (i *idempotencyUsecase) Release(ctx context.Context, key string) error {
+	return i.idempotencyRepo.DeleteByKey(ctx, key)
+}
@@ -0,0 +1,243 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyUsecase_Reserve(t *testing.T) {
+	const (
+		replayWindow     = 10 * time.Minute
+		reservationLease = 30 * time.Second
+	)
+
+	tests := []struct {
+		name           string
+		mockDependency func(repo *mock.MockIdempotencyRepository)
+		assertFn       func(*entity.IdempotencyRecord, error)
+	}{
+		{
+			name: "should reserve an unused key",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, record *entity.IdempotencyRecord) error {
+						assert.Equal(t, "key-1", record.Key)
+						assert.Equal(t, "hash-1", record.RequestHash)
+						assert.False(t, record.IsCompleted())
+						assert.WithinDuration(t, time.Now().Add(reservationLease), record.ExpiresAt, 2*time.Second)
+						return nil
+					})
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "should return the stored record of an identical completed request",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(entity.ErrIdempotencyKeyDuplicate)
+				repo.EXPECT().
+					FindByKey(gomock.Any(), "key-1").
+					Return(&entity.IdempotencyRecord{
+						Key:            "key-1",
+						RequestHash:    "hash-1",
+						ResponseStatus: 200,
+						ResponseBody:   []byte(`{"otp":"123456"}`),
+						ExpiresAt:      time.Now().Add(5 * time.Minute),
+					}, nil)
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 200, record.ResponseStatus)
+				assert.Equal(t, []byte(`{"otp":"123456"}`), record.ResponseBody)
+			},
+		},
+		{
+			name: "should reject a key reused with a different request",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(entity.ErrIdempotencyKeyDuplicate)
+				repo.EXPECT().
+					FindByKey(gomock.Any(), "key-1").
+					Return(&entity.IdempotencyRecord{
+						Key:            "key-1",
+						RequestHash:    "hash-2",
+						ResponseStatus: 200,
+						ExpiresAt:      time.Now().Add(5 * time.Minute),
+					}, nil)
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Equal(t, entity.ErrIdempotencyKeyReused, err)
+			},
+		},
+		{
+			name: "should reject a retry while the original request is in progress",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(entity.ErrIdempotencyKeyDuplicate)
+				repo.EXPECT().
+					FindByKey(gomock.Any(), "key-1").
+					Return(&entity.IdempotencyRecord{
+						Key:         "key-1",
+						RequestHash: "hash-1",
+						ExpiresAt:   time.Now().Add(5 * time.Minute),
+					}, nil)
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Equal(t, entity.ErrIdempotencyRequestInProgress, err)
+			},
+		},
+		{
+			name: "should reserve a key whose record has expired",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(entity.ErrIdempotencyKeyDuplicate)
+				repo.EXPECT().
+					FindByKey(gomock.Any(), "key-1").
+					Return(&entity.IdempotencyRecord{
+						Key:            "key-1",
+						RequestHash:    "hash-2",
+						ResponseStatus: 200,
+						ExpiresAt:      time.Now().Add(-1 * time.Minute),
+					}, nil)
+				repo.EXPECT().
+					DeleteByKeyIfExpired(gomock.Any(), "key-1", gomock.Any()).
+					Return(true, nil)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "should take over a key whose original request did not complete within the lease",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(entity.ErrIdempotencyKeyDuplicate)
+				repo.EXPECT().
+					FindByKey(gomock.Any(), "key-1").
+					Return(&entity.IdempotencyRecord{
+						Key:         "key-1",
+						RequestHash: "hash-1",
+						ExpiresAt:   time.Now().Add(-1 * time.Second),
+					}, nil)
+				repo.EXPECT().
+					DeleteByKeyIfExpired(gomock.Any(), "key-1", gomock.Any()).
+					Return(true, nil)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, record *entity.IdempotencyRecord) error {
+						assert.False(t, record.IsCompleted())
+						assert.WithinDuration(t, time.Now().Add(reservationLease), record.ExpiresAt, 2*time.Second)
+						return nil
+					})
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "should return in progress when a concurrent request has claimed the expired key",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(entity.ErrIdempotencyKeyDuplicate)
+				repo.EXPECT().
+					FindByKey(gomock.Any(), "key-1").
+					Return(&entity.IdempotencyRecord{
+						Key:            "key-1",
+						RequestHash:    "hash-1",
+						ResponseStatus: 200,
+						ExpiresAt:      time.Now().Add(-1 * time.Minute),
+					}, nil)
+				repo.EXPECT().
+					DeleteByKeyIfExpired(gomock.Any(), "key-1", gomock.Any()).
+					Return(false, nil)
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.Equal(t, entity.ErrIdempotencyRequestInProgress, err)
+			},
+		},
+		{
+			name: "should return error when repository fails",
+			mockDependency: func(repo *mock.MockIdempotencyRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(errors.New("db error"))
+			},
+			assertFn: func(record *entity.IdempotencyRecord, err error) {
+				assert.Nil(t, record)
+				assert.EqualError(t, err, "db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockIdempotencyRepository(ctrl)
+			tt.mockDependency(repo)
+
+			usc := usecase.NewIdempotencyUsecase(repo, replayWindow, reservationLease)
+			tt.assertFn(usc.Reserve(context.Background(), "key-1", "hash-1"))
+		})
+	}
+}
+
+func TestIdempotencyUsecase_Complete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockIdempotencyRepository(ctrl)
+	repo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, record *entity.IdempotencyRecord) error {
+			assert.Equal(t, "key-1", record.Key)
+			assert.Equal(t, 200, record.ResponseStatus)
+			assert.Equal(t, []byte(`{}`), record.ResponseBody)
+			// The response is replayed for the replay window, not the lease of the reservation
+			assert.WithinDuration(t, time.Now().Add(time.Minute), record.ExpiresAt, 2*time.Second)
+			return nil
+		})
+
+	usc := usecase.NewIdempotencyUsecase(repo, time.Minute, time.Second)
+	assert.NoError(t, usc.Complete(context.Background(), "key-1", 200, []byte(`{}`)))
+}
+
+func TestIdempotencyUsecase_Release(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockIdempotencyRepository(ctrl)
+	repo.EXPECT().
+		DeleteByKey(gomock.Any(), "key-1").
+		Return(nil)
+
+	usc := usecase.NewIdempotencyUsecase(repo, time.Minute, time.Second)
+	assert.NoError(t, usc.Release(context.Background(), "key-1"))
+}
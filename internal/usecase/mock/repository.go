@@ -7,9 +7,11 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	entity "github.com/imansohibul/otp-service/entity"
+	usecase "github.com/imansohibul/otp-service/internal/usecase"
 )
 
 // MockTransactionManager is a mock of TransactionManager interface.
@@ -49,6 +51,20 @@ func (mr *MockTransactionManagerMockRecorder) WithTransaction(ctx, fn interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockTransactionManager)(nil).WithTransaction), ctx, fn)
 }
 
+// WithTransactionOptions mocks base method.
+func (m *MockTransactionManager) WithTransactionOptions(ctx context.Context, opts usecase.TransactionOptions, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTransactionOptions", ctx, opts, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTransactionOptions indicates an expected call of WithTransactionOptions.
+func (mr *MockTransactionManagerMockRecorder) WithTransactionOptions(ctx, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransactionOptions", reflect.TypeOf((*MockTransactionManager)(nil).WithTransactionOptions), ctx, opts, fn)
+}
+
 // MockOTPRepository is a mock of OTPRepository interface.
 type MockOTPRepository struct {
 	ctrl     *gomock.Controller
@@ -72,6 +88,21 @@ func (m *MockOTPRepository) EXPECT() *MockOTPRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountByStatus mocks base method.
+func (m *MockOTPRepository) CountByStatus(ctx context.Context) (map[entity.OTPStatus]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", ctx)
+	ret0, _ := ret[0].(map[entity.OTPStatus]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockOTPRepositoryMockRecorder) CountByStatus(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockOTPRepository)(nil).CountByStatus), ctx)
+}
+
 // Create mocks base method.
 func (m *MockOTPRepository) Create(ctx context.Context, otp *entity.OTP) error {
 	m.ctrl.T.Helper()
@@ -86,6 +117,36 @@ func (mr *MockOTPRepositoryMockRecorder) Create(ctx, otp interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOTPRepository)(nil).Create), ctx, otp)
 }
 
+// DeleteExpiredBefore mocks base method.
+func (m *MockOTPRepository) DeleteExpiredBefore(ctx context.Context, before, createdFrom, createdTo time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredBefore", ctx, before, createdFrom, createdTo, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredBefore indicates an expected call of DeleteExpiredBefore.
+func (mr *MockOTPRepositoryMockRecorder) DeleteExpiredBefore(ctx, before, createdFrom, createdTo, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredBefore", reflect.TypeOf((*MockOTPRepository)(nil).DeleteExpiredBefore), ctx, before, createdFrom, createdTo, limit)
+}
+
+// FindByID mocks base method.
+func (m *MockOTPRepository) FindByID(ctx context.Context, id uint64) (*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockOTPRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockOTPRepository)(nil).FindByID), ctx, id)
+}
+
 // FindByUserIDAndCode mocks base method.
 func (m *MockOTPRepository) FindByUserIDAndCode(ctx context.Context, userID, otpCode string) (*entity.OTP, error) {
 	m.ctrl.T.Helper()
@@ -101,31 +162,854 @@ func (mr *MockOTPRepositoryMockRecorder) FindByUserIDAndCode(ctx, userID, otpCod
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserIDAndCode", reflect.TypeOf((*MockOTPRepository)(nil).FindByUserIDAndCode), ctx, userID, otpCode)
 }
 
+// FindNextByUserID mocks base method.
+func (m *MockOTPRepository) FindNextByUserID(ctx context.Context, after *entity.OTP) (*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindNextByUserID", ctx, after)
+	ret0, _ := ret[0].(*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindNextByUserID indicates an expected call of FindNextByUserID.
+func (mr *MockOTPRepositoryMockRecorder) FindNextByUserID(ctx, after interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindNextByUserID", reflect.TypeOf((*MockOTPRepository)(nil).FindNextByUserID), ctx, after)
+}
+
 // GetLastByUserID mocks base method.
-func (m *MockOTPRepository) GetLastByUserID(ctx context.Context, userID string) (*entity.OTP, error) {
+func (m *MockOTPRepository) GetLastByUserID(ctx context.Context, userID string, createdFrom time.Time) (*entity.OTP, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLastByUserID", ctx, userID)
+	ret := m.ctrl.Call(m, "GetLastByUserID", ctx, userID, createdFrom)
 	ret0, _ := ret[0].(*entity.OTP)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLastByUserID indicates an expected call of GetLastByUserID.
-func (mr *MockOTPRepositoryMockRecorder) GetLastByUserID(ctx, userID interface{}) *gomock.Call {
+func (mr *MockOTPRepositoryMockRecorder) GetLastByUserID(ctx, userID, createdFrom interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastByUserID", reflect.TypeOf((*MockOTPRepository)(nil).GetLastByUserID), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastByUserID", reflect.TypeOf((*MockOTPRepository)(nil).GetLastByUserID), ctx, userID, createdFrom)
 }
 
-// Update mocks base method.
-func (m *MockOTPRepository) Update(ctx context.Context, otp *entity.OTP) error {
+// IncrementResendCount mocks base method.
+func (m *MockOTPRepository) IncrementResendCount(ctx context.Context, otp *entity.OTP) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, otp)
+	ret := m.ctrl.Call(m, "IncrementResendCount", ctx, otp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementResendCount indicates an expected call of IncrementResendCount.
+func (mr *MockOTPRepositoryMockRecorder) IncrementResendCount(ctx, otp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementResendCount", reflect.TypeOf((*MockOTPRepository)(nil).IncrementResendCount), ctx, otp)
+}
+
+// List mocks base method.
+func (m *MockOTPRepository) List(ctx context.Context, filter entity.OTPFilter, beforeID uint64, limit int) ([]*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, beforeID, limit)
+	ret0, _ := ret[0].([]*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockOTPRepositoryMockRecorder) List(ctx, filter, beforeID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOTPRepository)(nil).List), ctx, filter, beforeID, limit)
+}
+
+// ListActiveByUserID mocks base method.
+func (m *MockOTPRepository) ListActiveByUserID(ctx context.Context, userID string, now, createdFrom time.Time) ([]*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveByUserID", ctx, userID, now, createdFrom)
+	ret0, _ := ret[0].([]*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveByUserID indicates an expected call of ListActiveByUserID.
+func (mr *MockOTPRepositoryMockRecorder) ListActiveByUserID(ctx, userID, now, createdFrom interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveByUserID", reflect.TypeOf((*MockOTPRepository)(nil).ListActiveByUserID), ctx, userID, now, createdFrom)
+}
+
+// ListExpirable mocks base method.
+func (m *MockOTPRepository) ListExpirable(ctx context.Context, now, createdFrom, createdTo time.Time, limit int) ([]*entity.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpirable", ctx, now, createdFrom, createdTo, limit)
+	ret0, _ := ret[0].([]*entity.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpirable indicates an expected call of ListExpirable.
+func (mr *MockOTPRepositoryMockRecorder) ListExpirable(ctx, now, createdFrom, createdTo, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpirable", reflect.TypeOf((*MockOTPRepository)(nil).ListExpirable), ctx, now, createdFrom, createdTo, limit)
+}
+
+// MarkExpired mocks base method.
+func (m *MockOTPRepository) MarkExpired(ctx context.Context, otps []*entity.OTP) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpired", ctx, otps)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// MarkExpired indicates an expected call of MarkExpired.
+func (mr *MockOTPRepositoryMockRecorder) MarkExpired(ctx, otps interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpired", reflect.TypeOf((*MockOTPRepository)(nil).MarkExpired), ctx, otps)
+}
+
+// MarkRevoked mocks base method.
+func (m *MockOTPRepository) MarkRevoked(ctx context.Context, otp *entity.OTP, revokedAt time.Time, reason string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRevoked", ctx, otp, revokedAt, reason)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRevoked indicates an expected call of MarkRevoked.
+func (mr *MockOTPRepositoryMockRecorder) MarkRevoked(ctx, otp, revokedAt, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRevoked", reflect.TypeOf((*MockOTPRepository)(nil).MarkRevoked), ctx, otp, revokedAt, reason)
+}
+
+// Update mocks base method.
+func (m *MockOTPRepository) Update(ctx context.Context, otp *entity.OTP) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, otp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Update indicates an expected call of Update.
 func (mr *MockOTPRepositoryMockRecorder) Update(ctx, otp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockOTPRepository)(nil).Update), ctx, otp)
 }
+
+// MockUserLockoutRepository is a mock of UserLockoutRepository interface.
+type MockUserLockoutRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserLockoutRepositoryMockRecorder
+}
+
+// MockUserLockoutRepositoryMockRecorder is the mock recorder for MockUserLockoutRepository.
+type MockUserLockoutRepositoryMockRecorder struct {
+	mock *MockUserLockoutRepository
+}
+
+// NewMockUserLockoutRepository creates a new mock instance.
+func NewMockUserLockoutRepository(ctrl *gomock.Controller) *MockUserLockoutRepository {
+	mock := &MockUserLockoutRepository{ctrl: ctrl}
+	mock.recorder = &MockUserLockoutRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserLockoutRepository) EXPECT() *MockUserLockoutRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteByUserID mocks base method.
+func (m *MockUserLockoutRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUserID", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUserID indicates an expected call of DeleteByUserID.
+func (mr *MockUserLockoutRepositoryMockRecorder) DeleteByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUserID", reflect.TypeOf((*MockUserLockoutRepository)(nil).DeleteByUserID), ctx, userID)
+}
+
+// FindByUserID mocks base method.
+func (m *MockUserLockoutRepository) FindByUserID(ctx context.Context, userID string) (*entity.UserLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
+	ret0, _ := ret[0].(*entity.UserLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserID indicates an expected call of FindByUserID.
+func (mr *MockUserLockoutRepositoryMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockUserLockoutRepository)(nil).FindByUserID), ctx, userID)
+}
+
+// IncrementFailedAttempts mocks base method.
+func (m *MockUserLockoutRepository) IncrementFailedAttempts(ctx context.Context, userID string, failedAt time.Time) (*entity.UserLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementFailedAttempts", ctx, userID, failedAt)
+	ret0, _ := ret[0].(*entity.UserLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementFailedAttempts indicates an expected call of IncrementFailedAttempts.
+func (mr *MockUserLockoutRepositoryMockRecorder) IncrementFailedAttempts(ctx, userID, failedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementFailedAttempts", reflect.TypeOf((*MockUserLockoutRepository)(nil).IncrementFailedAttempts), ctx, userID, failedAt)
+}
+
+// Lock mocks base method.
+func (m *MockUserLockoutRepository) Lock(ctx context.Context, userID string, lockedUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, userID, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockUserLockoutRepositoryMockRecorder) Lock(ctx, userID, lockedUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockUserLockoutRepository)(nil).Lock), ctx, userID, lockedUntil)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIdempotencyRepository) Create(ctx context.Context, record *entity.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIdempotencyRepositoryMockRecorder) Create(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIdempotencyRepository)(nil).Create), ctx, record)
+}
+
+// DeleteByKey mocks base method.
+func (m *MockIdempotencyRepository) DeleteByKey(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByKey indicates an expected call of DeleteByKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteByKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteByKey), ctx, key)
+}
+
+// DeleteByKeyIfExpired mocks base method.
+func (m *MockIdempotencyRepository) DeleteByKeyIfExpired(ctx context.Context, key string, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKeyIfExpired", ctx, key, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByKeyIfExpired indicates an expected call of DeleteByKeyIfExpired.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteByKeyIfExpired(ctx, key, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKeyIfExpired", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteByKeyIfExpired), ctx, key, now)
+}
+
+// DeleteExpiredBefore mocks base method.
+func (m *MockIdempotencyRepository) DeleteExpiredBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredBefore", ctx, before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredBefore indicates an expected call of DeleteExpiredBefore.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteExpiredBefore(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredBefore", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteExpiredBefore), ctx, before, limit)
+}
+
+// FindByKey mocks base method.
+func (m *MockIdempotencyRepository) FindByKey(ctx context.Context, key string) (*entity.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByKey", ctx, key)
+	ret0, _ := ret[0].(*entity.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByKey indicates an expected call of FindByKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) FindByKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).FindByKey), ctx, key)
+}
+
+// Update mocks base method.
+func (m *MockIdempotencyRepository) Update(ctx context.Context, record *entity.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIdempotencyRepositoryMockRecorder) Update(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIdempotencyRepository)(nil).Update), ctx, record)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOutboxRepository) Create(ctx context.Context, event *entity.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOutboxRepositoryMockRecorder) Create(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOutboxRepository)(nil).Create), ctx, event)
+}
+
+// ListByOTPID mocks base method.
+func (m *MockOutboxRepository) ListByOTPID(ctx context.Context, otpID uint64) ([]*entity.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOTPID", ctx, otpID)
+	ret0, _ := ret[0].([]*entity.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOTPID indicates an expected call of ListByOTPID.
+func (mr *MockOutboxRepositoryMockRecorder) ListByOTPID(ctx, otpID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOTPID", reflect.TypeOf((*MockOutboxRepository)(nil).ListByOTPID), ctx, otpID)
+}
+
+// ListDue mocks base method.
+func (m *MockOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDue", ctx, now, limit)
+	ret0, _ := ret[0].([]*entity.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDue indicates an expected call of ListDue.
+func (mr *MockOutboxRepositoryMockRecorder) ListDue(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDue", reflect.TypeOf((*MockOutboxRepository)(nil).ListDue), ctx, now, limit)
+}
+
+// Update mocks base method.
+func (m *MockOutboxRepository) Update(ctx context.Context, event *entity.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockOutboxRepositoryMockRecorder) Update(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockOutboxRepository)(nil).Update), ctx, event)
+}
+
+// MockWebhookSubscriptionRepository is a mock of WebhookSubscriptionRepository interface.
+type MockWebhookSubscriptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookSubscriptionRepositoryMockRecorder
+}
+
+// MockWebhookSubscriptionRepositoryMockRecorder is the mock recorder for MockWebhookSubscriptionRepository.
+type MockWebhookSubscriptionRepositoryMockRecorder struct {
+	mock *MockWebhookSubscriptionRepository
+}
+
+// NewMockWebhookSubscriptionRepository creates a new mock instance.
+func NewMockWebhookSubscriptionRepository(ctrl *gomock.Controller) *MockWebhookSubscriptionRepository {
+	mock := &MockWebhookSubscriptionRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookSubscriptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookSubscriptionRepository) EXPECT() *MockWebhookSubscriptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookSubscriptionRepository) Create(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) Create(ctx, subscription interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).Create), ctx, subscription)
+}
+
+// DeleteByID mocks base method.
+func (m *MockWebhookSubscriptionRepository) DeleteByID(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByID", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByID indicates an expected call of DeleteByID.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) DeleteByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByID", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).DeleteByID), ctx, id)
+}
+
+// FindByID mocks base method.
+func (m *MockWebhookSubscriptionRepository) FindByID(ctx context.Context, id uint64) (*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).FindByID), ctx, id)
+}
+
+// ListByClientID mocks base method.
+func (m *MockWebhookSubscriptionRepository) ListByClientID(ctx context.Context, clientID string) ([]*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByClientID", ctx, clientID)
+	ret0, _ := ret[0].([]*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByClientID indicates an expected call of ListByClientID.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) ListByClientID(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByClientID", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).ListByClientID), ctx, clientID)
+}
+
+// ListByClientIDAndEventType mocks base method.
+func (m *MockWebhookSubscriptionRepository) ListByClientIDAndEventType(ctx context.Context, clientID string, eventType entity.EventType) ([]*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByClientIDAndEventType", ctx, clientID, eventType)
+	ret0, _ := ret[0].([]*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByClientIDAndEventType indicates an expected call of ListByClientIDAndEventType.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) ListByClientIDAndEventType(ctx, clientID, eventType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByClientIDAndEventType", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).ListByClientIDAndEventType), ctx, clientID, eventType)
+}
+
+// MockWebhookDeliveryRepository is a mock of WebhookDeliveryRepository interface.
+type MockWebhookDeliveryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookDeliveryRepositoryMockRecorder
+}
+
+// MockWebhookDeliveryRepositoryMockRecorder is the mock recorder for MockWebhookDeliveryRepository.
+type MockWebhookDeliveryRepositoryMockRecorder struct {
+	mock *MockWebhookDeliveryRepository
+}
+
+// NewMockWebhookDeliveryRepository creates a new mock instance.
+func NewMockWebhookDeliveryRepository(ctrl *gomock.Controller) *MockWebhookDeliveryRepository {
+	mock := &MockWebhookDeliveryRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookDeliveryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookDeliveryRepository) EXPECT() *MockWebhookDeliveryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) Create(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).Create), ctx, delivery)
+}
+
+// FindByID mocks base method.
+func (m *MockWebhookDeliveryRepository) FindByID(ctx context.Context, id uint64) (*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).FindByID), ctx, id)
+}
+
+// ListByEventID mocks base method.
+func (m *MockWebhookDeliveryRepository) ListByEventID(ctx context.Context, eventID uint64) ([]*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByEventID", ctx, eventID)
+	ret0, _ := ret[0].([]*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByEventID indicates an expected call of ListByEventID.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) ListByEventID(ctx, eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByEventID", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).ListByEventID), ctx, eventID)
+}
+
+// ListBySubscriptionID mocks base method.
+func (m *MockWebhookDeliveryRepository) ListBySubscriptionID(ctx context.Context, subscriptionID uint64, limit int) ([]*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySubscriptionID", ctx, subscriptionID, limit)
+	ret0, _ := ret[0].([]*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySubscriptionID indicates an expected call of ListBySubscriptionID.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) ListBySubscriptionID(ctx, subscriptionID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySubscriptionID", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).ListBySubscriptionID), ctx, subscriptionID, limit)
+}
+
+// ListDue mocks base method.
+func (m *MockWebhookDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDue", ctx, now, limit)
+	ret0, _ := ret[0].([]*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDue indicates an expected call of ListDue.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) ListDue(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDue", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).ListDue), ctx, now, limit)
+}
+
+// Update mocks base method.
+func (m *MockWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) Update(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).Update), ctx, delivery)
+}
+
+// MockAuditEventRepository is a mock of AuditEventRepository interface.
+type MockAuditEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditEventRepositoryMockRecorder
+}
+
+// MockAuditEventRepositoryMockRecorder is the mock recorder for MockAuditEventRepository.
+type MockAuditEventRepositoryMockRecorder struct {
+	mock *MockAuditEventRepository
+}
+
+// NewMockAuditEventRepository creates a new mock instance.
+func NewMockAuditEventRepository(ctrl *gomock.Controller) *MockAuditEventRepository {
+	mock := &MockAuditEventRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditEventRepository) EXPECT() *MockAuditEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAuditEventRepository) Append(ctx context.Context, event *entity.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAuditEventRepositoryMockRecorder) Append(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAuditEventRepository)(nil).Append), ctx, event)
+}
+
+// GetChainHead mocks base method.
+func (m *MockAuditEventRepository) GetChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChainHead", ctx)
+	ret0, _ := ret[0].(*entity.AuditChainHead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChainHead indicates an expected call of GetChainHead.
+func (mr *MockAuditEventRepositoryMockRecorder) GetChainHead(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChainHead", reflect.TypeOf((*MockAuditEventRepository)(nil).GetChainHead), ctx)
+}
+
+// List mocks base method.
+func (m *MockAuditEventRepository) List(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAuditEventRepositoryMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAuditEventRepository)(nil).List), ctx, filter)
+}
+
+// ListAfter mocks base method.
+func (m *MockAuditEventRepository) ListAfter(ctx context.Context, afterID uint64, limit int) ([]*entity.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAfter", ctx, afterID, limit)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAfter indicates an expected call of ListAfter.
+func (mr *MockAuditEventRepositoryMockRecorder) ListAfter(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAfter", reflect.TypeOf((*MockAuditEventRepository)(nil).ListAfter), ctx, afterID, limit)
+}
+
+// ListByOTPID mocks base method.
+func (m *MockAuditEventRepository) ListByOTPID(ctx context.Context, otpID uint64) ([]*entity.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOTPID", ctx, otpID)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOTPID indicates an expected call of ListByOTPID.
+func (mr *MockAuditEventRepositoryMockRecorder) ListByOTPID(ctx, otpID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOTPID", reflect.TypeOf((*MockAuditEventRepository)(nil).ListByOTPID), ctx, otpID)
+}
+
+// MockOTPPartitionRepository is a mock of OTPPartitionRepository interface.
+type MockOTPPartitionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOTPPartitionRepositoryMockRecorder
+}
+
+// MockOTPPartitionRepositoryMockRecorder is the mock recorder for MockOTPPartitionRepository.
+type MockOTPPartitionRepositoryMockRecorder struct {
+	mock *MockOTPPartitionRepository
+}
+
+// NewMockOTPPartitionRepository creates a new mock instance.
+func NewMockOTPPartitionRepository(ctrl *gomock.Controller) *MockOTPPartitionRepository {
+	mock := &MockOTPPartitionRepository{ctrl: ctrl}
+	mock.recorder = &MockOTPPartitionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOTPPartitionRepository) EXPECT() *MockOTPPartitionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddPartitions mocks base method.
+func (m *MockOTPPartitionRepository) AddPartitions(ctx context.Context, bounds []time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPartitions", ctx, bounds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPartitions indicates an expected call of AddPartitions.
+func (mr *MockOTPPartitionRepositoryMockRecorder) AddPartitions(ctx, bounds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPartitions", reflect.TypeOf((*MockOTPPartitionRepository)(nil).AddPartitions), ctx, bounds)
+}
+
+// DropPartitions mocks base method.
+func (m *MockOTPPartitionRepository) DropPartitions(ctx context.Context, partitions []entity.OTPPartition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropPartitions", ctx, partitions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropPartitions indicates an expected call of DropPartitions.
+func (mr *MockOTPPartitionRepositoryMockRecorder) DropPartitions(ctx, partitions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropPartitions", reflect.TypeOf((*MockOTPPartitionRepository)(nil).DropPartitions), ctx, partitions)
+}
+
+// ListPartitions mocks base method.
+func (m *MockOTPPartitionRepository) ListPartitions(ctx context.Context) ([]entity.OTPPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPartitions", ctx)
+	ret0, _ := ret[0].([]entity.OTPPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPartitions indicates an expected call of ListPartitions.
+func (mr *MockOTPPartitionRepositoryMockRecorder) ListPartitions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPartitions", reflect.TypeOf((*MockOTPPartitionRepository)(nil).ListPartitions), ctx)
+}
+
+// MockOTPEncryptionRepository is a mock of OTPEncryptionRepository interface.
+type MockOTPEncryptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOTPEncryptionRepositoryMockRecorder
+}
+
+// MockOTPEncryptionRepositoryMockRecorder is the mock recorder for MockOTPEncryptionRepository.
+type MockOTPEncryptionRepositoryMockRecorder struct {
+	mock *MockOTPEncryptionRepository
+}
+
+// NewMockOTPEncryptionRepository creates a new mock instance.
+func NewMockOTPEncryptionRepository(ctrl *gomock.Controller) *MockOTPEncryptionRepository {
+	mock := &MockOTPEncryptionRepository{ctrl: ctrl}
+	mock.recorder = &MockOTPEncryptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOTPEncryptionRepository) EXPECT() *MockOTPEncryptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ReencryptStale mocks base method.
+func (m *MockOTPEncryptionRepository) ReencryptStale(ctx context.Context, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptStale", ctx, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptStale indicates an expected call of ReencryptStale.
+func (mr *MockOTPEncryptionRepositoryMockRecorder) ReencryptStale(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptStale", reflect.TypeOf((*MockOTPEncryptionRepository)(nil).ReencryptStale), ctx, limit)
+}
+
+// MockWebhookSecretEncryptionRepository is a mock of WebhookSecretEncryptionRepository interface.
+type MockWebhookSecretEncryptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookSecretEncryptionRepositoryMockRecorder
+}
+
+// MockWebhookSecretEncryptionRepositoryMockRecorder is the mock recorder for MockWebhookSecretEncryptionRepository.
+type MockWebhookSecretEncryptionRepositoryMockRecorder struct {
+	mock *MockWebhookSecretEncryptionRepository
+}
+
+// NewMockWebhookSecretEncryptionRepository creates a new mock instance.
+func NewMockWebhookSecretEncryptionRepository(ctrl *gomock.Controller) *MockWebhookSecretEncryptionRepository {
+	mock := &MockWebhookSecretEncryptionRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookSecretEncryptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookSecretEncryptionRepository) EXPECT() *MockWebhookSecretEncryptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ReencryptStaleSecrets mocks base method.
+func (m *MockWebhookSecretEncryptionRepository) ReencryptStaleSecrets(ctx context.Context, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptStaleSecrets", ctx, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptStaleSecrets indicates an expected call of ReencryptStaleSecrets.
+func (mr *MockWebhookSecretEncryptionRepositoryMockRecorder) ReencryptStaleSecrets(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptStaleSecrets", reflect.TypeOf((*MockWebhookSecretEncryptionRepository)(nil).ReencryptStaleSecrets), ctx, limit)
+}
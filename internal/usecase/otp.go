@@ -2,7 +2,13 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/imansohibul/otp-service/entity"
@@ -11,25 +17,89 @@ import (
 const (
 	otpValidityDuration = 2 * time.Minute
 	otpRateLimitWindow  = 2 * time.Minute
+
+	// clockSkewMargin widens the creation time bounds of the lookups of recent OTPs,
+	// as the creation times are set by the clock of the database
+	clockSkewMargin = time.Minute
 )
 
+// LockoutPolicy configures the account-level lockout applied after repeated
+// validation failures across any of a user's OTPs.
+type LockoutPolicy struct {
+	// MaxFailedAttempts is the number of failed validations that triggers a lockout, it must be positive.
+	MaxFailedAttempts int
+	// Duration is how long the user stays locked out.
+	Duration time.Duration
+}
+
+// ResendPolicy configures how OTPs are resent.
+type ResendPolicy struct {
+	// Rotate replaces the code of a resent OTP with a new one, superseding the old code,
+	// instead of delivering the same code again.
+	Rotate bool
+	// MaxResends is how many times an OTP can be resent, counting the resends of the OTPs it replaced.
+	MaxResends int
+}
+
+// OTPPolicy configures the behaviour of the OTP usecase.
+type OTPPolicy struct {
+	Lockout LockoutPolicy
+	Resend  ResendPolicy
+	// ValidationGracePeriod is how long after a successful validation an identical retry
+	// from the same session gets the original success. Zero disables such retries.
+	ValidationGracePeriod time.Duration
+}
+
 type otpUsecase struct {
-	otpRepo      OTPRepository
-	otpGenerator OTPGenerator
+	txManager       TransactionManager
+	otpRepo         OTPRepository
+	userLockoutRepo UserLockoutRepository
+	outboxRepo      OutboxRepository
+	otpGenerator    OTPGenerator
+	auditRecorder   AuditRecorder
+	policy          OTPPolicy
 }
 
-func NewOtpUsecase(otpRepo OTPRepository, otpGenerator OTPGenerator) *otpUsecase {
+func NewOtpUsecase(
+	txManager TransactionManager,
+	otpRepo OTPRepository,
+	userLockoutRepo UserLockoutRepository,
+	outboxRepo OutboxRepository,
+	otpGenerator OTPGenerator,
+	auditRecorder AuditRecorder,
+	policy OTPPolicy,
+) *otpUsecase {
 	return &otpUsecase{
-		otpRepo:      otpRepo,
-		otpGenerator: otpGenerator,
+		txManager:       txManager,
+		otpRepo:         otpRepo,
+		userLockoutRepo: userLockoutRepo,
+		outboxRepo:      outboxRepo,
+		otpGenerator:    otpGenerator,
+		auditRecorder:   auditRecorder,
+		policy:          policy,
 	}
 }
 
 // Create generates a new OTP for the specified user and stores it in the system.
 // The OTP will have an expiration time and can only be used once.
-func (o *otpUsecase) Create(ctx context.Context, userID string) (*entity.OTP, error) {
+// Every request is recorded in the audit log, whether or not an OTP was issued.
+func (o *otpUsecase) Create(ctx context.Context, params entity.CreateOTPParams) (*entity.OTP, error) {
+	otp, err := o.create(ctx, params)
+	o.audit(ctx, entity.AuditActionOTPRequest, params.UserID, otp, err)
+	return otp, err
+}
+
+// create issues an OTP for the request, see Create
+func (o *otpUsecase) create(ctx context.Context, params entity.CreateOTPParams) (*entity.OTP, error) {
+	// A read replica lagging behind the primary could miss the last OTP of the user, and with it the rate limit
+	ctx = WithReadYourWrites(ctx)
+
+	if _, err := o.ensureUserNotLocked(ctx, params.UserID); err != nil {
+		return nil, err
+	}
+
 	// Check rate limiting
-	lastOTP, _ := o.otpRepo.GetLastByUserID(ctx, userID)
+	lastOTP, _ := o.otpRepo.GetLastByUserID(ctx, params.UserID, time.Now().Add(-otpRateLimitWindow-clockSkewMargin))
 	if lastOTP != nil && lastOTP.Status == entity.OTPStatusCreated {
 		if time.Since(lastOTP.CreatedAt) < otpRateLimitWindow {
 			return nil, entity.ErrOTPRateLimitExceeded
@@ -42,12 +112,24 @@ func (o *otpUsecase) Create(ctx context.Context, userID string) (*entity.OTP, er
 	}
 
 	otp := &entity.OTP{
-		UserID:    userID,
+		UserID:    params.UserID,
 		OTPCode:   otpCode,
 		Status:    entity.OTPStatusCreated,
 		ExpiresAt: time.Now().Add(2 * time.Minute),
 	}
-	if err := o.otpRepo.Create(ctx, otp); err != nil {
+	if params.BindingID != "" {
+		otp.BindingHash = hashIdentifier(params.BindingID)
+	}
+	otp.Purpose = params.Purpose
+	otp.ClientID = entity.RequestMetadataFromContext(ctx).ClientID
+	otp.ResendLimit = o.policy.Resend.MaxResends
+	err = o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := o.otpRepo.Create(ctx, otp); err != nil {
+			return err
+		}
+		return appendEvent(ctx, o.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPCreated, otp, time.Now()))
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -57,26 +139,406 @@ func (o *otpUsecase) Create(ctx context.Context, userID string) (*entity.OTP, er
 // Validate verifies that the provided OTP code is valid for the specified user.
 // This checks if the code matches, hasn't expired, and hasn't been used before.
 // Upon successful validation, the OTP should be marked as validated.
-func (o *otpUsecase) Validate(ctx context.Context, userID string, otpCode string) (*entity.OTP, error) {
-	otp, err := o.otpRepo.FindByUserIDAndCode(ctx, userID, otpCode)
+// Every attempt is recorded in the audit log, along with the OTP it matched, if any.
+func (o *otpUsecase) Validate(ctx context.Context, params entity.ValidateOTPParams) (*entity.OTP, error) {
+	otp, err := o.validate(ctx, params)
+	o.audit(ctx, entity.AuditActionOTPValidate, params.UserID, otp, err)
 	if err != nil {
 		return nil, err
 	}
 
+	return otp, nil
+}
+
+// validate performs a validation attempt, see Validate.
+// It returns the OTP matched by the code, if any, even when the attempt is rejected.
+func (o *otpUsecase) validate(ctx context.Context, params entity.ValidateOTPParams) (*entity.OTP, error) {
+	// The OTP may have been issued an instant ago, before a read replica lagging behind the primary has it
+	ctx = WithReadYourWrites(ctx)
+
+	lockout, err := o.ensureUserNotLocked(ctx, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	otp, err := o.otpRepo.FindByUserIDAndCode(ctx, params.UserID, params.OTPCode)
+	if err != nil {
+		// A code that matches none of the user's OTPs counts towards the lockout
+		if errors.Is(err, entity.ErrOTPNotFound) {
+			if err := o.recordFailedAttempt(ctx, params.UserID); err != nil {
+				return nil, fmt.Errorf("failed to record failed attempt: %w", err)
+			}
+		}
+		return nil, err
+	}
+
+	// A bound OTP can only be validated by the session or device that requested it
+	if !o.matchesBinding(otp, params.BindingID) {
+		if err := o.recordFailedAttempt(ctx, params.UserID); err != nil {
+			return otp, fmt.Errorf("failed to record failed attempt: %w", err)
+		}
+		return otp, entity.ErrOTPBindingMismatch
+	}
+
+	// The session that validated the OTP may retry if it lost the response
+	if o.isValidationRetry(otp, params.SessionID) {
+		return otp, nil
+	}
+
 	// Validate OTP status and expiration
 	if err := o.validateOTPStatus(ctx, otp); err != nil {
+		return otp, err
+	}
+
+	err = o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		// Mark OTP as used
+		validated, err := o.markOTPAsValidated(ctx, otp, params.SessionID)
+		if err != nil {
+			return fmt.Errorf("failed to update OTP status: %w", err)
+		}
+		// The OTP was validated, expired or revoked since it was read
+		if !validated {
+			return entity.ErrOTPInvalid
+		}
+
+		// A successful validation resets the failure ledger
+		if lockout != nil {
+			if err := o.userLockoutRepo.DeleteByUserID(ctx, params.UserID); err != nil {
+				return fmt.Errorf("failed to reset user lockout: %w", err)
+			}
+		}
+
+		return appendEvent(ctx, o.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPValidated, otp, *otp.ValidatedAt))
+	})
+	if err != nil {
+		return otp, err
+	}
+
+	return otp, nil
+}
+
+// Revoke cancels an OTP of a user before it is used, so that it can no longer be validated.
+// Returns entity.ErrOTPNotFound if the OTP does not exist or was issued to another user, and
+// entity.ErrOTPUsed, entity.ErrOTPExpired or entity.ErrOTPRevoked if it is no longer active.
+// Every attempt is recorded in the audit log.
+func (o *otpUsecase) Revoke(ctx context.Context, params entity.RevokeOTPParams) (*entity.OTP, error) {
+	otp, err := o.revoke(ctx, params)
+	o.audit(ctx, entity.AuditActionOTPRevoke, params.UserID, otp, err)
+	if err != nil {
 		return nil, err
 	}
 
-	// Mark OTP as used
-	if err := o.markOTPAsValidated(ctx, otp); err != nil {
-		return nil, fmt.Errorf("failed to update OTP status: %w", err)
+	return otp, nil
+}
+
+// revoke performs a revocation, see Revoke.
+// It returns the OTP it found, if any, even when the revocation is rejected.
+func (o *otpUsecase) revoke(ctx context.Context, params entity.RevokeOTPParams) (*entity.OTP, error) {
+	// Like validations, revocations act on the OTPs read from the primary
+	ctx = WithReadYourWrites(ctx)
+
+	otp, err := o.otpRepo.FindByID(ctx, params.OTPID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The OTP of another user is reported as missing, so that IDs cannot be probed
+	if otp.UserID != params.UserID {
+		return nil, entity.ErrOTPNotFound
+	}
+
+	now := time.Now()
+	if err := ensureOTPActive(otp, now); err != nil {
+		return otp, err
+	}
+
+	err = o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		revoked, err := o.markOTPAsRevoked(ctx, otp, now, params.Reason)
+		if err != nil {
+			return fmt.Errorf("failed to revoke OTP: %w", err)
+		}
+		// The OTP was validated or expired since it was read
+		if !revoked {
+			return entity.ErrOTPInvalid
+		}
+		return appendEvent(ctx, o.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPRevoked, otp, now))
+	})
+	if err != nil {
+		return otp, err
 	}
 
 	return otp, nil
 }
 
-// validateOTPStatus checks if OTP is expired or already used
+// RevokeAllForUser cancels every OTP of a user that can still be validated, e.g. after a SIM swap,
+// and returns the OTPs it revoked. The revocation of each OTP is recorded in the audit log,
+// or a single event if the user had no active OTP.
+func (o *otpUsecase) RevokeAllForUser(ctx context.Context, userID string, reason string) ([]*entity.OTP, error) {
+	revoked, err := o.revokeAllForUser(ctx, userID, reason)
+	if err != nil || len(revoked) == 0 {
+		o.audit(ctx, entity.AuditActionOTPRevoke, userID, nil, err)
+		return nil, err
+	}
+
+	for _, otp := range revoked {
+		o.audit(ctx, entity.AuditActionOTPRevoke, userID, otp, nil)
+	}
+
+	return revoked, nil
+}
+
+// revokeAllForUser performs the revocation of the active OTPs of a user, see RevokeAllForUser
+func (o *otpUsecase) revokeAllForUser(ctx context.Context, userID string, reason string) ([]*entity.OTP, error) {
+	// An OTP issued an instant ago must be revoked too, though a read replica lagging behind may not have it yet
+	ctx = WithReadYourWrites(ctx)
+
+	now := time.Now()
+	// An OTP expires once its validity has passed since its creation, so older OTPs are not searched
+	otps, err := o.otpRepo.ListActiveByUserID(ctx, userID, now, now.Add(-otpValidityDuration-clockSkewMargin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active OTPs: %w", err)
+	}
+
+	var revoked []*entity.OTP
+	err = o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		revoked = nil
+		for _, otp := range otps {
+			ok, err := o.markOTPAsRevoked(ctx, otp, now, reason)
+			if err != nil {
+				return fmt.Errorf("failed to revoke OTP %d: %w", otp.ID, err)
+			}
+			// An OTP validated since it was listed is left alone
+			if !ok {
+				continue
+			}
+			if err := appendEvent(ctx, o.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPRevoked, otp, now)); err != nil {
+				return err
+			}
+			revoked = append(revoked, otp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revoked, nil
+}
+
+// Resend delivers an OTP of a user again. Depending on the resend policy, the same code is delivered again
+// or the OTP is superseded by a new one with a new code, which is returned. Either way the resend is counted
+// on the returned OTP and published in an otp.resent event, along with the channel asked for, if any.
+// Returns entity.ErrOTPNotFound if the OTP does not exist or was issued to another user,
+// entity.ErrOTPUsed, entity.ErrOTPExpired or entity.ErrOTPRevoked if it is no longer active,
+// and entity.ErrOTPResendLimit if it has no resends left.
+// Every attempt is recorded in the audit log, along with the OTP the resend was requested for.
+func (o *otpUsecase) Resend(ctx context.Context, params entity.ResendOTPParams) (*entity.OTP, error) {
+	requested, resent, err := o.resend(ctx, params)
+	o.audit(ctx, entity.AuditActionOTPResend, params.UserID, requested, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return resent, nil
+}
+
+// resend performs a resend, see Resend.
+// It returns the OTP the resend was requested for, if found, and the OTP that was resent.
+func (o *otpUsecase) resend(ctx context.Context, params entity.ResendOTPParams) (*entity.OTP, *entity.OTP, error) {
+	// Like validations, resends act on the OTPs read from the primary
+	ctx = WithReadYourWrites(ctx)
+
+	if _, err := o.ensureUserNotLocked(ctx, params.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	otp, err := o.otpRepo.FindByID(ctx, params.OTPID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The OTP of another user is reported as missing, so that IDs cannot be probed
+	if otp.UserID != params.UserID {
+		return nil, nil, entity.ErrOTPNotFound
+	}
+
+	now := time.Now()
+	if err := ensureOTPActive(otp, now); err != nil {
+		return otp, nil, err
+	}
+	if !otp.CanResend() {
+		return otp, nil, entity.ErrOTPResendLimit
+	}
+
+	if o.policy.Resend.Rotate {
+		resent, err := o.rotate(ctx, otp, params.Channel, now)
+		return otp, resent, err
+	}
+
+	// The transaction may be run again, which must not count the resend twice
+	resendCount := otp.ResendCount + 1
+	err = o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		incremented, err := o.otpRepo.IncrementResendCount(ctx, otp)
+		if err != nil {
+			return fmt.Errorf("failed to count OTP resend: %w", err)
+		}
+		// The OTP was validated, expired or resent up to the limit since it was read
+		if !incremented {
+			return entity.ErrOTPInvalid
+		}
+		otp.ResendCount = resendCount
+		return appendEvent(ctx, o.outboxRepo, entity.NewOTPResentEvent(otp, nil, params.Channel, now))
+	})
+	if err != nil {
+		return otp, nil, err
+	}
+
+	return otp, otp, nil
+}
+
+// rotate supersedes an OTP with a new OTP for the same user, binding and purpose, which carries on
+// its resend count, and returns the new OTP
+func (o *otpUsecase) rotate(ctx context.Context, otp *entity.OTP, channel string, now time.Time) (*entity.OTP, error) {
+	otpCode, err := o.otpGenerator.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate OTP code: %w", err)
+	}
+
+	next := &entity.OTP{
+		UserID:      otp.UserID,
+		OTPCode:     otpCode,
+		Status:      entity.OTPStatusCreated,
+		ExpiresAt:   now.Add(otpValidityDuration),
+		BindingHash: otp.BindingHash,
+		Purpose:     otp.Purpose,
+		ClientID:    otp.ClientID,
+		ResendCount: otp.ResendCount + 1,
+		ResendLimit: otp.ResendLimit,
+	}
+	err = o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		revoked, err := o.markOTPAsRevoked(ctx, otp, now, entity.RevokeReasonSuperseded)
+		if err != nil {
+			return fmt.Errorf("failed to supersede OTP: %w", err)
+		}
+		// The OTP was validated or expired since it was read
+		if !revoked {
+			return entity.ErrOTPInvalid
+		}
+		if err := o.otpRepo.Create(ctx, next); err != nil {
+			return err
+		}
+		if err := appendEvent(ctx, o.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPRevoked, otp, now)); err != nil {
+			return err
+		}
+		return appendEvent(ctx, o.outboxRepo, entity.NewOTPResentEvent(next, otp, channel, now))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// List retrieves a page of up to limit OTPs matching the filter, newest first, starting after
+// the given cursor or from the most recent OTP if the cursor is empty.
+// Returns entity.ErrInvalidRequest if the cursor is malformed or the created-at range is empty.
+func (o *otpUsecase) List(ctx context.Context, filter entity.OTPFilter, cursor string, limit int) (*entity.OTPPage, error) {
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, entity.ErrInvalidRequest
+	}
+
+	beforeID, err := decodeOTPCursor(cursor)
+	if err != nil {
+		return nil, entity.ErrInvalidRequest
+	}
+
+	// One more OTP than requested tells whether there is a next page
+	otps, err := o.otpRepo.List(ctx, filter, beforeID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OTPs: %w", err)
+	}
+
+	page := &entity.OTPPage{OTPs: otps}
+	if len(otps) > limit {
+		page.OTPs = otps[:limit]
+		page.NextCursor = encodeOTPCursor(page.OTPs[limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// CountByStatus counts the stored OTPs of every status, in lifecycle order.
+// Statuses without OTPs are counted as zero.
+func (o *otpUsecase) CountByStatus(ctx context.Context) ([]entity.OTPStatusCount, error) {
+	counts, err := o.otpRepo.CountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count OTPs: %w", err)
+	}
+
+	statuses := entity.OTPStatuses()
+	statusCounts := make([]entity.OTPStatusCount, 0, len(statuses))
+	for _, status := range statuses {
+		statusCounts = append(statusCounts, entity.OTPStatusCount{Status: status, Count: counts[status]})
+	}
+
+	return statusCounts, nil
+}
+
+// ensureUserNotLocked returns entity.ErrUserLocked if the user is currently locked out.
+// It returns the user's lockout ledger, or nil if no failures have been recorded.
+func (o *otpUsecase) ensureUserNotLocked(ctx context.Context, userID string) (*entity.UserLockout, error) {
+	lockout, err := o.userLockoutRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserLockoutNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if lockout.IsLocked(time.Now()) {
+		return nil, entity.ErrUserLocked
+	}
+
+	return lockout, nil
+}
+
+// recordFailedAttempt adds a failure to the user's ledger and locks the user out
+// once the configured number of failures has been reached
+func (o *otpUsecase) recordFailedAttempt(ctx context.Context, userID string) error {
+	return o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		lockout, err := o.userLockoutRepo.IncrementFailedAttempts(ctx, userID, now)
+		if err != nil {
+			return err
+		}
+
+		if lockout.FailedAttempts < o.policy.Lockout.MaxFailedAttempts {
+			return nil
+		}
+
+		lockedUntil := now.Add(o.policy.Lockout.Duration)
+		if err := o.userLockoutRepo.Lock(ctx, userID, lockedUntil); err != nil {
+			return err
+		}
+		return appendEvent(ctx, o.outboxRepo, entity.NewUserLockedEvent(userID, entity.RequestMetadataFromContext(ctx).ClientID, lockedUntil, now))
+	})
+}
+
+// ensureOTPActive returns the error telling why an OTP can no longer be validated, if any
+func ensureOTPActive(otp *entity.OTP, now time.Time) error {
+	switch {
+	case otp.Status == entity.OTPStatusValidated:
+		return entity.ErrOTPUsed
+	case otp.Status == entity.OTPStatusRevoked:
+		return entity.ErrOTPRevoked
+	case otp.Status == entity.OTPStatusExpired || now.After(otp.ExpiresAt):
+		return entity.ErrOTPExpired
+	}
+
+	return nil
+}
+
+// validateOTPStatus checks if OTP is expired, revoked or already used
 func (o *otpUsecase) validateOTPStatus(ctx context.Context, otp *entity.OTP) error {
 	now := time.Now()
 
@@ -85,11 +547,24 @@ func (o *otpUsecase) validateOTPStatus(ctx context.Context, otp *entity.OTP) err
 		return entity.ErrOTPUsed
 	}
 
+	// A revoked OTP stays revoked once it would have expired
+	if otp.Status == entity.OTPStatusRevoked {
+		return entity.ErrOTPRevoked
+	}
+
 	// Check expiration
 	if now.After(otp.ExpiresAt) {
 		if otp.Status != entity.OTPStatusExpired {
 			otp.Status = entity.OTPStatusExpired
-			if err := o.otpRepo.Update(ctx, otp); err != nil {
+			err := o.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+				expired, err := o.otpRepo.Update(ctx, otp)
+				// The OTP left the created status since it was read, and its event was published then
+				if err != nil || !expired {
+					return err
+				}
+				return appendEvent(ctx, o.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPExpired, otp, now))
+			})
+			if err != nil {
 				return err
 			}
 		}
@@ -99,10 +574,101 @@ func (o *otpUsecase) validateOTPStatus(ctx context.Context, otp *entity.OTP) err
 	return nil
 }
 
-// markOTPAsValidated updates OTP status to used
-func (o *otpUsecase) markOTPAsValidated(ctx context.Context, otp *entity.OTP) error {
+// markOTPAsValidated updates OTP status to used, remembering which session validated it,
+// provided it is still created, and reports whether it was validated
+func (o *otpUsecase) markOTPAsValidated(ctx context.Context, otp *entity.OTP, sessionID string) (bool, error) {
 	now := time.Now()
-	otp.Status = entity.OTPStatusValidated
-	otp.ValidatedAt = &now
-	return o.otpRepo.Update(ctx, otp)
+	validated := *otp
+	validated.Status = entity.OTPStatusValidated
+	validated.ValidatedAt = &now
+	if sessionID != "" {
+		validated.ValidatedSessionHash = hashIdentifier(sessionID)
+	}
+
+	ok, err := o.otpRepo.Update(ctx, &validated)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	*otp = validated
+	return true, nil
+}
+
+// markOTPAsRevoked updates OTP status to revoked, provided it is still created,
+// and reports whether it was revoked
+func (o *otpUsecase) markOTPAsRevoked(ctx context.Context, otp *entity.OTP, now time.Time, reason string) (bool, error) {
+	revoked, err := o.otpRepo.MarkRevoked(ctx, otp, now, reason)
+	if err != nil || !revoked {
+		return false, err
+	}
+
+	otp.Status = entity.OTPStatusRevoked
+	otp.RevokedAt = &now
+	otp.RevokeReason = reason
+	return true, nil
+}
+
+// isValidationRetry reports whether the attempt repeats a successful validation of the OTP
+// by the same session within the grace period
+func (o *otpUsecase) isValidationRetry(otp *entity.OTP, sessionID string) bool {
+	if otp.Status != entity.OTPStatusValidated || otp.ValidatedAt == nil {
+		return false
+	}
+	if sessionID == "" || otp.ValidatedSessionHash == "" {
+		return false
+	}
+	if time.Since(*otp.ValidatedAt) > o.policy.ValidationGracePeriod {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(otp.ValidatedSessionHash), []byte(hashIdentifier(sessionID))) == 1
+}
+
+// matchesBinding reports whether the binding supplied with a validation attempt
+// matches the one the OTP was requested with. An unbound OTP matches any attempt.
+func (o *otpUsecase) matchesBinding(otp *entity.OTP, bindingID string) bool {
+	if !otp.IsBound() {
+		return true
+	}
+	if bindingID == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(otp.BindingHash), []byte(hashIdentifier(bindingID))) == 1
+}
+
+// audit records the outcome of an operation on an OTP of a user in the audit log
+func (o *otpUsecase) audit(ctx context.Context, action entity.AuditAction, userID string, otp *entity.OTP, err error) {
+	var otpID uint64
+	if otp != nil {
+		otpID = otp.ID
+	}
+
+	o.auditRecorder.Record(ctx, newAuditEvent(action, userID, otpID, err))
+}
+
+// hashIdentifier returns the hex encoded SHA-256 hash of a client supplied identifier,
+// so that the identifier itself is never stored
+func hashIdentifier(identifier string) string {
+	hash := sha256.Sum256([]byte(identifier))
+	return hex.EncodeToString(hash[:])
+}
+
+// encodeOTPCursor returns the opaque cursor of the page following the OTP with the given ID
+func encodeOTPCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(id, 10)))
+}
+
+// decodeOTPCursor returns the ID of the OTP a page starts after, or zero for an empty cursor
+func decodeOTPCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(string(decoded), 10, 64)
 }
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// partitionSpan is the range of creation times of the OTPs held by a partition
+const partitionSpan = 24 * time.Hour
+
+// PartitionPolicy configures the maintenance of the daily partitions of the stored OTPs.
+type PartitionPolicy struct {
+	// Lookahead is how far ahead of the current time partitions are created, so that new OTPs
+	// never land past the last partition, from where they could only be moved by copying them.
+	Lookahead time.Duration
+	// Retention is how long after its last OTP was created a partition is dropped.
+	// It must exceed the validity of the OTPs and the retention period of the sweeper.
+	Retention time.Duration
+}
+
+type otpPartitionUsecase struct {
+	partitionRepo OTPPartitionRepository
+	policy        PartitionPolicy
+}
+
+func NewOTPPartitionUsecase(partitionRepo OTPPartitionRepository, policy PartitionPolicy) *otpPartitionUsecase {
+	return &otpPartitionUsecase{
+		partitionRepo: partitionRepo,
+		policy:        policy,
+	}
+}
+
+// MaintainPartitions creates the daily partitions up to the lookahead, then drops the partitions
+// past the retention period, and returns the number of partitions it created and dropped.
+// A partition missed while the maintenance was not run is not created afterwards,
+// the OTPs of the days since the last partition are held by the partition of the current day.
+func (u *otpPartitionUsecase) MaintainPartitions(ctx context.Context) (int, int, error) {
+	now := time.Now().UTC()
+	partitions, err := u.partitionRepo.ListPartitions(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var covered time.Time
+	if len(partitions) > 0 {
+		covered = partitions[len(partitions)-1].Before
+	}
+
+	// Every partition ends at midnight UTC, starting with the one of the current day
+	var bounds []time.Time
+	horizon := now.Add(u.policy.Lookahead)
+	for bound := now.Truncate(partitionSpan).Add(partitionSpan); !covered.After(horizon); bound = bound.Add(partitionSpan) {
+		if bound.After(covered) {
+			bounds = append(bounds, bound)
+			covered = bound
+		}
+	}
+	if len(bounds) > 0 {
+		if err := u.partitionRepo.AddPartitions(ctx, bounds); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	cutoff := now.Add(-u.policy.Retention)
+	var expired []entity.OTPPartition
+	for _, partition := range partitions {
+		if partition.Before.After(cutoff) {
+			break
+		}
+		expired = append(expired, partition)
+	}
+	if len(expired) > 0 {
+		if err := u.partitionRepo.DropPartitions(ctx, expired); err != nil {
+			return len(bounds), 0, err
+		}
+	}
+
+	return len(bounds), len(expired), nil
+}
@@ -0,0 +1,145 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var partitionPolicy = usecase.PartitionPolicy{
+	Lookahead: 2 * 24 * time.Hour,
+	Retention: 3 * 24 * time.Hour,
+}
+
+// partitionDay returns the midnight UTC that is the given number of days after the current day
+func partitionDay(days int) time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, days)
+}
+
+// partitionsBefore returns partitions bounded by the midnights the given numbers of days after the current day
+func partitionsBefore(days ...int) []entity.OTPPartition {
+	partitions := make([]entity.OTPPartition, 0, len(days))
+	for _, day := range days {
+		before := partitionDay(day)
+		partitions = append(partitions, entity.OTPPartition{Name: "p_before_" + before.Format("20060102"), Before: before})
+	}
+	return partitions
+}
+
+func TestOTPPartitionUsecase_MaintainPartitions(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockDependency func(partitionRepo *mock.MockOTPPartitionRepository)
+		assertFn       func(created int, dropped int, err error)
+	}{
+		{
+			name: "should create the partitions up to the lookahead when there is none",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(nil, nil)
+				partitionRepo.EXPECT().
+					AddPartitions(gomock.Any(), []time.Time{partitionDay(1), partitionDay(2), partitionDay(3)}).
+					Return(nil)
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, created)
+				assert.Zero(t, dropped)
+			},
+		},
+		{
+			name: "should create the partitions following the last one and drop the ones past the retention period",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitions := partitionsBefore(-4, -3, -2, -1, 0, 1, 2)
+				gomock.InOrder(
+					partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(partitions, nil),
+					partitionRepo.EXPECT().AddPartitions(gomock.Any(), []time.Time{partitionDay(3)}).Return(nil),
+					partitionRepo.EXPECT().DropPartitions(gomock.Any(), partitions[:2]).Return(nil),
+				)
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, created)
+				assert.Equal(t, 2, dropped)
+			},
+		},
+		{
+			name: "should not create a partition for every day missed since the last one",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(partitionsBefore(-2), nil)
+				partitionRepo.EXPECT().
+					AddPartitions(gomock.Any(), []time.Time{partitionDay(1), partitionDay(2), partitionDay(3)}).
+					Return(nil)
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, created)
+				assert.Zero(t, dropped)
+			},
+		},
+		{
+			name: "should do nothing when the partitions are up to date",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(partitionsBefore(1, 2, 3), nil)
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.NoError(t, err)
+				assert.Zero(t, created)
+				assert.Zero(t, dropped)
+			},
+		},
+		{
+			name: "should not drop partitions if creating them fails",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(partitionsBefore(-4, 1, 2), nil)
+				partitionRepo.EXPECT().AddPartitions(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.EqualError(t, err, "db error")
+				assert.Zero(t, created)
+				assert.Zero(t, dropped)
+			},
+		},
+		{
+			name: "should return the partitions created if dropping fails",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(partitionsBefore(-4, 1, 2), nil)
+				partitionRepo.EXPECT().AddPartitions(gomock.Any(), gomock.Any()).Return(nil)
+				partitionRepo.EXPECT().DropPartitions(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.EqualError(t, err, "db error")
+				assert.Equal(t, 1, created)
+				assert.Zero(t, dropped)
+			},
+		},
+		{
+			name: "should return error if listing the partitions fails",
+			mockDependency: func(partitionRepo *mock.MockOTPPartitionRepository) {
+				partitionRepo.EXPECT().ListPartitions(gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			assertFn: func(created int, dropped int, err error) {
+				assert.EqualError(t, err, "db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			partitionRepo := mock.NewMockOTPPartitionRepository(ctrl)
+			tt.mockDependency(partitionRepo)
+
+			usc := usecase.NewOTPPartitionUsecase(partitionRepo, partitionPolicy)
+			tt.assertFn(usc.MaintainPartitions(context.Background()))
+		})
+	}
+}
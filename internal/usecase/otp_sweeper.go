@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// SweepPolicy configures the background sweeping of stale OTPs.
+type SweepPolicy struct {
+	// BatchSize is the maximum number of OTPs updated or deleted by a single statement.
+	BatchSize int
+	// Retention is how long after expiring an OTP is kept before it is deleted.
+	Retention time.Duration
+}
+
+type otpSweeperUsecase struct {
+	txManager       TransactionManager
+	otpRepo         OTPRepository
+	outboxRepo      OutboxRepository
+	idempotencyRepo IdempotencyRepository
+	policy          SweepPolicy
+
+	// purgedBefore is the expiry time before which the last complete purge deleted every OTP,
+	// so that the next purge only searches the OTPs created since. Zero until a purge completed.
+	purgedBefore time.Time
+}
+
+func NewOTPSweeperUsecase(
+	txManager TransactionManager,
+	otpRepo OTPRepository,
+	outboxRepo OutboxRepository,
+	idempotencyRepo IdempotencyRepository,
+	policy SweepPolicy,
+) *otpSweeperUsecase {
+	return &otpSweeperUsecase{
+		txManager:       txManager,
+		otpRepo:         otpRepo,
+		outboxRepo:      outboxRepo,
+		idempotencyRepo: idempotencyRepo,
+		policy:          policy,
+	}
+}
+
+// ExpireStale marks every created OTP that is past its expiry as expired, batch by batch,
+// and returns the number of OTPs it marked.
+// The OTPs that expired longer ago than the retention period are left to PurgeStale.
+func (s *otpSweeperUsecase) ExpireStale(ctx context.Context) (int64, error) {
+	var (
+		now = time.Now()
+		// An OTP that expired by now was created an OTP lifetime before, and one that expired
+		// before the retention period is deleted rather than marked
+		createdFrom = now.Add(-s.policy.Retention - otpValidityDuration - clockSkewMargin)
+		createdTo   = now.Add(-otpValidityDuration + clockSkewMargin)
+	)
+	return s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+		return s.expireBatch(ctx, now, createdFrom, createdTo)
+	})
+}
+
+// expireBatch marks a batch of expired OTPs as such and publishes an event for each of them,
+// in a single transaction.
+func (s *otpSweeperUsecase) expireBatch(ctx context.Context, now time.Time, createdFrom time.Time, createdTo time.Time) (int64, error) {
+	var expired int64
+	err := s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		otps, err := s.otpRepo.ListExpirable(ctx, now, createdFrom, createdTo, s.policy.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		if err := s.otpRepo.MarkExpired(ctx, otps); err != nil {
+			return err
+		}
+
+		for _, otp := range otps {
+			otp.Status = entity.OTPStatusExpired
+			if err := appendEvent(ctx, s.outboxRepo, entity.NewOTPEvent(entity.EventTypeOTPExpired, otp, now)); err != nil {
+				return err
+			}
+		}
+
+		expired = int64(len(otps))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// PurgeStale deletes every OTP that expired longer ago than the retention period, batch by batch,
+// and returns the number of OTPs it deleted.
+func (s *otpSweeperUsecase) PurgeStale(ctx context.Context) (int64, error) {
+	var (
+		before = time.Now().Add(-s.policy.Retention)
+		// The OTPs that expired before the last complete purge are gone, and the others expired an OTP lifetime
+		// after they were created
+		createdFrom time.Time
+		createdTo   = before.Add(-otpValidityDuration + clockSkewMargin)
+	)
+	if !s.purgedBefore.IsZero() {
+		createdFrom = s.purgedBefore.Add(-otpValidityDuration - clockSkewMargin)
+	}
+
+	purged, err := s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+		return s.otpRepo.DeleteExpiredBefore(ctx, before, createdFrom, createdTo, s.policy.BatchSize)
+	})
+	if err != nil {
+		return purged, err
+	}
+
+	s.purgedBefore = before
+	return purged, nil
+}
+
+// PurgeIdempotencyKeys deletes every idempotency key past its replay window, batch by batch,
+// and returns the number of keys it deleted.
+func (s *otpSweeperUsecase) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	now := time.Now()
+	return s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+		return s.idempotencyRepo.DeleteExpiredBefore(ctx, now, s.policy.BatchSize)
+	})
+}
+
+// inBatches runs the given batch until it affects fewer rows than the batch size,
+// so that no single statement holds locks on a large part of the table.
+// It stops early when the context is cancelled and returns the total number of affected rows.
+func (s *otpSweeperUsecase) inBatches(ctx context.Context, batch func(ctx context.Context) (int64, error)) (int64, error) {
+	// No batch would ever affect fewer rows than a batch size below one
+	if s.policy.BatchSize <= 0 {
+		return 0, errors.New("sweep batch size must be positive")
+	}
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		affected, err := batch(ctx)
+		total += affected
+		if err != nil {
+			return total, err
+		}
+
+		if affected < int64(s.policy.BatchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var sweepPolicy = usecase.SweepPolicy{
+	BatchSize: 2,
+	Retention: 24 * time.Hour,
+}
+
+type sweeperDependency struct {
+	txManager       *mock.MockTransactionManager
+	otpRepo         *mock.MockOTPRepository
+	outboxRepo      *mock.MockOutboxRepository
+	idempotencyRepo *mock.MockIdempotencyRepository
+}
+
+func newSweeperDependency(ctrl *gomock.Controller) *sweeperDependency {
+	return &sweeperDependency{
+		txManager:       mock.NewMockTransactionManager(ctrl),
+		otpRepo:         mock.NewMockOTPRepository(ctrl),
+		outboxRepo:      mock.NewMockOutboxRepository(ctrl),
+		idempotencyRepo: mock.NewMockIdempotencyRepository(ctrl),
+	}
+}
+
+// expirableOTPs returns created OTPs with the given IDs that are past their expiry
+func expirableOTPs(ids ...uint64) []*entity.OTP {
+	otps := make([]*entity.OTP, 0, len(ids))
+	for _, id := range ids {
+		otps = append(otps, &entity.OTP{
+			ID:        id,
+			UserID:    "user-1",
+			Status:    entity.OTPStatusCreated,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+	}
+	return otps
+}
+
+func TestOTPSweeperUsecase_ExpireStale(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockDependency func(dep *sweeperDependency)
+		assertFn       func(int64, error)
+	}{
+		{
+			name: "should keep expiring batches until a batch is not full",
+			mockDependency: func(dep *sweeperDependency) {
+				first, second := expirableOTPs(1, 2), expirableOTPs(3)
+				gomock.InOrder(
+					dep.otpRepo.EXPECT().
+						ListExpirable(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+						DoAndReturn(func(ctx context.Context, now time.Time, createdFrom time.Time, createdTo time.Time, limit int) ([]*entity.OTP, error) {
+							// Only the OTPs created over an OTP lifetime before and not yet purged can expire
+							assert.Equal(t, now.Add(-24*time.Hour-3*time.Minute), createdFrom)
+							assert.Equal(t, now.Add(-time.Minute), createdTo)
+							return first, nil
+						}),
+					dep.otpRepo.EXPECT().
+						MarkExpired(gomock.Any(), first).
+						Return(nil),
+					dep.otpRepo.EXPECT().
+						ListExpirable(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+						Return(second, nil),
+					dep.otpRepo.EXPECT().
+						MarkExpired(gomock.Any(), second).
+						Return(nil),
+				)
+				for i := 0; i < 3; i++ {
+					expectEvent(t, dep.outboxRepo, entity.EventTypeOTPExpired)
+				}
+			},
+			assertFn: func(expired int64, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, int64(3), expired)
+			},
+		},
+		{
+			name: "should return the OTPs expired so far if a batch fails",
+			mockDependency: func(dep *sweeperDependency) {
+				first := expirableOTPs(1, 2)
+				gomock.InOrder(
+					dep.otpRepo.EXPECT().
+						ListExpirable(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+						Return(first, nil),
+					dep.otpRepo.EXPECT().
+						MarkExpired(gomock.Any(), first).
+						Return(nil),
+					dep.otpRepo.EXPECT().
+						ListExpirable(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+						Return(nil, errors.New("db error")),
+				)
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPExpired)
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPExpired)
+			},
+			assertFn: func(expired int64, err error) {
+				assert.EqualError(t, err, "db error")
+				assert.Equal(t, int64(2), expired)
+			},
+		},
+		{
+			name: "should not count a batch whose events cannot be stored",
+			mockDependency: func(dep *sweeperDependency) {
+				batch := expirableOTPs(1)
+				dep.otpRepo.EXPECT().
+					ListExpirable(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+					Return(batch, nil)
+				dep.otpRepo.EXPECT().
+					MarkExpired(gomock.Any(), batch).
+					Return(nil)
+				dep.outboxRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(errors.New("db error"))
+			},
+			assertFn: func(expired int64, err error) {
+				assert.EqualError(t, err, "db error")
+				assert.Zero(t, expired)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := newSweeperDependency(ctrl)
+			tt.mockDependency(dep)
+			runInTransaction(dep.txManager)
+
+			usc := usecase.NewOTPSweeperUsecase(dep.txManager, dep.otpRepo, dep.outboxRepo, dep.idempotencyRepo, sweepPolicy)
+			tt.assertFn(usc.ExpireStale(context.Background()))
+		})
+	}
+}
+
+func TestOTPSweeperUsecase_PurgeStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var firstBefore time.Time
+	dep := newSweeperDependency(ctrl)
+	gomock.InOrder(
+		dep.otpRepo.EXPECT().
+			DeleteExpiredBefore(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+			DoAndReturn(func(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error) {
+				// Nothing is known to be purged yet, so the creation time is only bounded above
+				assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Second)
+				assert.True(t, createdFrom.IsZero())
+				assert.Equal(t, before.Add(-time.Minute), createdTo)
+				firstBefore = before
+				return 1, nil
+			}),
+		dep.otpRepo.EXPECT().
+			DeleteExpiredBefore(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+			DoAndReturn(func(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error) {
+				// The OTPs that expired before the first purge are gone
+				assert.Equal(t, firstBefore.Add(-3*time.Minute), createdFrom)
+				assert.Equal(t, before.Add(-time.Minute), createdTo)
+				return 0, nil
+			}),
+	)
+
+	usc := usecase.NewOTPSweeperUsecase(dep.txManager, dep.otpRepo, dep.outboxRepo, dep.idempotencyRepo, sweepPolicy)
+	purged, err := usc.PurgeStale(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	purged, err = usc.PurgeStale(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+}
+
+func TestOTPSweeperUsecase_PurgeIdempotencyKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := newSweeperDependency(ctrl)
+	gomock.InOrder(
+		dep.idempotencyRepo.EXPECT().
+			DeleteExpiredBefore(gomock.Any(), gomock.Any(), 2).
+			DoAndReturn(func(ctx context.Context, before time.Time, limit int) (int64, error) {
+				assert.WithinDuration(t, time.Now(), before, time.Second)
+				return 2, nil
+			}),
+		dep.idempotencyRepo.EXPECT().
+			DeleteExpiredBefore(gomock.Any(), gomock.Any(), 2).
+			Return(int64(1), nil),
+	)
+
+	usc := usecase.NewOTPSweeperUsecase(dep.txManager, dep.otpRepo, dep.outboxRepo, dep.idempotencyRepo, sweepPolicy)
+	purged, err := usc.PurgeIdempotencyKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestOTPSweeperUsecase_StopsWhenContextIsCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dep := newSweeperDependency(ctrl)
+	dep.otpRepo.EXPECT().
+		DeleteExpiredBefore(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2).
+		DoAndReturn(func(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error) {
+			cancel()
+			return 2, nil
+		})
+
+	usc := usecase.NewOTPSweeperUsecase(dep.txManager, dep.otpRepo, dep.outboxRepo, dep.idempotencyRepo, sweepPolicy)
+	purged, err := usc.PurgeStale(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestOTPSweeperUsecase_RejectsNonPositiveBatchSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := newSweeperDependency(ctrl)
+	policy := sweepPolicy
+	policy.BatchSize = 0
+
+	usc := usecase.NewOTPSweeperUsecase(dep.txManager, dep.otpRepo, dep.outboxRepo, dep.idempotencyRepo, policy)
+	purged, err := usc.PurgeStale(context.Background())
+	assert.EqualError(t, err, "sweep batch size must be positive")
+	assert.Zero(t, purged)
+}
@@ -2,7 +2,10 @@ package usecase_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -13,15 +16,67 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var otpPolicy = usecase.OTPPolicy{
+	Lockout: usecase.LockoutPolicy{
+		MaxFailedAttempts: 3,
+		Duration:          15 * time.Minute,
+	},
+	ValidationGracePeriod: 30 * time.Second,
+}
+
+// identifierHash returns the hash under which a client supplied identifier is stored on an OTP
+func identifierHash(identifier string) string {
+	hash := sha256.Sum256([]byte(identifier))
+	return hex.EncodeToString(hash[:])
+}
+
+// runInTransaction makes a mocked TransactionManager run the transaction inline
+func runInTransaction(txManager *mock.MockTransactionManager) {
+	txManager.EXPECT().
+		WithTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+}
+
+// expectEvent expects a pending event of the given type to be stored in the outbox
+func expectEvent(t *testing.T, outboxRepo *mock.MockOutboxRepository, eventType entity.EventType) {
+	outboxRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+			assert.Equal(t, eventType, event.EventType)
+			assert.Equal(t, entity.OutboxStatusPending, event.Status)
+			return nil
+		})
+}
+
+// otpWithID matches the OTP of the given ID, whatever its other fields
+type otpWithID uint64
+
+func (id otpWithID) Matches(x interface{}) bool {
+	otp, ok := x.(*entity.OTP)
+	return ok && otp != nil && otp.ID == uint64(id)
+}
+
+func (id otpWithID) String() string {
+	return fmt.Sprintf("is the OTP %d", uint64(id))
+}
+
 func TestOtpUsecase_Create(t *testing.T) {
 	type useCaseDependency struct {
-		otpRepo      *mock.MockOTPRepository
-		otpGenerator *mock.MockOTPGenerator
+		txManager       *mock.MockTransactionManager
+		otpRepo         *mock.MockOTPRepository
+		userLockoutRepo *mock.MockUserLockoutRepository
+		outboxRepo      *mock.MockOutboxRepository
+		otpGenerator    *mock.MockOTPGenerator
+		auditRecorder   *mock.MockAuditRecorder
 	}
 
 	tests := []struct {
 		name           string
 		userID         string
+		bindingID      string
 		mockDependency func(dep *useCaseDependency)
 		assertFn       func(*entity.OTP, error)
 	}{
@@ -29,8 +84,11 @@ func TestOtpUsecase_Create(t *testing.T) {
 			name:   "should return error when repository Create fails",
 			userID: "user-1",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, entity.ErrUserLockoutNotFound)
 				dep.otpRepo.EXPECT().
-					GetLastByUserID(gomock.Any(), "user-1").
+					GetLastByUserID(gomock.Any(), "user-1", gomock.Any()).
 					Return(nil, nil)
 				dep.otpGenerator.EXPECT().
 					Generate().
@@ -49,8 +107,11 @@ func TestOtpUsecase_Create(t *testing.T) {
 			name:   "should create otp successfully",
 			userID: "user-1",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, entity.ErrUserLockoutNotFound)
 				dep.otpRepo.EXPECT().
-					GetLastByUserID(gomock.Any(), "user-1").
+					GetLastByUserID(gomock.Any(), "user-1", gomock.Any()).
 					Return(nil, nil)
 				dep.otpGenerator.EXPECT().
 					Generate().
@@ -61,9 +122,18 @@ func TestOtpUsecase_Create(t *testing.T) {
 						assert.Equal(t, "user-1", otp.UserID)
 						assert.Equal(t, "123456", otp.OTPCode)
 						assert.Equal(t, entity.OTPStatusCreated, otp.Status)
+						assert.Equal(t, "client-1", otp.ClientID)
 						assert.WithinDuration(t, time.Now().Add(2*time.Minute), otp.ExpiresAt, 2*time.Second)
 						return nil
 					})
+				dep.outboxRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Equal(t, entity.EventTypeOTPCreated, event.EventType)
+						// The event is only published to the webhooks of the client that requested the OTP
+						assert.Equal(t, "client-1", event.ClientID)
+						return nil
+					})
 			},
 			assertFn: func(otp *entity.OTP, err error) {
 				assert.NotNil(t, otp)
@@ -72,12 +142,40 @@ func TestOtpUsecase_Create(t *testing.T) {
 				assert.Equal(t, "123456", otp.OTPCode)
 			},
 		},
+		{
+			name:   "should not create otp if the event cannot be stored",
+			userID: "user-1",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					GetLastByUserID(gomock.Any(), "user-1", gomock.Any()).
+					Return(nil, entity.ErrOTPNotFound)
+				dep.otpGenerator.EXPECT().
+					Generate().
+					Return("123456", nil)
+				dep.otpRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+				dep.outboxRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(errors.New("db error"))
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.EqualError(t, err, "db error")
+			},
+		},
 		{
 			name:   "should return rate limit error if OTP requested too soon",
 			userID: "user-1",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, entity.ErrUserLockoutNotFound)
 				dep.otpRepo.EXPECT().
-					GetLastByUserID(gomock.Any(), "user-1").
+					GetLastByUserID(gomock.Any(), "user-1", gomock.Any()).
 					Return(&entity.OTP{
 						UserID:    "user-1",
 						OTPCode:   "654321",
@@ -92,6 +190,47 @@ func TestOtpUsecase_Create(t *testing.T) {
 				assert.Equal(t, entity.ErrOTPRateLimitExceeded, err)
 			},
 		},
+		{
+			name:      "should store the binding hash when a binding is supplied",
+			userID:    "user-1",
+			bindingID: "device-1",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					GetLastByUserID(gomock.Any(), "user-1", gomock.Any()).
+					Return(nil, entity.ErrOTPNotFound)
+				dep.otpGenerator.EXPECT().
+					Generate().
+					Return("123456", nil)
+				dep.otpRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, otp *entity.OTP) error {
+						assert.Equal(t, identifierHash("device-1"), otp.BindingHash)
+						return nil
+					})
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPCreated)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.True(t, otp.IsBound())
+			},
+		},
+		{
+			name:   "should return user locked error if user is locked out",
+			userID: "user-1",
+			mockDependency: func(dep *useCaseDependency) {
+				lockedUntil := time.Now().Add(10 * time.Minute)
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(&entity.UserLockout{UserID: "user-1", LockedUntil: &lockedUntil}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrUserLocked, err)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -100,15 +239,25 @@ func TestOtpUsecase_Create(t *testing.T) {
 			defer ctrl.Finish()
 
 			dep := useCaseDependency{
-				otpRepo:      mock.NewMockOTPRepository(ctrl),
-				otpGenerator: mock.NewMockOTPGenerator(ctrl),
+				txManager:       mock.NewMockTransactionManager(ctrl),
+				otpRepo:         mock.NewMockOTPRepository(ctrl),
+				userLockoutRepo: mock.NewMockUserLockoutRepository(ctrl),
+				outboxRepo:      mock.NewMockOutboxRepository(ctrl),
+				otpGenerator:    mock.NewMockOTPGenerator(ctrl),
+				auditRecorder:   mock.NewMockAuditRecorder(ctrl),
 			}
 
 			tt.mockDependency(&dep)
+			runInTransaction(dep.txManager)
+			dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
 
-			usc := usecase.NewOtpUsecase(dep.otpRepo, dep.otpGenerator)
+			usc := usecase.NewOtpUsecase(dep.txManager, dep.otpRepo, dep.userLockoutRepo, dep.outboxRepo, dep.otpGenerator, dep.auditRecorder, otpPolicy)
 
-			otp, err := usc.Create(context.Background(), tt.userID)
+			ctx := entity.ContextWithRequestMetadata(context.Background(), entity.RequestMetadata{ClientID: "client-1"})
+			otp, err := usc.Create(ctx, entity.CreateOTPParams{
+				UserID:    tt.userID,
+				BindingID: tt.bindingID,
+			})
 
 			tt.assertFn(otp, err)
 		})
@@ -117,7 +266,11 @@ func TestOtpUsecase_Create(t *testing.T) {
 
 func TestOtpUsecase_Validate(t *testing.T) {
 	type useCaseDependency struct {
-		otpRepo *mock.MockOTPRepository
+		txManager       *mock.MockTransactionManager
+		otpRepo         *mock.MockOTPRepository
+		userLockoutRepo *mock.MockUserLockoutRepository
+		outboxRepo      *mock.MockOutboxRepository
+		auditRecorder   *mock.MockAuditRecorder
 	}
 
 	userID := "user-1"
@@ -125,6 +278,8 @@ func TestOtpUsecase_Validate(t *testing.T) {
 	tests := []struct {
 		name           string
 		otpCode        string
+		sessionID      string
+		bindingID      string
 		mockDependency func(dep *useCaseDependency)
 		assertFn       func(*entity.OTP, error)
 	}{
@@ -132,19 +287,49 @@ func TestOtpUsecase_Validate(t *testing.T) {
 			name:    "should return error if OTP not found",
 			otpCode: "000000",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
 				dep.otpRepo.EXPECT().
 					FindByUserIDAndCode(gomock.Any(), userID, "000000").
 					Return(nil, entity.ErrOTPNotFound)
+				dep.userLockoutRepo.EXPECT().
+					IncrementFailedAttempts(gomock.Any(), userID, gomock.Any()).
+					Return(&entity.UserLockout{UserID: userID, FailedAttempts: 1}, nil)
 			},
 			assertFn: func(otp *entity.OTP, err error) {
 				assert.Nil(t, otp)
 				assert.Equal(t, entity.ErrOTPNotFound, err)
 			},
 		},
+		{
+			name:    "should return error if OTP was revoked",
+			otpCode: "222222",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "222222").
+					Return(&entity.OTP{
+						UserID:    userID,
+						OTPCode:   "222222",
+						Status:    entity.OTPStatusRevoked,
+						ExpiresAt: time.Now().Add(time.Minute),
+					}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPRevoked, err)
+			},
+		},
 		{
 			name:    "should return error if OTP already validated",
 			otpCode: "111111",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
 				dep.otpRepo.EXPECT().
 					FindByUserIDAndCode(gomock.Any(), userID, "111111").
 					Return(&entity.OTP{
@@ -162,6 +347,9 @@ func TestOtpUsecase_Validate(t *testing.T) {
 			name:    "should return error if OTP expired",
 			otpCode: "222222",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
 				otp := &entity.OTP{
 					UserID:    userID,
 					OTPCode:   "222222",
@@ -173,7 +361,8 @@ func TestOtpUsecase_Validate(t *testing.T) {
 					Return(otp, nil)
 				dep.otpRepo.EXPECT().
 					Update(gomock.Any(), otp).
-					Return(nil) // update status to expired
+					Return(true, nil) // update status to expired
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPExpired)
 			},
 			assertFn: func(otp *entity.OTP, err error) {
 				assert.Nil(t, otp)
@@ -184,6 +373,9 @@ func TestOtpUsecase_Validate(t *testing.T) {
 			name:    "should validate OTP successfully",
 			otpCode: "333333",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
 				otp := &entity.OTP{
 					UserID:    userID,
 					OTPCode:   "333333",
@@ -195,11 +387,12 @@ func TestOtpUsecase_Validate(t *testing.T) {
 					Return(otp, nil)
 				dep.otpRepo.EXPECT().
 					Update(gomock.Any(), gomock.Any()).
-					DoAndReturn(func(ctx context.Context, updatedOTP *entity.OTP) error {
+					DoAndReturn(func(ctx context.Context, updatedOTP *entity.OTP) (bool, error) {
 						assert.Equal(t, entity.OTPStatusValidated, updatedOTP.Status)
 						assert.NotNil(t, updatedOTP.ValidatedAt)
-						return nil
+						return true, nil
 					})
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPValidated)
 			},
 			assertFn: func(otp *entity.OTP, err error) {
 				assert.NotNil(t, otp)
@@ -208,10 +401,38 @@ func TestOtpUsecase_Validate(t *testing.T) {
 				assert.NotNil(t, otp.ValidatedAt)
 			},
 		},
+		{
+			name:    "should reject the validation if the OTP was validated in the meantime",
+			otpCode: "333334",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				otp := &entity.OTP{
+					UserID:    userID,
+					OTPCode:   "333334",
+					Status:    entity.OTPStatusCreated,
+					ExpiresAt: time.Now().Add(1 * time.Minute),
+				}
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "333334").
+					Return(otp, nil)
+				dep.otpRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					Return(false, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPInvalid, err)
+			},
+		},
 		{
 			name:    "should return error if update fails when validating",
 			otpCode: "444444",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
 				otp := &entity.OTP{
 					UserID:    userID,
 					OTPCode:   "444444",
@@ -223,7 +444,7 @@ func TestOtpUsecase_Validate(t *testing.T) {
 					Return(otp, nil)
 				dep.otpRepo.EXPECT().
 					Update(gomock.Any(), gomock.Any()).
-					Return(errors.New("db update failed"))
+					Return(false, errors.New("db update failed"))
 			},
 			assertFn: func(otp *entity.OTP, err error) {
 				assert.Nil(t, otp)
@@ -235,6 +456,9 @@ func TestOtpUsecase_Validate(t *testing.T) {
 			name:    "should return error if update fails when marking expired OTP",
 			otpCode: "555555",
 			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
 				// Simulate an OTP that is expired and still in Created status
 				otp := &entity.OTP{
 					UserID:    userID,
@@ -248,7 +472,7 @@ func TestOtpUsecase_Validate(t *testing.T) {
 				// Simulate update failure when trying to mark it as expired
 				dep.otpRepo.EXPECT().
 					Update(gomock.Any(), otp).
-					Return(errors.New("db update failed"))
+					Return(false, errors.New("db update failed"))
 			},
 			assertFn: func(otp *entity.OTP, err error) {
 				assert.Nil(t, otp)
@@ -256,6 +480,275 @@ func TestOtpUsecase_Validate(t *testing.T) {
 				assert.EqualError(t, err, "db update failed") // error comes directly from repository
 			},
 		},
+		{
+			name:      "should store the session hash when validating with a session",
+			otpCode:   "123123",
+			sessionID: "session-1",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "123123").
+					Return(&entity.OTP{
+						UserID:    userID,
+						OTPCode:   "123123",
+						Status:    entity.OTPStatusCreated,
+						ExpiresAt: time.Now().Add(1 * time.Minute),
+					}, nil)
+				dep.otpRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, updatedOTP *entity.OTP) (bool, error) {
+						assert.Equal(t, identifierHash("session-1"), updatedOTP.ValidatedSessionHash)
+						return true, nil
+					})
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPValidated)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, entity.OTPStatusValidated, otp.Status)
+			},
+		},
+		{
+			name:      "should return the original success for a retry from the same session within the grace period",
+			otpCode:   "234234",
+			sessionID: "session-1",
+			mockDependency: func(dep *useCaseDependency) {
+				validatedAt := time.Now().Add(-5 * time.Second)
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "234234").
+					Return(&entity.OTP{
+						UserID:               userID,
+						OTPCode:              "234234",
+						Status:               entity.OTPStatusValidated,
+						ExpiresAt:            time.Now().Add(1 * time.Minute),
+						ValidatedAt:          &validatedAt,
+						ValidatedSessionHash: identifierHash("session-1"),
+					}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, entity.OTPStatusValidated, otp.Status)
+			},
+		},
+		{
+			name:      "should reject a retry from another session",
+			otpCode:   "345345",
+			sessionID: "session-2",
+			mockDependency: func(dep *useCaseDependency) {
+				validatedAt := time.Now().Add(-5 * time.Second)
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "345345").
+					Return(&entity.OTP{
+						UserID:               userID,
+						OTPCode:              "345345",
+						Status:               entity.OTPStatusValidated,
+						ExpiresAt:            time.Now().Add(1 * time.Minute),
+						ValidatedAt:          &validatedAt,
+						ValidatedSessionHash: identifierHash("session-1"),
+					}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPUsed, err)
+			},
+		},
+		{
+			name:      "should reject a retry from the same session after the grace period",
+			otpCode:   "456456",
+			sessionID: "session-1",
+			mockDependency: func(dep *useCaseDependency) {
+				validatedAt := time.Now().Add(-1 * time.Minute)
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "456456").
+					Return(&entity.OTP{
+						UserID:               userID,
+						OTPCode:              "456456",
+						Status:               entity.OTPStatusValidated,
+						ExpiresAt:            time.Now().Add(1 * time.Minute),
+						ValidatedAt:          &validatedAt,
+						ValidatedSessionHash: identifierHash("session-1"),
+					}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPUsed, err)
+			},
+		},
+		{
+			name:      "should validate a bound OTP with the matching binding",
+			otpCode:   "135135",
+			bindingID: "device-1",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "135135").
+					Return(&entity.OTP{
+						UserID:      userID,
+						OTPCode:     "135135",
+						Status:      entity.OTPStatusCreated,
+						ExpiresAt:   time.Now().Add(1 * time.Minute),
+						BindingHash: identifierHash("device-1"),
+					}, nil)
+				dep.otpRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					Return(true, nil)
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPValidated)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, entity.OTPStatusValidated, otp.Status)
+			},
+		},
+		{
+			name:      "should reject a bound OTP validated with another binding",
+			otpCode:   "246246",
+			bindingID: "device-2",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "246246").
+					Return(&entity.OTP{
+						UserID:      userID,
+						OTPCode:     "246246",
+						Status:      entity.OTPStatusCreated,
+						ExpiresAt:   time.Now().Add(1 * time.Minute),
+						BindingHash: identifierHash("device-1"),
+					}, nil)
+				dep.userLockoutRepo.EXPECT().
+					IncrementFailedAttempts(gomock.Any(), userID, gomock.Any()).
+					Return(&entity.UserLockout{UserID: userID, FailedAttempts: 1}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPBindingMismatch, err)
+			},
+		},
+		{
+			name:    "should reject a bound OTP validated without a binding",
+			otpCode: "357357",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "357357").
+					Return(&entity.OTP{
+						UserID:      userID,
+						OTPCode:     "357357",
+						Status:      entity.OTPStatusCreated,
+						ExpiresAt:   time.Now().Add(1 * time.Minute),
+						BindingHash: identifierHash("device-1"),
+					}, nil)
+				dep.userLockoutRepo.EXPECT().
+					IncrementFailedAttempts(gomock.Any(), userID, gomock.Any()).
+					Return(&entity.UserLockout{UserID: userID, FailedAttempts: 1}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPBindingMismatch, err)
+			},
+		},
+		{
+			name:    "should return user locked error if user is locked out",
+			otpCode: "666666",
+			mockDependency: func(dep *useCaseDependency) {
+				lockedUntil := time.Now().Add(10 * time.Minute)
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(&entity.UserLockout{UserID: userID, LockedUntil: &lockedUntil}, nil)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrUserLocked, err)
+			},
+		},
+		{
+			name:    "should lock user out when failed attempts reach the limit",
+			otpCode: "777777",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(&entity.UserLockout{UserID: userID, FailedAttempts: 2}, nil)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "777777").
+					Return(nil, entity.ErrOTPNotFound)
+				dep.userLockoutRepo.EXPECT().
+					IncrementFailedAttempts(gomock.Any(), userID, gomock.Any()).
+					Return(&entity.UserLockout{UserID: userID, FailedAttempts: 3}, nil)
+				dep.userLockoutRepo.EXPECT().
+					Lock(gomock.Any(), userID, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, userID string, lockedUntil time.Time) error {
+						assert.WithinDuration(t, time.Now().Add(otpPolicy.Lockout.Duration), lockedUntil, 2*time.Second)
+						return nil
+					})
+				expectEvent(t, dep.outboxRepo, entity.EventTypeUserLocked)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
+		{
+			name:    "should return error if recording failed attempt fails",
+			otpCode: "888888",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(nil, entity.ErrUserLockoutNotFound)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "888888").
+					Return(nil, entity.ErrOTPNotFound)
+				dep.userLockoutRepo.EXPECT().
+					IncrementFailedAttempts(gomock.Any(), userID, gomock.Any()).
+					Return(nil, errors.New("db error"))
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Contains(t, err.Error(), "failed to record failed attempt")
+			},
+		},
+		{
+			name:    "should reset failure ledger after successful validation",
+			otpCode: "999999",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.userLockoutRepo.EXPECT().
+					FindByUserID(gomock.Any(), userID).
+					Return(&entity.UserLockout{UserID: userID, FailedAttempts: 2}, nil)
+				dep.otpRepo.EXPECT().
+					FindByUserIDAndCode(gomock.Any(), userID, "999999").
+					Return(&entity.OTP{
+						UserID:    userID,
+						OTPCode:   "999999",
+						Status:    entity.OTPStatusCreated,
+						ExpiresAt: time.Now().Add(1 * time.Minute),
+					}, nil)
+				dep.otpRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					Return(true, nil)
+				dep.userLockoutRepo.EXPECT().
+					DeleteByUserID(gomock.Any(), userID).
+					Return(nil)
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPValidated)
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, entity.OTPStatusValidated, otp.Status)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -264,16 +757,596 @@ func TestOtpUsecase_Validate(t *testing.T) {
 			defer ctrl.Finish()
 
 			dep := useCaseDependency{
-				otpRepo: mock.NewMockOTPRepository(ctrl),
+				txManager:       mock.NewMockTransactionManager(ctrl),
+				otpRepo:         mock.NewMockOTPRepository(ctrl),
+				userLockoutRepo: mock.NewMockUserLockoutRepository(ctrl),
+				outboxRepo:      mock.NewMockOutboxRepository(ctrl),
+				auditRecorder:   mock.NewMockAuditRecorder(ctrl),
 			}
 
 			tt.mockDependency(&dep)
+			runInTransaction(dep.txManager)
+			dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
 
-			usc := usecase.NewOtpUsecase(dep.otpRepo, nil)
+			usc := usecase.NewOtpUsecase(dep.txManager, dep.otpRepo, dep.userLockoutRepo, dep.outboxRepo, nil, dep.auditRecorder, otpPolicy)
 
-			otp, err := usc.Validate(context.Background(), userID, tt.otpCode)
+			otp, err := usc.Validate(context.Background(), entity.ValidateOTPParams{
+				UserID:    userID,
+				OTPCode:   tt.otpCode,
+				SessionID: tt.sessionID,
+				BindingID: tt.bindingID,
+			})
 
 			tt.assertFn(otp, err)
 		})
 	}
 }
+
+func TestOtpUsecase_Validate_RecordsAuditEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		txManager       = mock.NewMockTransactionManager(ctrl)
+		otpRepo         = mock.NewMockOTPRepository(ctrl)
+		userLockoutRepo = mock.NewMockUserLockoutRepository(ctrl)
+		outboxRepo      = mock.NewMockOutboxRepository(ctrl)
+		auditRecorder   = mock.NewMockAuditRecorder(ctrl)
+	)
+
+	userLockoutRepo.EXPECT().
+		FindByUserID(gomock.Any(), "user-1").
+		Return(nil, entity.ErrUserLockoutNotFound)
+	otpRepo.EXPECT().
+		FindByUserIDAndCode(gomock.Any(), "user-1", "123456").
+		Return(&entity.OTP{ID: 42, UserID: "user-1", Status: entity.OTPStatusValidated}, nil)
+	auditRecorder.EXPECT().
+		Record(gomock.Any(), entity.AuditEvent{
+			UserID:  "user-1",
+			OTPID:   42,
+			Action:  entity.AuditActionOTPValidate,
+			Outcome: entity.AuditOutcomeFailure,
+			Reason:  entity.ErrOTPUsed.Code,
+		})
+
+	usc := usecase.NewOtpUsecase(txManager, otpRepo, userLockoutRepo, outboxRepo, nil, auditRecorder, otpPolicy)
+	otp, err := usc.Validate(context.Background(), entity.ValidateOTPParams{UserID: "user-1", OTPCode: "123456"})
+
+	assert.Nil(t, otp)
+	assert.Equal(t, entity.ErrOTPUsed, err)
+}
+
+func TestOtpUsecase_List(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	filter := entity.OTPFilter{UserID: "user-1", Status: entity.OTPStatusCreated}
+
+	tests := []struct {
+		name      string
+		filter    entity.OTPFilter
+		cursor    string
+		mockSetup func(*mock.MockOTPRepository)
+		assertFn  func(*entity.OTPPage, error)
+	}{
+		{
+			name:   "should return a cursor when there is a next page",
+			filter: filter,
+			mockSetup: func(otpRepo *mock.MockOTPRepository) {
+				otpRepo.EXPECT().
+					List(gomock.Any(), filter, uint64(0), 3).
+					Return([]*entity.OTP{{ID: 45}, {ID: 44}, {ID: 43}}, nil)
+			},
+			assertFn: func(page *entity.OTPPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []*entity.OTP{{ID: 45}, {ID: 44}}, page.OTPs)
+				assert.Equal(t, "NDQ", page.NextCursor) // OTP 44
+			},
+		},
+		{
+			name:   "should start after the cursor and return no cursor on the last page",
+			filter: filter,
+			cursor: "NDQ", // OTP 44
+			mockSetup: func(otpRepo *mock.MockOTPRepository) {
+				otpRepo.EXPECT().
+					List(gomock.Any(), filter, uint64(44), 3).
+					Return([]*entity.OTP{{ID: 43}}, nil)
+			},
+			assertFn: func(page *entity.OTPPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []*entity.OTP{{ID: 43}}, page.OTPs)
+				assert.Empty(t, page.NextCursor)
+			},
+		},
+		{
+			name:      "should reject a malformed cursor",
+			filter:    filter,
+			cursor:    "not-a-cursor",
+			mockSetup: func(otpRepo *mock.MockOTPRepository) {},
+			assertFn: func(page *entity.OTPPage, err error) {
+				assert.Nil(t, page)
+				assert.Equal(t, entity.ErrInvalidRequest, err)
+			},
+		},
+		{
+			name:      "should reject an empty created-at range",
+			filter:    entity.OTPFilter{CreatedFrom: &now, CreatedTo: &earlier},
+			mockSetup: func(otpRepo *mock.MockOTPRepository) {},
+			assertFn: func(page *entity.OTPPage, err error) {
+				assert.Nil(t, page)
+				assert.Equal(t, entity.ErrInvalidRequest, err)
+			},
+		},
+		{
+			name:   "should return error when the repository fails",
+			filter: filter,
+			mockSetup: func(otpRepo *mock.MockOTPRepository) {
+				otpRepo.EXPECT().
+					List(gomock.Any(), filter, uint64(0), 3).
+					Return(nil, errors.New("db error"))
+			},
+			assertFn: func(page *entity.OTPPage, err error) {
+				assert.Nil(t, page)
+				assert.EqualError(t, err, "failed to list OTPs: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			otpRepo := mock.NewMockOTPRepository(ctrl)
+			tt.mockSetup(otpRepo)
+
+			usc := usecase.NewOtpUsecase(nil, otpRepo, nil, nil, nil, nil, otpPolicy)
+			tt.assertFn(usc.List(context.Background(), tt.filter, tt.cursor, 2))
+		})
+	}
+}
+
+func TestOtpUsecase_CountByStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	otpRepo := mock.NewMockOTPRepository(ctrl)
+	otpRepo.EXPECT().CountByStatus(gomock.Any()).Return(map[entity.OTPStatus]int64{
+		entity.OTPStatusValidated: 40,
+		entity.OTPStatusCreated:   12,
+	}, nil)
+	otpRepo.EXPECT().CountByStatus(gomock.Any()).Return(nil, errors.New("db error"))
+
+	usc := usecase.NewOtpUsecase(nil, otpRepo, nil, nil, nil, nil, otpPolicy)
+
+	counts, err := usc.CountByStatus(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.OTPStatusCount{
+		{Status: entity.OTPStatusCreated, Count: 12},
+		{Status: entity.OTPStatusValidated, Count: 40},
+		{Status: entity.OTPStatusExpired, Count: 0},
+		{Status: entity.OTPStatusRevoked, Count: 0},
+	}, counts)
+
+	counts, err = usc.CountByStatus(context.Background())
+	assert.Nil(t, counts)
+	assert.EqualError(t, err, "failed to count OTPs: db error")
+}
+
+func TestOtpUsecase_Revoke(t *testing.T) {
+	type useCaseDependency struct {
+		txManager     *mock.MockTransactionManager
+		otpRepo       *mock.MockOTPRepository
+		outboxRepo    *mock.MockOutboxRepository
+		auditRecorder *mock.MockAuditRecorder
+	}
+
+	params := entity.RevokeOTPParams{OTPID: 42, UserID: "user-1", Reason: "sim_swap"}
+	activeOTP := func() *entity.OTP {
+		return &entity.OTP{ID: 42, UserID: "user-1", Status: entity.OTPStatusCreated, ExpiresAt: time.Now().Add(time.Minute)}
+	}
+
+	tests := []struct {
+		name           string
+		mockDependency func(dep *useCaseDependency)
+		assertFn       func(*entity.OTP, error)
+	}{
+		{
+			name: "should revoke an active OTP and publish an event",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(activeOTP(), nil)
+				dep.otpRepo.EXPECT().MarkRevoked(gomock.Any(), otpWithID(42), gomock.Any(), "sim_swap").Return(true, nil)
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPRevoked)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+					UserID:  "user-1",
+					OTPID:   42,
+					Action:  entity.AuditActionOTPRevoke,
+					Outcome: entity.AuditOutcomeSuccess,
+				})
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.OTPStatusRevoked, otp.Status)
+				assert.NotNil(t, otp.RevokedAt)
+				assert.Equal(t, "sim_swap", otp.RevokeReason)
+			},
+		},
+		{
+			name: "should report the OTP of another user as not found",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.UserID = "user-2"
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+					UserID:  "user-1",
+					Action:  entity.AuditActionOTPRevoke,
+					Outcome: entity.AuditOutcomeFailure,
+					Reason:  entity.ErrOTPNotFound.Code,
+				})
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
+		{
+			name: "should reject an OTP that was already validated",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.Status = entity.OTPStatusValidated
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPUsed, err)
+			},
+		},
+		{
+			name: "should reject an OTP that was already revoked",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.Status = entity.OTPStatusRevoked
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPRevoked, err)
+			},
+		},
+		{
+			name: "should reject an OTP past its expiry",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.ExpiresAt = time.Now().Add(-time.Second)
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPExpired, err)
+			},
+		},
+		{
+			name: "should return ErrOTPInvalid when the OTP was validated concurrently",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(activeOTP(), nil)
+				dep.otpRepo.EXPECT().MarkRevoked(gomock.Any(), otpWithID(42), gomock.Any(), "sim_swap").Return(false, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPInvalid, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := useCaseDependency{
+				txManager:     mock.NewMockTransactionManager(ctrl),
+				otpRepo:       mock.NewMockOTPRepository(ctrl),
+				outboxRepo:    mock.NewMockOutboxRepository(ctrl),
+				auditRecorder: mock.NewMockAuditRecorder(ctrl),
+			}
+
+			tt.mockDependency(&dep)
+			runInTransaction(dep.txManager)
+
+			usc := usecase.NewOtpUsecase(dep.txManager, dep.otpRepo, nil, dep.outboxRepo, nil, dep.auditRecorder, otpPolicy)
+			tt.assertFn(usc.Revoke(context.Background(), params))
+		})
+	}
+}
+
+func TestOtpUsecase_RevokeAllForUser(t *testing.T) {
+	type useCaseDependency struct {
+		txManager     *mock.MockTransactionManager
+		otpRepo       *mock.MockOTPRepository
+		outboxRepo    *mock.MockOutboxRepository
+		auditRecorder *mock.MockAuditRecorder
+	}
+
+	tests := []struct {
+		name           string
+		mockDependency func(dep *useCaseDependency)
+		assertFn       func([]*entity.OTP, error)
+	}{
+		{
+			name: "should revoke the active OTPs and skip those validated concurrently",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().
+					ListActiveByUserID(gomock.Any(), "user-1", gomock.Any(), gomock.Any()).
+					Return([]*entity.OTP{{ID: 41, UserID: "user-1"}, {ID: 42, UserID: "user-1"}}, nil)
+				dep.otpRepo.EXPECT().MarkRevoked(gomock.Any(), otpWithID(41), gomock.Any(), "sim_swap").Return(false, nil)
+				dep.otpRepo.EXPECT().MarkRevoked(gomock.Any(), otpWithID(42), gomock.Any(), "sim_swap").Return(true, nil)
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPRevoked)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+					UserID:  "user-1",
+					OTPID:   42,
+					Action:  entity.AuditActionOTPRevoke,
+					Outcome: entity.AuditOutcomeSuccess,
+				})
+			},
+			assertFn: func(otps []*entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, otps, 1)
+				assert.Equal(t, uint64(42), otps[0].ID)
+				assert.Equal(t, entity.OTPStatusRevoked, otps[0].Status)
+			},
+		},
+		{
+			name: "should record a single audit event when the user has no active OTP",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().ListActiveByUserID(gomock.Any(), "user-1", gomock.Any(), gomock.Any()).Return(nil, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+					UserID:  "user-1",
+					Action:  entity.AuditActionOTPRevoke,
+					Outcome: entity.AuditOutcomeSuccess,
+				})
+			},
+			assertFn: func(otps []*entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, otps)
+			},
+		},
+		{
+			name: "should return error when listing the active OTPs fails",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().ListActiveByUserID(gomock.Any(), "user-1", gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otps []*entity.OTP, err error) {
+				assert.Nil(t, otps)
+				assert.EqualError(t, err, "failed to list active OTPs: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := useCaseDependency{
+				txManager:     mock.NewMockTransactionManager(ctrl),
+				otpRepo:       mock.NewMockOTPRepository(ctrl),
+				outboxRepo:    mock.NewMockOutboxRepository(ctrl),
+				auditRecorder: mock.NewMockAuditRecorder(ctrl),
+			}
+
+			tt.mockDependency(&dep)
+			runInTransaction(dep.txManager)
+
+			usc := usecase.NewOtpUsecase(dep.txManager, dep.otpRepo, nil, dep.outboxRepo, nil, dep.auditRecorder, otpPolicy)
+			tt.assertFn(usc.RevokeAllForUser(context.Background(), "user-1", "sim_swap"))
+		})
+	}
+}
+
+func TestOtpUsecase_Resend(t *testing.T) {
+	type useCaseDependency struct {
+		txManager       *mock.MockTransactionManager
+		otpRepo         *mock.MockOTPRepository
+		userLockoutRepo *mock.MockUserLockoutRepository
+		outboxRepo      *mock.MockOutboxRepository
+		otpGenerator    *mock.MockOTPGenerator
+		auditRecorder   *mock.MockAuditRecorder
+	}
+
+	params := entity.ResendOTPParams{OTPID: 42, UserID: "user-1", Channel: "voice"}
+	activeOTP := func() *entity.OTP {
+		return &entity.OTP{
+			ID:          42,
+			UserID:      "user-1",
+			OTPCode:     "123456",
+			Status:      entity.OTPStatusCreated,
+			ExpiresAt:   time.Now().Add(time.Minute),
+			BindingHash: "binding-hash",
+			Purpose:     "login",
+			ResendCount: 1,
+			ResendLimit: 3,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		rotate         bool
+		mockDependency func(dep *useCaseDependency)
+		assertFn       func(*entity.OTP, error)
+	}{
+		{
+			name: "should deliver the same code again and count the resend",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(activeOTP(), nil)
+				dep.otpRepo.EXPECT().IncrementResendCount(gomock.Any(), otpWithID(42)).Return(true, nil)
+				dep.outboxRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Equal(t, entity.EventTypeOTPResent, event.EventType)
+						assert.Contains(t, string(event.Payload), `"resend_count":2,"channel":"voice"`)
+						return nil
+					})
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+					UserID:  "user-1",
+					OTPID:   42,
+					Action:  entity.AuditActionOTPResend,
+					Outcome: entity.AuditOutcomeSuccess,
+				})
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(42), otp.ID)
+				assert.Equal(t, "123456", otp.OTPCode)
+				assert.Equal(t, 2, otp.ResendCount)
+			},
+		},
+		{
+			name: "should count the resend once when the transaction is run again",
+			mockDependency: func(dep *useCaseDependency) {
+				// The first attempt fails on a deadlock after counting the resend, and is rolled back
+				dep.txManager.EXPECT().
+					WithTransaction(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						_ = fn(ctx)
+						return fn(ctx)
+					})
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(activeOTP(), nil)
+				dep.otpRepo.EXPECT().IncrementResendCount(gomock.Any(), otpWithID(42)).Return(true, nil).Times(2)
+				dep.outboxRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Contains(t, string(event.Payload), `"resend_count":2,`)
+						return nil
+					}).
+					Times(2)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, otp.ResendCount)
+			},
+		},
+		{
+			name:   "should supersede the OTP with a new code when rotating",
+			rotate: true,
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(activeOTP(), nil)
+				dep.otpGenerator.EXPECT().Generate().Return("654321", nil)
+				dep.otpRepo.EXPECT().MarkRevoked(gomock.Any(), otpWithID(42), gomock.Any(), entity.RevokeReasonSuperseded).Return(true, nil)
+				dep.otpRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, otp *entity.OTP) error {
+						otp.ID = 43
+						return nil
+					})
+				expectEvent(t, dep.outboxRepo, entity.EventTypeOTPRevoked)
+				dep.outboxRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Equal(t, entity.EventTypeOTPResent, event.EventType)
+						assert.Equal(t, uint64(43), event.OTPID)
+						assert.Contains(t, string(event.Payload), `"previous_otp_id":42`)
+						return nil
+					})
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+					UserID:  "user-1",
+					OTPID:   42,
+					Action:  entity.AuditActionOTPResend,
+					Outcome: entity.AuditOutcomeSuccess,
+				})
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(43), otp.ID)
+				assert.Equal(t, "654321", otp.OTPCode)
+				assert.Equal(t, "binding-hash", otp.BindingHash)
+				assert.Equal(t, "login", otp.Purpose)
+				assert.Equal(t, 2, otp.ResendCount)
+				assert.Equal(t, 3, otp.ResendLimit)
+			},
+		},
+		{
+			name: "should reject an OTP without resends left",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.ResendCount = 3
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPResendLimit, err)
+			},
+		},
+		{
+			name: "should reject an OTP that was revoked",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.Status = entity.OTPStatusRevoked
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPRevoked, err)
+			},
+		},
+		{
+			name: "should report the OTP of another user as not found",
+			mockDependency: func(dep *useCaseDependency) {
+				otp := activeOTP()
+				otp.UserID = "user-2"
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(otp, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
+		{
+			name: "should return ErrOTPInvalid when the OTP was validated concurrently",
+			mockDependency: func(dep *useCaseDependency) {
+				dep.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(activeOTP(), nil)
+				dep.otpRepo.EXPECT().IncrementResendCount(gomock.Any(), otpWithID(42)).Return(false, nil)
+				dep.auditRecorder.EXPECT().Record(gomock.Any(), gomock.Any())
+			},
+			assertFn: func(otp *entity.OTP, err error) {
+				assert.Nil(t, otp)
+				assert.Equal(t, entity.ErrOTPInvalid, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := useCaseDependency{
+				txManager:       mock.NewMockTransactionManager(ctrl),
+				otpRepo:         mock.NewMockOTPRepository(ctrl),
+				userLockoutRepo: mock.NewMockUserLockoutRepository(ctrl),
+				outboxRepo:      mock.NewMockOutboxRepository(ctrl),
+				otpGenerator:    mock.NewMockOTPGenerator(ctrl),
+				auditRecorder:   mock.NewMockAuditRecorder(ctrl),
+			}
+
+			dep.userLockoutRepo.EXPECT().
+				FindByUserID(gomock.Any(), "user-1").
+				Return(nil, entity.ErrUserLockoutNotFound)
+			tt.mockDependency(&dep)
+			runInTransaction(dep.txManager)
+
+			policy := otpPolicy
+			policy.Resend = usecase.ResendPolicy{Rotate: tt.rotate, MaxResends: 3}
+
+			usc := usecase.NewOtpUsecase(dep.txManager, dep.otpRepo, dep.userLockoutRepo, dep.outboxRepo, dep.otpGenerator, dep.auditRecorder, policy)
+			tt.assertFn(usc.Resend(context.Background(), params))
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// timelineAuditEventsLimit bounds the audit events of a user read to find the validation attempts
+// that matched none of the user's OTPs during the lifetime of an OTP
+const timelineAuditEventsLimit = 1000
+
+type otpTimelineUsecase struct {
+	otpRepo             OTPRepository
+	auditRepo           AuditEventRepository
+	outboxRepo          OutboxRepository
+	webhookDeliveryRepo WebhookDeliveryRepository
+}
+
+func NewOTPTimelineUsecase(
+	otpRepo OTPRepository,
+	auditRepo AuditEventRepository,
+	outboxRepo OutboxRepository,
+	webhookDeliveryRepo WebhookDeliveryRepository,
+) *otpTimelineUsecase {
+	return &otpTimelineUsecase{
+		otpRepo:             otpRepo,
+		auditRepo:           auditRepo,
+		outboxRepo:          outboxRepo,
+		webhookDeliveryRepo: webhookDeliveryRepo,
+	}
+}
+
+// GetTimeline builds the ordered history of an OTP from its persisted records: its issuance,
+// the deliveries of its otp.created event, every validation attempt and resend that matched it,
+// its revocation or expiry and its supersession by a newer OTP of the user.
+// Validation attempts of the user whose code matched no OTP while the OTP was outstanding are included as well,
+// since a mistyped code is the most common reason why a code failed.
+// Returns entity.ErrOTPNotFound if no OTP exists with the ID.
+func (u *otpTimelineUsecase) GetTimeline(ctx context.Context, otpID uint64) (*entity.OTPTimeline, error) {
+	otp, err := u.otpRepo.FindByID(ctx, otpID)
+	if err != nil {
+		return nil, err
+	}
+
+	issued := entity.OTPTimelineEvent{
+		Type:       entity.OTPTimelineIssued,
+		OccurredAt: otp.CreatedAt,
+		Result:     entity.AuditOutcomeSuccess.String(),
+	}
+
+	auditEvents, err := u.auditRepo.ListByOTPID(ctx, otpID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events of OTP %d: %w", otpID, err)
+	}
+
+	var revoked *entity.OTPTimelineEvent
+	if otp.RevokedAt != nil {
+		revoked = &entity.OTPTimelineEvent{
+			Type:       entity.OTPTimelineRevoked,
+			OccurredAt: *otp.RevokedAt,
+			Result:     entity.AuditOutcomeSuccess.String(),
+			Reason:     otp.RevokeReason,
+		}
+	}
+
+	var events []entity.OTPTimelineEvent
+	for _, auditEvent := range auditEvents {
+		switch auditEvent.Action {
+		case entity.AuditActionOTPRequest:
+			// The request that issued the OTP tells who it was issued to
+			issued.Actor = auditEvent.Actor
+			issued.ClientID = auditEvent.ClientID
+			issued.IP = auditEvent.IP
+		case entity.AuditActionOTPValidate, entity.AuditActionOTPResend:
+			eventType := entity.OTPTimelineValidation
+			if auditEvent.Action == entity.AuditActionOTPResend {
+				eventType = entity.OTPTimelineResend
+			}
+			events = append(events, entity.OTPTimelineEvent{
+				Type:       eventType,
+				OccurredAt: auditEvent.CreatedAt,
+				Result:     auditEvent.Outcome.String(),
+				Reason:     auditEvent.Reason,
+				Actor:      auditEvent.Actor,
+				ClientID:   auditEvent.ClientID,
+				IP:         auditEvent.IP,
+			})
+		case entity.AuditActionOTPRevoke:
+			// The successful revocation tells who revoked the OTP
+			if revoked != nil && auditEvent.Outcome == entity.AuditOutcomeSuccess {
+				revoked.Actor = auditEvent.Actor
+				revoked.ClientID = auditEvent.ClientID
+				revoked.IP = auditEvent.IP
+			}
+		}
+	}
+	events = append(events, issued)
+	if revoked != nil {
+		events = append(events, *revoked)
+	}
+
+	deliveries, err := u.deliveryEvents(ctx, otpID)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, deliveries...)
+
+	if isExpired(otp, time.Now()) {
+		events = append(events, entity.OTPTimelineEvent{
+			Type:       entity.OTPTimelineExpired,
+			OccurredAt: otp.ExpiresAt,
+		})
+	}
+
+	superseded, err := u.supersededEvent(ctx, otp)
+	if err != nil {
+		return nil, err
+	}
+	if superseded != nil {
+		events = append(events, *superseded)
+	}
+
+	unmatched, err := u.unmatchedValidationEvents(ctx, otp, superseded)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, unmatched...)
+
+	// The issuance precedes anything that happened at the same time
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].Type == entity.OTPTimelineIssued && events[j].Type != entity.OTPTimelineIssued
+		}
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	return &entity.OTPTimeline{
+		OTP:    otp,
+		Events: events,
+	}, nil
+}
+
+// deliveryEvents returns the deliveries of the otp.created event of an OTP to the event sink and to webhook subscriptions
+func (u *otpTimelineUsecase) deliveryEvents(ctx context.Context, otpID uint64) ([]entity.OTPTimelineEvent, error) {
+	outboxEvents, err := u.outboxRepo.ListByOTPID(ctx, otpID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events of OTP %d: %w", otpID, err)
+	}
+
+	var events []entity.OTPTimelineEvent
+	for _, outboxEvent := range outboxEvents {
+		if outboxEvent.EventType != entity.EventTypeOTPCreated {
+			continue
+		}
+
+		events = append(events, entity.OTPTimelineEvent{
+			Type:       entity.OTPTimelineDelivery,
+			OccurredAt: deliveryTime(outboxEvent.CreatedAt, outboxEvent.DeliveredAt),
+			Result:     outboxEvent.Status.String(),
+			Reason:     outboxEvent.LastError,
+			Attempts:   outboxEvent.Attempts,
+		})
+
+		webhookDeliveries, err := u.webhookDeliveryRepo.ListByEventID(ctx, outboxEvent.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list webhook deliveries of event %d: %w", outboxEvent.ID, err)
+		}
+		for _, delivery := range webhookDeliveries {
+			events = append(events, entity.OTPTimelineEvent{
+				Type:                  entity.OTPTimelineDelivery,
+				OccurredAt:            deliveryTime(delivery.CreatedAt, delivery.DeliveredAt),
+				Result:                delivery.Status.String(),
+				Reason:                delivery.LastError,
+				Attempts:              delivery.Attempts,
+				ResponseCode:          delivery.ResponseCode,
+				WebhookSubscriptionID: delivery.SubscriptionID,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// unmatchedValidationEvents returns the validation attempts of the user of an OTP whose code matched none
+// of the user's OTPs, made from its issuance until it was validated, revoked, superseded or expired.
+// These attempts are audited without an OTP, so they are found by the user and the time they were made.
+func (u *otpTimelineUsecase) unmatchedValidationEvents(ctx context.Context, otp *entity.OTP, superseded *entity.OTPTimelineEvent) ([]entity.OTPTimelineEvent, error) {
+	endedAt := otp.ExpiresAt
+	for _, t := range []*time.Time{otp.ValidatedAt, otp.RevokedAt} {
+		if t != nil && t.Before(endedAt) {
+			endedAt = *t
+		}
+	}
+	if superseded != nil && superseded.OccurredAt.Before(endedAt) {
+		endedAt = superseded.OccurredAt
+	}
+
+	auditEvents, err := u.auditRepo.List(ctx, entity.AuditEventFilter{
+		UserID: otp.UserID,
+		From:   &otp.CreatedAt,
+		To:     &endedAt,
+		Limit:  timelineAuditEventsLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events of user %s: %w", otp.UserID, err)
+	}
+
+	var events []entity.OTPTimelineEvent
+	for _, auditEvent := range auditEvents {
+		if auditEvent.Action != entity.AuditActionOTPValidate || auditEvent.OTPID != 0 {
+			continue
+		}
+
+		events = append(events, entity.OTPTimelineEvent{
+			Type:       entity.OTPTimelineValidation,
+			OccurredAt: auditEvent.CreatedAt,
+			Result:     auditEvent.Outcome.String(),
+			Reason:     auditEvent.Reason,
+			Actor:      auditEvent.Actor,
+			ClientID:   auditEvent.ClientID,
+			IP:         auditEvent.IP,
+		})
+	}
+
+	return events, nil
+}
+
+// supersededEvent returns the supersession of an OTP by the next OTP issued to the user while it was
+// still outstanding, or nil if the OTP was validated, revoked or expired before a newer one was issued
+func (u *otpTimelineUsecase) supersededEvent(ctx context.Context, otp *entity.OTP) (*entity.OTPTimelineEvent, error) {
+	next, err := u.otpRepo.FindNextByUserID(ctx, otp)
+	if err != nil {
+		if errors.Is(err, entity.ErrOTPNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find the OTP issued after OTP %d: %w", otp.ID, err)
+	}
+
+	if !next.CreatedAt.Before(otp.ExpiresAt) {
+		return nil, nil
+	}
+	if otp.ValidatedAt != nil && !next.CreatedAt.Before(*otp.ValidatedAt) {
+		return nil, nil
+	}
+	if otp.RevokedAt != nil && !next.CreatedAt.Before(*otp.RevokedAt) {
+		return nil, nil
+	}
+
+	return &entity.OTPTimelineEvent{
+		Type:              entity.OTPTimelineSuperseded,
+		OccurredAt:        next.CreatedAt,
+		SupersededByOTPID: next.ID,
+	}, nil
+}
+
+// isExpired reports whether an OTP expired before it was validated
+func isExpired(otp *entity.OTP, now time.Time) bool {
+	if otp.Status == entity.OTPStatusExpired {
+		return true
+	}
+	return otp.Status == entity.OTPStatusCreated && now.After(otp.ExpiresAt)
+}
+
+// deliveryTime returns when a delivery completed, or when it was created if it has not been delivered
+func deliveryTime(createdAt time.Time, deliveredAt *time.Time) time.Time {
+	if deliveredAt != nil {
+		return *deliveredAt
+	}
+	return createdAt
+}
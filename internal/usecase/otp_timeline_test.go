@@ -0,0 +1,243 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type otpTimelineDependency struct {
+	otpRepo             *mock.MockOTPRepository
+	auditRepo           *mock.MockAuditEventRepository
+	outboxRepo          *mock.MockOutboxRepository
+	webhookDeliveryRepo *mock.MockWebhookDeliveryRepository
+}
+
+func TestOTPTimelineUsecase_GetTimeline(t *testing.T) {
+	issuedAt := time.Date(2025, 11, 26, 9, 0, 0, 0, time.UTC)
+	deliveredAt := issuedAt.Add(time.Second)
+	validatedAt := issuedAt.Add(30 * time.Second)
+	expiresAt := issuedAt.Add(2 * time.Minute)
+
+	tests := []struct {
+		name      string
+		mockSetup func(*otpTimelineDependency)
+		assertFn  func(*testing.T, *entity.OTPTimeline, error)
+	}{
+		{
+			name: "Should order issuance, deliveries and validation attempts",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{
+					ID:          42,
+					UserID:      "user123",
+					Status:      entity.OTPStatusValidated,
+					CreatedAt:   issuedAt,
+					ExpiresAt:   expiresAt,
+					ValidatedAt: &validatedAt,
+				}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return([]*entity.AuditEvent{
+					{Actor: entity.AuditActorClient, IP: "203.0.113.7", Action: entity.AuditActionOTPRequest, Outcome: entity.AuditOutcomeSuccess, CreatedAt: issuedAt},
+					{Actor: entity.AuditActorClient, IP: "198.51.100.2", Action: entity.AuditActionOTPValidate, Outcome: entity.AuditOutcomeFailure, Reason: "otp_binding_mismatch", CreatedAt: issuedAt.Add(10 * time.Second)},
+					{Actor: entity.AuditActorClient, IP: "203.0.113.7", Action: entity.AuditActionOTPValidate, Outcome: entity.AuditOutcomeSuccess, CreatedAt: validatedAt},
+				}, nil)
+				d.outboxRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return([]*entity.OutboxEvent{
+					{ID: 5, EventType: entity.EventTypeOTPCreated, Status: entity.OutboxStatusDelivered, CreatedAt: issuedAt, DeliveredAt: &deliveredAt},
+					{ID: 6, EventType: entity.EventTypeOTPValidated, Status: entity.OutboxStatusPending, CreatedAt: validatedAt},
+				}, nil)
+				d.webhookDeliveryRepo.EXPECT().ListByEventID(gomock.Any(), uint64(5)).Return([]*entity.WebhookDelivery{
+					{SubscriptionID: 3, Status: entity.OutboxStatusPending, Attempts: 2, ResponseCode: 503, LastError: "unexpected status code 503", CreatedAt: issuedAt},
+				}, nil)
+				d.otpRepo.EXPECT().FindNextByUserID(gomock.Any(), otpWithID(42)).Return(nil, entity.ErrOTPNotFound)
+				d.auditRepo.EXPECT().List(gomock.Any(), entity.AuditEventFilter{UserID: "user123", From: &issuedAt, To: &validatedAt, Limit: 1000}).Return(nil, nil)
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(42), timeline.OTP.ID)
+				assert.Equal(t, []entity.OTPTimelineEvent{
+					{Type: entity.OTPTimelineIssued, OccurredAt: issuedAt, Result: "success", Actor: entity.AuditActorClient, IP: "203.0.113.7"},
+					{Type: entity.OTPTimelineDelivery, OccurredAt: issuedAt, Result: "pending", Reason: "unexpected status code 503", Attempts: 2, ResponseCode: 503, WebhookSubscriptionID: 3},
+					{Type: entity.OTPTimelineDelivery, OccurredAt: deliveredAt, Result: "delivered"},
+					{Type: entity.OTPTimelineValidation, OccurredAt: issuedAt.Add(10 * time.Second), Result: "failure", Reason: "otp_binding_mismatch", Actor: entity.AuditActorClient, IP: "198.51.100.2"},
+					{Type: entity.OTPTimelineValidation, OccurredAt: validatedAt, Result: "success", Actor: entity.AuditActorClient, IP: "203.0.113.7"},
+				}, timeline.Events)
+			},
+		},
+		{
+			name: "Should include the supersession and expiry of an OTP that was never validated",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{
+					ID:        42,
+					UserID:    "user123",
+					Status:    entity.OTPStatusExpired,
+					CreatedAt: issuedAt,
+					ExpiresAt: expiresAt,
+				}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.outboxRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.otpRepo.EXPECT().FindNextByUserID(gomock.Any(), otpWithID(42)).Return(&entity.OTP{
+					ID:        43,
+					CreatedAt: validatedAt,
+				}, nil)
+				d.auditRepo.EXPECT().List(gomock.Any(), entity.AuditEventFilter{UserID: "user123", From: &issuedAt, To: &validatedAt, Limit: 1000}).Return(nil, nil)
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []entity.OTPTimelineEvent{
+					{Type: entity.OTPTimelineIssued, OccurredAt: issuedAt, Result: "success"},
+					{Type: entity.OTPTimelineSuperseded, OccurredAt: validatedAt, SupersededByOTPID: 43},
+					{Type: entity.OTPTimelineExpired, OccurredAt: expiresAt},
+				}, timeline.Events)
+			},
+		},
+		{
+			name: "Should not supersede an OTP with an OTP issued after its expiry",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{
+					ID:        42,
+					UserID:    "user123",
+					Status:    entity.OTPStatusExpired,
+					CreatedAt: issuedAt,
+					ExpiresAt: expiresAt,
+				}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.outboxRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.otpRepo.EXPECT().FindNextByUserID(gomock.Any(), otpWithID(42)).Return(&entity.OTP{
+					ID:        43,
+					CreatedAt: expiresAt.Add(time.Minute),
+				}, nil)
+				d.auditRepo.EXPECT().List(gomock.Any(), entity.AuditEventFilter{UserID: "user123", From: &issuedAt, To: &expiresAt, Limit: 1000}).Return(nil, nil)
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, timeline.Events, 2)
+				assert.Equal(t, entity.OTPTimelineExpired, timeline.Events[1].Type)
+			},
+		},
+		{
+			name: "Should include the resends and who revoked an OTP, and not supersede it afterwards",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{
+					ID:           42,
+					UserID:       "user123",
+					Status:       entity.OTPStatusRevoked,
+					CreatedAt:    issuedAt,
+					ExpiresAt:    expiresAt,
+					RevokedAt:    &validatedAt,
+					RevokeReason: "sim_swap",
+				}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return([]*entity.AuditEvent{
+					{Actor: entity.AuditActorClient, IP: "203.0.113.7", Action: entity.AuditActionOTPResend, Outcome: entity.AuditOutcomeSuccess, CreatedAt: deliveredAt},
+					{Actor: entity.AuditActorAdmin, IP: "10.0.0.1", Action: entity.AuditActionOTPRevoke, Outcome: entity.AuditOutcomeSuccess, CreatedAt: validatedAt},
+				}, nil)
+				d.outboxRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.otpRepo.EXPECT().FindNextByUserID(gomock.Any(), otpWithID(42)).Return(&entity.OTP{
+					ID:        43,
+					CreatedAt: validatedAt.Add(time.Second),
+				}, nil)
+				d.auditRepo.EXPECT().List(gomock.Any(), entity.AuditEventFilter{UserID: "user123", From: &issuedAt, To: &validatedAt, Limit: 1000}).Return(nil, nil)
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []entity.OTPTimelineEvent{
+					{Type: entity.OTPTimelineIssued, OccurredAt: issuedAt, Result: "success"},
+					{Type: entity.OTPTimelineResend, OccurredAt: deliveredAt, Result: "success", Actor: entity.AuditActorClient, IP: "203.0.113.7"},
+					{Type: entity.OTPTimelineRevoked, OccurredAt: validatedAt, Result: "success", Reason: "sim_swap", Actor: entity.AuditActorAdmin, IP: "10.0.0.1"},
+				}, timeline.Events)
+			},
+		},
+		{
+			name: "Should include the attempts with a wrong code while the OTP was outstanding",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{
+					ID:        42,
+					UserID:    "user123",
+					Status:    entity.OTPStatusExpired,
+					CreatedAt: issuedAt,
+					ExpiresAt: expiresAt,
+				}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.outboxRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.otpRepo.EXPECT().FindNextByUserID(gomock.Any(), otpWithID(42)).Return(nil, entity.ErrOTPNotFound)
+				d.auditRepo.EXPECT().List(gomock.Any(), entity.AuditEventFilter{UserID: "user123", From: &issuedAt, To: &expiresAt, Limit: 1000}).Return([]*entity.AuditEvent{
+					{OTPID: 42, Actor: entity.AuditActorClient, IP: "203.0.113.7", Action: entity.AuditActionOTPRequest, Outcome: entity.AuditOutcomeSuccess, CreatedAt: issuedAt},
+					{Actor: entity.AuditActorClient, IP: "203.0.113.7", Action: entity.AuditActionOTPValidate, Outcome: entity.AuditOutcomeFailure, Reason: "otp_not_found", CreatedAt: deliveredAt},
+					{OTPID: 41, Actor: entity.AuditActorClient, IP: "203.0.113.7", Action: entity.AuditActionOTPValidate, Outcome: entity.AuditOutcomeFailure, Reason: "otp_expired", CreatedAt: validatedAt},
+				}, nil)
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []entity.OTPTimelineEvent{
+					{Type: entity.OTPTimelineIssued, OccurredAt: issuedAt, Result: "success"},
+					{Type: entity.OTPTimelineValidation, OccurredAt: deliveredAt, Result: "failure", Reason: "otp_not_found", Actor: entity.AuditActorClient, IP: "203.0.113.7"},
+					{Type: entity.OTPTimelineExpired, OccurredAt: expiresAt},
+				}, timeline.Events)
+			},
+		},
+		{
+			name: "Should return error when listing the audit events of the user fails",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{ID: 42, UserID: "user123", CreatedAt: issuedAt, ExpiresAt: expiresAt}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.outboxRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, nil)
+				d.otpRepo.EXPECT().FindNextByUserID(gomock.Any(), otpWithID(42)).Return(nil, entity.ErrOTPNotFound)
+				d.auditRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.Nil(t, timeline)
+				assert.EqualError(t, err, "failed to list audit events of user user123: db error")
+			},
+		},
+		{
+			name: "Should return ErrOTPNotFound when the OTP does not exist",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(nil, entity.ErrOTPNotFound)
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.Nil(t, timeline)
+				assert.Equal(t, entity.ErrOTPNotFound, err)
+			},
+		},
+		{
+			name: "Should return error when listing the audit events fails",
+			mockSetup: func(d *otpTimelineDependency) {
+				d.otpRepo.EXPECT().FindByID(gomock.Any(), uint64(42)).Return(&entity.OTP{ID: 42}, nil)
+				d.auditRepo.EXPECT().ListByOTPID(gomock.Any(), uint64(42)).Return(nil, errors.New("db error"))
+			},
+			assertFn: func(t *testing.T, timeline *entity.OTPTimeline, err error) {
+				assert.Nil(t, timeline)
+				assert.EqualError(t, err, "failed to list audit events of OTP 42: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dependency := &otpTimelineDependency{
+				otpRepo:             mock.NewMockOTPRepository(ctrl),
+				auditRepo:           mock.NewMockAuditEventRepository(ctrl),
+				outboxRepo:          mock.NewMockOutboxRepository(ctrl),
+				webhookDeliveryRepo: mock.NewMockWebhookDeliveryRepository(ctrl),
+			}
+			tt.mockSetup(dependency)
+
+			usc := usecase.NewOTPTimelineUsecase(
+				dependency.otpRepo,
+				dependency.auditRepo,
+				dependency.outboxRepo,
+				dependency.webhookDeliveryRepo,
+			)
+			timeline, err := usc.GetTimeline(context.Background(), 42)
+			tt.assertFn(t, timeline, err)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// OutboxPolicy configures the delivery of outbox events.
+type OutboxPolicy struct {
+	// BatchSize is the maximum number of events delivered by a single dispatch.
+	BatchSize int
+	// MaxAttempts is the number of failed deliveries after which an event is given up.
+	MaxAttempts int
+	// BackoffBase is the delay before the first retry; it doubles with every further failure.
+	BackoffBase time.Duration
+	// BackoffMax caps the delay between retries.
+	BackoffMax time.Duration
+}
+
+type outboxUsecase struct {
+	outboxRepo OutboxRepository
+	publisher  EventPublisher
+	policy     OutboxPolicy
+}
+
+func NewOutboxUsecase(outboxRepo OutboxRepository, publisher EventPublisher, policy OutboxPolicy) *outboxUsecase {
+	return &outboxUsecase{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		policy:     policy,
+	}
+}
+
+// DispatchPending delivers the pending events that are due, oldest first, and returns
+// the number of events delivered and the number of failed deliveries.
+// A failed event is retried with exponential backoff until the maximum number of attempts is reached.
+func (u *outboxUsecase) DispatchPending(ctx context.Context) (int, int, error) {
+	events, err := u.outboxRepo.ListDue(ctx, time.Now(), u.policy.BatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list due events: %w", err)
+	}
+
+	var delivered, failed int
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return delivered, failed, err
+		}
+
+		publishErr := u.publisher.Publish(ctx, event)
+
+		now := time.Now()
+		if publishErr == nil {
+			delivered++
+			event.Status = entity.OutboxStatusDelivered
+			event.DeliveredAt = &now
+		} else {
+			failed++
+			event.Attempts++
+			event.LastError = publishErr.Error()
+			if event.Attempts >= u.policy.MaxAttempts {
+				event.Status = entity.OutboxStatusFailed
+			} else {
+				event.NextAttemptAt = now.Add(backoff(event.Attempts, u.policy.BackoffBase, u.policy.BackoffMax))
+			}
+		}
+
+		if err := u.outboxRepo.Update(ctx, event); err != nil {
+			return delivered, failed, fmt.Errorf("failed to update event %d: %w", event.ID, err)
+		}
+	}
+
+	return delivered, failed, nil
+}
+
+// backoff returns the delay before retrying a delivery that failed the given number of times:
+// base, doubled with every further failure and capped at max
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	return min(delay, max)
+}
+
+// appendEvent stores an event in the outbox, to be delivered once the surrounding transaction commits.
+// It must be called within the transaction of the change the event describes, so that neither
+// is persisted without the other.
+func appendEvent(ctx context.Context, outboxRepo OutboxRepository, event entity.OTPEvent) error {
+	outboxEvent, err := entity.NewOutboxEvent(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	return outboxRepo.Create(ctx, outboxEvent)
+}
+
+type multiEventPublisher struct {
+	publishers []EventPublisher
+}
+
+// NewMultiEventPublisher creates a publisher that delivers every event to all the given publishers.
+func NewMultiEventPublisher(publishers ...EventPublisher) *multiEventPublisher {
+	return &multiEventPublisher{
+		publishers: publishers,
+	}
+}
+
+// Publish delivers the event to every publisher, even if some of them fail.
+// The event is retried as a whole, so every publisher must tolerate receiving it more than once.
+func (m *multiEventPublisher) Publish(ctx context.Context, event *entity.OutboxEvent) error {
+	var errs []error
+	for _, publisher := range m.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
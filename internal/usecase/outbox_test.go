@@ -0,0 +1,168 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxPolicy = usecase.OutboxPolicy{
+	BatchSize:   10,
+	MaxAttempts: 3,
+	BackoffBase: time.Second,
+	BackoffMax:  90 * time.Second,
+}
+
+func TestOutboxUsecase_DispatchPending(t *testing.T) {
+	type outboxDependency struct {
+		outboxRepo *mock.MockOutboxRepository
+		publisher  *mock.MockEventPublisher
+	}
+
+	tests := []struct {
+		name           string
+		mockDependency func(dep *outboxDependency)
+		assertFn       func(delivered int, failed int, err error)
+	}{
+		{
+			name: "should mark published events as delivered",
+			mockDependency: func(dep *outboxDependency) {
+				event := &entity.OutboxEvent{ID: 1, Status: entity.OutboxStatusPending}
+				dep.outboxRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.OutboxEvent{event}, nil)
+				dep.publisher.EXPECT().
+					Publish(gomock.Any(), event).
+					Return(nil)
+				dep.outboxRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Equal(t, entity.OutboxStatusDelivered, event.Status)
+						assert.NotNil(t, event.DeliveredAt)
+						return nil
+					})
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 1, delivered)
+				assert.Zero(t, failed)
+			},
+		},
+		{
+			name: "should retry a failed event with exponential backoff",
+			mockDependency: func(dep *outboxDependency) {
+				event := &entity.OutboxEvent{ID: 1, Status: entity.OutboxStatusPending, Attempts: 1}
+				dep.outboxRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.OutboxEvent{event}, nil)
+				dep.publisher.EXPECT().
+					Publish(gomock.Any(), event).
+					Return(errors.New("connection refused"))
+				dep.outboxRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Equal(t, entity.OutboxStatusPending, event.Status)
+						assert.Equal(t, 2, event.Attempts)
+						assert.Equal(t, "connection refused", event.LastError)
+						assert.WithinDuration(t, time.Now().Add(2*time.Second), event.NextAttemptAt, time.Second)
+						return nil
+					})
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Zero(t, delivered)
+				assert.Equal(t, 1, failed)
+			},
+		},
+		{
+			name: "should give up an event after the maximum number of attempts",
+			mockDependency: func(dep *outboxDependency) {
+				event := &entity.OutboxEvent{ID: 1, Status: entity.OutboxStatusPending, Attempts: 2}
+				dep.outboxRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.OutboxEvent{event}, nil)
+				dep.publisher.EXPECT().
+					Publish(gomock.Any(), event).
+					Return(errors.New("connection refused"))
+				dep.outboxRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, event *entity.OutboxEvent) error {
+						assert.Equal(t, entity.OutboxStatusFailed, event.Status)
+						assert.Equal(t, 3, event.Attempts)
+						return nil
+					})
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 1, failed)
+			},
+		},
+		{
+			name: "should return error if listing due events fails",
+			mockDependency: func(dep *outboxDependency) {
+				dep.outboxRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return(nil, errors.New("db error"))
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.EqualError(t, err, "failed to list due events: db error")
+			},
+		},
+		{
+			name: "should return error if the delivery status cannot be stored",
+			mockDependency: func(dep *outboxDependency) {
+				event := &entity.OutboxEvent{ID: 1, Status: entity.OutboxStatusPending}
+				dep.outboxRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.OutboxEvent{event, {ID: 2}}, nil)
+				dep.publisher.EXPECT().
+					Publish(gomock.Any(), event).
+					Return(nil)
+				dep.outboxRepo.EXPECT().
+					Update(gomock.Any(), event).
+					Return(errors.New("db error"))
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.EqualError(t, err, "failed to update event 1: db error")
+				assert.Equal(t, 1, delivered)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := &outboxDependency{
+				outboxRepo: mock.NewMockOutboxRepository(ctrl),
+				publisher:  mock.NewMockEventPublisher(ctrl),
+			}
+			tt.mockDependency(dep)
+
+			usc := usecase.NewOutboxUsecase(dep.outboxRepo, dep.publisher, outboxPolicy)
+			tt.assertFn(usc.DispatchPending(context.Background()))
+		})
+	}
+}
+
+func TestMultiEventPublisher_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	event := &entity.OutboxEvent{ID: 1}
+	first := mock.NewMockEventPublisher(ctrl)
+	second := mock.NewMockEventPublisher(ctrl)
+	first.EXPECT().Publish(gomock.Any(), event).Return(errors.New("connection refused"))
+	second.EXPECT().Publish(gomock.Any(), event).Return(nil)
+
+	err := usecase.NewMultiEventPublisher(first, second).Publish(context.Background(), event)
+	assert.EqualError(t, err, "connection refused")
+}
@@ -7,6 +7,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/imansohibul/otp-service/entity"
 )
@@ -14,24 +15,259 @@ import (
 //go:generate mockgen -destination=mock/repository.go -package=mock -source=repository.go
 
 // TransactionManager defines the interface for managing database transactions.
+// A transaction started while another one is in the context runs within a savepoint of it,
+// so that only its own changes are rolled back when fn returns an error.
 type TransactionManager interface {
+	// WithTransaction runs fn within a transaction with the default options of the database.
 	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// WithTransactionOptions runs fn within a transaction with the given options.
+	// The options are ignored when a transaction is already in the context.
+	// fn may be called again when the transaction fails on a deadlock or a lock wait timeout,
+	// so it must not have side effects outside of the transaction.
+	WithTransactionOptions(ctx context.Context, opts TransactionOptions, fn func(ctx context.Context) error) error
+}
+
+// IsolationLevel is the isolation level of a transaction
+type IsolationLevel int
+
+const (
+	// IsolationDefault uses the isolation level the database is configured with
+	IsolationDefault IsolationLevel = iota
+	IsolationReadCommitted
+	IsolationRepeatableRead
+	IsolationSerializable
+)
+
+// TransactionOptions are the options of a transaction
+type TransactionOptions struct {
+	Isolation IsolationLevel
+	// ReadOnly rejects the writes of the transaction on databases that support it
+	ReadOnly bool
+}
+
+// readYourWritesKey is a key used to store the read-your-writes flag in the context
+type readYourWritesKey struct{}
+
+// WithReadYourWrites returns a context whose reads see every write committed before them. Outside of a transaction,
+// reads may otherwise be served by a read replica lagging behind the primary database.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, true)
+}
+
+// ReadsYourWrites reports whether the reads made with the context must see every write committed before them
+func ReadsYourWrites(ctx context.Context) bool {
+	readYourWrites, _ := ctx.Value(readYourWritesKey{}).(bool)
+	return readYourWrites
 }
 
 // OTPRepository defines the interface for OTP data access operations
 type OTPRepository interface {
-	// Create inserts a new OTP into the database.
-	// Returns entity.ErrDuplicateOTP if an OTP with the same user_id and otp_code already exists.
+	// Create inserts a new OTP into the database and sets its ID.
+	// Returns entity.ErrOTPDuplicate if an OTP with the same user_id and otp_code already exists.
 	Create(ctx context.Context, otp *entity.OTP) error
 
 	// FindByUserIDAndCode retrieves an OTP by user ID and OTP code from the database.
+	// Returns entity.ErrOTPNotFound if the user has no OTP with the code.
 	FindByUserIDAndCode(ctx context.Context, userID string, otpCode string) (*entity.OTP, error)
 
-	// Update updates an existing OTP record in the database.
+	// Update updates an existing OTP record in the database, provided it is still created.
 	// Typically used to update the status and validated_at fields.
-	Update(ctx context.Context, otp *entity.OTP) error
+	// Returns false if the OTP was validated, expired or revoked in the meantime.
+	Update(ctx context.Context, otp *entity.OTP) (bool, error)
+
+	// GetLastByUserID retrieves the most recent OTP record for a given user created at or after createdFrom,
+	// ordered by creation timestamp descending, then by ID descending for OTPs created at the same time.
+	// Returns entity.ErrOTPNotFound if no such OTP exists for the user.
+	GetLastByUserID(ctx context.Context, userID string, createdFrom time.Time) (*entity.OTP, error)
+
+	// FindByID retrieves an OTP by its ID.
+	// Returns entity.ErrOTPNotFound if no OTP exists with the ID.
+	FindByID(ctx context.Context, id uint64) (*entity.OTP, error)
+
+	// FindNextByUserID retrieves the first OTP issued to the user of the given OTP after it.
+	// Returns entity.ErrOTPNotFound if no later OTP exists for the user.
+	FindNextByUserID(ctx context.Context, after *entity.OTP) (*entity.OTP, error)
+
+	// List retrieves up to limit OTPs matching the filter with an ID lower than beforeID, newest first.
+	// A zero beforeID starts from the most recent OTP.
+	List(ctx context.Context, filter entity.OTPFilter, beforeID uint64, limit int) ([]*entity.OTP, error)
+
+	// ListExpirable retrieves and locks up to limit OTPs that are still created but expired at the given time,
+	// among the OTPs created between createdFrom and createdTo, both inclusive, which stores may use to narrow the search.
+	// OTPs locked by a concurrent transaction are skipped, so it must be called within a transaction.
+	ListExpirable(ctx context.Context, now time.Time, createdFrom time.Time, createdTo time.Time, limit int) ([]*entity.OTP, error)
+
+	// MarkExpired marks the given OTPs as expired.
+	MarkExpired(ctx context.Context, otps []*entity.OTP) error
+
+	// ListActiveByUserID retrieves the OTPs of a user created at or after createdFrom that are still created
+	// and not expired at the given time, oldest first.
+	ListActiveByUserID(ctx context.Context, userID string, now time.Time, createdFrom time.Time) ([]*entity.OTP, error)
+
+	// IncrementResendCount counts a resend of the given OTP, provided it is still created
+	// and has resends left. Returns false otherwise.
+	IncrementResendCount(ctx context.Context, otp *entity.OTP) (bool, error)
+
+	// MarkRevoked marks the given OTP as revoked at the given time, provided it is still created.
+	// Returns false if the OTP was validated, expired or revoked in the meantime.
+	MarkRevoked(ctx context.Context, otp *entity.OTP, revokedAt time.Time, reason string) (bool, error)
+
+	// DeleteExpiredBefore deletes up to limit OTPs that expired before the given time, among the OTPs created
+	// between createdFrom and createdTo, both inclusive, which stores may use to narrow the search.
+	// A zero createdFrom leaves the creation time unbounded below.
+	// Returns the number of OTPs that were deleted.
+	DeleteExpiredBefore(ctx context.Context, before time.Time, createdFrom time.Time, createdTo time.Time, limit int) (int64, error)
+
+	// CountByStatus counts the stored OTPs of each status. Statuses without OTPs are omitted.
+	CountByStatus(ctx context.Context) (map[entity.OTPStatus]int64, error)
+}
+
+// UserLockoutRepository defines the interface for the per-user ledger of failed OTP validations
+type UserLockoutRepository interface {
+	// FindByUserID retrieves the lockout ledger of a user.
+	// Returns entity.ErrUserLockoutNotFound if the user has no recorded failures.
+	FindByUserID(ctx context.Context, userID string) (*entity.UserLockout, error)
+
+	// IncrementFailedAttempts atomically increments the failed attempts counter of a user,
+	// creating the ledger if it does not exist yet, and returns the updated ledger.
+	IncrementFailedAttempts(ctx context.Context, userID string, failedAt time.Time) (*entity.UserLockout, error)
+
+	// Lock locks the user out until the given time and resets the failed attempts counter.
+	Lock(ctx context.Context, userID string, lockedUntil time.Time) error
+
+	// DeleteByUserID removes the lockout ledger of a user, clearing any active lock.
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// IdempotencyRepository defines the interface for stored responses of requests made with an Idempotency-Key
+type IdempotencyRepository interface {
+	// Create inserts a new idempotency record.
+	// Returns entity.ErrIdempotencyKeyDuplicate if a record with the same key already exists.
+	Create(ctx context.Context, record *entity.IdempotencyRecord) error
+
+	// FindByKey retrieves an idempotency record by its key.
+	// Returns entity.ErrIdempotencyRecordNotFound if no record exists for the key.
+	FindByKey(ctx context.Context, key string) (*entity.IdempotencyRecord, error)
+
+	// Update stores the response of the original request on an idempotency record, along with when it expires.
+	Update(ctx context.Context, record *entity.IdempotencyRecord) error
+
+	// DeleteByKey removes an idempotency record.
+	DeleteByKey(ctx context.Context, key string) error
+
+	// DeleteByKeyIfExpired removes an idempotency record if it expired at the given time,
+	// and reports whether it did.
+	DeleteByKeyIfExpired(ctx context.Context, key string, now time.Time) (bool, error)
+
+	// DeleteExpiredBefore deletes up to limit idempotency records that expired before the given time,
+	// and returns the number of records it deleted.
+	DeleteExpiredBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// OutboxRepository defines the interface for the outbox of events to be delivered to other services
+type OutboxRepository interface {
+	// Create inserts a new event into the outbox and sets its ID.
+	// It is called within the transaction of the change the event describes.
+	Create(ctx context.Context, event *entity.OutboxEvent) error
+
+	// ListDue retrieves up to limit pending events whose next attempt is due at the given time, oldest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.OutboxEvent, error)
+
+	// ListByOTPID retrieves the events describing an OTP, oldest first.
+	ListByOTPID(ctx context.Context, otpID uint64) ([]*entity.OutboxEvent, error)
+
+	// Update stores the delivery status of an outbox event.
+	Update(ctx context.Context, event *entity.OutboxEvent) error
+}
+
+// WebhookSubscriptionRepository defines the interface for webhook subscriptions of client applications
+type WebhookSubscriptionRepository interface {
+	// Create inserts a new webhook subscription and sets its ID.
+	Create(ctx context.Context, subscription *entity.WebhookSubscription) error
+
+	// FindByID retrieves a webhook subscription by its ID.
+	// Returns entity.ErrWebhookSubscriptionNotFound if no subscription exists with the ID.
+	FindByID(ctx context.Context, id uint64) (*entity.WebhookSubscription, error)
+
+	// ListByClientID retrieves the webhook subscriptions of a client application, or of all clients if clientID is empty.
+	ListByClientID(ctx context.Context, clientID string) ([]*entity.WebhookSubscription, error)
+
+	// ListByClientIDAndEventType retrieves the webhook subscriptions of a client application that subscribe to the given event type.
+	ListByClientIDAndEventType(ctx context.Context, clientID string, eventType entity.EventType) ([]*entity.WebhookSubscription, error)
+
+	// DeleteByID removes a webhook subscription together with its deliveries.
+	// Returns entity.ErrWebhookSubscriptionNotFound if no subscription exists with the ID.
+	DeleteByID(ctx context.Context, id uint64) error
+}
+
+// WebhookDeliveryRepository defines the interface for deliveries of events to webhook subscriptions
+type WebhookDeliveryRepository interface {
+	// Create inserts a new webhook delivery and sets its ID.
+	// A delivery of the same event to the same subscription is only inserted once, in which case the ID is left unset.
+	Create(ctx context.Context, delivery *entity.WebhookDelivery) error
+
+	// FindByID retrieves a webhook delivery by its ID.
+	// Returns entity.ErrWebhookDeliveryNotFound if no delivery exists with the ID.
+	FindByID(ctx context.Context, id uint64) (*entity.WebhookDelivery, error)
+
+	// ListDue retrieves up to limit pending deliveries whose next attempt is due at the given time, oldest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error)
+
+	// ListBySubscriptionID retrieves up to limit deliveries of a subscription, newest first.
+	ListBySubscriptionID(ctx context.Context, subscriptionID uint64, limit int) ([]*entity.WebhookDelivery, error)
+
+	// ListByEventID retrieves the deliveries of an outbox event to every subscription, oldest first.
+	ListByEventID(ctx context.Context, eventID uint64) ([]*entity.WebhookDelivery, error)
+
+	// Update stores the delivery status of a webhook delivery.
+	Update(ctx context.Context, delivery *entity.WebhookDelivery) error
+}
+
+// AuditEventRepository defines the interface for the append-only, hash chained audit log
+type AuditEventRepository interface {
+	// Append chains the event to the last event of the audit log, stores it and sets its ID and hashes.
+	// It must be called within a transaction, which serializes concurrent appends.
+	Append(ctx context.Context, event *entity.AuditEvent) error
+
+	// List retrieves up to limit audit events of a user within the time range of the filter, in chain order.
+	List(ctx context.Context, filter entity.AuditEventFilter) ([]*entity.AuditEvent, error)
+
+	// ListAfter retrieves up to limit audit events following the event with the given ID, in chain order.
+	ListAfter(ctx context.Context, afterID uint64, limit int) ([]*entity.AuditEvent, error)
+
+	// ListByOTPID retrieves the audit events of the operations on an OTP, in chain order.
+	ListByOTPID(ctx context.Context, otpID uint64) ([]*entity.AuditEvent, error)
+
+	// GetChainHead retrieves the pointer to the last event of the audit log.
+	GetChainHead(ctx context.Context) (*entity.AuditChainHead, error)
+}
+
+// OTPPartitionRepository defines the interface for managing the partitions of the stored OTPs by creation time.
+// The partitions after the last bound and the OTPs created after it are left out, they are never dropped.
+type OTPPartitionRepository interface {
+	// ListPartitions retrieves the bounded partitions of the OTPs, in creation time order.
+	ListPartitions(ctx context.Context) ([]entity.OTPPartition, error)
+
+	// AddPartitions splits off a partition for the OTPs created before each of the given days,
+	// which must be in order and later than the bound of the last partition.
+	AddPartitions(ctx context.Context, bounds []time.Time) error
+
+	// DropPartitions deletes the given partitions, which must be the oldest ones, with the OTPs they hold.
+	DropPartitions(ctx context.Context, partitions []entity.OTPPartition) error
+}
+
+// OTPEncryptionRepository defines the interface for encrypting the stored OTPs again with the current data key.
+type OTPEncryptionRepository interface {
+	// ReencryptStale encrypts up to limit OTPs stored in plaintext or encrypted with a previous data key
+	// with the current data key, and returns the number of OTPs it encrypted again.
+	ReencryptStale(ctx context.Context, limit int) (int64, error)
+}
 
-	// GetLastByUserID retrieves the most recent OTP record for a given user,
-	// ordered by creation timestamp descending.
-	GetLastByUserID(ctx context.Context, userID string) (*entity.OTP, error)
+// WebhookSecretEncryptionRepository defines the interface for encrypting the stored webhook signing secrets
+// again with the current data key.
+type WebhookSecretEncryptionRepository interface {
+	// ReencryptStaleSecrets encrypts up to limit signing secrets stored in plaintext or encrypted with a previous data key
+	// with the current data key, and returns the number of secrets it encrypted again.
+	ReencryptStaleSecrets(ctx context.Context, limit int) (int64, error)
 }
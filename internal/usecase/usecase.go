@@ -1,5 +1,12 @@
 package usecase
 
+import (
+	"context"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
 //go:generate mockgen -destination=mock/usecase.go -package=mock -source=usecase.go
 
 type OTPGenerator interface {
@@ -8,3 +15,34 @@ type OTPGenerator interface {
 	// Returns the generated OTP string or an error if random generation fails.
 	Generate() (string, error)
 }
+
+// EventPublisher delivers outbox events to other services.
+type EventPublisher interface {
+	// Publish delivers a single event. An error means the delivery failed and will be retried.
+	Publish(ctx context.Context, event *entity.OutboxEvent) error
+}
+
+// WebhookSender delivers events to the endpoints of webhook subscriptions.
+type WebhookSender interface {
+	// Send posts a delivery to the subscription endpoint, signed with the subscription secret.
+	// It returns the HTTP status code of the response, or zero if no response was received.
+	// An error means the delivery failed and will be retried.
+	Send(ctx context.Context, subscription *entity.WebhookSubscription, delivery *entity.WebhookDelivery) (int, error)
+}
+
+// AuditRecorder records operations in the audit log.
+type AuditRecorder interface {
+	// Record appends an event to the audit log, attributed to the request metadata stored in ctx.
+	// A failure to record is logged and does not fail the audited operation.
+	Record(ctx context.Context, event entity.AuditEvent)
+}
+
+// KeyManager manages the keys the sensitive columns of the stored rows are encrypted with.
+type KeyManager interface {
+	// RotateDataKey creates a new data key to encrypt the new values with if the current one is older than maxAge,
+	// and reports whether it did.
+	RotateDataKey(ctx context.Context, maxAge time.Duration) (bool, error)
+	// RewrapKeys wraps the keys wrapped by a previous master key with the current one,
+	// and returns the number of keys it wrapped again.
+	RewrapKeys(ctx context.Context) (int, error)
+}
@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+type userLockoutUsecase struct {
+	userLockoutRepo UserLockoutRepository
+	auditRecorder   AuditRecorder
+}
+
+func NewUserLockoutUsecase(userLockoutRepo UserLockoutRepository, auditRecorder AuditRecorder) *userLockoutUsecase {
+	return &userLockoutUsecase{
+		userLockoutRepo: userLockoutRepo,
+		auditRecorder:   auditRecorder,
+	}
+}
+
+// Get returns the lockout ledger of the specified user.
+// A user without recorded failures gets an empty, unlocked ledger.
+func (u *userLockoutUsecase) Get(ctx context.Context, userID string) (*entity.UserLockout, error) {
+	lockout, err := u.userLockoutRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserLockoutNotFound) {
+			return &entity.UserLockout{UserID: userID}, nil
+		}
+		return nil, err
+	}
+
+	return lockout, nil
+}
+
+// Clear removes any lockout and resets the failure ledger of the specified user.
+// It is recorded in the audit log.
+func (u *userLockoutUsecase) Clear(ctx context.Context, userID string) error {
+	err := u.userLockoutRepo.DeleteByUserID(ctx, userID)
+	u.auditRecorder.Record(ctx, newAuditEvent(entity.AuditActionLockoutClear, userID, 0, err))
+	return err
+}
@@ -0,0 +1,95 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserLockoutUsecase_Get(t *testing.T) {
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name           string
+		mockDependency func(repo *mock.MockUserLockoutRepository)
+		assertFn       func(*entity.UserLockout, error)
+	}{
+		{
+			name: "should return the user lockout",
+			mockDependency: func(repo *mock.MockUserLockoutRepository) {
+				repo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(&entity.UserLockout{UserID: "user-1", LockedUntil: &lockedUntil}, nil)
+			},
+			assertFn: func(lockout *entity.UserLockout, err error) {
+				assert.Nil(t, err)
+				assert.True(t, lockout.IsLocked(time.Now()))
+			},
+		},
+		{
+			name: "should return an empty ledger if user has no recorded failures",
+			mockDependency: func(repo *mock.MockUserLockoutRepository) {
+				repo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, entity.ErrUserLockoutNotFound)
+			},
+			assertFn: func(lockout *entity.UserLockout, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, &entity.UserLockout{UserID: "user-1"}, lockout)
+			},
+		},
+		{
+			name: "should return error if repository fails",
+			mockDependency: func(repo *mock.MockUserLockoutRepository) {
+				repo.EXPECT().
+					FindByUserID(gomock.Any(), "user-1").
+					Return(nil, errors.New("db error"))
+			},
+			assertFn: func(lockout *entity.UserLockout, err error) {
+				assert.Nil(t, lockout)
+				assert.EqualError(t, err, "db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserLockoutRepository(ctrl)
+			tt.mockDependency(repo)
+
+			usc := usecase.NewUserLockoutUsecase(repo, mock.NewMockAuditRecorder(ctrl))
+			tt.assertFn(usc.Get(context.Background(), "user-1"))
+		})
+	}
+}
+
+func TestUserLockoutUsecase_Clear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockUserLockoutRepository(ctrl)
+	repo.EXPECT().
+		DeleteByUserID(gomock.Any(), "user-1").
+		Return(nil)
+
+	auditRecorder := mock.NewMockAuditRecorder(ctrl)
+	auditRecorder.EXPECT().
+		Record(gomock.Any(), entity.AuditEvent{
+			UserID:  "user-1",
+			Action:  entity.AuditActionLockoutClear,
+			Outcome: entity.AuditOutcomeSuccess,
+		})
+
+	usc := usecase.NewUserLockoutUsecase(repo, auditRecorder)
+	assert.NoError(t, usc.Clear(context.Background(), "user-1"))
+}
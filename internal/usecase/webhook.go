@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+// webhookSecretBytes is the number of random bytes of a webhook subscription secret
+const webhookSecretBytes = 32
+
+// WebhookPolicy configures the delivery of events to webhook subscriptions.
+type WebhookPolicy struct {
+	// BatchSize is the maximum number of deliveries attempted by a single dispatch.
+	BatchSize int
+	// MaxAttempts is the number of failed attempts after which a delivery is given up.
+	MaxAttempts int
+	// BackoffBase is the delay before the first retry; it doubles with every further failure.
+	BackoffBase time.Duration
+	// BackoffMax caps the delay between retries.
+	BackoffMax time.Duration
+}
+
+type webhookUsecase struct {
+	subscriptionRepo WebhookSubscriptionRepository
+	deliveryRepo     WebhookDeliveryRepository
+	sender           WebhookSender
+	policy           WebhookPolicy
+}
+
+func NewWebhookUsecase(
+	subscriptionRepo WebhookSubscriptionRepository,
+	deliveryRepo WebhookDeliveryRepository,
+	sender WebhookSender,
+	policy WebhookPolicy,
+) *webhookUsecase {
+	return &webhookUsecase{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		sender:           sender,
+		policy:           policy,
+	}
+}
+
+// CreateSubscription subscribes an endpoint of a client application to the given event types.
+// The returned subscription holds the generated secret that signs every delivery; it is not returned again.
+func (u *webhookUsecase) CreateSubscription(ctx context.Context, params entity.CreateWebhookSubscriptionParams) (*entity.WebhookSubscription, error) {
+	if err := validateWebhookSubscription(params); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	subscription := &entity.WebhookSubscription{
+		ClientID:   params.ClientID,
+		URL:        params.URL,
+		EventTypes: params.EventTypes,
+		Secret:     hex.EncodeToString(secret),
+		CreatedAt:  time.Now(),
+	}
+	if err := u.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// GetSubscription retrieves a webhook subscription by its ID.
+func (u *webhookUsecase) GetSubscription(ctx context.Context, id uint64) (*entity.WebhookSubscription, error) {
+	return u.subscriptionRepo.FindByID(ctx, id)
+}
+
+// ListSubscriptions retrieves the webhook subscriptions of a client application, or of all clients if clientID is empty.
+func (u *webhookUsecase) ListSubscriptions(ctx context.Context, clientID string) ([]*entity.WebhookSubscription, error) {
+	return u.subscriptionRepo.ListByClientID(ctx, clientID)
+}
+
+// DeleteSubscription unsubscribes an endpoint; its pending deliveries are dropped.
+func (u *webhookUsecase) DeleteSubscription(ctx context.Context, id uint64) error {
+	return u.subscriptionRepo.DeleteByID(ctx, id)
+}
+
+// ListDeliveries retrieves up to limit of the most recent deliveries of a subscription, newest first.
+func (u *webhookUsecase) ListDeliveries(ctx context.Context, subscriptionID uint64, limit int) ([]*entity.WebhookDelivery, error) {
+	if _, err := u.subscriptionRepo.FindByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	return u.deliveryRepo.ListBySubscriptionID(ctx, subscriptionID, limit)
+}
+
+// ReplayDelivery schedules a delivery to be attempted again by the next dispatch,
+// whether it was delivered, given up or is still being retried.
+func (u *webhookUsecase) ReplayDelivery(ctx context.Context, id uint64) (*entity.WebhookDelivery, error) {
+	delivery, err := u.deliveryRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Replay(time.Now())
+	if err := u.deliveryRepo.Update(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery %d: %w", id, err)
+	}
+
+	return delivery, nil
+}
+
+// Publish fans an outbox event out to the webhook subscriptions of its type of the client the event is about,
+// creating a delivery for each of them. An event of an unknown client is published to no subscription.
+// Publishing the same event again does not duplicate its deliveries, so the outbox can safely retry it.
+func (u *webhookUsecase) Publish(ctx context.Context, event *entity.OutboxEvent) error {
+	if event.ClientID == "" {
+		return nil
+	}
+
+	subscriptions, err := u.subscriptionRepo.ListByClientIDAndEventType(ctx, event.ClientID, event.EventType)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		delivery := &entity.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.EventType,
+			Payload:        event.Payload,
+			Status:         entity.OutboxStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      event.CreatedAt,
+		}
+		if err := u.deliveryRepo.Create(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to create webhook delivery for subscription %d: %w", subscription.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// DispatchPending attempts the webhook deliveries that are due, oldest first, and returns
+// the number of successful and the number of failed deliveries.
+// The response code of every attempt is recorded, and a failed delivery is retried with
+// exponential backoff until the maximum number of attempts is reached.
+// A delivery whose subscription cannot be loaded fails like an attempt that could not be sent,
+// and right away if the subscription was deleted, without holding up the rest of the batch.
+func (u *webhookUsecase) DispatchPending(ctx context.Context) (int, int, error) {
+	deliveries, err := u.deliveryRepo.ListDue(ctx, time.Now(), u.policy.BatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+
+	var (
+		subscriptions = make(map[uint64]*entity.WebhookSubscription)
+		lookupErrs    = make(map[uint64]error)
+	)
+
+	var delivered, failed int
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return delivered, failed, err
+		}
+
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		lookupErr, lookedUp := lookupErrs[delivery.SubscriptionID]
+		if !ok && !lookedUp {
+			subscription, err = u.subscriptionRepo.FindByID(ctx, delivery.SubscriptionID)
+			if err != nil {
+				lookupErr = fmt.Errorf("failed to find webhook subscription %d: %w", delivery.SubscriptionID, err)
+				lookupErrs[delivery.SubscriptionID] = lookupErr
+			} else {
+				subscriptions[delivery.SubscriptionID] = subscription
+			}
+		}
+
+		var (
+			responseCode int
+			sendErr      = lookupErr
+		)
+		if sendErr == nil {
+			responseCode, sendErr = u.sender.Send(ctx, subscription, delivery)
+		}
+
+		now := time.Now()
+		delivery.ResponseCode = responseCode
+		if sendErr == nil {
+			delivered++
+			delivery.Status = entity.OutboxStatusDelivered
+			delivery.LastError = ""
+			delivery.DeliveredAt = &now
+		} else {
+			failed++
+			delivery.Attempts++
+			delivery.LastError = sendErr.Error()
+			if delivery.Attempts >= u.policy.MaxAttempts || errors.Is(sendErr, entity.ErrWebhookSubscriptionNotFound) {
+				delivery.Status = entity.OutboxStatusFailed
+			} else {
+				delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts, u.policy.BackoffBase, u.policy.BackoffMax))
+			}
+		}
+
+		if err := u.deliveryRepo.Update(ctx, delivery); err != nil {
+			return delivered, failed, fmt.Errorf("failed to update webhook delivery %d: %w", delivery.ID, err)
+		}
+	}
+
+	return delivered, failed, nil
+}
+
+// validateWebhookSubscription checks that a subscription has an absolute http(s) URL
+// and subscribes to at least one known event type
+func validateWebhookSubscription(params entity.CreateWebhookSubscriptionParams) error {
+	if params.ClientID == "" || len(params.EventTypes) == 0 {
+		return entity.ErrInvalidRequest
+	}
+
+	endpoint, err := url.ParseRequestURI(params.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return entity.ErrInvalidRequest
+	}
+
+	for _, eventType := range params.EventTypes {
+		if !eventType.IsValid() {
+			return entity.ErrInvalidRequest
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,392 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/usecase"
+	"github.com/imansohibul/otp-service/internal/usecase/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var webhookPolicy = usecase.WebhookPolicy{
+	BatchSize:   10,
+	MaxAttempts: 3,
+	BackoffBase: time.Second,
+	BackoffMax:  90 * time.Second,
+}
+
+type webhookDependency struct {
+	subscriptionRepo *mock.MockWebhookSubscriptionRepository
+	deliveryRepo     *mock.MockWebhookDeliveryRepository
+	sender           *mock.MockWebhookSender
+}
+
+func TestWebhookUsecase_CreateSubscription(t *testing.T) {
+	validParams := entity.CreateWebhookSubscriptionParams{
+		ClientID:   "client-1",
+		URL:        "https://client.example/hooks",
+		EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+	}
+
+	tests := []struct {
+		name           string
+		params         entity.CreateWebhookSubscriptionParams
+		mockDependency func(dep *webhookDependency)
+		assertFn       func(subscription *entity.WebhookSubscription, err error)
+	}{
+		{
+			name:   "should create the subscription with a generated secret",
+			params: validParams,
+			mockDependency: func(dep *webhookDependency) {
+				dep.subscriptionRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subscription *entity.WebhookSubscription) error {
+						subscription.ID = 3
+						return nil
+					})
+			},
+			assertFn: func(subscription *entity.WebhookSubscription, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(3), subscription.ID)
+				assert.Equal(t, "client-1", subscription.ClientID)
+				assert.Len(t, subscription.Secret, 64)
+			},
+		},
+		{
+			name: "should reject an URL that is not http or https",
+			params: entity.CreateWebhookSubscriptionParams{
+				ClientID:   "client-1",
+				URL:        "ftp://client.example/hooks",
+				EventTypes: []entity.EventType{entity.EventTypeOTPValidated},
+			},
+			mockDependency: func(dep *webhookDependency) {},
+			assertFn: func(subscription *entity.WebhookSubscription, err error) {
+				assert.Nil(t, subscription)
+				assert.Equal(t, entity.ErrInvalidRequest, err)
+			},
+		},
+		{
+			name: "should reject an unknown event type",
+			params: entity.CreateWebhookSubscriptionParams{
+				ClientID:   "client-1",
+				URL:        "https://client.example/hooks",
+				EventTypes: []entity.EventType{"otp.deleted"},
+			},
+			mockDependency: func(dep *webhookDependency) {},
+			assertFn: func(subscription *entity.WebhookSubscription, err error) {
+				assert.Nil(t, subscription)
+				assert.Equal(t, entity.ErrInvalidRequest, err)
+			},
+		},
+		{
+			name:   "should return error if the subscription cannot be stored",
+			params: validParams,
+			mockDependency: func(dep *webhookDependency) {
+				dep.subscriptionRepo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(errors.New("db error"))
+			},
+			assertFn: func(subscription *entity.WebhookSubscription, err error) {
+				assert.Nil(t, subscription)
+				assert.EqualError(t, err, "failed to create webhook subscription: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := &webhookDependency{
+				subscriptionRepo: mock.NewMockWebhookSubscriptionRepository(ctrl),
+				deliveryRepo:     mock.NewMockWebhookDeliveryRepository(ctrl),
+				sender:           mock.NewMockWebhookSender(ctrl),
+			}
+			tt.mockDependency(dep)
+
+			usc := usecase.NewWebhookUsecase(dep.subscriptionRepo, dep.deliveryRepo, dep.sender, webhookPolicy)
+			tt.assertFn(usc.CreateSubscription(context.Background(), tt.params))
+		})
+	}
+}
+
+func TestWebhookUsecase_ListDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	subscriptionRepo := mock.NewMockWebhookSubscriptionRepository(ctrl)
+	deliveryRepo := mock.NewMockWebhookDeliveryRepository(ctrl)
+	subscriptionRepo.EXPECT().
+		FindByID(gomock.Any(), uint64(3)).
+		Return(nil, entity.ErrWebhookSubscriptionNotFound)
+
+	usc := usecase.NewWebhookUsecase(subscriptionRepo, deliveryRepo, mock.NewMockWebhookSender(ctrl), webhookPolicy)
+	deliveries, err := usc.ListDeliveries(context.Background(), 3, 50)
+	assert.Nil(t, deliveries)
+	assert.Equal(t, entity.ErrWebhookSubscriptionNotFound, err)
+}
+
+func TestWebhookUsecase_ReplayDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deliveredAt := time.Now().Add(-time.Hour)
+	subscriptionRepo := mock.NewMockWebhookSubscriptionRepository(ctrl)
+	deliveryRepo := mock.NewMockWebhookDeliveryRepository(ctrl)
+	deliveryRepo.EXPECT().
+		FindByID(gomock.Any(), uint64(11)).
+		Return(&entity.WebhookDelivery{ID: 11, Status: entity.OutboxStatusDelivered, DeliveredAt: &deliveredAt}, nil)
+	deliveryRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+			assert.Equal(t, entity.OutboxStatusPending, delivery.Status)
+			assert.Zero(t, delivery.Attempts)
+			assert.Nil(t, delivery.DeliveredAt)
+			assert.WithinDuration(t, time.Now(), delivery.NextAttemptAt, time.Second)
+			return nil
+		})
+
+	usc := usecase.NewWebhookUsecase(subscriptionRepo, deliveryRepo, mock.NewMockWebhookSender(ctrl), webhookPolicy)
+	delivery, err := usc.ReplayDelivery(context.Background(), 11)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(11), delivery.ID)
+}
+
+func TestWebhookUsecase_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Now().Add(-time.Minute)
+	event := &entity.OutboxEvent{ID: 7, EventType: entity.EventTypeOTPValidated, ClientID: "client-1", Payload: []byte(`{}`), CreatedAt: createdAt}
+
+	subscriptionRepo := mock.NewMockWebhookSubscriptionRepository(ctrl)
+	deliveryRepo := mock.NewMockWebhookDeliveryRepository(ctrl)
+	subscriptionRepo.EXPECT().
+		ListByClientIDAndEventType(gomock.Any(), "client-1", entity.EventTypeOTPValidated).
+		Return([]*entity.WebhookSubscription{{ID: 3}, {ID: 4}}, nil)
+	deliveryRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+			assert.Equal(t, uint64(7), delivery.EventID)
+			assert.Equal(t, entity.OutboxStatusPending, delivery.Status)
+			assert.Equal(t, createdAt, delivery.CreatedAt)
+			return nil
+		}).
+		Times(2)
+
+	usc := usecase.NewWebhookUsecase(subscriptionRepo, deliveryRepo, mock.NewMockWebhookSender(ctrl), webhookPolicy)
+	assert.Nil(t, usc.Publish(context.Background(), event))
+}
+
+func TestWebhookUsecase_Publish_UnknownClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No subscription is looked up for an event of an OTP requested without a client ID
+	event := &entity.OutboxEvent{ID: 7, EventType: entity.EventTypeOTPValidated, Payload: []byte(`{}`), CreatedAt: time.Now()}
+
+	usc := usecase.NewWebhookUsecase(
+		mock.NewMockWebhookSubscriptionRepository(ctrl),
+		mock.NewMockWebhookDeliveryRepository(ctrl),
+		mock.NewMockWebhookSender(ctrl),
+		webhookPolicy,
+	)
+	assert.Nil(t, usc.Publish(context.Background(), event))
+}
+
+func TestWebhookUsecase_DispatchPending(t *testing.T) {
+	subscription := &entity.WebhookSubscription{ID: 3, URL: "https://client.example/hooks", Secret: "secret"}
+
+	tests := []struct {
+		name           string
+		mockDependency func(dep *webhookDependency)
+		assertFn       func(delivered int, failed int, err error)
+	}{
+		{
+			name: "should send due deliveries and record the response code",
+			mockDependency: func(dep *webhookDependency) {
+				first := &entity.WebhookDelivery{ID: 11, SubscriptionID: 3, Status: entity.OutboxStatusPending}
+				second := &entity.WebhookDelivery{ID: 12, SubscriptionID: 3, Status: entity.OutboxStatusPending}
+				dep.deliveryRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.WebhookDelivery{first, second}, nil)
+				dep.subscriptionRepo.EXPECT().
+					FindByID(gomock.Any(), uint64(3)).
+					Return(subscription, nil)
+				dep.sender.EXPECT().
+					Send(gomock.Any(), subscription, gomock.Any()).
+					Return(http.StatusNoContent, nil).
+					Times(2)
+				dep.deliveryRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+						assert.Equal(t, entity.OutboxStatusDelivered, delivery.Status)
+						assert.Equal(t, http.StatusNoContent, delivery.ResponseCode)
+						assert.NotNil(t, delivery.DeliveredAt)
+						return nil
+					}).
+					Times(2)
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 2, delivered)
+				assert.Zero(t, failed)
+			},
+		},
+		{
+			name: "should retry a failed delivery with exponential backoff",
+			mockDependency: func(dep *webhookDependency) {
+				delivery := &entity.WebhookDelivery{ID: 11, SubscriptionID: 3, Status: entity.OutboxStatusPending, Attempts: 1}
+				dep.deliveryRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.WebhookDelivery{delivery}, nil)
+				dep.subscriptionRepo.EXPECT().
+					FindByID(gomock.Any(), uint64(3)).
+					Return(subscription, nil)
+				dep.sender.EXPECT().
+					Send(gomock.Any(), subscription, delivery).
+					Return(http.StatusServiceUnavailable, errors.New("webhook responded with status 503"))
+				dep.deliveryRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+						assert.Equal(t, entity.OutboxStatusPending, delivery.Status)
+						assert.Equal(t, 2, delivery.Attempts)
+						assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseCode)
+						assert.Equal(t, "webhook responded with status 503", delivery.LastError)
+						assert.WithinDuration(t, time.Now().Add(2*time.Second), delivery.NextAttemptAt, time.Second)
+						return nil
+					})
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Zero(t, delivered)
+				assert.Equal(t, 1, failed)
+			},
+		},
+		{
+			name: "should give up a delivery after the maximum number of attempts",
+			mockDependency: func(dep *webhookDependency) {
+				delivery := &entity.WebhookDelivery{ID: 11, SubscriptionID: 3, Status: entity.OutboxStatusPending, Attempts: 2}
+				dep.deliveryRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.WebhookDelivery{delivery}, nil)
+				dep.subscriptionRepo.EXPECT().
+					FindByID(gomock.Any(), uint64(3)).
+					Return(subscription, nil)
+				dep.sender.EXPECT().
+					Send(gomock.Any(), subscription, delivery).
+					Return(0, errors.New("connection refused"))
+				dep.deliveryRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+						assert.Equal(t, entity.OutboxStatusFailed, delivery.Status)
+						assert.Equal(t, 3, delivery.Attempts)
+						assert.Zero(t, delivery.ResponseCode)
+						return nil
+					})
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 1, failed)
+			},
+		},
+		{
+			name: "should fail the deliveries of a deleted subscription and send the rest of the batch",
+			mockDependency: func(dep *webhookDependency) {
+				orphaned := &entity.WebhookDelivery{ID: 11, SubscriptionID: 4, Status: entity.OutboxStatusPending}
+				sibling := &entity.WebhookDelivery{ID: 12, SubscriptionID: 4, Status: entity.OutboxStatusPending}
+				delivery := &entity.WebhookDelivery{ID: 13, SubscriptionID: 3, Status: entity.OutboxStatusPending}
+				dep.deliveryRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.WebhookDelivery{orphaned, sibling, delivery}, nil)
+				dep.subscriptionRepo.EXPECT().
+					FindByID(gomock.Any(), uint64(4)).
+					Return(nil, entity.ErrWebhookSubscriptionNotFound)
+				dep.subscriptionRepo.EXPECT().
+					FindByID(gomock.Any(), uint64(3)).
+					Return(subscription, nil)
+				dep.sender.EXPECT().
+					Send(gomock.Any(), subscription, delivery).
+					Return(http.StatusNoContent, nil)
+				dep.deliveryRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+						if delivery.SubscriptionID == 4 {
+							assert.Equal(t, entity.OutboxStatusFailed, delivery.Status)
+							assert.Equal(t, 1, delivery.Attempts)
+							assert.Equal(t, "failed to find webhook subscription 4: "+entity.ErrWebhookSubscriptionNotFound.Error(), delivery.LastError)
+						} else {
+							assert.Equal(t, entity.OutboxStatusDelivered, delivery.Status)
+						}
+						return nil
+					}).
+					Times(3)
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 1, delivered)
+				assert.Equal(t, 2, failed)
+			},
+		},
+		{
+			name: "should retry a delivery whose subscription could not be loaded",
+			mockDependency: func(dep *webhookDependency) {
+				delivery := &entity.WebhookDelivery{ID: 11, SubscriptionID: 3, Status: entity.OutboxStatusPending}
+				dep.deliveryRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return([]*entity.WebhookDelivery{delivery}, nil)
+				dep.subscriptionRepo.EXPECT().
+					FindByID(gomock.Any(), uint64(3)).
+					Return(nil, errors.New("db error"))
+				dep.deliveryRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, delivery *entity.WebhookDelivery) error {
+						assert.Equal(t, entity.OutboxStatusPending, delivery.Status)
+						assert.Equal(t, 1, delivery.Attempts)
+						assert.Equal(t, "failed to find webhook subscription 3: db error", delivery.LastError)
+						return nil
+					})
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.Nil(t, err)
+				assert.Zero(t, delivered)
+				assert.Equal(t, 1, failed)
+			},
+		},
+		{
+			name: "should return error if listing due deliveries fails",
+			mockDependency: func(dep *webhookDependency) {
+				dep.deliveryRepo.EXPECT().
+					ListDue(gomock.Any(), gomock.Any(), 10).
+					Return(nil, errors.New("db error"))
+			},
+			assertFn: func(delivered int, failed int, err error) {
+				assert.EqualError(t, err, "failed to list due webhook deliveries: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := &webhookDependency{
+				subscriptionRepo: mock.NewMockWebhookSubscriptionRepository(ctrl),
+				deliveryRepo:     mock.NewMockWebhookDeliveryRepository(ctrl),
+				sender:           mock.NewMockWebhookSender(ctrl),
+			}
+			tt.mockDependency(dep)
+
+			usc := usecase.NewWebhookUsecase(dep.subscriptionRepo, dep.deliveryRepo, dep.sender, webhookPolicy)
+			tt.assertFn(usc.DispatchPending(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExpirySweeper marks expired OTPs as such and purges OTPs past the retention period,
+// along with the idempotency keys past their replay window.
+// It is run periodically as a scheduler job, so only one instance sweeps at a time.
+type ExpirySweeper struct {
+	SweeperUsecase OTPSweeperUsecase
+}
+
+// NewExpirySweeper creates an expiry sweeper.
+func NewExpirySweeper(sweeperUsecase OTPSweeperUsecase) *ExpirySweeper {
+	return &ExpirySweeper{
+		SweeperUsecase: sweeperUsecase,
+	}
+}
+
+// RunOnce expires stale OTPs and then purges the ones past the retention period and the expired idempotency keys,
+// recording the progress in the sweeper metrics.
+func (s *ExpirySweeper) RunOnce(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		sweeperRunDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	expired, err := s.SweeperUsecase.ExpireStale(ctx)
+	sweeperOTPsExpired.Add(float64(expired))
+	if err != nil {
+		sweeperRuns.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to expire stale OTPs: %w", err)
+	}
+
+	purged, err := s.SweeperUsecase.PurgeStale(ctx)
+	sweeperOTPsPurged.Add(float64(purged))
+	if err != nil {
+		sweeperRuns.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to purge stale OTPs: %w", err)
+	}
+
+	idempotencyKeysPurged, err := s.SweeperUsecase.PurgeIdempotencyKeys(ctx)
+	sweeperIdempotencyKeysPurged.Add(float64(idempotencyKeysPurged))
+	if err != nil {
+		sweeperRuns.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to purge expired idempotency keys: %w", err)
+	}
+
+	sweeperRuns.WithLabelValues("success").Inc()
+	sweeperLastSuccess.SetToCurrentTime()
+
+	log.Info().
+		Int64("expired", expired).
+		Int64("purged", purged).
+		Int64("idempotency_keys_purged", idempotencyKeysPurged).
+		Dur("duration", time.Since(start)).
+		Msg("OTP expiry sweeper run completed")
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/internal/worker"
+	usecasemock "github.com/imansohibul/otp-service/internal/worker/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpirySweeper_RunOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(sweeperUsecase *usecasemock.MockOTPSweeperUsecase)
+		assertFn  func(err error)
+	}{
+		{
+			name: "Should expire and then purge stale OTPs and idempotency keys",
+			mockSetup: func(sweeperUsecase *usecasemock.MockOTPSweeperUsecase) {
+				gomock.InOrder(
+					sweeperUsecase.EXPECT().ExpireStale(gomock.Any()).Return(int64(3), nil),
+					sweeperUsecase.EXPECT().PurgeStale(gomock.Any()).Return(int64(2), nil),
+					sweeperUsecase.EXPECT().PurgeIdempotencyKeys(gomock.Any()).Return(int64(4), nil),
+				)
+			},
+			assertFn: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "Should not purge if expiring fails",
+			mockSetup: func(sweeperUsecase *usecasemock.MockOTPSweeperUsecase) {
+				sweeperUsecase.EXPECT().ExpireStale(gomock.Any()).Return(int64(0), errors.New("db error"))
+			},
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "failed to expire stale OTPs: db error")
+			},
+		},
+		{
+			name: "Should return error if purging fails",
+			mockSetup: func(sweeperUsecase *usecasemock.MockOTPSweeperUsecase) {
+				sweeperUsecase.EXPECT().ExpireStale(gomock.Any()).Return(int64(3), nil)
+				sweeperUsecase.EXPECT().PurgeStale(gomock.Any()).Return(int64(0), errors.New("db error"))
+			},
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "failed to purge stale OTPs: db error")
+			},
+		},
+		{
+			name: "Should return error if purging idempotency keys fails",
+			mockSetup: func(sweeperUsecase *usecasemock.MockOTPSweeperUsecase) {
+				sweeperUsecase.EXPECT().ExpireStale(gomock.Any()).Return(int64(3), nil)
+				sweeperUsecase.EXPECT().PurgeStale(gomock.Any()).Return(int64(2), nil)
+				sweeperUsecase.EXPECT().PurgeIdempotencyKeys(gomock.Any()).Return(int64(0), errors.New("db error"))
+			},
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "failed to purge expired idempotency keys: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sweeperUsecase := usecasemock.NewMockOTPSweeperUsecase(ctrl)
+			tt.mockSetup(sweeperUsecase)
+
+			sweeper := worker.NewExpirySweeper(sweeperUsecase)
+			tt.assertFn(sweeper.RunOnce(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// KeyRotator rotates the keys the stored OTPs and webhook secrets are encrypted with, and encrypts them again with the current data key.
+// It is run periodically as a scheduler job, so only one instance rotates the keys at a time.
+type KeyRotator struct {
+	EncryptionUsecase EncryptionUsecase
+}
+
+// NewKeyRotator creates a key rotator.
+func NewKeyRotator(encryptionUsecase EncryptionUsecase) *KeyRotator {
+	return &KeyRotator{
+		EncryptionUsecase: encryptionUsecase,
+	}
+}
+
+// RunOnce rotates the keys, recording the progress in the encryption metrics.
+func (r *KeyRotator) RunOnce(ctx context.Context) error {
+	start := time.Now()
+
+	rotation, err := r.EncryptionUsecase.RotateKeys(ctx)
+	if rotation != nil {
+		if rotation.DataKeyRotated {
+			dataKeysRotated.Inc()
+		}
+		keysRewrapped.Add(float64(rotation.Rewrapped))
+		otpsReencrypted.Add(float64(rotation.Reencrypted))
+		webhookSecretsReencrypted.Add(float64(rotation.ReencryptedSecrets))
+	}
+	if err != nil {
+		encryptionRuns.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to rotate encryption keys: %w", err)
+	}
+
+	encryptionRuns.WithLabelValues("success").Inc()
+	encryptionLastSuccess.SetToCurrentTime()
+
+	log.Info().
+		Bool("data_key_rotated", rotation.DataKeyRotated).
+		Int("rewrapped", rotation.Rewrapped).
+		Int64("reencrypted", rotation.Reencrypted).
+		Int64("reencrypted_secrets", rotation.ReencryptedSecrets).
+		Dur("duration", time.Since(start)).
+		Msg("Key rotator run completed")
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/entity"
+	"github.com/imansohibul/otp-service/internal/worker"
+	usecasemock "github.com/imansohibul/otp-service/internal/worker/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotator_RunOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(encryptionUsecase *usecasemock.MockEncryptionUsecase)
+		assertFn  func(err error)
+	}{
+		{
+			name: "Should rotate the keys",
+			mockSetup: func(encryptionUsecase *usecasemock.MockEncryptionUsecase) {
+				encryptionUsecase.EXPECT().RotateKeys(gomock.Any()).
+					Return(&entity.KeyRotation{DataKeyRotated: true, Rewrapped: 1, Reencrypted: 10, ReencryptedSecrets: 2}, nil)
+			},
+			assertFn: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "Should return error if rotating the keys fails",
+			mockSetup: func(encryptionUsecase *usecasemock.MockEncryptionUsecase) {
+				encryptionUsecase.EXPECT().RotateKeys(gomock.Any()).
+					Return(&entity.KeyRotation{DataKeyRotated: true}, errors.New("kms error"))
+			},
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "failed to rotate encryption keys: kms error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			encryptionUsecase := usecasemock.NewMockEncryptionUsecase(ctrl)
+			tt.mockSetup(encryptionUsecase)
+
+			rotator := worker.NewKeyRotator(encryptionUsecase)
+			tt.assertFn(rotator.RunOnce(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered on the default registry, which is served by the /metrics route of the REST API.
+var (
+	sweeperRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "sweeper",
+		Name:      "runs_total",
+		Help:      "Number of expiry sweeper runs, partitioned by result.",
+	}, []string{"result"})
+
+	sweeperRunDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "otp_service",
+		Subsystem: "sweeper",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of expiry sweeper runs.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	sweeperLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "otp_service",
+		Subsystem: "sweeper",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last expiry sweeper run that completed without error.",
+	})
+
+	sweeperOTPsExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "sweeper",
+		Name:      "otps_expired_total",
+		Help:      "Number of OTPs marked as expired by the expiry sweeper.",
+	})
+
+	sweeperOTPsPurged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "sweeper",
+		Name:      "otps_purged_total",
+		Help:      "Number of OTPs deleted by the expiry sweeper after the retention period.",
+	})
+
+	sweeperIdempotencyKeysPurged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "sweeper",
+		Name:      "idempotency_keys_purged_total",
+		Help:      "Number of idempotency keys deleted by the expiry sweeper after their replay window.",
+	})
+)
+
+var (
+	outboxEventsDelivered = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "outbox",
+		Name:      "events_delivered_total",
+		Help:      "Number of outbox events delivered by the dispatcher.",
+	})
+
+	outboxDeliveryFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "outbox",
+		Name:      "delivery_failures_total",
+		Help:      "Number of failed outbox event deliveries, each of which is retried until the maximum number of attempts.",
+	})
+)
+
+var (
+	webhookDeliveriesSucceeded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "webhook",
+		Name:      "deliveries_succeeded_total",
+		Help:      "Number of webhook deliveries acknowledged with a 2xx response.",
+	})
+
+	webhookDeliveryFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "webhook",
+		Name:      "delivery_failures_total",
+		Help:      "Number of failed webhook delivery attempts, each of which is retried until the maximum number of attempts.",
+	})
+)
+
+var (
+	partitionRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "partitions",
+		Name:      "runs_total",
+		Help:      "Number of partition maintainer runs, partitioned by result.",
+	}, []string{"result"})
+
+	partitionLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "otp_service",
+		Subsystem: "partitions",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last partition maintainer run that completed without error.",
+	})
+
+	partitionsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "partitions",
+		Name:      "created_total",
+		Help:      "Number of partitions of the otps table created ahead of time by the partition maintainer.",
+	})
+
+	partitionsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "partitions",
+		Name:      "dropped_total",
+		Help:      "Number of partitions of the otps table dropped by the partition maintainer after the retention period.",
+	})
+
+	encryptionRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "encryption",
+		Name:      "runs_total",
+		Help:      "Number of key rotator runs, partitioned by result.",
+	}, []string{"result"})
+
+	encryptionLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "otp_service",
+		Subsystem: "encryption",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last key rotator run that completed without error.",
+	})
+
+	dataKeysRotated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "encryption",
+		Name:      "data_keys_rotated_total",
+		Help:      "Number of data keys created by the key rotator to replace the current one past its maximum age.",
+	})
+
+	keysRewrapped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "encryption",
+		Name:      "keys_rewrapped_total",
+		Help:      "Number of keys wrapped again with the current master key by the key rotator.",
+	})
+
+	otpsReencrypted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "encryption",
+		Name:      "otps_reencrypted_total",
+		Help:      "Number of OTPs encrypted again with the current data key by the key rotator.",
+	})
+
+	webhookSecretsReencrypted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "otp_service",
+		Subsystem: "encryption",
+		Name:      "webhook_secrets_reencrypted_total",
+		Help:      "Number of webhook signing secrets encrypted again with the current data key by the key rotator.",
+	})
+)
@@ -0,0 +1,236 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	entity "github.com/imansohibul/otp-service/entity"
+)
+
+// MockOTPSweeperUsecase is a mock of OTPSweeperUsecase interface.
+type MockOTPSweeperUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockOTPSweeperUsecaseMockRecorder
+}
+
+// MockOTPSweeperUsecaseMockRecorder is the mock recorder for MockOTPSweeperUsecase.
+type MockOTPSweeperUsecaseMockRecorder struct {
+	mock *MockOTPSweeperUsecase
+}
+
+// NewMockOTPSweeperUsecase creates a new mock instance.
+func NewMockOTPSweeperUsecase(ctrl *gomock.Controller) *MockOTPSweeperUsecase {
+	mock := &MockOTPSweeperUsecase{ctrl: ctrl}
+	mock.recorder = &MockOTPSweeperUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOTPSweeperUsecase) EXPECT() *MockOTPSweeperUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ExpireStale mocks base method.
+func (m *MockOTPSweeperUsecase) ExpireStale(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireStale", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireStale indicates an expected call of ExpireStale.
+func (mr *MockOTPSweeperUsecaseMockRecorder) ExpireStale(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireStale", reflect.TypeOf((*MockOTPSweeperUsecase)(nil).ExpireStale), ctx)
+}
+
+// PurgeIdempotencyKeys mocks base method.
+func (m *MockOTPSweeperUsecase) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeIdempotencyKeys", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeIdempotencyKeys indicates an expected call of PurgeIdempotencyKeys.
+func (mr *MockOTPSweeperUsecaseMockRecorder) PurgeIdempotencyKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeIdempotencyKeys", reflect.TypeOf((*MockOTPSweeperUsecase)(nil).PurgeIdempotencyKeys), ctx)
+}
+
+// PurgeStale mocks base method.
+func (m *MockOTPSweeperUsecase) PurgeStale(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeStale", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeStale indicates an expected call of PurgeStale.
+func (mr *MockOTPSweeperUsecaseMockRecorder) PurgeStale(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeStale", reflect.TypeOf((*MockOTPSweeperUsecase)(nil).PurgeStale), ctx)
+}
+
+// MockOutboxUsecase is a mock of OutboxUsecase interface.
+type MockOutboxUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxUsecaseMockRecorder
+}
+
+// MockOutboxUsecaseMockRecorder is the mock recorder for MockOutboxUsecase.
+type MockOutboxUsecaseMockRecorder struct {
+	mock *MockOutboxUsecase
+}
+
+// NewMockOutboxUsecase creates a new mock instance.
+func NewMockOutboxUsecase(ctrl *gomock.Controller) *MockOutboxUsecase {
+	mock := &MockOutboxUsecase{ctrl: ctrl}
+	mock.recorder = &MockOutboxUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxUsecase) EXPECT() *MockOutboxUsecaseMockRecorder {
+	return m.recorder
+}
+
+// DispatchPending mocks base method.
+func (m *MockOutboxUsecase) DispatchPending(ctx context.Context) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchPending", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DispatchPending indicates an expected call of DispatchPending.
+func (mr *MockOutboxUsecaseMockRecorder) DispatchPending(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchPending", reflect.TypeOf((*MockOutboxUsecase)(nil).DispatchPending), ctx)
+}
+
+// MockWebhookDispatchUsecase is a mock of WebhookDispatchUsecase interface.
+type MockWebhookDispatchUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookDispatchUsecaseMockRecorder
+}
+
+// MockWebhookDispatchUsecaseMockRecorder is the mock recorder for MockWebhookDispatchUsecase.
+type MockWebhookDispatchUsecaseMockRecorder struct {
+	mock *MockWebhookDispatchUsecase
+}
+
+// NewMockWebhookDispatchUsecase creates a new mock instance.
+func NewMockWebhookDispatchUsecase(ctrl *gomock.Controller) *MockWebhookDispatchUsecase {
+	mock := &MockWebhookDispatchUsecase{ctrl: ctrl}
+	mock.recorder = &MockWebhookDispatchUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookDispatchUsecase) EXPECT() *MockWebhookDispatchUsecaseMockRecorder {
+	return m.recorder
+}
+
+// DispatchPending mocks base method.
+func (m *MockWebhookDispatchUsecase) DispatchPending(ctx context.Context) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchPending", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DispatchPending indicates an expected call of DispatchPending.
+func (mr *MockWebhookDispatchUsecaseMockRecorder) DispatchPending(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchPending", reflect.TypeOf((*MockWebhookDispatchUsecase)(nil).DispatchPending), ctx)
+}
+
+// MockOTPPartitionUsecase is a mock of OTPPartitionUsecase interface.
+type MockOTPPartitionUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockOTPPartitionUsecaseMockRecorder
+}
+
+// MockOTPPartitionUsecaseMockRecorder is the mock recorder for MockOTPPartitionUsecase.
+type MockOTPPartitionUsecaseMockRecorder struct {
+	mock *MockOTPPartitionUsecase
+}
+
+// NewMockOTPPartitionUsecase creates a new mock instance.
+func NewMockOTPPartitionUsecase(ctrl *gomock.Controller) *MockOTPPartitionUsecase {
+	mock := &MockOTPPartitionUsecase{ctrl: ctrl}
+	mock.recorder = &MockOTPPartitionUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOTPPartitionUsecase) EXPECT() *MockOTPPartitionUsecaseMockRecorder {
+	return m.recorder
+}
+
+// MaintainPartitions mocks base method.
+func (m *MockOTPPartitionUsecase) MaintainPartitions(ctx context.Context) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaintainPartitions", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MaintainPartitions indicates an expected call of MaintainPartitions.
+func (mr *MockOTPPartitionUsecaseMockRecorder) MaintainPartitions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaintainPartitions", reflect.TypeOf((*MockOTPPartitionUsecase)(nil).MaintainPartitions), ctx)
+}
+
+// MockEncryptionUsecase is a mock of EncryptionUsecase interface.
+type MockEncryptionUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockEncryptionUsecaseMockRecorder
+}
+
+// MockEncryptionUsecaseMockRecorder is the mock recorder for MockEncryptionUsecase.
+type MockEncryptionUsecaseMockRecorder struct {
+	mock *MockEncryptionUsecase
+}
+
+// NewMockEncryptionUsecase creates a new mock instance.
+func NewMockEncryptionUsecase(ctrl *gomock.Controller) *MockEncryptionUsecase {
+	mock := &MockEncryptionUsecase{ctrl: ctrl}
+	mock.recorder = &MockEncryptionUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEncryptionUsecase) EXPECT() *MockEncryptionUsecaseMockRecorder {
+	return m.recorder
+}
+
+// RotateKeys mocks base method.
+func (m *MockEncryptionUsecase) RotateKeys(ctx context.Context) (*entity.KeyRotation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateKeys", ctx)
+	ret0, _ := ret[0].(*entity.KeyRotation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateKeys indicates an expected call of RotateKeys.
+func (mr *MockEncryptionUsecaseMockRecorder) RotateKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKeys", reflect.TypeOf((*MockEncryptionUsecase)(nil).RotateKeys), ctx)
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// OutboxDispatcher delivers pending outbox events to other services.
+// It is run periodically as a scheduler job, so only one instance delivers at a time.
+type OutboxDispatcher struct {
+	OutboxUsecase OutboxUsecase
+}
+
+// NewOutboxDispatcher creates an outbox dispatcher.
+func NewOutboxDispatcher(outboxUsecase OutboxUsecase) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		OutboxUsecase: outboxUsecase,
+	}
+}
+
+// RunOnce delivers the pending events that are due, recording the progress in the outbox metrics.
+func (d *OutboxDispatcher) RunOnce(ctx context.Context) error {
+	delivered, failed, err := d.OutboxUsecase.DispatchPending(ctx)
+	outboxEventsDelivered.Add(float64(delivered))
+	outboxDeliveryFailures.Add(float64(failed))
+	if err != nil {
+		return fmt.Errorf("failed to dispatch outbox events: %w", err)
+	}
+
+	if delivered > 0 || failed > 0 {
+		log.Info().
+			Int("delivered", delivered).
+			Int("failed", failed).
+			Msg("Outbox dispatcher run completed")
+	}
+
+	return nil
+}
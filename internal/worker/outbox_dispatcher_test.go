@@ -0,0 +1,52 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/internal/worker"
+	usecasemock "github.com/imansohibul/otp-service/internal/worker/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxDispatcher_RunOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(outboxUsecase *usecasemock.MockOutboxUsecase)
+		assertFn  func(err error)
+	}{
+		{
+			name: "Should dispatch pending events",
+			mockSetup: func(outboxUsecase *usecasemock.MockOutboxUsecase) {
+				outboxUsecase.EXPECT().DispatchPending(gomock.Any()).Return(3, 1, nil)
+			},
+			assertFn: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "Should return error if dispatching fails",
+			mockSetup: func(outboxUsecase *usecasemock.MockOutboxUsecase) {
+				outboxUsecase.EXPECT().DispatchPending(gomock.Any()).Return(0, 0, errors.New("db error"))
+			},
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "failed to dispatch outbox events: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			outboxUsecase := usecasemock.NewMockOutboxUsecase(ctrl)
+			tt.mockSetup(outboxUsecase)
+
+			dispatcher := worker.NewOutboxDispatcher(outboxUsecase)
+			tt.assertFn(dispatcher.RunOnce(context.Background()))
+		})
+	}
+}
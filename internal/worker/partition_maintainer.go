@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PartitionMaintainer creates the partitions of the otps table ahead of time and drops the ones past the retention period.
+// It is run periodically as a scheduler job, so only one instance alters the table at a time.
+type PartitionMaintainer struct {
+	PartitionUsecase OTPPartitionUsecase
+}
+
+// NewPartitionMaintainer creates a partition maintainer.
+func NewPartitionMaintainer(partitionUsecase OTPPartitionUsecase) *PartitionMaintainer {
+	return &PartitionMaintainer{
+		PartitionUsecase: partitionUsecase,
+	}
+}
+
+// RunOnce maintains the partitions, recording the progress in the partition metrics.
+func (m *PartitionMaintainer) RunOnce(ctx context.Context) error {
+	start := time.Now()
+
+	created, dropped, err := m.PartitionUsecase.MaintainPartitions(ctx)
+	partitionsCreated.Add(float64(created))
+	partitionsDropped.Add(float64(dropped))
+	if err != nil {
+		partitionRuns.WithLabelValues("failure").Inc()
+		return fmt.Errorf("failed to maintain OTP partitions: %w", err)
+	}
+
+	partitionRuns.WithLabelValues("success").Inc()
+	partitionLastSuccess.SetToCurrentTime()
+
+	log.Info().
+		Int("created", created).
+		Int("dropped", dropped).
+		Dur("duration", time.Since(start)).
+		Msg("OTP partition maintainer run completed")
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imansohibul/otp-service/internal/worker"
+	usecasemock "github.com/imansohibul/otp-service/internal/worker/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionMaintainer_RunOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(partitionUsecase *usecasemock.MockOTPPartitionUsecase)
+		assertFn  func(err error)
+	}{
+		{
+			name: "Should maintain the partitions",
+			mockSetup: func(partitionUsecase *usecasemock.MockOTPPartitionUsecase) {
+				partitionUsecase.EXPECT().MaintainPartitions(gomock.Any()).Return(1, 1, nil)
+			},
+			assertFn: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "Should return error if maintaining the partitions fails",
+			mockSetup: func(partitionUsecase *usecasemock.MockOTPPartitionUsecase) {
+				partitionUsecase.EXPECT().MaintainPartitions(gomock.Any()).Return(1, 0, errors.New("db error"))
+			},
+			assertFn: func(err error) {
+				assert.EqualError(t, err, "failed to maintain OTP partitions: db error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			partitionUsecase := usecasemock.NewMockOTPPartitionUsecase(ctrl)
+			tt.mockSetup(partitionUsecase)
+
+			maintainer := worker.NewPartitionMaintainer(partitionUsecase)
+			tt.assertFn(maintainer.RunOnce(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/imansohibul/otp-service/entity"
+)
+
+//go:generate mockgen -destination=mock/usecase.go -package=mock -source=usecase.go
+
+// OTPSweeperUsecase defines the business logic interface for sweeping stale OTPs,
+// which would otherwise only be expired lazily when someone tries to validate them.
+type OTPSweeperUsecase interface {
+	// ExpireStale marks every created OTP that is past its expiry as expired
+	// and returns the number of OTPs it marked.
+	ExpireStale(ctx context.Context) (int64, error)
+
+	// PurgeStale deletes every OTP that expired longer ago than the retention period
+	// and returns the number of OTPs it deleted.
+	PurgeStale(ctx context.Context) (int64, error)
+
+	// PurgeIdempotencyKeys deletes every idempotency key past its replay window
+	// and returns the number of keys it deleted.
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+// OutboxUsecase defines the business logic interface for delivering outbox events to other services.
+type OutboxUsecase interface {
+	// DispatchPending delivers the pending events that are due and returns
+	// the number of events delivered and the number of failed deliveries.
+	DispatchPending(ctx context.Context) (int, int, error)
+}
+
+// WebhookDispatchUsecase defines the business logic interface for delivering events to webhook subscriptions.
+type WebhookDispatchUsecase interface {
+	// DispatchPending attempts the webhook deliveries that are due and returns
+	// the number of successful and the number of failed deliveries.
+	DispatchPending(ctx context.Context) (int, int, error)
+}
+
+// OTPPartitionUsecase defines the business logic interface for maintaining the partitions of the stored OTPs.
+type OTPPartitionUsecase interface {
+	// MaintainPartitions creates the partitions ahead of time and drops the ones past the retention period,
+	// and returns the number of partitions created and the number of partitions dropped.
+	MaintainPartitions(ctx context.Context) (int, int, error)
+}
+
+// EncryptionUsecase defines the business logic interface for rotating the keys the stored OTPs are encrypted with.
+type EncryptionUsecase interface {
+	// RotateKeys rotates the data key and the wrapping of the keys when due, and encrypts the stored OTPs
+	// with the current data key. The rotation done so far is returned along with any error.
+	RotateKeys(ctx context.Context) (*entity.KeyRotation, error)
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookDispatcher delivers pending webhook deliveries to the endpoints of client applications.
+// It is run periodically as a scheduler job, so only one instance delivers at a time.
+type WebhookDispatcher struct {
+	WebhookUsecase WebhookDispatchUsecase
+}
+
+// NewWebhookDispatcher creates a webhook dispatcher.
+func NewWebhookDispatcher(webhookUsecase WebhookDispatchUsecase) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		WebhookUsecase: webhookUsecase,
+	}
+}
+
+// RunOnce attempts the webhook deliveries that are due, recording the progress in the webhook metrics.
+func (d *WebhookDispatcher) RunOnce(ctx context.Context) error {
+	delivered, failed, err := d.WebhookUsecase.DispatchPending(ctx)
+	webhookDeliveriesSucceeded.Add(float64(delivered))
+	webhookDeliveryFailures.Add(float64(failed))
+	if err != nil {
+		return fmt.Errorf("failed to dispatch webhook deliveries: %w", err)
+	}
+
+	if delivered > 0 || failed > 0 {
+		log.Info().
+			Int("delivered", delivered).
+			Int("failed", failed).
+			Msg("Webhook dispatcher run completed")
+	}
+
+	return nil
+}